@@ -342,6 +342,15 @@ func (s *Service) httpClient() *http.Client {
 	return s.network.HTTPClient(10 * time.Second)
 }
 
+// MikanHost 获取配置的蜜柑计划域名，不包含端口
+func (s *Service) MikanHost() string {
+	u, err := url.Parse(s.currentBaseURL())
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
+
 func (s *Service) currentBaseURL() string {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -3,32 +3,116 @@ package adapter
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
 	"sync"
 
 	"github.com/MangataL/BangumiBuddy/internal/downloader"
+	"github.com/MangataL/BangumiBuddy/internal/downloader/aria2"
+	"github.com/MangataL/BangumiBuddy/internal/downloader/builtin"
+	"github.com/MangataL/BangumiBuddy/internal/downloader/deluge"
 	"github.com/MangataL/BangumiBuddy/internal/downloader/qbittorrent"
+	"github.com/MangataL/BangumiBuddy/internal/downloader/transmission"
+	"github.com/MangataL/BangumiBuddy/internal/network"
 	"github.com/MangataL/BangumiBuddy/pkg/log"
 )
 
-var _ downloader.Downloader = &Adapter{}
+var (
+	_ downloader.Downloader = &Adapter{}
+	_ downloader.Instances  = &Adapter{}
+)
+
+const bytesPerMiB = 1 << 20
 
 type Adapter struct {
-	mu         sync.RWMutex
-	downloader downloader.Downloader
+	mu          sync.RWMutex
+	downloaders map[string]downloader.Downloader
+	names       []string
+	rules       []RoutingRule
+	instances   map[string]InstanceConfig // 当前运行的下载器实例配置，重新加载失败时用于恢复
+	network     network.HTTPClientProvider
 }
 
-func NewAdapter(config Config) *Adapter {
-	adapter := &Adapter{}
+func NewAdapter(config Config, provider network.HTTPClientProvider) *Adapter {
+	adapter := &Adapter{network: provider}
 	if err := adapter.Reload(&config); err != nil {
 		log.Errorf(context.Background(), "初始化下载器失败: %v", err)
-		adapter.downloader = &downloader.Empty{}
 	}
 	return adapter
 }
 
+// Config 下载器配置，顶层字段为默认实例的配置
 type Config struct {
-	DownloadType string             `mapstructure:"download_type" json:"downloadType"`
-	QBitTorrent  qbittorrent.Config `mapstructure:"qbittorrent" json:"qbittorrent"`
+	InstanceConfig `mapstructure:",squash"`
+	Instances      []InstanceConfig `mapstructure:"instances" json:"instances"` // 其他下载器实例
+	Rules          []RoutingRule    `mapstructure:"rules" json:"rules"`         // 路由规则，按顺序匹配
+}
+
+// InstanceConfig 下载器实例配置
+type InstanceConfig struct {
+	Name         string              `mapstructure:"name" json:"name"` // 实例名称，默认实例可以为空
+	DownloadType string              `mapstructure:"download_type" json:"downloadType"`
+	QBitTorrent  qbittorrent.Config  `mapstructure:"qbittorrent" json:"qbittorrent"`
+	Transmission transmission.Config `mapstructure:"transmission" json:"transmission"`
+	Aria2        aria2.Config        `mapstructure:"aria2" json:"aria2"`
+	Deluge       deluge.Config       `mapstructure:"deluge" json:"deluge"`
+	Builtin      builtin.Config      `mapstructure:"builtin" json:"builtin"`
+}
+
+// RoutingRule 下载器路由规则，所有设置了的条件都满足时使用对应的下载器实例
+type RoutingRule struct {
+	Downloader    string   `mapstructure:"downloader" json:"downloader"`        // 下载器实例名称
+	DownloadTypes []string `mapstructure:"download_types" json:"downloadTypes"` // 下载类型，tv 或 movie
+	MinSize       int64    `mapstructure:"min_size" json:"minSize"`             // 最小种子大小，单位 MiB，0 表示不限制
+	MaxSize       int64    `mapstructure:"max_size" json:"maxSize"`             // 最大种子大小，单位 MiB，0 表示不限制
+	ReleaseGroups []string `mapstructure:"release_groups" json:"releaseGroups"` // 发布组，不区分大小写
+}
+
+// match 判断下载请求是否满足路由规则，种子大小未知时不匹配设置了大小限制的规则
+func (r RoutingRule) match(req downloader.RouteReq) bool {
+	if len(r.DownloadTypes) > 0 && !slices.Contains(r.DownloadTypes, string(req.DownloadType)) {
+		return false
+	}
+	if r.MinSize > 0 && (req.Size == 0 || req.Size < r.MinSize*bytesPerMiB) {
+		return false
+	}
+	if r.MaxSize > 0 && (req.Size == 0 || req.Size > r.MaxSize*bytesPerMiB) {
+		return false
+	}
+	if len(r.ReleaseGroups) > 0 && !slices.ContainsFunc(r.ReleaseGroups, func(group string) bool {
+		return strings.EqualFold(group, req.ReleaseGroup)
+	}) {
+		return false
+	}
+	return true
+}
+
+// instanceConfigs 返回包含默认实例在内的所有实例配置
+func (c *Config) instanceConfigs() []InstanceConfig {
+	defaultInstance := c.InstanceConfig
+	defaultInstance.Name = downloader.InstanceName(defaultInstance.Name)
+	return append([]InstanceConfig{defaultInstance}, c.Instances...)
+}
+
+func (c *Config) validate() error {
+	names := make(map[string]struct{})
+	for _, instance := range c.instanceConfigs() {
+		if instance.Name == "" {
+			return errors.New("下载器实例名称不能为空")
+		}
+		if _, ok := names[instance.Name]; ok {
+			return fmt.Errorf("下载器实例名称 %s 重复", instance.Name)
+		}
+		names[instance.Name] = struct{}{}
+	}
+	for _, rule := range c.Rules {
+		if _, ok := names[downloader.InstanceName(rule.Downloader)]; !ok {
+			return fmt.Errorf("路由规则中的下载器实例 %s 不存在", rule.Downloader)
+		}
+	}
+	return nil
 }
 
 func (a *Adapter) Reload(config interface{}) error {
@@ -36,13 +120,128 @@ func (a *Adapter) Reload(config interface{}) error {
 	if !ok {
 		return errors.New("配置类型错误")
 	}
-	switch cfg.DownloadType {
+	if err := cfg.validate(); err != nil {
+		return err
+	}
+
+	instances := cfg.instanceConfigs()
+	// 旧实例占用了新实例需要的监听端口或数据目录时需要先关闭
+	released := a.release(instances)
+	downloaders := make(map[string]downloader.Downloader, len(instances))
+	names := make([]string, 0, len(instances))
+	for _, instance := range instances {
+		d, err := newDownloader(instance, a.network)
+		if err != nil {
+			closeDownloaders(downloaders)
+			a.restore(released)
+			return fmt.Errorf("初始化下载器 %s 失败: %w", instance.Name, err)
+		}
+		downloaders[instance.Name] = d
+		names = append(names, instance.Name)
+	}
+	a.setDownloaders(downloaders, names, cfg.Rules, instances)
+	return nil
+}
+
+// release 关闭与新实例冲突的旧实例并从当前实例中移除，返回这些实例的配置
+func (a *Adapter) release(instances []InstanceConfig) []InstanceConfig {
+	a.mu.Lock()
+	released := make(map[string]downloader.Downloader)
+	var configs []InstanceConfig
+	for name, config := range a.instances {
+		if !conflicts(config, instances) {
+			continue
+		}
+		if d, ok := a.downloaders[name]; ok {
+			released[name] = d
+			delete(a.downloaders, name)
+		}
+		configs = append(configs, config)
+	}
+	a.mu.Unlock()
+
+	closeDownloaders(released)
+	return configs
+}
+
+// restore 重新创建被关闭的旧实例，恢复重新加载前的下载器
+func (a *Adapter) restore(configs []InstanceConfig) {
+	for _, config := range configs {
+		d, err := newDownloader(config, a.network)
+		if err != nil {
+			log.Errorf(context.Background(), "恢复下载器 %s 失败: %v", config.Name, err)
+			continue
+		}
+		a.mu.Lock()
+		a.downloaders[config.Name] = d
+		a.mu.Unlock()
+	}
+}
+
+// conflicts 判断旧实例是否占用了新实例需要的资源，目前只有内置下载器会占用监听端口和数据目录
+func conflicts(old InstanceConfig, instances []InstanceConfig) bool {
+	if old.DownloadType != "builtin" {
+		return false
+	}
+	return slices.ContainsFunc(instances, func(instance InstanceConfig) bool {
+		return instance.DownloadType == "builtin" && old.Builtin.Conflicts(instance.Builtin)
+	})
+}
+
+func newDownloader(config InstanceConfig, provider network.HTTPClientProvider) (downloader.Downloader, error) {
+	switch config.DownloadType {
 	case "qbittorrent":
-		a.setDownloader(qbittorrent.NewQBittorrent(cfg.QBitTorrent))
+		return qbittorrent.NewQBittorrent(config.QBitTorrent), nil
+	case "transmission":
+		return transmission.NewTransmission(config.Transmission), nil
+	case "aria2":
+		return aria2.NewAria2(config.Aria2, provider), nil
+	case "deluge":
+		return deluge.NewDeluge(config.Deluge), nil
+	case "builtin":
+		return builtin.NewBuiltin(config.Builtin, provider)
+	case "":
+		return &downloader.Empty{}, nil
 	default:
-		a.setDownloader(&downloader.Empty{})
+		return nil, fmt.Errorf("不支持的下载器类型 %s", config.DownloadType)
 	}
-	return nil
+}
+
+// Get 获取指定名称的下载器实例，名称为空时返回默认实例
+func (a *Adapter) Get(name string) (downloader.Downloader, error) {
+	name = downloader.InstanceName(name)
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	d, ok := a.downloaders[name]
+	if ok {
+		return d, nil
+	}
+	if name == downloader.DefaultInstance {
+		return &downloader.Empty{}, nil
+	}
+	return nil, fmt.Errorf("下载器实例 %s 不存在", name)
+}
+
+// Names 列出所有下载器实例名称，默认实例排在最前
+func (a *Adapter) Names() []string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if len(a.names) == 0 {
+		return []string{downloader.DefaultInstance}
+	}
+	return slices.Clone(a.names)
+}
+
+// Route 按顺序匹配路由规则，没有匹配的规则时返回默认实例
+func (a *Adapter) Route(req downloader.RouteReq) string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	for _, rule := range a.rules {
+		if rule.match(req) {
+			return downloader.InstanceName(rule.Downloader)
+		}
+	}
+	return downloader.DefaultInstance
 }
 
 func (a *Adapter) GetDownloadStatuses(ctx context.Context, hashes []string) ([]downloader.DownloadStatus, error) {
@@ -85,17 +284,41 @@ func (a *Adapter) ContinueDownload(ctx context.Context, hash string) error {
 	return a.currentDownloader().ContinueDownload(ctx, hash)
 }
 
-func (a *Adapter) setDownloader(d downloader.Downloader) {
+func (a *Adapter) setDownloaders(
+	downloaders map[string]downloader.Downloader,
+	names []string,
+	rules []RoutingRule,
+	instances []InstanceConfig,
+) {
+	configs := make(map[string]InstanceConfig, len(instances))
+	for _, instance := range instances {
+		configs[instance.Name] = instance
+	}
 	a.mu.Lock()
-	defer a.mu.Unlock()
-	a.downloader = d
+	old := a.downloaders
+	a.downloaders = downloaders
+	a.names = names
+	a.rules = rules
+	a.instances = configs
+	a.mu.Unlock()
+
+	closeDownloaders(old)
 }
 
-func (a *Adapter) currentDownloader() downloader.Downloader {
-	a.mu.RLock()
-	defer a.mu.RUnlock()
-	if a.downloader == nil {
-		return &downloader.Empty{}
+func closeDownloaders(downloaders map[string]downloader.Downloader) {
+	for name, d := range downloaders {
+		closer, ok := d.(io.Closer)
+		if !ok {
+			continue
+		}
+		if err := closer.Close(); err != nil {
+			log.Errorf(context.Background(), "关闭下载器 %s 失败: %v", name, err)
+		}
 	}
-	return a.downloader
+}
+
+// currentDownloader 返回默认下载器实例
+func (a *Adapter) currentDownloader() downloader.Downloader {
+	d, _ := a.Get(downloader.DefaultInstance)
+	return d
 }
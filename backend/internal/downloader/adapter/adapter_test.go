@@ -2,28 +2,33 @@ package adapter
 
 import (
 	"context"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"sync/atomic"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/MangataL/BangumiBuddy/internal/downloader"
+	"github.com/MangataL/BangumiBuddy/internal/downloader/builtin"
 	"github.com/MangataL/BangumiBuddy/internal/downloader/qbittorrent"
+	"github.com/MangataL/BangumiBuddy/internal/downloader/transmission"
 )
 
 func TestReloadReplacesInitializedQBittorrentClient(t *testing.T) {
 	firstServer, firstLoginCount := newQBittorrentServer(t)
 	secondServer, secondLoginCount := newQBittorrentServer(t)
 
-	adapter := NewAdapter(Config{
+	adapter := NewAdapter(Config{InstanceConfig: InstanceConfig{
 		DownloadType: "qbittorrent",
 		QBitTorrent: qbittorrent.Config{
 			Host:     firstServer.URL,
 			Username: "first-user",
 			Password: "first-password",
 		},
-	})
+	}}, nil)
 
 	_, err := adapter.GetTorrentFileNames(context.Background(), "first-hash")
 	require.NoError(t, err)
@@ -31,14 +36,14 @@ func TestReloadReplacesInitializedQBittorrentClient(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, int32(1), firstLoginCount.Load())
 
-	err = adapter.Reload(&Config{
+	err = adapter.Reload(&Config{InstanceConfig: InstanceConfig{
 		DownloadType: "qbittorrent",
 		QBitTorrent: qbittorrent.Config{
 			Host:     secondServer.URL,
 			Username: "second-user",
 			Password: "second-password",
 		},
-	})
+	}})
 	require.NoError(t, err)
 
 	_, err = adapter.GetTorrentFileNames(context.Background(), "second-hash")
@@ -74,3 +79,135 @@ func newQBittorrentServer(t *testing.T) (*httptest.Server, *atomic.Int32) {
 
 	return server, &loginCount
 }
+
+func TestReloadClosesBuiltinDownloader(t *testing.T) {
+	dataDir := t.TempDir()
+	config := Config{InstanceConfig: InstanceConfig{
+		DownloadType: "builtin",
+		Builtin: builtin.Config{
+			DataDir:    dataDir,
+			ListenPort: freePort(t),
+			DisableDHT: true,
+		},
+	}}
+	adapter := NewAdapter(config, nil)
+
+	// 重新加载相同配置时需要先释放旧的监听端口
+	require.NoError(t, adapter.Reload(&config))
+	statuses, err := adapter.ListTorrentsStatus(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, statuses)
+
+	require.NoError(t, adapter.Reload(&Config{}))
+}
+
+func TestReloadKeepsDownloadersOnFailure(t *testing.T) {
+	config := Config{
+		InstanceConfig: InstanceConfig{DownloadType: "qbittorrent"},
+		Instances: []InstanceConfig{{
+			Name:         "builtin",
+			DownloadType: "builtin",
+			Builtin: builtin.Config{
+				DataDir:    t.TempDir(),
+				ListenPort: freePort(t),
+				DisableDHT: true,
+			},
+		}},
+	}
+	adapter := NewAdapter(config, nil)
+	previous, err := adapter.Get(downloader.DefaultInstance)
+	require.NoError(t, err)
+
+	// 内置下载器占用的资源被释放后，后面的实例初始化失败时恢复旧的下载器
+	failed := config
+	failed.Instances = append(slices.Clone(config.Instances), InstanceConfig{Name: "unknown", DownloadType: "unknown"})
+	require.Error(t, adapter.Reload(&failed))
+
+	d, err := adapter.Get(downloader.DefaultInstance)
+	require.NoError(t, err)
+	require.Same(t, previous, d)
+	d, err = adapter.Get("builtin")
+	require.NoError(t, err)
+	statuses, err := d.ListTorrentsStatus(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, statuses)
+	require.Equal(t, []string{downloader.DefaultInstance, "builtin"}, adapter.Names())
+
+	require.NoError(t, adapter.Reload(&Config{}))
+}
+
+func freePort(t *testing.T) int {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+func TestRoute(t *testing.T) {
+	adapter := NewAdapter(Config{
+		InstanceConfig: InstanceConfig{DownloadType: "qbittorrent"},
+		Instances: []InstanceConfig{
+			{Name: "seedbox", DownloadType: "qbittorrent"},
+			{Name: "movie", DownloadType: "transmission"},
+		},
+		Rules: []RoutingRule{
+			{Downloader: "seedbox", MinSize: 10 * 1024},
+			{Downloader: "seedbox", ReleaseGroups: []string{"VCB-Studio"}},
+			{Downloader: "movie", DownloadTypes: []string{"movie"}},
+		},
+	}, nil)
+	require.Equal(t, []string{downloader.DefaultInstance, "seedbox", "movie"}, adapter.Names())
+
+	tests := []struct {
+		name string
+		req  downloader.RouteReq
+		want string
+	}{
+		{
+			name: "大小匹配",
+			req:  downloader.RouteReq{DownloadType: downloader.DownloadTypeTV, Size: 20 << 30},
+			want: "seedbox",
+		},
+		{
+			name: "发布组匹配不区分大小写",
+			req:  downloader.RouteReq{DownloadType: downloader.DownloadTypeTV, ReleaseGroup: "vcb-studio"},
+			want: "seedbox",
+		},
+		{
+			name: "下载类型匹配",
+			req:  downloader.RouteReq{DownloadType: downloader.DownloadTypeMovie, Size: 2 << 30},
+			want: "movie",
+		},
+		{
+			name: "没有匹配的规则使用默认实例",
+			req:  downloader.RouteReq{DownloadType: downloader.DownloadTypeTV, ReleaseGroup: "LoliHouse"},
+			want: downloader.DefaultInstance,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, adapter.Route(tt.req))
+		})
+	}
+
+	d, err := adapter.Get("")
+	require.NoError(t, err)
+	require.IsType(t, &qbittorrent.QBittorrent{}, d)
+	d, err = adapter.Get("movie")
+	require.NoError(t, err)
+	require.IsType(t, &transmission.Transmission{}, d)
+	_, err = adapter.Get("missing")
+	require.Error(t, err)
+}
+
+func TestReloadRejectsInvalidInstances(t *testing.T) {
+	adapter := NewAdapter(Config{}, nil)
+
+	err := adapter.Reload(&Config{Instances: []InstanceConfig{{DownloadType: "qbittorrent"}}})
+	require.Error(t, err)
+	err = adapter.Reload(&Config{Instances: []InstanceConfig{{Name: downloader.DefaultInstance}}})
+	require.Error(t, err)
+	err = adapter.Reload(&Config{Rules: []RoutingRule{{Downloader: "missing"}}})
+	require.Error(t, err)
+}
@@ -0,0 +1,630 @@
+package aria2
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"github.com/MangataL/BangumiBuddy/internal/downloader"
+	"github.com/MangataL/BangumiBuddy/internal/network"
+	"github.com/MangataL/BangumiBuddy/pkg/log"
+)
+
+// 确保Aria2实现了Downloader接口
+var (
+	_ downloader.Downloader     = (*Aria2)(nil)
+	_ downloader.TorrentRemover = (*Aria2)(nil)
+	_ downloader.TorrentPauser  = (*Aria2)(nil)
+)
+
+// aria2 下载状态
+const (
+	statusActive   = "active"
+	statusWaiting  = "waiting"
+	statusPaused   = "paused"
+	statusError    = "error"
+	statusComplete = "complete"
+	statusRemoved  = "removed"
+)
+
+// listLimit 单次查询等待和已停止下载的最大数量
+const listLimit = 1000
+
+// gidPrefix BangumiBuddy 添加的任务使用的 gid 前缀，aria2 不支持标签，以此区分受管理的任务
+const gidPrefix = "bb0d0b0d"
+
+const fetchTimeout = 30 * time.Second
+
+var statusKeys = []string{
+	"gid",
+	"status",
+	"totalLength",
+	"completedLength",
+	"uploadLength",
+	"downloadSpeed",
+	"errorMessage",
+	"dir",
+	"infoHash",
+	"followedBy",
+	"following",
+	"seeder",
+	"bittorrent",
+}
+
+var errTorrentNotFound = errors.New("种子不存在")
+
+// Config aria2配置
+type Config struct {
+	Host   string `mapstructure:"host" json:"host"`
+	Secret string `mapstructure:"secret" json:"secret"`
+	// RemotePath 和 LocalPath 组成路径映射，aria2 的 RemotePath 目录挂载在本机的 LocalPath 上，
+	// aria2 不会删除或移动已下载的文件，只有配置了路径映射才能在本机上完成这些操作，
+	// aria2 与 BangumiBuddy 运行在同一文件系统时两者设置为相同的路径即可
+	RemotePath string `mapstructure:"remote_path" json:"remotePath"`
+	LocalPath  string `mapstructure:"local_path" json:"localPath"`
+}
+
+// Aria2 实现Downloader接口
+//
+// aria2 以 gid 标识下载任务，这里通过 infoHash 与种子哈希对应；
+// aria2 不支持标签，添加任务时使用带有 gidPrefix 前缀的 gid 标记受管理的任务
+type Aria2 struct {
+	client  *client
+	config  Config
+	network network.HTTPClientProvider
+}
+
+// NewAria2 创建一个新的Aria2实例，provider 用于下载种子文件，为空时直连
+func NewAria2(config Config, provider network.HTTPClientProvider) *Aria2 {
+	return &Aria2{
+		client:  newClient(config),
+		config:  config,
+		network: provider,
+	}
+}
+
+type task struct {
+	GID             string      `json:"gid"`
+	Status          string      `json:"status"`
+	TotalLength     string      `json:"totalLength"`
+	CompletedLength string      `json:"completedLength"`
+	UploadLength    string      `json:"uploadLength"`
+	DownloadSpeed   string      `json:"downloadSpeed"`
+	ErrorMessage    string      `json:"errorMessage"`
+	Dir             string      `json:"dir"`
+	InfoHash        string      `json:"infoHash"`
+	FollowedBy      []string    `json:"followedBy"`
+	Following       string      `json:"following"`
+	Seeder          string      `json:"seeder"`
+	BitTorrent      *bitTorrent `json:"bittorrent"`
+}
+
+type bitTorrent struct {
+	Info struct {
+		Name string `json:"name"`
+	} `json:"info"`
+}
+
+type taskFile struct {
+	Index    string `json:"index"`
+	Path     string `json:"path"`
+	Length   string `json:"length"`
+	Selected string `json:"selected"`
+}
+
+// hasMetadata 是否已获取到元数据，磁力链接获取元数据阶段的任务会被 followedBy 指向真正的下载任务
+func (t task) hasMetadata() bool {
+	return t.BitTorrent != nil && t.BitTorrent.Info.Name != "" && len(t.FollowedBy) == 0
+}
+
+// managed 是否为 BangumiBuddy 添加的任务，磁力链接的下载任务由元数据任务派生，通过 following 判断
+func (t task) managed() bool {
+	return strings.HasPrefix(t.GID, gidPrefix) || strings.HasPrefix(t.Following, gidPrefix)
+}
+
+func (t task) name() string {
+	if t.BitTorrent != nil && t.BitTorrent.Info.Name != "" {
+		return t.BitTorrent.Info.Name
+	}
+	return t.InfoHash
+}
+
+// listTasks 获取全部 BT 任务，同一个种子只保留真正的下载任务
+func (a *Aria2) listTasks(ctx context.Context) ([]task, error) {
+	var active, waiting, stopped []task
+	if err := a.client.call(ctx, "aria2.tellActive", &active, statusKeys); err != nil {
+		return nil, err
+	}
+	if err := a.client.call(ctx, "aria2.tellWaiting", &waiting, 0, listLimit, statusKeys); err != nil {
+		return nil, err
+	}
+	if err := a.client.call(ctx, "aria2.tellStopped", &stopped, 0, listLimit, statusKeys); err != nil {
+		return nil, err
+	}
+
+	all := append(append(active, waiting...), stopped...)
+	tasks := make([]task, 0, len(all))
+	indexes := make(map[string]int, len(all))
+	for _, t := range all {
+		if t.InfoHash == "" {
+			continue
+		}
+		hash := strings.ToLower(t.InfoHash)
+		index, ok := indexes[hash]
+		if !ok {
+			indexes[hash] = len(tasks)
+			tasks = append(tasks, t)
+			continue
+		}
+		if len(tasks[index].FollowedBy) > 0 && len(t.FollowedBy) == 0 {
+			tasks[index] = t
+		}
+	}
+	return tasks, nil
+}
+
+func (a *Aria2) getTask(ctx context.Context, hash string) (task, error) {
+	tasks, err := a.listTasks(ctx)
+	if err != nil {
+		return task{}, err
+	}
+	for _, t := range tasks {
+		if strings.EqualFold(t.InfoHash, hash) {
+			return t, nil
+		}
+	}
+	return task{}, fmt.Errorf("%w: %s", errTorrentNotFound, hash)
+}
+
+func (a *Aria2) getFiles(ctx context.Context, t task) ([]taskFile, error) {
+	var files []taskFile
+	if err := a.client.call(ctx, "aria2.getFiles", &files, t.GID); err != nil {
+		return nil, fmt.Errorf("获取种子文件列表失败: %w", err)
+	}
+	return files, nil
+}
+
+// relativeFileName aria2 返回的是文件完整路径，这里转换为相对保存路径的文件名
+func relativeFileName(dir, path string) string {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
+
+// GetTorrentFileNames implements downloader.Downloader.
+func (a *Aria2) GetTorrentFileNames(ctx context.Context, hash string) ([]string, error) {
+	t, err := a.getTask(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	files, err := a.getFiles(ctx, t)
+	if err != nil {
+		return nil, err
+	}
+	fileNames := make([]string, 0, len(files))
+	for _, f := range files {
+		fileNames = append(fileNames, relativeFileName(t.Dir, f.Path))
+	}
+	return fileNames, nil
+}
+
+// GetDownloadStatuses 实现Downloader接口的GetDownloadStatuses方法
+func (a *Aria2) GetDownloadStatuses(ctx context.Context, hashes []string) ([]downloader.DownloadStatus, error) {
+	tasks, err := a.listTasks(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("获取种子列表失败: %w", err)
+	}
+	if len(hashes) > 0 {
+		wanted := make(map[string]struct{}, len(hashes))
+		for _, hash := range hashes {
+			wanted[strings.ToLower(hash)] = struct{}{}
+		}
+		filtered := make([]task, 0, len(hashes))
+		for _, t := range tasks {
+			if _, ok := wanted[strings.ToLower(t.InfoHash)]; ok {
+				filtered = append(filtered, t)
+			}
+		}
+		tasks = filtered
+	}
+	return convertTasksToDownloadStatuses(tasks), nil
+}
+
+// convertTasksToDownloadStatuses 将aria2的任务信息转换为DownloadStatus
+func convertTasksToDownloadStatuses(tasks []task) []downloader.DownloadStatus {
+	statuses := make([]downloader.DownloadStatus, 0, len(tasks))
+	for _, t := range tasks {
+		total := parseInt(t.TotalLength)
+		completed := parseInt(t.CompletedLength)
+		status := downloader.DownloadStatus{
+			Hash:          strings.ToLower(t.InfoHash),
+			Name:          t.name(),
+			DownloadSpeed: parseInt(t.DownloadSpeed),
+			Size:          total,
+			Uploaded:      parseInt(t.UploadLength),
+		}
+		// aria2 不提供做种时长，只能按分享率执行做种策略
+		if total > 0 {
+			status.Progress = float64(completed) / float64(total)
+			status.Ratio = float64(status.Uploaded) / float64(total)
+		}
+
+		switch {
+		case t.Status == statusError:
+			status.Status = downloader.TorrentStatusDownloadError
+			status.Error = t.ErrorMessage
+		case t.hasMetadata() && total > 0 && completed == total:
+			// 做种中或已完成的任务都视为下载完成
+			status.Status = downloader.TorrentStatusDownloaded
+		case t.Status == statusPaused:
+			status.Status = downloader.TorrentStatusDownloadPaused
+		default:
+			status.Status = downloader.TorrentStatusDownloading
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+func parseInt(s string) int64 {
+	v, _ := strconv.ParseInt(s, 10, 64)
+	return v
+}
+
+func (a *Aria2) SetTorrentFilePriorities(ctx context.Context, hash string, files []downloader.TorrentFileSelection) error {
+	if len(files) == 0 {
+		return fmt.Errorf("未提供文件选择信息")
+	}
+	fileSelections := make(map[string]bool, len(files))
+	for _, f := range files {
+		fileSelections[f.FileName] = f.Download
+	}
+
+	t, err := a.getTask(ctx, hash)
+	if err != nil {
+		return fmt.Errorf("获取种子文件信息失败: %w", err)
+	}
+	taskFiles, err := a.getFiles(ctx, t)
+	if err != nil {
+		return fmt.Errorf("获取种子文件信息失败: %w", err)
+	}
+
+	usedSelections := make(map[string]struct{}, len(files))
+	selected := make([]string, 0, len(taskFiles))
+	for _, info := range taskFiles {
+		name := relativeFileName(t.Dir, info.Path)
+		download, ok := fileSelections[name]
+		if !ok {
+			return fmt.Errorf("文件 %s 不在任务选择列表中", name)
+		}
+		usedSelections[name] = struct{}{}
+		if download {
+			selected = append(selected, info.Index)
+		}
+	}
+	if len(usedSelections) != len(fileSelections) {
+		return fmt.Errorf("任务选择列表包含未匹配的文件")
+	}
+	if len(selected) == 0 {
+		return fmt.Errorf("未选择任何文件，无法开始下载")
+	}
+
+	if err := a.changeOption(ctx, t, map[string]string{
+		"select-file": strings.Join(selected, ","),
+	}); err != nil {
+		return fmt.Errorf("设置文件下载失败: %w", err)
+	}
+	return nil
+}
+
+// changeOption 修改任务选项，aria2 只允许修改等待或暂停中的任务，活跃任务会先暂停再恢复
+func (a *Aria2) changeOption(ctx context.Context, t task, options map[string]string) error {
+	return a.withPaused(ctx, t, func() error {
+		return a.client.call(ctx, "aria2.changeOption", nil, t.GID, options)
+	})
+}
+
+// withPaused 在任务暂停的状态下执行 fn，活跃任务会先暂停，执行完成后恢复
+func (a *Aria2) withPaused(ctx context.Context, t task, fn func() error) error {
+	if t.Status != statusActive {
+		return fn()
+	}
+	if err := a.client.call(ctx, "aria2.pause", nil, t.GID); err != nil {
+		return fmt.Errorf("暂停任务失败: %w", err)
+	}
+	if err := wait.PollUntilContextTimeout(ctx, 200*time.Millisecond, 10*time.Second, true, func(ctx context.Context) (bool, error) {
+		var current task
+		if err := a.client.call(ctx, "aria2.tellStatus", &current, t.GID, []string{"status"}); err != nil {
+			return false, err
+		}
+		return current.Status == statusPaused, nil
+	}); err != nil {
+		return fmt.Errorf("等待任务暂停失败: %w", err)
+	}
+	if err := fn(); err != nil {
+		return err
+	}
+	return a.client.call(ctx, "aria2.unpause", nil, t.GID)
+}
+
+// AddTorrent 添加种子，stopCondition 不为空时在获取到元数据后暂停下载
+func (a *Aria2) AddTorrent(ctx context.Context, torrentLink, savePath, stopCondition string) error {
+	gid, err := newGID()
+	if err != nil {
+		return err
+	}
+	options := map[string]string{"gid": gid}
+	if savePath != "" {
+		options["dir"] = savePath
+	}
+
+	if strings.HasPrefix(torrentLink, "magnet:") {
+		if stopCondition != "" {
+			options["pause-metadata"] = "true"
+		}
+		if err := a.client.call(ctx, "aria2.addUri", nil, []string{torrentLink}, options); err != nil {
+			return fmt.Errorf("添加种子失败: %w", err)
+		}
+		return nil
+	}
+
+	content, err := a.fetchTorrent(ctx, torrentLink)
+	if err != nil {
+		return err
+	}
+	if stopCondition != "" {
+		options["pause"] = "true"
+	}
+	if err := a.client.call(ctx, "aria2.addTorrent", nil,
+		base64.StdEncoding.EncodeToString(content), []string{}, options); err != nil {
+		return fmt.Errorf("添加种子失败: %w", err)
+	}
+	return nil
+}
+
+// newGID 生成带有 gidPrefix 前缀的 gid，aria2 的 gid 为16位十六进制字符串
+func newGID() (string, error) {
+	suffix := make([]byte, (16-len(gidPrefix))/2)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", fmt.Errorf("生成任务 gid 失败: %w", err)
+	}
+	return gidPrefix + hex.EncodeToString(suffix), nil
+}
+
+func (a *Aria2) httpClient() *http.Client {
+	if a.network == nil {
+		return &http.Client{Timeout: fetchTimeout}
+	}
+	return a.network.HTTPClient(fetchTimeout)
+}
+
+func (a *Aria2) fetchTorrent(ctx context.Context, torrentLink string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, torrentLink, nil)
+	if err != nil {
+		return nil, fmt.Errorf("创建种子下载请求失败: %w", err)
+	}
+	resp, err := a.httpClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("下载种子文件失败: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("下载种子文件失败: %d", resp.StatusCode)
+	}
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取种子文件失败: %w", err)
+	}
+	return content, nil
+}
+
+// SetLocation 修改任务的保存路径，aria2 只修改 dir 选项而不会移动已下载的文件，
+// 已有下载数据时通过路径映射在本机移动文件
+func (a *Aria2) SetLocation(ctx context.Context, hash, savePath string) error {
+	t, err := a.getTask(ctx, hash)
+	if err != nil {
+		return err
+	}
+	if t.Status == statusComplete || t.Status == statusRemoved {
+		return fmt.Errorf("aria2 不支持移动已完成任务的保存路径")
+	}
+	options := map[string]string{"dir": savePath}
+	if parseInt(t.CompletedLength) == 0 {
+		return a.changeOption(ctx, t, options)
+	}
+
+	oldDir, ok := a.localPath(t.Dir)
+	if !ok {
+		return fmt.Errorf("任务已有下载数据，需要配置 aria2 路径映射才能移动 %s 下的文件", t.Dir)
+	}
+	newDir, ok := a.localPath(savePath)
+	if !ok {
+		return fmt.Errorf("保存路径 %s 不在 aria2 路径映射中", savePath)
+	}
+	files, err := a.getFiles(ctx, t)
+	if err != nil {
+		return err
+	}
+	return a.withPaused(ctx, t, func() error {
+		for _, path := range taskPaths(t, files) {
+			local, ok := a.localPath(path)
+			if !ok {
+				continue
+			}
+			rel, err := filepath.Rel(oldDir, local)
+			if err != nil || strings.HasPrefix(rel, "..") {
+				continue
+			}
+			if err := moveFile(local, filepath.Join(newDir, rel)); err != nil {
+				return fmt.Errorf("移动种子文件失败: %w", err)
+			}
+		}
+		removeEmptyDirs(oldDir, files, a.localPath)
+		return a.client.call(ctx, "aria2.changeOption", nil, t.GID, options)
+	})
+}
+
+// localPath 将 aria2 中的路径映射为本机路径，未配置路径映射或路径不在映射目录下时返回false
+func (a *Aria2) localPath(remote string) (string, bool) {
+	if a.config.RemotePath == "" || a.config.LocalPath == "" {
+		return "", false
+	}
+	rel, err := filepath.Rel(a.config.RemotePath, remote)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, "../") {
+		return "", false
+	}
+	return filepath.Join(a.config.LocalPath, rel), true
+}
+
+// taskPaths 返回任务在 aria2 中的所有文件路径，包含断点续传使用的控制文件
+func taskPaths(t task, files []taskFile) []string {
+	paths := make([]string, 0, len(files)+1)
+	for _, f := range files {
+		paths = append(paths, f.Path)
+	}
+	return append(paths, filepath.Join(t.Dir, t.name()+".aria2"))
+}
+
+func moveFile(src, dst string) error {
+	if _, err := os.Stat(src); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	return os.Rename(src, dst)
+}
+
+// removeEmptyDirs 删除文件后清理任务目录下留下的空目录
+func removeEmptyDirs(root string, files []taskFile, localPath func(string) (string, bool)) {
+	for _, f := range files {
+		local, ok := localPath(f.Path)
+		if !ok {
+			continue
+		}
+		for dir := filepath.Dir(local); dir != root && strings.HasPrefix(dir, root); dir = filepath.Dir(dir) {
+			if err := os.Remove(dir); err != nil {
+				break
+			}
+		}
+	}
+}
+
+func (a *Aria2) GetTorrentName(ctx context.Context, hash string) (string, error) {
+	var name string
+	if err := wait.PollUntilContextTimeout(ctx, 500*time.Millisecond, 30*time.Second, true, func(ctx context.Context) (bool, error) {
+		t, err := a.getTask(ctx, hash)
+		if err != nil {
+			log.Errorf(ctx, "获取种子属性信息失败: %s", err)
+			return false, nil
+		}
+		if t.hasMetadata() {
+			name = t.name()
+			return true, nil
+		}
+		return false, nil
+	}); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// ListTorrentsStatus 获取 BangumiBuddy 添加的所有任务的状态
+func (a *Aria2) ListTorrentsStatus(ctx context.Context) ([]downloader.DownloadStatus, error) {
+	tasks, err := a.listTasks(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("获取种子列表失败: %w", err)
+	}
+	managed := make([]task, 0, len(tasks))
+	for _, t := range tasks {
+		if t.managed() {
+			managed = append(managed, t)
+		}
+	}
+	return convertTasksToDownloadStatuses(managed), nil
+}
+
+// DeleteTorrent 删除种子文件，aria2 不会删除已下载的文件，通过路径映射在本机清理
+func (a *Aria2) DeleteTorrent(ctx context.Context, hash string) error {
+	return a.RemoveTorrent(ctx, hash, true)
+}
+
+// RemoveTorrent 移除种子任务，deleteFiles 为 true 时同时删除已下载的文件
+func (a *Aria2) RemoveTorrent(ctx context.Context, hash string, deleteFiles bool) error {
+	t, err := a.getTask(ctx, hash)
+	if err != nil {
+		return fmt.Errorf("删除种子文件失败: %w", err)
+	}
+	files, err := a.getFiles(ctx, t)
+	if err != nil {
+		return fmt.Errorf("删除种子文件失败: %w", err)
+	}
+
+	if t.Status == statusActive || t.Status == statusWaiting || t.Status == statusPaused {
+		if err := a.client.call(ctx, "aria2.forceRemove", nil, t.GID); err != nil {
+			return fmt.Errorf("删除种子失败: %w", err)
+		}
+	}
+	if err := a.client.call(ctx, "aria2.removeDownloadResult", nil, t.GID); err != nil {
+		log.Warnf(ctx, "清理 aria2 下载记录失败: %s", err)
+	}
+	if !deleteFiles {
+		return nil
+	}
+	return a.deleteFiles(ctx, t, files)
+}
+
+// deleteFiles 通过路径映射删除任务在本机上的文件，aria2 的路径只在 aria2 所在的文件系统中有效，
+// 未配置路径映射时不删除，避免误删本机上的同名文件
+func (a *Aria2) deleteFiles(ctx context.Context, t task, files []taskFile) error {
+	root, ok := a.localPath(t.Dir)
+	if !ok {
+		log.Warnf(ctx, "未配置 aria2 路径映射，种子 %s 的文件需要手动删除: %s", t.name(), t.Dir)
+		return nil
+	}
+	if _, err := os.Stat(a.config.LocalPath); err != nil {
+		return fmt.Errorf("aria2 本地路径 %s 不可用: %w", a.config.LocalPath, err)
+	}
+	for _, path := range taskPaths(t, files) {
+		local, ok := a.localPath(path)
+		if !ok {
+			continue
+		}
+		if err := os.Remove(local); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("删除种子文件失败: %w", err)
+		}
+	}
+	removeEmptyDirs(root, files, a.localPath)
+	return nil
+}
+
+func (a *Aria2) ContinueDownload(ctx context.Context, hash string) error {
+	t, err := a.getTask(ctx, hash)
+	if err != nil {
+		return err
+	}
+	return a.client.call(ctx, "aria2.unpause", nil, t.GID)
+}
+
+// PauseTorrent 暂停种子下载
+func (a *Aria2) PauseTorrent(ctx context.Context, hash string) error {
+	t, err := a.getTask(ctx, hash)
+	if err != nil {
+		return err
+	}
+	return a.client.call(ctx, "aria2.forcePause", nil, t.GID)
+}
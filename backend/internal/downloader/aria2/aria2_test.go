@@ -0,0 +1,305 @@
+package aria2
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MangataL/BangumiBuddy/internal/downloader"
+)
+
+type rpcCall struct {
+	Method string
+	Params []interface{}
+}
+
+type fakeServer struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	active  []map[string]interface{}
+	waiting []map[string]interface{}
+	stopped []map[string]interface{}
+	files   map[string][]map[string]interface{}
+	calls   []rpcCall
+}
+
+func newFakeServer(t *testing.T) *fakeServer {
+	t.Helper()
+
+	fs := &fakeServer{files: make(map[string][]map[string]interface{})}
+	fs.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/test.torrent" {
+			_, _ = w.Write([]byte("torrent-content"))
+			return
+		}
+		if r.URL.Path != rpcPath {
+			http.NotFound(w, r)
+			return
+		}
+		var req struct {
+			ID     string        `json:"id"`
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+		resp := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+		if len(req.Params) == 0 || req.Params[0] != "token:secret" {
+			resp["error"] = map[string]interface{}{"code": 1, "message": "Unauthorized"}
+			require.NoError(t, json.NewEncoder(w).Encode(resp))
+			return
+		}
+		params := req.Params[1:]
+
+		fs.mu.Lock()
+		defer fs.mu.Unlock()
+		fs.calls = append(fs.calls, rpcCall{Method: req.Method, Params: params})
+		switch req.Method {
+		case "aria2.tellActive":
+			resp["result"] = fs.active
+		case "aria2.tellWaiting":
+			resp["result"] = fs.waiting
+		case "aria2.tellStopped":
+			resp["result"] = fs.stopped
+		case "aria2.getFiles":
+			resp["result"] = fs.files[params[0].(string)]
+		default:
+			resp["result"] = "OK"
+		}
+		require.NoError(t, json.NewEncoder(w).Encode(resp))
+	}))
+	t.Cleanup(fs.Close)
+	return fs
+}
+
+func (fs *fakeServer) lastCall(method string) (rpcCall, bool) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	for i := len(fs.calls) - 1; i >= 0; i-- {
+		if fs.calls[i].Method == method {
+			return fs.calls[i], true
+		}
+	}
+	return rpcCall{}, false
+}
+
+func newTestAria2(fs *fakeServer) *Aria2 {
+	return NewAria2(Config{Host: fs.URL, Secret: "secret"}, nil)
+}
+
+func btTask(gid, hash, status, name string, total, completed string) map[string]interface{} {
+	task := map[string]interface{}{
+		"gid":             gid,
+		"status":          status,
+		"infoHash":        hash,
+		"totalLength":     total,
+		"completedLength": completed,
+		"downloadSpeed":   "1024",
+		"dir":             "/downloads",
+	}
+	if name != "" {
+		task["bittorrent"] = map[string]interface{}{"info": map[string]interface{}{"name": name}}
+	}
+	return task
+}
+
+func TestAddTorrentMagnetPausesAfterMetadata(t *testing.T) {
+	fs := newFakeServer(t)
+	a := newTestAria2(fs)
+
+	require.NoError(t, a.AddTorrent(context.Background(), "magnet:?xt=urn:btih:abc", "/downloads/tv", "MetadataReceived"))
+	call, ok := fs.lastCall("aria2.addUri")
+	require.True(t, ok)
+	assert.Equal(t, []interface{}{"magnet:?xt=urn:btih:abc"}, call.Params[0])
+	options := call.Params[1].(map[string]interface{})
+	assert.Equal(t, "/downloads/tv", options["dir"])
+	assert.Equal(t, "true", options["pause-metadata"])
+	assert.True(t, strings.HasPrefix(options["gid"].(string), gidPrefix))
+	assert.Len(t, options["gid"], 16)
+}
+
+func TestAddTorrentFileUsesAddTorrent(t *testing.T) {
+	fs := newFakeServer(t)
+	a := newTestAria2(fs)
+
+	require.NoError(t, a.AddTorrent(context.Background(), fs.URL+"/test.torrent", "/downloads/tv", ""))
+	call, ok := fs.lastCall("aria2.addTorrent")
+	require.True(t, ok)
+	assert.Equal(t, base64.StdEncoding.EncodeToString([]byte("torrent-content")), call.Params[0])
+	options := call.Params[2].(map[string]interface{})
+	assert.Equal(t, "/downloads/tv", options["dir"])
+	assert.True(t, strings.HasPrefix(options["gid"].(string), gidPrefix))
+}
+
+func TestGetDownloadStatusesPrefersFollowedTask(t *testing.T) {
+	fs := newFakeServer(t)
+	metadata := btTask("m1", "abc", statusComplete, "", "100", "100")
+	metadata["followedBy"] = []string{"g1"}
+	fs.stopped = []map[string]interface{}{metadata}
+	fs.waiting = []map[string]interface{}{btTask("g1", "abc", statusPaused, "Test", "200", "50")}
+	fs.active = []map[string]interface{}{btTask("g2", "def", statusActive, "Other", "200", "200")}
+	a := newTestAria2(fs)
+
+	statuses, err := a.GetDownloadStatuses(context.Background(), []string{"ABC"})
+	require.NoError(t, err)
+	require.Len(t, statuses, 1)
+	assert.Equal(t, "abc", statuses[0].Hash)
+	assert.Equal(t, "Test", statuses[0].Name)
+	assert.Equal(t, downloader.TorrentStatusDownloadPaused, statuses[0].Status)
+	assert.InDelta(t, 0.25, statuses[0].Progress, 0.001)
+
+	statuses, err = a.ListTorrentsStatus(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, statuses)
+}
+
+func TestListTorrentsStatusOnlyManagedTasks(t *testing.T) {
+	fs := newFakeServer(t)
+	metadata := btTask(gidPrefix+"00000001", "abc", statusComplete, "", "100", "100")
+	metadata["followedBy"] = []string{"g1"}
+	followed := btTask("g1", "abc", statusPaused, "Test", "200", "50")
+	followed["following"] = gidPrefix + "00000001"
+	fs.stopped = []map[string]interface{}{metadata}
+	fs.waiting = []map[string]interface{}{followed}
+	fs.active = []map[string]interface{}{
+		btTask(gidPrefix+"00000002", "def", statusActive, "Managed", "200", "100"),
+		btTask("g3", "ghi", statusActive, "Other", "200", "100"),
+	}
+	a := newTestAria2(fs)
+
+	statuses, err := a.ListTorrentsStatus(context.Background())
+	require.NoError(t, err)
+	hashes := make([]string, 0, len(statuses))
+	for _, status := range statuses {
+		hashes = append(hashes, status.Hash)
+	}
+	assert.ElementsMatch(t, []string{"abc", "def"}, hashes)
+}
+
+func TestRemoveTorrentDeletesFilesThroughPathMapping(t *testing.T) {
+	fs := newFakeServer(t)
+	local := t.TempDir()
+	item := btTask("g1", "abc", statusComplete, "Test", "200", "200")
+	item["dir"] = "/downloads/tv"
+	fs.stopped = []map[string]interface{}{item}
+	fs.files["g1"] = []map[string]interface{}{{"index": "1", "path": "/downloads/tv/Test/01.mkv"}}
+	require.NoError(t, os.MkdirAll(filepath.Join(local, "tv", "Test"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(local, "tv", "Test", "01.mkv"), []byte("video"), 0o644))
+
+	// 未配置路径映射时不删除本机文件
+	require.NoError(t, newTestAria2(fs).DeleteTorrent(context.Background(), "abc"))
+	assert.FileExists(t, filepath.Join(local, "tv", "Test", "01.mkv"))
+
+	a := NewAria2(Config{Host: fs.URL, Secret: "secret", RemotePath: "/downloads", LocalPath: local}, nil)
+	require.NoError(t, a.DeleteTorrent(context.Background(), "abc"))
+	assert.NoFileExists(t, filepath.Join(local, "tv", "Test", "01.mkv"))
+	assert.NoDirExists(t, filepath.Join(local, "tv", "Test"))
+	assert.DirExists(t, filepath.Join(local, "tv"))
+
+	missing := NewAria2(Config{Host: fs.URL, Secret: "secret", RemotePath: "/downloads", LocalPath: filepath.Join(local, "missing")}, nil)
+	assert.Error(t, missing.DeleteTorrent(context.Background(), "abc"))
+}
+
+func TestSetLocationMovesDownloadedFiles(t *testing.T) {
+	fs := newFakeServer(t)
+	local := t.TempDir()
+	item := btTask("g1", "abc", statusPaused, "Test", "200", "100")
+	item["dir"] = "/downloads/tv"
+	fs.waiting = []map[string]interface{}{item}
+	fs.files["g1"] = []map[string]interface{}{{"index": "1", "path": "/downloads/tv/Test/01.mkv"}}
+	require.NoError(t, os.MkdirAll(filepath.Join(local, "tv", "Test"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(local, "tv", "Test", "01.mkv"), []byte("video"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(local, "tv", "Test.aria2"), []byte("control"), 0o644))
+
+	assert.Error(t, newTestAria2(fs).SetLocation(context.Background(), "abc", "/downloads/movie"))
+
+	a := NewAria2(Config{Host: fs.URL, Secret: "secret", RemotePath: "/downloads", LocalPath: local}, nil)
+	require.NoError(t, a.SetLocation(context.Background(), "abc", "/downloads/movie"))
+	assert.FileExists(t, filepath.Join(local, "movie", "Test", "01.mkv"))
+	assert.FileExists(t, filepath.Join(local, "movie", "Test.aria2"))
+	assert.NoDirExists(t, filepath.Join(local, "tv", "Test"))
+	call, ok := fs.lastCall("aria2.changeOption")
+	require.True(t, ok)
+	assert.Equal(t, map[string]interface{}{"dir": "/downloads/movie"}, call.Params[1])
+}
+
+func TestConvertTasksToDownloadStatuses(t *testing.T) {
+	statuses := convertTasksToDownloadStatuses([]task{
+		{InfoHash: "a", Status: statusError, ErrorMessage: "boom"},
+		{InfoHash: "b", Status: statusActive, Seeder: "true", TotalLength: "10", CompletedLength: "10", BitTorrent: &bitTorrent{}},
+		{InfoHash: "c", Status: statusActive, TotalLength: "10", CompletedLength: "5"},
+	})
+	require.Len(t, statuses, 3)
+	assert.Equal(t, downloader.TorrentStatusDownloadError, statuses[0].Status)
+	assert.Equal(t, "boom", statuses[0].Error)
+	// 没有元数据的任务不会被当作下载完成
+	assert.Equal(t, downloader.TorrentStatusDownloading, statuses[1].Status)
+	assert.Equal(t, downloader.TorrentStatusDownloading, statuses[2].Status)
+
+	done := task{InfoHash: "d", Status: statusComplete, TotalLength: "10", CompletedLength: "10", BitTorrent: &bitTorrent{}}
+	done.BitTorrent.Info.Name = "Test"
+	statuses = convertTasksToDownloadStatuses([]task{done})
+	assert.Equal(t, downloader.TorrentStatusDownloaded, statuses[0].Status)
+}
+
+func TestSetTorrentFilePriorities(t *testing.T) {
+	fs := newFakeServer(t)
+	fs.waiting = []map[string]interface{}{btTask("g1", "abc", statusPaused, "Test", "200", "0")}
+	fs.files["g1"] = []map[string]interface{}{
+		{"index": "1", "path": "/downloads/Test/01.mkv"},
+		{"index": "2", "path": "/downloads/Test/02.mkv"},
+	}
+	a := newTestAria2(fs)
+
+	names, err := a.GetTorrentFileNames(context.Background(), "abc")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Test/01.mkv", "Test/02.mkv"}, names)
+
+	require.NoError(t, a.SetTorrentFilePriorities(context.Background(), "abc", []downloader.TorrentFileSelection{
+		{FileName: "Test/01.mkv", Download: false},
+		{FileName: "Test/02.mkv", Download: true},
+	}))
+	call, ok := fs.lastCall("aria2.changeOption")
+	require.True(t, ok)
+	assert.Equal(t, "g1", call.Params[0])
+	assert.Equal(t, map[string]interface{}{"select-file": "2"}, call.Params[1])
+
+	err = a.SetTorrentFilePriorities(context.Background(), "abc", []downloader.TorrentFileSelection{
+		{FileName: "Test/01.mkv", Download: false},
+		{FileName: "Test/02.mkv", Download: false},
+	})
+	assert.Error(t, err)
+}
+
+func TestContinueDownloadUnpausesByGID(t *testing.T) {
+	fs := newFakeServer(t)
+	fs.waiting = []map[string]interface{}{btTask("g1", "abc", statusPaused, "Test", "200", "0")}
+	a := newTestAria2(fs)
+
+	require.NoError(t, a.ContinueDownload(context.Background(), "abc"))
+	call, ok := fs.lastCall("aria2.unpause")
+	require.True(t, ok)
+	assert.Equal(t, []interface{}{"g1"}, call.Params)
+
+	assert.Error(t, a.ContinueDownload(context.Background(), "missing"))
+}
+
+func TestCallReturnsRPCError(t *testing.T) {
+	fs := newFakeServer(t)
+	a := NewAria2(Config{Host: fs.URL}, nil)
+
+	_, err := a.ListTorrentsStatus(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Unauthorized")
+}
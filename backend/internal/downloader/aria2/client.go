@@ -0,0 +1,107 @@
+package aria2
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const rpcPath = "/jsonrpc"
+
+// client aria2 JSON-RPC客户端
+type client struct {
+	endpoint   string
+	secret     string
+	httpClient *http.Client
+	id         atomic.Int64
+}
+
+func newClient(config Config) *client {
+	return &client{
+		endpoint:   buildEndpoint(config.Host),
+		secret:     config.Secret,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// buildEndpoint 未指定RPC路径时补全默认路径
+func buildEndpoint(host string) string {
+	host = strings.TrimRight(host, "/")
+	if strings.HasSuffix(host, rpcPath) {
+		return host
+	}
+	return host + rpcPath
+}
+
+type rpcRequest struct {
+	JSONRPC string        `json:"jsonrpc"`
+	ID      string        `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type rpcResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *rpcError       `json:"error"`
+}
+
+// call 调用RPC方法，配置了密钥时自动在参数前追加token
+func (c *client) call(ctx context.Context, method string, result interface{}, params ...interface{}) error {
+	if c.secret != "" {
+		params = append([]interface{}{"token:" + c.secret}, params...)
+	}
+	if params == nil {
+		params = []interface{}{}
+	}
+	body, err := json.Marshal(rpcRequest{
+		JSONRPC: "2.0",
+		ID:      strconv.FormatInt(c.id.Add(1), 10),
+		Method:  method,
+		Params:  params,
+	})
+	if err != nil {
+		return fmt.Errorf("序列化请求失败: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求 aria2 失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("读取 aria2 响应失败: %w", err)
+	}
+	var rpcResp rpcResponse
+	if err := json.Unmarshal(respBody, &rpcResp); err != nil {
+		return fmt.Errorf("解析 aria2 响应失败: %d, %s", resp.StatusCode, string(respBody))
+	}
+	if rpcResp.Error != nil {
+		return fmt.Errorf("aria2 %s 调用失败: %s", method, rpcResp.Error.Message)
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(rpcResp.Result, result); err != nil {
+		return fmt.Errorf("解析 aria2 %s 响应失败: %w", method, err)
+	}
+	return nil
+}
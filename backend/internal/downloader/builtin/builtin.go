@@ -0,0 +1,783 @@
+package builtin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	g "github.com/anacrolix/generics"
+	"github.com/anacrolix/torrent"
+	"github.com/anacrolix/torrent/metainfo"
+	"github.com/anacrolix/torrent/storage"
+	"golang.org/x/time/rate"
+
+	"github.com/MangataL/BangumiBuddy/internal/downloader"
+	"github.com/MangataL/BangumiBuddy/internal/network"
+	"github.com/MangataL/BangumiBuddy/pkg/log"
+)
+
+// 确保Builtin实现了Downloader接口
+var (
+	_ downloader.Downloader     = (*Builtin)(nil)
+	_ downloader.TorrentRemover = (*Builtin)(nil)
+	_ downloader.TorrentPauser  = (*Builtin)(nil)
+)
+
+const (
+	defaultDataDir    = "/data/builtin"
+	defaultListenPort = 6881
+	// speedInterval 计算下载速度的最小时间间隔
+	speedInterval = time.Second
+	// 客户端关闭时异步释放监听端口，重新加载配置时需要等待端口释放
+	listenRetries       = 20
+	listenRetryInterval = 100 * time.Millisecond
+	// fetchTimeout 下载种子文件的超时时间
+	fetchTimeout = 30 * time.Second
+)
+
+// Config 内置下载器配置
+type Config struct {
+	DataDir           string `mapstructure:"data_dir" json:"dataDir" default:"/data/builtin"` // 数据目录，保存断点续传信息
+	DefaultSavePath   string `mapstructure:"default_save_path" json:"defaultSavePath"`        // 未指定保存路径时的下载目录
+	ListenPort        int    `mapstructure:"listen_port" json:"listenPort" default:"6881"`    // 监听端口
+	DownloadRateLimit int64  `mapstructure:"download_rate_limit" json:"downloadRateLimit"`    // 全局下载限速，单位 KiB/s，0 表示不限速
+	UploadRateLimit   int64  `mapstructure:"upload_rate_limit" json:"uploadRateLimit"`        // 全局上传限速，单位 KiB/s，0 表示不限速
+	DisableDHT        bool   `mapstructure:"disable_dht" json:"disableDHT"`                   // 禁用DHT
+	DisableUpload     bool   `mapstructure:"disable_upload" json:"disableUpload"`             // 禁止上传
+}
+
+// Conflicts 判断两个配置是否使用相同的数据目录或监听端口，冲突的下载器不能同时运行
+func (c Config) Conflicts(other Config) bool {
+	return c.dataDir() == other.dataDir() || c.listenPort() == other.listenPort()
+}
+
+func (c Config) dataDir() string {
+	if c.DataDir == "" {
+		return defaultDataDir
+	}
+	return filepath.Clean(c.DataDir)
+}
+
+func (c Config) listenPort() int {
+	if c.ListenPort == 0 {
+		return defaultListenPort
+	}
+	return c.ListenPort
+}
+
+// Builtin 基于 anacrolix/torrent 的内置下载器，实现Downloader接口
+type Builtin struct {
+	config     Config
+	client     *torrent.Client
+	completion storage.PieceCompletion
+	store      *stateStore
+	network    network.HTTPClientProvider
+
+	mu    sync.RWMutex
+	tasks map[string]*task
+
+	// 保存路径单独加锁，存储层在获取到元数据时会回调查询
+	pathsMu   sync.RWMutex
+	savePaths map[metainfo.Hash]string
+}
+
+type task struct {
+	t     *torrent.Torrent
+	state taskState
+
+	lastRead int64
+	lastAt   time.Time
+	speed    int64
+}
+
+// NewBuiltin 创建内置下载器，并恢复数据目录中保存的种子任务
+func NewBuiltin(config Config, provider network.HTTPClientProvider) (*Builtin, error) {
+	if config.DataDir == "" {
+		config.DataDir = defaultDataDir
+	}
+	if config.DefaultSavePath == "" {
+		config.DefaultSavePath = filepath.Join(config.DataDir, "downloads")
+	}
+	if err := os.MkdirAll(config.DataDir, stateDirPermMode); err != nil {
+		return nil, fmt.Errorf("创建内置下载器数据目录失败: %w", err)
+	}
+	store, err := newStateStore(config.DataDir)
+	if err != nil {
+		return nil, err
+	}
+	completion, err := storage.NewBoltPieceCompletion(config.DataDir)
+	if err != nil {
+		return nil, fmt.Errorf("初始化分片完成记录失败: %w", err)
+	}
+
+	b := &Builtin{
+		config:     config,
+		completion: completion,
+		store:      store,
+		network:    provider,
+		tasks:      make(map[string]*task),
+		savePaths:  make(map[metainfo.Hash]string),
+	}
+
+	clientConfig := torrent.NewDefaultClientConfig()
+	clientConfig.DataDir = config.DataDir
+	clientConfig.ListenPort = config.ListenPort
+	if clientConfig.ListenPort == 0 {
+		clientConfig.ListenPort = defaultListenPort
+	}
+	clientConfig.NoDHT = config.DisableDHT
+	clientConfig.NoUpload = config.DisableUpload
+	clientConfig.Seed = !config.DisableUpload
+	clientConfig.DefaultStorage = storage.NewFileOpts(storage.NewFileClientOpts{
+		ClientBaseDir:   config.DefaultSavePath,
+		TorrentDirMaker: b.torrentDir,
+		PieceCompletion: completion,
+		UsePartFiles:    g.Some(false),
+	})
+	// burst 为 0 时由客户端按限速设置默认值
+	if config.DownloadRateLimit > 0 {
+		clientConfig.DownloadRateLimiter = rate.NewLimiter(rate.Limit(config.DownloadRateLimit*1024), 0)
+	}
+	if config.UploadRateLimit > 0 {
+		clientConfig.UploadRateLimiter = rate.NewLimiter(rate.Limit(config.UploadRateLimit*1024), 0)
+	}
+
+	client, err := newClient(clientConfig)
+	if err != nil {
+		_ = completion.Close()
+		return nil, fmt.Errorf("启动内置下载器失败: %w", err)
+	}
+	b.client = client
+
+	if err := b.restore(); err != nil {
+		_ = b.Close()
+		return nil, err
+	}
+	return b, nil
+}
+
+func newClient(clientConfig *torrent.ClientConfig) (*torrent.Client, error) {
+	for i := 0; ; i++ {
+		client, err := torrent.NewClient(clientConfig)
+		if err == nil || !errors.Is(err, syscall.EADDRINUSE) || i >= listenRetries {
+			return client, err
+		}
+		time.Sleep(listenRetryInterval)
+	}
+}
+
+// Close 关闭内置下载器，释放监听端口
+func (b *Builtin) Close() error {
+	b.saveUploaded()
+	errs := b.client.Close()
+	if err := b.completion.Close(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// saveUploaded 保存本次运行的上传量，重启后分享率不会被清零
+func (b *Builtin) saveUploaded() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, tk := range b.tasks {
+		tk.state.Uploaded = uploaded(tk)
+		if err := b.store.save(tk.state); err != nil {
+			log.Errorf(context.Background(), "保存种子 %s 状态失败: %s", tk.state.Hash, err)
+		}
+	}
+}
+
+func uploaded(tk *task) int64 {
+	stats := tk.t.Stats()
+	return tk.state.Uploaded + stats.BytesWrittenData.Int64()
+}
+
+// ListenAddr 内置下载器实际监听的地址
+func (b *Builtin) ListenAddr() string {
+	for _, addr := range b.client.ListenAddrs() {
+		return addr.String()
+	}
+	return ""
+}
+
+func (b *Builtin) torrentDir(baseDir string, _ *metainfo.Info, infoHash metainfo.Hash) string {
+	b.pathsMu.RLock()
+	defer b.pathsMu.RUnlock()
+	if path, ok := b.savePaths[infoHash]; ok {
+		return path
+	}
+	return baseDir
+}
+
+func (b *Builtin) setSavePath(infoHash metainfo.Hash, savePath string) {
+	b.pathsMu.Lock()
+	defer b.pathsMu.Unlock()
+	b.savePaths[infoHash] = savePath
+}
+
+func (b *Builtin) deleteSavePath(infoHash metainfo.Hash) {
+	b.pathsMu.Lock()
+	defer b.pathsMu.Unlock()
+	delete(b.savePaths, infoHash)
+}
+
+// restore 恢复数据目录中保存的种子任务
+func (b *Builtin) restore() error {
+	states, err := b.store.list()
+	if err != nil {
+		return err
+	}
+	for _, state := range states {
+		spec, err := b.specFromState(state)
+		if err != nil {
+			log.Errorf(context.Background(), "恢复种子 %s 失败: %s", state.Hash, err)
+			continue
+		}
+		if _, err := b.addSpec(spec, state); err != nil {
+			log.Errorf(context.Background(), "恢复种子 %s 失败: %s", state.Hash, err)
+		}
+	}
+	return nil
+}
+
+func (b *Builtin) specFromState(state taskState) (*torrent.TorrentSpec, error) {
+	mi, err := b.store.loadMetainfo(state.Hash)
+	if err != nil {
+		return nil, err
+	}
+	if mi != nil {
+		return torrent.TorrentSpecFromMetaInfoErr(mi)
+	}
+	if strings.HasPrefix(state.Source, "magnet:") {
+		return torrent.TorrentSpecFromMagnetUri(state.Source)
+	}
+	return nil, fmt.Errorf("种子元数据不存在")
+}
+
+// addSpec 将种子加入客户端并开始跟踪，获取到元数据后会按照任务状态设置文件优先级
+func (b *Builtin) addSpec(spec *torrent.TorrentSpec, state taskState) (*task, error) {
+	state.Hash = spec.InfoHash.HexString()
+	b.setSavePath(spec.InfoHash, state.SavePath)
+
+	t, err := b.addToClient(spec, state.Paused)
+	if err != nil {
+		b.deleteSavePath(spec.InfoHash)
+		return nil, fmt.Errorf("添加种子失败: %w", err)
+	}
+	tk := &task{t: t, state: state}
+	b.mu.Lock()
+	b.tasks[state.Hash] = tk
+	b.mu.Unlock()
+
+	go b.waitInfo(tk)
+	return tk, nil
+}
+
+// addToClient 将种子加入客户端，暂停的种子会在设置元数据前禁止下载数据
+func (b *Builtin) addToClient(spec *torrent.TorrentSpec, paused bool) (*torrent.Torrent, error) {
+	// 客户端不会处理 spec 中的 DisallowDataDownload，需要在元数据可用前手动禁止
+	infoBytes := spec.InfoBytes
+	spec.InfoBytes = nil
+	t, _, err := b.client.AddTorrentSpec(spec)
+	if err != nil {
+		return nil, err
+	}
+	if paused {
+		t.DisallowDataDownload()
+	}
+	if infoBytes != nil {
+		if err := t.SetInfoBytes(infoBytes); err != nil {
+			t.Drop()
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+func (b *Builtin) waitInfo(tk *task) {
+	select {
+	case <-tk.t.GotInfo():
+	case <-tk.t.Closed():
+		return
+	}
+	ctx := log.NewContext()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.store.saveMetainfo(tk.state.Hash, tk.t.Metainfo()); err != nil {
+		log.Errorf(ctx, "保存种子 %s 元数据失败: %s", tk.state.Hash, err)
+	}
+	applySelection(tk)
+}
+
+// applySelection 按照文件选择设置优先级，需要在获取到元数据后调用
+func applySelection(tk *task) {
+	for i, f := range tk.t.Files() {
+		if tk.state.FileSelection == nil || (i < len(tk.state.FileSelection) && tk.state.FileSelection[i]) {
+			f.SetPriority(torrent.PiecePriorityNormal)
+		} else {
+			f.SetPriority(torrent.PiecePriorityNone)
+		}
+	}
+}
+
+func (b *Builtin) getTask(hash string) (*task, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	tk, ok := b.tasks[strings.ToLower(hash)]
+	if !ok {
+		return nil, fmt.Errorf("种子 %s 不存在", hash)
+	}
+	return tk, nil
+}
+
+func hasInfo(tk *task) bool {
+	return tk.t.Info() != nil
+}
+
+// GetTorrentFileNames implements downloader.Downloader.
+func (b *Builtin) GetTorrentFileNames(ctx context.Context, hash string) ([]string, error) {
+	tk, err := b.getTask(hash)
+	if err != nil {
+		return nil, err
+	}
+	if !hasInfo(tk) {
+		return nil, fmt.Errorf("种子 %s 尚未获取到元数据", hash)
+	}
+	files := tk.t.Files()
+	fileNames := make([]string, 0, len(files))
+	for _, f := range files {
+		fileNames = append(fileNames, f.Path())
+	}
+	return fileNames, nil
+}
+
+// GetDownloadStatuses 实现Downloader接口的GetDownloadStatuses方法
+func (b *Builtin) GetDownloadStatuses(ctx context.Context, hashes []string) ([]downloader.DownloadStatus, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	tasks := make([]*task, 0, len(b.tasks))
+	if len(hashes) == 0 {
+		for _, tk := range b.tasks {
+			tasks = append(tasks, tk)
+		}
+	} else {
+		for _, hash := range hashes {
+			if tk, ok := b.tasks[strings.ToLower(hash)]; ok {
+				tasks = append(tasks, tk)
+			}
+		}
+	}
+
+	statuses := make([]downloader.DownloadStatus, 0, len(tasks))
+	for _, tk := range tasks {
+		statuses = append(statuses, b.convertTaskToDownloadStatus(ctx, tk))
+	}
+	return statuses, nil
+}
+
+// convertTaskToDownloadStatus 将任务信息转换为DownloadStatus，调用方需持有写锁
+func (b *Builtin) convertTaskToDownloadStatus(ctx context.Context, tk *task) downloader.DownloadStatus {
+	status := downloader.DownloadStatus{
+		Hash: tk.state.Hash,
+		Name: tk.t.Name(),
+	}
+	updateSpeed(tk)
+	status.DownloadSpeed = tk.speed
+
+	if !hasInfo(tk) {
+		// 获取元数据中，即使任务暂停也需要等待元数据获取完成
+		status.Status = downloader.TorrentStatusDownloading
+		return status
+	}
+
+	var wanted, completed int64
+	for i, f := range tk.t.Files() {
+		if tk.state.FileSelection != nil && (i >= len(tk.state.FileSelection) || !tk.state.FileSelection[i]) {
+			continue
+		}
+		wanted += f.Length()
+		completed += f.BytesCompleted()
+	}
+	status.Size = wanted
+	status.Uploaded = uploaded(tk)
+	if wanted > 0 {
+		status.Progress = float64(completed) / float64(wanted)
+		status.Ratio = float64(status.Uploaded) / float64(wanted)
+	}
+
+	switch {
+	case tk.state.Paused:
+		status.Status = downloader.TorrentStatusDownloadPaused
+	case completed >= wanted:
+		status.Status = downloader.TorrentStatusDownloaded
+		if tk.state.CompletedAt.IsZero() {
+			tk.state.CompletedAt = time.Now()
+			if err := b.store.save(tk.state); err != nil {
+				log.Errorf(ctx, "保存种子 %s 状态失败: %s", tk.state.Hash, err)
+			}
+		}
+		status.Cost = tk.state.CompletedAt.Sub(tk.state.AddedAt)
+		status.SeedingTime = time.Since(tk.state.CompletedAt)
+	default:
+		status.Status = downloader.TorrentStatusDownloading
+	}
+	return status
+}
+
+func updateSpeed(tk *task) {
+	stats := tk.t.Stats()
+	read := stats.BytesReadUsefulData.Int64()
+	now := time.Now()
+	if tk.lastAt.IsZero() {
+		tk.lastRead, tk.lastAt = read, now
+		return
+	}
+	elapsed := now.Sub(tk.lastAt)
+	if elapsed < speedInterval {
+		return
+	}
+	tk.speed = int64(float64(read-tk.lastRead) / elapsed.Seconds())
+	tk.lastRead, tk.lastAt = read, now
+}
+
+func (b *Builtin) SetTorrentFilePriorities(ctx context.Context, hash string, files []downloader.TorrentFileSelection) error {
+	if len(files) == 0 {
+		return fmt.Errorf("未提供文件选择信息")
+	}
+	fileSelections := make(map[string]bool, len(files))
+	for _, f := range files {
+		fileSelections[f.FileName] = f.Download
+	}
+
+	tk, err := b.getTask(hash)
+	if err != nil {
+		return err
+	}
+	if !hasInfo(tk) {
+		return fmt.Errorf("种子 %s 尚未获取到元数据", hash)
+	}
+
+	torrentFiles := tk.t.Files()
+	usedSelections := make(map[string]struct{}, len(files))
+	selection := make([]bool, len(torrentFiles))
+	var selected int
+	for i, info := range torrentFiles {
+		download, ok := fileSelections[info.Path()]
+		if !ok {
+			return fmt.Errorf("文件 %s 不在任务选择列表中", info.Path())
+		}
+		usedSelections[info.Path()] = struct{}{}
+		selection[i] = download
+		if download {
+			selected++
+		}
+	}
+	if len(usedSelections) != len(fileSelections) {
+		return fmt.Errorf("任务选择列表包含未匹配的文件")
+	}
+	if selected == 0 {
+		return fmt.Errorf("未选择任何文件，无法开始下载")
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	tk.state.FileSelection = selection
+	applySelection(tk)
+	return b.store.save(tk.state)
+}
+
+// AddTorrent 添加种子，stopCondition 不为空时只获取元数据，不下载数据
+func (b *Builtin) AddTorrent(ctx context.Context, torrentLink, savePath, stopCondition string) error {
+	if savePath == "" {
+		savePath = b.config.DefaultSavePath
+	}
+	var (
+		spec *torrent.TorrentSpec
+		mi   *metainfo.MetaInfo
+		err  error
+	)
+	if strings.HasPrefix(torrentLink, "magnet:") {
+		spec, err = torrent.TorrentSpecFromMagnetUri(torrentLink)
+	} else {
+		mi, err = b.fetchMetainfo(ctx, torrentLink)
+		if err == nil {
+			spec, err = torrent.TorrentSpecFromMetaInfoErr(mi)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("解析种子失败: %w", err)
+	}
+
+	hash := spec.InfoHash.HexString()
+	if _, err := b.getTask(hash); err == nil {
+		return nil
+	}
+	if mi != nil {
+		if err := b.store.saveMetainfo(hash, *mi); err != nil {
+			return err
+		}
+	}
+	state := taskState{
+		Hash:     hash,
+		Source:   torrentLink,
+		SavePath: savePath,
+		Paused:   stopCondition != "",
+		AddedAt:  time.Now(),
+	}
+	if err := b.store.save(state); err != nil {
+		return err
+	}
+	_, err = b.addSpec(spec, state)
+	return err
+}
+
+// httpClient 获取下载种子文件使用的HTTP客户端，未设置网络配置时直连
+func (b *Builtin) httpClient() *http.Client {
+	if b.network == nil {
+		return &http.Client{Timeout: fetchTimeout}
+	}
+	return b.network.HTTPClient(fetchTimeout)
+}
+
+func (b *Builtin) fetchMetainfo(ctx context.Context, torrentLink string) (*metainfo.MetaInfo, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, torrentLink, nil)
+	if err != nil {
+		return nil, fmt.Errorf("创建种子下载请求失败: %w", err)
+	}
+	resp, err := b.httpClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("下载种子文件失败: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("下载种子文件失败: %d", resp.StatusCode)
+	}
+	return metainfo.Load(resp.Body)
+}
+
+// SetLocation 移动种子文件到新的保存路径，移动期间会暂时移除种子
+func (b *Builtin) SetLocation(ctx context.Context, hash, savePath string) error {
+	tk, err := b.getTask(hash)
+	if err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	oldPath := tk.state.SavePath
+	if filepath.Clean(oldPath) == filepath.Clean(savePath) {
+		return nil
+	}
+	spec, err := b.specFromState(tk.state)
+	if err != nil {
+		return err
+	}
+	var filePaths []string
+	if hasInfo(tk) {
+		for _, f := range tk.t.Files() {
+			filePaths = append(filePaths, f.Path())
+		}
+	}
+	tk.t.Drop()
+	<-tk.t.Closed()
+
+	moved := make([]string, 0, len(filePaths))
+	for _, name := range filePaths {
+		if err := moveFile(filepath.Join(oldPath, name), filepath.Join(savePath, name)); err != nil {
+			b.restoreLocation(ctx, tk, spec, oldPath, savePath, moved)
+			return fmt.Errorf("移动种子文件失败: %w", err)
+		}
+		moved = append(moved, name)
+	}
+
+	tk.state.SavePath = savePath
+	if err := b.store.save(tk.state); err != nil {
+		tk.state.SavePath = oldPath
+		b.restoreLocation(ctx, tk, spec, oldPath, savePath, moved)
+		return err
+	}
+	removeEmptyDirs(oldPath, filePaths)
+	b.setSavePath(spec.InfoHash, savePath)
+	t, err := b.addToClient(spec, tk.state.Paused)
+	if err != nil {
+		return fmt.Errorf("重新添加种子失败: %w", err)
+	}
+	tk.t = t
+	tk.lastAt = time.Time{}
+	go b.waitInfo(tk)
+	return nil
+}
+
+// restoreLocation 移动失败时将已移动的文件移回原保存路径，并在原路径重新添加种子
+func (b *Builtin) restoreLocation(ctx context.Context, tk *task, spec *torrent.TorrentSpec, oldPath, savePath string, moved []string) {
+	for _, name := range moved {
+		if err := moveFile(filepath.Join(savePath, name), filepath.Join(oldPath, name)); err != nil {
+			log.Errorf(ctx, "恢复种子 %s 的文件 %s 失败: %s", tk.state.Hash, name, err)
+		}
+	}
+	removeEmptyDirs(savePath, moved)
+	t, err := b.addToClient(spec, tk.state.Paused)
+	if err != nil {
+		log.Errorf(ctx, "在原保存路径重新添加种子 %s 失败: %s", tk.state.Hash, err)
+		return
+	}
+	tk.t = t
+	tk.lastAt = time.Time{}
+	go b.waitInfo(tk)
+}
+
+func moveFile(src, dst string) error {
+	if _, err := os.Stat(src); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), stateDirPermMode); err != nil {
+		return err
+	}
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+	// 跨设备时无法直接重命名，复制后删除
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		_ = os.Remove(dst)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		_ = os.Remove(dst)
+		return err
+	}
+	return os.Remove(src)
+}
+
+// removeEmptyDirs 清理文件移动或删除后留下的空目录
+func removeEmptyDirs(base string, filePaths []string) {
+	for _, name := range filePaths {
+		dir := filepath.Dir(filepath.Join(base, name))
+		for dir != filepath.Clean(base) && strings.HasPrefix(dir, filepath.Clean(base)) {
+			if err := os.Remove(dir); err != nil {
+				break
+			}
+			dir = filepath.Dir(dir)
+		}
+	}
+}
+
+func (b *Builtin) GetTorrentName(ctx context.Context, hash string) (string, error) {
+	tk, err := b.getTask(hash)
+	if err != nil {
+		return "", err
+	}
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	select {
+	case <-tk.t.GotInfo():
+		return tk.t.Name(), nil
+	case <-ctx.Done():
+		return "", fmt.Errorf("获取种子名称超时: %w", ctx.Err())
+	}
+}
+
+func (b *Builtin) ListTorrentsStatus(ctx context.Context) ([]downloader.DownloadStatus, error) {
+	return b.GetDownloadStatuses(ctx, nil)
+}
+
+// DeleteTorrent 删除种子文件
+func (b *Builtin) DeleteTorrent(ctx context.Context, hash string) error {
+	return b.RemoveTorrent(ctx, hash, true)
+}
+
+// RemoveTorrent 移除种子任务，deleteFiles 为 true 时同时删除已下载的文件
+func (b *Builtin) RemoveTorrent(ctx context.Context, hash string, deleteFiles bool) error {
+	tk, err := b.getTask(hash)
+	if err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	info := tk.t.Info()
+	var filePaths []string
+	if info != nil && deleteFiles {
+		for _, f := range tk.t.Files() {
+			filePaths = append(filePaths, f.Path())
+		}
+	}
+	ih := tk.t.InfoHash()
+	tk.t.Drop()
+	<-tk.t.Closed()
+
+	for _, name := range filePaths {
+		if err := os.Remove(filepath.Join(tk.state.SavePath, name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("删除种子文件失败: %w", err)
+		}
+	}
+	removeEmptyDirs(tk.state.SavePath, filePaths)
+	// 清理分片完成记录，避免重新添加时误认为已下载
+	if info != nil && deleteFiles {
+		for i := 0; i < info.NumPieces(); i++ {
+			_ = b.completion.Set(metainfo.PieceKey{InfoHash: ih, Index: i}, false)
+		}
+	}
+	if err := b.store.delete(tk.state.Hash); err != nil {
+		return err
+	}
+	delete(b.tasks, tk.state.Hash)
+	b.deleteSavePath(ih)
+	return nil
+}
+
+func (b *Builtin) ContinueDownload(ctx context.Context, hash string) error {
+	tk, err := b.getTask(hash)
+	if err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !tk.state.Paused {
+		return nil
+	}
+	tk.state.Paused = false
+	if err := b.store.save(tk.state); err != nil {
+		return err
+	}
+	tk.t.AllowDataDownload()
+	return nil
+}
+
+// PauseTorrent 暂停种子下载
+func (b *Builtin) PauseTorrent(ctx context.Context, hash string) error {
+	tk, err := b.getTask(hash)
+	if err != nil {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if tk.state.Paused {
+		return nil
+	}
+	tk.state.Paused = true
+	if err := b.store.save(tk.state); err != nil {
+		return err
+	}
+	tk.t.DisallowDataDownload()
+	return nil
+}
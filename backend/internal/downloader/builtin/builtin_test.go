@@ -0,0 +1,189 @@
+package builtin
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/anacrolix/torrent"
+	"github.com/anacrolix/torrent/bencode"
+	"github.com/anacrolix/torrent/metainfo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MangataL/BangumiBuddy/internal/downloader"
+)
+
+const (
+	testPieceLength = 16 * 1024
+	testFileSize    = 4 * testPieceLength
+)
+
+type seed struct {
+	hash    string
+	addr    string
+	content map[string][]byte
+}
+
+// newSeed 在本地启动一个做种客户端，种子包含两个文件
+func newSeed(t *testing.T) seed {
+	t.Helper()
+
+	dataDir := t.TempDir()
+	torrentDir := filepath.Join(dataDir, "Show")
+	require.NoError(t, os.MkdirAll(torrentDir, 0o755))
+	content := make(map[string][]byte)
+	for _, name := range []string{"01.mkv", "02.mkv"} {
+		data := make([]byte, testFileSize)
+		_, err := rand.Read(data)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(torrentDir, name), data, 0o644))
+		content["Show/"+name] = data
+	}
+
+	info := metainfo.Info{PieceLength: testPieceLength}
+	require.NoError(t, info.BuildFromFilePath(torrentDir))
+	infoBytes, err := bencode.Marshal(info)
+	require.NoError(t, err)
+	mi := &metainfo.MetaInfo{InfoBytes: infoBytes}
+
+	cfg := torrent.NewDefaultClientConfig()
+	cfg.DataDir = dataDir
+	cfg.Seed = true
+	cfg.NoDHT = true
+	cfg.DisableTrackers = true
+	cfg.ListenPort = 0
+	client, err := torrent.NewClient(cfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+
+	tt, err := client.AddTorrent(mi)
+	require.NoError(t, err)
+	require.NoError(t, tt.VerifyData())
+	require.True(t, tt.Complete().Bool())
+
+	port := client.LocalPort()
+	return seed{
+		hash:    mi.HashInfoBytes().HexString(),
+		addr:    fmt.Sprintf("127.0.0.1:%d", port),
+		content: content,
+	}
+}
+
+func freePort(t *testing.T) int {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+func newTestBuiltin(t *testing.T, dataDir string) *Builtin {
+	t.Helper()
+	b, err := NewBuiltin(Config{
+		DataDir:    dataDir,
+		ListenPort: freePort(t),
+		DisableDHT: true,
+	}, nil)
+	require.NoError(t, err)
+	return b
+}
+
+func waitStatus(t *testing.T, b *Builtin, hash string, want downloader.TorrentStatus) downloader.DownloadStatus {
+	t.Helper()
+	var status downloader.DownloadStatus
+	require.Eventually(t, func() bool {
+		statuses, err := b.GetDownloadStatuses(context.Background(), []string{hash})
+		require.NoError(t, err)
+		require.Len(t, statuses, 1)
+		status = statuses[0]
+		return status.Status == want
+	}, 30*time.Second, 100*time.Millisecond, "等待种子状态 %s", want)
+	return status
+}
+
+func TestBuiltinDownloadEndToEnd(t *testing.T) {
+	s := newSeed(t)
+	dataDir := t.TempDir()
+	savePath := t.TempDir()
+	b := newTestBuiltin(t, dataDir)
+	ctx := context.Background()
+
+	magnet := fmt.Sprintf("magnet:?xt=urn:btih:%s&x.pe=%s", s.hash, s.addr)
+	require.NoError(t, b.AddTorrent(ctx, magnet, savePath, "MetadataReceived"))
+
+	// 只获取元数据，不下载数据
+	waitStatus(t, b, s.hash, downloader.TorrentStatusDownloadPaused)
+	name, err := b.GetTorrentName(ctx, s.hash)
+	require.NoError(t, err)
+	assert.Equal(t, "Show", name)
+	fileNames, err := b.GetTorrentFileNames(ctx, s.hash)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"Show/01.mkv", "Show/02.mkv"}, fileNames)
+	assert.NoFileExists(t, filepath.Join(savePath, "Show", "01.mkv"))
+
+	require.NoError(t, b.SetTorrentFilePriorities(ctx, s.hash, []downloader.TorrentFileSelection{
+		{FileName: "Show/01.mkv", Download: true},
+		{FileName: "Show/02.mkv", Download: false},
+	}))
+	require.NoError(t, b.ContinueDownload(ctx, s.hash))
+	status := waitStatus(t, b, s.hash, downloader.TorrentStatusDownloaded)
+	assert.Equal(t, int64(testFileSize), status.Size)
+
+	data, err := os.ReadFile(filepath.Join(savePath, "Show", "01.mkv"))
+	require.NoError(t, err)
+	assert.True(t, bytes.Equal(s.content["Show/01.mkv"], data))
+	assert.NoFileExists(t, filepath.Join(savePath, "Show", "02.mkv"))
+
+	// 移动失败时已移动的文件移回原路径，种子在原路径继续可用
+	newPath := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(savePath, "Show", "02.mkv"), []byte("placeholder"), 0o644))
+	require.NoError(t, os.MkdirAll(filepath.Join(newPath, "Show", "02.mkv", "blocked"), 0o755))
+	require.Error(t, b.SetLocation(ctx, s.hash, newPath))
+	assert.FileExists(t, filepath.Join(savePath, "Show", "01.mkv"))
+	assert.NoFileExists(t, filepath.Join(newPath, "Show", "01.mkv"))
+	waitStatus(t, b, s.hash, downloader.TorrentStatusDownloaded)
+	require.NoError(t, os.Remove(filepath.Join(savePath, "Show", "02.mkv")))
+	require.NoError(t, os.RemoveAll(filepath.Join(newPath, "Show")))
+
+	// 移动保存路径
+	require.NoError(t, b.SetLocation(ctx, s.hash, newPath))
+	assert.FileExists(t, filepath.Join(newPath, "Show", "01.mkv"))
+	assert.NoFileExists(t, filepath.Join(savePath, "Show", "01.mkv"))
+	waitStatus(t, b, s.hash, downloader.TorrentStatusDownloaded)
+
+	// 重启后从数据目录恢复
+	require.NoError(t, b.Close())
+	b = newTestBuiltin(t, dataDir)
+	t.Cleanup(func() { _ = b.Close() })
+	status = waitStatus(t, b, s.hash, downloader.TorrentStatusDownloaded)
+	assert.Equal(t, "Show", status.Name)
+	statuses, err := b.ListTorrentsStatus(ctx)
+	require.NoError(t, err)
+	assert.Len(t, statuses, 1)
+
+	require.NoError(t, b.DeleteTorrent(ctx, s.hash))
+	assert.NoFileExists(t, filepath.Join(newPath, "Show", "01.mkv"))
+	assert.NoDirExists(t, filepath.Join(newPath, "Show"))
+	statuses, err = b.ListTorrentsStatus(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, statuses)
+}
+
+func TestBuiltinUnknownTorrent(t *testing.T) {
+	b := newTestBuiltin(t, t.TempDir())
+	t.Cleanup(func() { _ = b.Close() })
+
+	_, err := b.GetTorrentFileNames(context.Background(), "missing")
+	assert.Error(t, err)
+	assert.Error(t, b.ContinueDownload(context.Background(), "missing"))
+	statuses, err := b.GetDownloadStatuses(context.Background(), []string{"missing"})
+	require.NoError(t, err)
+	assert.Empty(t, statuses)
+}
@@ -0,0 +1,126 @@
+package builtin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/anacrolix/torrent/metainfo"
+)
+
+const (
+	stateDirName     = "torrents"
+	stateFileExt     = ".json"
+	metainfoFileExt  = ".torrent"
+	stateFilePerm    = 0o644
+	stateDirPermMode = 0o755
+)
+
+// taskState 种子任务的持久化状态，用于重启后恢复下载
+type taskState struct {
+	Hash          string    `json:"hash"`
+	Source        string    `json:"source"`        // 添加时的磁力链接或种子链接
+	SavePath      string    `json:"savePath"`      // 保存路径
+	Paused        bool      `json:"paused"`        // 是否暂停
+	FileSelection []bool    `json:"fileSelection"` // 文件选择，为空时下载全部文件
+	AddedAt       time.Time `json:"addedAt"`
+	CompletedAt   time.Time `json:"completedAt"`
+	Uploaded      int64     `json:"uploaded"` // 之前运行期间累计的上传量
+}
+
+// stateStore 将种子状态和元数据保存在数据目录下
+type stateStore struct {
+	dir string
+}
+
+func newStateStore(dataDir string) (*stateStore, error) {
+	dir := filepath.Join(dataDir, stateDirName)
+	if err := os.MkdirAll(dir, stateDirPermMode); err != nil {
+		return nil, fmt.Errorf("创建种子状态目录失败: %w", err)
+	}
+	return &stateStore{dir: dir}, nil
+}
+
+func (s *stateStore) statePath(hash string) string {
+	return filepath.Join(s.dir, hash+stateFileExt)
+}
+
+func (s *stateStore) metainfoPath(hash string) string {
+	return filepath.Join(s.dir, hash+metainfoFileExt)
+}
+
+func (s *stateStore) save(state taskState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("序列化种子状态失败: %w", err)
+	}
+	// 先写临时文件再重命名，避免写入中断导致状态文件损坏
+	tmp := s.statePath(state.Hash) + ".tmp"
+	if err := os.WriteFile(tmp, data, stateFilePerm); err != nil {
+		return fmt.Errorf("保存种子状态失败: %w", err)
+	}
+	if err := os.Rename(tmp, s.statePath(state.Hash)); err != nil {
+		return fmt.Errorf("保存种子状态失败: %w", err)
+	}
+	return nil
+}
+
+func (s *stateStore) saveMetainfo(hash string, mi metainfo.MetaInfo) error {
+	file, err := os.Create(s.metainfoPath(hash))
+	if err != nil {
+		return fmt.Errorf("保存种子元数据失败: %w", err)
+	}
+	defer file.Close()
+	if err := mi.Write(file); err != nil {
+		return fmt.Errorf("保存种子元数据失败: %w", err)
+	}
+	return nil
+}
+
+// loadMetainfo 读取已保存的种子元数据，不存在时返回 nil
+func (s *stateStore) loadMetainfo(hash string) (*metainfo.MetaInfo, error) {
+	mi, err := metainfo.LoadFromFile(s.metainfoPath(hash))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取种子元数据失败: %w", err)
+	}
+	return mi, nil
+}
+
+func (s *stateStore) list() ([]taskState, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("读取种子状态目录失败: %w", err)
+	}
+	states := make([]taskState, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), stateFileExt) {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(s.dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("读取种子状态失败: %w", err)
+		}
+		var state taskState
+		if err := json.Unmarshal(data, &state); err != nil {
+			return nil, fmt.Errorf("解析种子状态 %s 失败: %w", entry.Name(), err)
+		}
+		states = append(states, state)
+	}
+	return states, nil
+}
+
+func (s *stateStore) delete(hash string) error {
+	for _, path := range []string{s.statePath(hash), s.metainfoPath(hash)} {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("删除种子状态失败: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package deluge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const rpcPath = "/json"
+
+// errCodeNotAuthenticated deluge 会话失效时返回的错误码
+const errCodeNotAuthenticated = 1
+
+var errNotAuthenticated = errors.New("deluge 会话未认证")
+
+// client Deluge Web JSON-RPC客户端，登录后的会话保存在cookie中
+type client struct {
+	endpoint   string
+	httpClient *http.Client
+	id         atomic.Int64
+}
+
+func newClient(config Config) *client {
+	jar, _ := cookiejar.New(nil)
+	return &client{
+		endpoint: buildEndpoint(config.Host),
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+			Jar:     jar,
+		},
+	}
+}
+
+// buildEndpoint 未指定RPC路径时补全默认路径
+func buildEndpoint(host string) string {
+	host = strings.TrimRight(host, "/")
+	if strings.HasSuffix(host, rpcPath) {
+		return host
+	}
+	return host + rpcPath
+}
+
+type rpcRequest struct {
+	ID     int64         `json:"id"`
+	Method string        `json:"method"`
+	Params []interface{} `json:"params"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type rpcResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *rpcError       `json:"error"`
+}
+
+func (c *client) call(ctx context.Context, method string, result interface{}, params ...interface{}) error {
+	if params == nil {
+		params = []interface{}{}
+	}
+	body, err := json.Marshal(rpcRequest{
+		ID:     c.id.Add(1),
+		Method: method,
+		Params: params,
+	})
+	if err != nil {
+		return fmt.Errorf("序列化请求失败: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求 deluge 失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("读取 deluge 响应失败: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("请求 deluge 失败: %d, %s", resp.StatusCode, string(respBody))
+	}
+	var rpcResp rpcResponse
+	if err := json.Unmarshal(respBody, &rpcResp); err != nil {
+		return fmt.Errorf("解析 deluge 响应失败: %w", err)
+	}
+	if rpcResp.Error != nil {
+		if rpcResp.Error.Code == errCodeNotAuthenticated {
+			return fmt.Errorf("%w: %s", errNotAuthenticated, rpcResp.Error.Message)
+		}
+		return fmt.Errorf("deluge %s 调用失败: %s", method, rpcResp.Error.Message)
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(rpcResp.Result, result); err != nil {
+		return fmt.Errorf("解析 deluge %s 响应失败: %w", method, err)
+	}
+	return nil
+}
@@ -0,0 +1,483 @@
+package deluge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"github.com/MangataL/BangumiBuddy/internal/downloader"
+	"github.com/MangataL/BangumiBuddy/pkg/log"
+)
+
+// 确保Deluge实现了Downloader接口
+var (
+	_ downloader.Downloader     = (*Deluge)(nil)
+	_ downloader.TorrentRemover = (*Deluge)(nil)
+	_ downloader.TorrentPauser  = (*Deluge)(nil)
+)
+
+const (
+	// 标签名称，deluge 的标签只支持小写
+	label = "bangumibuddy"
+	// 标签插件名称
+	labelPlugin = "Label"
+)
+
+// deluge 种子状态
+const (
+	stateError  = "Error"
+	statePaused = "Paused"
+	stateMoving = "Moving"
+)
+
+// priorityNormal deluge 文件的正常下载优先级，0 表示不下载
+const priorityNormal = 4
+
+var statusKeys = []string{
+	"hash",
+	"name",
+	"state",
+	"message",
+	"progress",
+	"download_payload_rate",
+	"total_wanted",
+	"total_done",
+	"num_files",
+	"time_added",
+	"completed_time",
+	"total_uploaded",
+	"ratio",
+	"seeding_time",
+}
+
+// Config Deluge配置，deluge web 只需要密码登录
+type Config struct {
+	Host     string `mapstructure:"host" json:"host"`
+	Password string `mapstructure:"password" json:"password"`
+}
+
+// Deluge 实现Downloader接口
+type Deluge struct {
+	client   *client
+	password string
+
+	initMu       sync.Mutex
+	initialized  bool
+	labelEnabled bool
+}
+
+// NewDeluge 创建一个新的Deluge实例
+func NewDeluge(config Config) *Deluge {
+	return &Deluge{
+		client:   newClient(config),
+		password: config.Password,
+	}
+}
+
+type torrentStatus struct {
+	Hash                string  `json:"hash"`
+	Name                string  `json:"name"`
+	State               string  `json:"state"`
+	Message             string  `json:"message"`
+	Progress            float64 `json:"progress"`
+	DownloadPayloadRate float64 `json:"download_payload_rate"`
+	TotalWanted         int64   `json:"total_wanted"`
+	TotalDone           int64   `json:"total_done"`
+	NumFiles            int     `json:"num_files"`
+	TimeAdded           float64 `json:"time_added"`
+	CompletedTime       float64 `json:"completed_time"`
+	Files               []file  `json:"files"`
+	TotalUploaded       int64   `json:"total_uploaded"`
+	Ratio               float64 `json:"ratio"`
+	SeedingTime         int64   `json:"seeding_time"`
+}
+
+type file struct {
+	Index int    `json:"index"`
+	Path  string `json:"path"`
+	Size  int64  `json:"size"`
+}
+
+// CheckConnection 检查deluge是否可以登录并连接到守护进程
+func (d *Deluge) CheckConnection(ctx context.Context) error {
+	return d.login(ctx)
+}
+
+func (d *Deluge) init(ctx context.Context) error {
+	d.initMu.Lock()
+	defer d.initMu.Unlock()
+
+	if d.initialized {
+		return nil
+	}
+	if err := d.login(ctx); err != nil {
+		return err
+	}
+	d.labelEnabled = d.ensureLabel(ctx)
+	d.initialized = true
+	return nil
+}
+
+func (d *Deluge) reset() {
+	d.initMu.Lock()
+	defer d.initMu.Unlock()
+	d.initialized = false
+}
+
+// login 登录deluge web，并确保web已连接到守护进程
+func (d *Deluge) login(ctx context.Context) error {
+	var ok bool
+	if err := d.client.call(ctx, "auth.login", &ok, d.password); err != nil {
+		return fmt.Errorf("deluge 登录失败: %w", err)
+	}
+	if !ok {
+		return errors.New("deluge 登录失败，请检查配置的密码是否正确")
+	}
+
+	var connected bool
+	if err := d.client.call(ctx, "web.connected", &connected); err != nil {
+		return fmt.Errorf("获取 deluge 连接状态失败: %w", err)
+	}
+	if connected {
+		return nil
+	}
+	// web.get_hosts 返回 [[id, host, port, status], ...]
+	var hosts [][]interface{}
+	if err := d.client.call(ctx, "web.get_hosts", &hosts); err != nil {
+		return fmt.Errorf("获取 deluge 守护进程列表失败: %w", err)
+	}
+	if len(hosts) == 0 || len(hosts[0]) == 0 {
+		return errors.New("deluge web 未配置守护进程")
+	}
+	hostID, _ := hosts[0][0].(string)
+	if err := d.client.call(ctx, "web.connect", nil, hostID); err != nil {
+		return fmt.Errorf("连接 deluge 守护进程失败: %w", err)
+	}
+	return nil
+}
+
+// ensureLabel 启用标签插件并创建标签，失败时不影响下载，只是无法按标签过滤种子
+func (d *Deluge) ensureLabel(ctx context.Context) bool {
+	var plugins []string
+	if err := d.client.call(ctx, "core.get_enabled_plugins", &plugins); err != nil {
+		log.Warnf(ctx, "获取 deluge 插件列表失败: %s", err)
+		return false
+	}
+	if !slices.Contains(plugins, labelPlugin) {
+		if err := d.client.call(ctx, "core.enable_plugin", nil, labelPlugin); err != nil {
+			log.Warnf(ctx, "启用 deluge 标签插件失败: %s", err)
+			return false
+		}
+	}
+	var labels []string
+	if err := d.client.call(ctx, "label.get_labels", &labels); err != nil {
+		log.Warnf(ctx, "获取 deluge 标签失败: %s", err)
+		return false
+	}
+	if !slices.Contains(labels, label) {
+		if err := d.client.call(ctx, "label.add", nil, label); err != nil {
+			log.Warnf(ctx, "创建 deluge 标签失败: %s", err)
+			return false
+		}
+	}
+	return true
+}
+
+// call 调用RPC方法，会话失效时重新登录后重试一次
+func (d *Deluge) call(ctx context.Context, method string, result interface{}, params ...interface{}) error {
+	if err := d.init(ctx); err != nil {
+		return err
+	}
+	err := d.client.call(ctx, method, result, params...)
+	if !errors.Is(err, errNotAuthenticated) {
+		return err
+	}
+	d.reset()
+	if err := d.init(ctx); err != nil {
+		return err
+	}
+	return d.client.call(ctx, method, result, params...)
+}
+
+func (d *Deluge) getTorrentStatus(ctx context.Context, hash string, keys []string) (torrentStatus, error) {
+	var status torrentStatus
+	if err := d.call(ctx, "core.get_torrent_status", &status, hash, keys); err != nil {
+		return torrentStatus{}, err
+	}
+	// deluge 查询不存在的种子时返回空对象
+	if status.Hash == "" {
+		return torrentStatus{}, fmt.Errorf("种子 %s 不存在", hash)
+	}
+	return status, nil
+}
+
+func (d *Deluge) getTorrentsStatus(ctx context.Context, filter map[string]interface{}) ([]torrentStatus, error) {
+	var result map[string]torrentStatus
+	if err := d.call(ctx, "core.get_torrents_status", &result, filter, statusKeys); err != nil {
+		return nil, fmt.Errorf("获取种子列表失败: %w", err)
+	}
+	torrents := make([]torrentStatus, 0, len(result))
+	for hash, t := range result {
+		if t.Hash == "" {
+			t.Hash = hash
+		}
+		torrents = append(torrents, t)
+	}
+	slices.SortFunc(torrents, func(a, b torrentStatus) int {
+		return strings.Compare(a.Hash, b.Hash)
+	})
+	return torrents, nil
+}
+
+// GetTorrentFileNames implements downloader.Downloader.
+func (d *Deluge) GetTorrentFileNames(ctx context.Context, hash string) ([]string, error) {
+	status, err := d.getTorrentStatus(ctx, hash, []string{"hash", "files"})
+	if err != nil {
+		return nil, fmt.Errorf("获取种子文件列表失败: %w", err)
+	}
+	fileNames := make([]string, 0, len(status.Files))
+	for _, f := range status.Files {
+		fileNames = append(fileNames, f.Path)
+	}
+	return fileNames, nil
+}
+
+// GetDownloadStatuses 实现Downloader接口的GetDownloadStatuses方法
+func (d *Deluge) GetDownloadStatuses(ctx context.Context, hashes []string) ([]downloader.DownloadStatus, error) {
+	filter := map[string]interface{}{}
+	if len(hashes) > 0 {
+		filter["id"] = hashes
+	}
+	torrents, err := d.getTorrentsStatus(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	return convertTorrentsToDownloadStatuses(torrents), nil
+}
+
+// convertTorrentsToDownloadStatuses 将deluge的种子信息转换为DownloadStatus
+func convertTorrentsToDownloadStatuses(torrents []torrentStatus) []downloader.DownloadStatus {
+	statuses := make([]downloader.DownloadStatus, 0, len(torrents))
+	for _, t := range torrents {
+		status := downloader.DownloadStatus{
+			Hash:          t.Hash,
+			Name:          t.Name,
+			Progress:      t.Progress / 100,
+			DownloadSpeed: int64(t.DownloadPayloadRate),
+			Size:          t.TotalWanted,
+			Uploaded:      t.TotalUploaded,
+			SeedingTime:   time.Duration(t.SeedingTime) * time.Second,
+		}
+		// 没有下载数据时 Deluge 返回 -1
+		if t.Ratio > 0 {
+			status.Ratio = t.Ratio
+		}
+		metadataReceived := t.NumFiles > 0
+		switch {
+		case t.State == stateError:
+			status.Status = downloader.TorrentStatusDownloadError
+			status.Error = t.Message
+		case metadataReceived && t.TotalWanted > 0 && t.TotalDone >= t.TotalWanted && t.State != stateMoving:
+			status.Status = downloader.TorrentStatusDownloaded
+			if t.CompletedTime > 0 {
+				status.Cost = time.Duration(t.CompletedTime-t.TimeAdded) * time.Second
+			}
+		case t.State == statePaused:
+			status.Status = downloader.TorrentStatusDownloadPaused
+		default:
+			status.Status = downloader.TorrentStatusDownloading
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+func (d *Deluge) SetTorrentFilePriorities(ctx context.Context, hash string, files []downloader.TorrentFileSelection) error {
+	if len(files) == 0 {
+		return fmt.Errorf("未提供文件选择信息")
+	}
+	fileSelections := make(map[string]bool, len(files))
+	for _, f := range files {
+		fileSelections[f.FileName] = f.Download
+	}
+
+	status, err := d.getTorrentStatus(ctx, hash, []string{"hash", "files"})
+	if err != nil {
+		return fmt.Errorf("获取种子文件信息失败: %w", err)
+	}
+
+	usedSelections := make(map[string]struct{}, len(files))
+	priorities := make([]int, len(status.Files))
+	var selected int
+	for _, info := range status.Files {
+		download, ok := fileSelections[info.Path]
+		if !ok {
+			return fmt.Errorf("文件 %s 不在任务选择列表中", info.Path)
+		}
+		usedSelections[info.Path] = struct{}{}
+		if info.Index < 0 || info.Index >= len(priorities) {
+			return fmt.Errorf("文件 %s 的序号 %d 无效", info.Path, info.Index)
+		}
+		if download {
+			priorities[info.Index] = priorityNormal
+			selected++
+		}
+	}
+	if len(usedSelections) != len(fileSelections) {
+		return fmt.Errorf("任务选择列表包含未匹配的文件")
+	}
+	if selected == 0 {
+		return fmt.Errorf("未选择任何文件，无法开始下载")
+	}
+
+	if err := d.call(ctx, "core.set_torrent_options", nil, []string{hash}, map[string]interface{}{
+		"file_priorities": priorities,
+	}); err != nil {
+		return fmt.Errorf("设置文件下载失败: %w", err)
+	}
+	return nil
+}
+
+// AddTorrent 添加种子，stopCondition 不为空时在获取到元数据后暂停下载
+//
+// deluge 不支持获取元数据后停止，种子文件自带元数据，直接以暂停状态添加；
+// 暂停的磁力链接不会获取元数据，添加后等待元数据获取完成再立即暂停
+func (d *Deluge) AddTorrent(ctx context.Context, torrentLink, savePath, stopCondition string) error {
+	magnet := strings.HasPrefix(torrentLink, "magnet:")
+	options := map[string]interface{}{
+		"add_paused": stopCondition != "" && !magnet,
+	}
+	if savePath != "" {
+		options["download_location"] = savePath
+	}
+	method := "core.add_torrent_url"
+	if magnet {
+		method = "core.add_torrent_magnet"
+	}
+	var hash string
+	if err := d.call(ctx, method, &hash, torrentLink, options); err != nil {
+		return fmt.Errorf("添加种子失败: %w", err)
+	}
+	if hash == "" {
+		return errors.New("添加种子失败: deluge 未返回种子哈希")
+	}
+	if err := d.setLabel(ctx, hash); err != nil {
+		return fmt.Errorf("添加标签失败: %w", err)
+	}
+	if stopCondition != "" && magnet {
+		return d.pauseAfterMetadata(ctx, hash)
+	}
+	return nil
+}
+
+// pauseAfterMetadata 等待磁力链接获取到元数据后暂停，避免种子不受控制地开始下载
+//
+// 超时时移除种子，调用方不会记录添加失败的种子，下次添加时重新获取元数据
+func (d *Deluge) pauseAfterMetadata(ctx context.Context, hash string) error {
+	waitErr := wait.PollUntilContextTimeout(ctx, 500*time.Millisecond, 30*time.Second, true, func(ctx context.Context) (bool, error) {
+		status, err := d.getTorrentStatus(ctx, hash, []string{"hash", "num_files"})
+		if err != nil {
+			log.Errorf(ctx, "获取种子属性信息失败: %s", err)
+			return false, nil
+		}
+		return status.NumFiles > 0, nil
+	})
+	if waitErr != nil {
+		if err := d.RemoveTorrent(context.WithoutCancel(ctx), hash, true); err != nil {
+			log.Errorf(ctx, "移除获取元数据超时的种子失败 [%s]: %s", hash, err)
+		}
+		return fmt.Errorf("等待种子元数据失败: %w", waitErr)
+	}
+	if err := d.pause(ctx, hash); err != nil {
+		return fmt.Errorf("暂停种子失败: %w", err)
+	}
+	return nil
+}
+
+func (d *Deluge) setLabel(ctx context.Context, hash string) error {
+	if !d.labelEnabled {
+		return nil
+	}
+	return d.call(ctx, "label.set_torrent", nil, hash, label)
+}
+
+func (d *Deluge) SetLocation(ctx context.Context, hash, savePath string) error {
+	if err := d.setLabel(ctx, hash); err != nil {
+		return fmt.Errorf("添加标签失败: %w", err)
+	}
+	// 未完成的种子在完成后也需要保存到新的路径
+	if err := d.call(ctx, "core.set_torrent_options", nil, []string{hash}, map[string]interface{}{
+		"move_completed":      true,
+		"move_completed_path": savePath,
+	}); err != nil {
+		return fmt.Errorf("设置种子保存路径失败: %w", err)
+	}
+	return d.call(ctx, "core.move_storage", nil, []string{hash}, savePath)
+}
+
+func (d *Deluge) GetTorrentName(ctx context.Context, hash string) (string, error) {
+	var name string
+	if err := wait.PollUntilContextTimeout(ctx, 500*time.Millisecond, 30*time.Second, true, func(ctx context.Context) (bool, error) {
+		status, err := d.getTorrentStatus(ctx, hash, []string{"hash", "name", "num_files"})
+		if err != nil {
+			log.Errorf(ctx, "获取种子属性信息失败: %s", err)
+			return false, nil
+		}
+		if status.NumFiles > 0 && !strings.EqualFold(status.Name, hash) {
+			name = status.Name
+			return true, nil
+		}
+		return false, nil
+	}); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+func (d *Deluge) ListTorrentsStatus(ctx context.Context) ([]downloader.DownloadStatus, error) {
+	if err := d.init(ctx); err != nil {
+		return nil, err
+	}
+	// 获取所有带有BangumiBuddy标签的种子，未启用标签插件时只能获取全部种子
+	filter := map[string]interface{}{}
+	if d.labelEnabled {
+		filter["label"] = label
+	}
+	torrents, err := d.getTorrentsStatus(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	return convertTorrentsToDownloadStatuses(torrents), nil
+}
+
+// DeleteTorrent 删除种子文件
+func (d *Deluge) DeleteTorrent(ctx context.Context, hash string) error {
+	return d.RemoveTorrent(ctx, hash, true)
+}
+
+// RemoveTorrent 移除种子任务，deleteFiles 为 true 时同时删除已下载的文件
+func (d *Deluge) RemoveTorrent(ctx context.Context, hash string, deleteFiles bool) error {
+	if err := d.call(ctx, "core.remove_torrent", nil, hash, deleteFiles); err != nil {
+		return fmt.Errorf("删除种子文件失败: %w", err)
+	}
+	return nil
+}
+
+func (d *Deluge) ContinueDownload(ctx context.Context, hash string) error {
+	return d.call(ctx, "core.resume_torrent", nil, []string{hash})
+}
+
+// PauseTorrent 暂停种子下载
+func (d *Deluge) PauseTorrent(ctx context.Context, hash string) error {
+	return d.pause(ctx, hash)
+}
+
+func (d *Deluge) pause(ctx context.Context, hash string) error {
+	return d.call(ctx, "core.pause_torrent", nil, []string{hash})
+}
@@ -0,0 +1,253 @@
+package deluge
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MangataL/BangumiBuddy/internal/downloader"
+)
+
+type fakeServer struct {
+	*httptest.Server
+
+	loginCount atomic.Int32
+	expired    atomic.Bool
+
+	mu       sync.Mutex
+	torrents map[string]map[string]interface{}
+	labels   []string
+	params   map[string][]interface{}
+	methods  []string
+}
+
+func newFakeServer(t *testing.T) *fakeServer {
+	t.Helper()
+
+	fs := &fakeServer{
+		torrents: make(map[string]map[string]interface{}),
+		params:   make(map[string][]interface{}),
+	}
+	fs.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != rpcPath {
+			http.NotFound(w, r)
+			return
+		}
+		var req struct {
+			ID     int64         `json:"id"`
+			Method string        `json:"method"`
+			Params []interface{} `json:"params"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		resp := map[string]interface{}{"id": req.ID, "error": nil}
+		defer func() {
+			require.NoError(t, json.NewEncoder(w).Encode(resp))
+		}()
+
+		if req.Method == "auth.login" {
+			fs.loginCount.Add(1)
+			if req.Params[0] != "deluge" {
+				resp["result"] = false
+				return
+			}
+			fs.expired.Store(false)
+			http.SetCookie(w, &http.Cookie{Name: "_session_id", Value: "session"})
+			resp["result"] = true
+			return
+		}
+		cookie, err := r.Cookie("_session_id")
+		if err != nil || cookie.Value != "session" || fs.expired.Load() {
+			resp["error"] = map[string]interface{}{"code": errCodeNotAuthenticated, "message": "Not authenticated"}
+			return
+		}
+
+		fs.mu.Lock()
+		defer fs.mu.Unlock()
+		fs.methods = append(fs.methods, req.Method)
+		fs.params[req.Method] = req.Params
+		switch req.Method {
+		case "web.connected":
+			resp["result"] = true
+		case "core.get_enabled_plugins":
+			resp["result"] = []string{labelPlugin}
+		case "label.get_labels":
+			resp["result"] = fs.labels
+		case "label.add":
+			fs.labels = append(fs.labels, req.Params[0].(string))
+		case "core.add_torrent_magnet", "core.add_torrent_url":
+			resp["result"] = "abc"
+		case "core.get_torrent_status":
+			resp["result"] = fs.torrents[req.Params[0].(string)]
+		case "core.get_torrents_status":
+			resp["result"] = fs.torrents
+		case "core.pause_torrent":
+			fs.torrents["abc"]["state"] = statePaused
+		}
+	}))
+	t.Cleanup(fs.Close)
+	return fs
+}
+
+func (fs *fakeServer) setTorrent(hash string, fields map[string]interface{}) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	fields["hash"] = hash
+	fs.torrents[hash] = fields
+}
+
+func (fs *fakeServer) paramsOf(method string) []interface{} {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return fs.params[method]
+}
+
+func (fs *fakeServer) called(method string) bool {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	for _, m := range fs.methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+func newTestDeluge(fs *fakeServer) *Deluge {
+	return NewDeluge(Config{Host: fs.URL, Password: "deluge"})
+}
+
+func TestInitLogsInAndCreatesLabel(t *testing.T) {
+	fs := newFakeServer(t)
+	d := newTestDeluge(fs)
+
+	require.NoError(t, d.init(context.Background()))
+	require.NoError(t, d.init(context.Background()))
+	assert.Equal(t, int32(1), fs.loginCount.Load())
+	assert.True(t, d.labelEnabled)
+	assert.Equal(t, []string{label}, fs.labels)
+}
+
+func TestCallReloginWhenSessionExpired(t *testing.T) {
+	fs := newFakeServer(t)
+	d := newTestDeluge(fs)
+
+	_, err := d.ListTorrentsStatus(context.Background())
+	require.NoError(t, err)
+	fs.expired.Store(true)
+	_, err = d.ListTorrentsStatus(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), fs.loginCount.Load())
+}
+
+func TestCheckConnectionWrongPassword(t *testing.T) {
+	fs := newFakeServer(t)
+	d := NewDeluge(Config{Host: fs.URL, Password: "wrong"})
+
+	assert.Error(t, d.CheckConnection(context.Background()))
+}
+
+func TestAddTorrentStopsAfterMetadataReceived(t *testing.T) {
+	fs := newFakeServer(t)
+	fs.setTorrent("abc", map[string]interface{}{"name": "Test", "state": "Downloading", "num_files": 2, "total_wanted": 10})
+	d := newTestDeluge(fs)
+	ctx := context.Background()
+
+	// 磁力链接添加后立即开始获取元数据，获取完成后在添加时就暂停
+	require.NoError(t, d.AddTorrent(ctx, "magnet:?xt=urn:btih:abc", "/downloads", "MetadataReceived"))
+	assert.Equal(t, []interface{}{"magnet:?xt=urn:btih:abc", map[string]interface{}{"download_location": "/downloads", "add_paused": false}},
+		fs.paramsOf("core.add_torrent_magnet"))
+	assert.Equal(t, []interface{}{"abc", label}, fs.paramsOf("label.set_torrent"))
+	assert.True(t, fs.called("core.pause_torrent"))
+
+	statuses, err := d.GetDownloadStatuses(ctx, []string{"abc"})
+	require.NoError(t, err)
+	require.Len(t, statuses, 1)
+	assert.Equal(t, downloader.TorrentStatusDownloadPaused, statuses[0].Status)
+}
+
+func TestAddTorrentRemovesWhenMetadataTimeout(t *testing.T) {
+	fs := newFakeServer(t)
+	fs.setTorrent("abc", map[string]interface{}{"name": "abc", "state": "Downloading", "num_files": 0})
+	d := newTestDeluge(fs)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// 获取元数据超时时移除种子，不留下没有记录的暂停种子
+	require.Error(t, d.AddTorrent(ctx, "magnet:?xt=urn:btih:abc", "/downloads", "MetadataReceived"))
+	assert.Equal(t, []interface{}{"abc", true}, fs.paramsOf("core.remove_torrent"))
+	assert.False(t, fs.called("core.pause_torrent"))
+}
+
+func TestAddTorrentFileAddsPaused(t *testing.T) {
+	fs := newFakeServer(t)
+	d := newTestDeluge(fs)
+
+	require.NoError(t, d.AddTorrent(context.Background(), "https://example.com/test.torrent", "/downloads", "MetadataReceived"))
+	assert.Equal(t, []interface{}{"https://example.com/test.torrent", map[string]interface{}{"download_location": "/downloads", "add_paused": true}},
+		fs.paramsOf("core.add_torrent_url"))
+	assert.False(t, fs.called("core.pause_torrent"))
+}
+
+func TestConvertTorrentsToDownloadStatuses(t *testing.T) {
+	statuses := convertTorrentsToDownloadStatuses([]torrentStatus{
+		{Hash: "a", State: stateError, Message: "boom"},
+		{Hash: "b", State: "Seeding", NumFiles: 1, Progress: 100, TotalWanted: 10, TotalDone: 10, TimeAdded: 100, CompletedTime: 130},
+		{Hash: "c", State: statePaused, NumFiles: 1, Progress: 50, TotalWanted: 10, TotalDone: 5},
+		{Hash: "d", State: stateMoving, NumFiles: 1, Progress: 100, TotalWanted: 10, TotalDone: 10},
+	})
+	require.Len(t, statuses, 4)
+	assert.Equal(t, downloader.TorrentStatusDownloadError, statuses[0].Status)
+	assert.Equal(t, "boom", statuses[0].Error)
+	assert.Equal(t, downloader.TorrentStatusDownloaded, statuses[1].Status)
+	assert.Equal(t, float64(30), statuses[1].Cost.Seconds())
+	assert.Equal(t, downloader.TorrentStatusDownloadPaused, statuses[2].Status)
+	assert.Equal(t, 0.5, statuses[2].Progress)
+	assert.Equal(t, downloader.TorrentStatusDownloading, statuses[3].Status)
+}
+
+func TestSetTorrentFilePriorities(t *testing.T) {
+	fs := newFakeServer(t)
+	fs.setTorrent("abc", map[string]interface{}{
+		"files": []map[string]interface{}{
+			{"index": 0, "path": "Test/01.mkv"},
+			{"index": 1, "path": "Test/02.mkv"},
+		},
+	})
+	d := newTestDeluge(fs)
+
+	names, err := d.GetTorrentFileNames(context.Background(), "abc")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Test/01.mkv", "Test/02.mkv"}, names)
+
+	require.NoError(t, d.SetTorrentFilePriorities(context.Background(), "abc", []downloader.TorrentFileSelection{
+		{FileName: "Test/01.mkv", Download: true},
+		{FileName: "Test/02.mkv", Download: false},
+	}))
+	assert.Equal(t, []interface{}{
+		[]interface{}{"abc"},
+		map[string]interface{}{"file_priorities": []interface{}{float64(priorityNormal), float64(0)}},
+	}, fs.paramsOf("core.set_torrent_options"))
+
+	_, err = d.GetTorrentFileNames(context.Background(), "missing")
+	assert.Error(t, err)
+}
+
+func TestSetLocationMovesStorage(t *testing.T) {
+	fs := newFakeServer(t)
+	d := newTestDeluge(fs)
+
+	require.NoError(t, d.SetLocation(context.Background(), "abc", "/media/tv"))
+	assert.Equal(t, []interface{}{[]interface{}{"abc"}, "/media/tv"}, fs.paramsOf("core.move_storage"))
+	assert.Equal(t, []interface{}{
+		[]interface{}{"abc"},
+		map[string]interface{}{"move_completed": true, "move_completed_path": "/media/tv"},
+	}, fs.paramsOf("core.set_torrent_options"))
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/MangataL/BangumiBuddy/internal/notice"
+	"github.com/MangataL/BangumiBuddy/internal/storage"
 	"github.com/MangataL/BangumiBuddy/pkg/log"
 )
 
@@ -17,36 +18,54 @@ var _ Interface = (*Manager)(nil)
 func NewManager(dep Dependency) *Manager {
 	ctx, cancel := context.WithCancel(context.Background())
 	m := &Manager{
-		downloader: dep.Downloader,
-		torrentOp:  dep.TorrentOperator,
-		stop:       cancel,
-		config:     dep.Config,
-		notifier:   dep.Notifier,
+		downloaders: dep.Instances,
+		torrentOp:   dep.TorrentOperator,
+		stop:        cancel,
+		config:      dep.Config,
+		notifier:    dep.Notifier,
+		storage:     dep.Storage,
+		queueRepo:   dep.QueueRepository,
+		library:     dep.MediaLibrary,
+		stallMarks:  make(map[string]*stallMark),
 	}
 
 	go m.runMonitor(ctx)
+	go m.runSeedingEnforcer(ctx)
 	return m
 }
 
 type Manager struct {
-	downloader Downloader
-	torrentOp  TorrentOperator
-	notifier   notice.Notifier
-	config     Config
+	downloaders Instances
+	torrentOp   TorrentOperator
+	notifier    notice.Notifier
+	config      Config
+	storage     storage.Interface
+	queueRepo   QueueRepository
+	library     MediaLibrary
+
+	// stallMarks 下载中种子的进度记录，用于检测下载停滞
+	stallMu    sync.Mutex
+	stallMarks map[string]*stallMark
 
 	stop func()
 }
 
 type Dependency struct {
-	Downloader
+	Instances
 	TorrentOperator
 	Config
 	notice.Notifier
+	Storage         storage.Interface
+	QueueRepository QueueRepository
+	MediaLibrary    MediaLibrary
 }
 
 type Config struct {
-	TVSavePath    string `mapstructure:"tv_save_path" json:"tvSavePath"`
-	MovieSavePath string `mapstructure:"movie_save_path" json:"movieSavePath"`
+	TVSavePath    string        `mapstructure:"tv_save_path" json:"tvSavePath"`
+	MovieSavePath string        `mapstructure:"movie_save_path" json:"movieSavePath"`
+	Seeding       SeedingPolicy `mapstructure:"seeding" json:"seeding"`
+	Queue         QueueConfig   `mapstructure:"queue" json:"queue"`
+	Stall         StallConfig   `mapstructure:"stall" json:"stall"`
 }
 
 type Downloader interface {
@@ -61,31 +80,137 @@ type Downloader interface {
 	ContinueDownload(ctx context.Context, hash string) error
 }
 
+// TorrentLabeler 支持为种子设置分类和标签的下载器
+type TorrentLabeler interface {
+	// SetTorrentLabels 设置种子分类并追加标签，分类为空时不修改分类
+	SetTorrentLabels(ctx context.Context, hash, category string, tags []string) error
+}
+
+// TorrentPauser 支持暂停种子下载的下载器
+type TorrentPauser interface {
+	// PauseTorrent 暂停种子下载
+	PauseTorrent(ctx context.Context, hash string) error
+}
+
+// TorrentRemover 支持仅移除种子任务而保留已下载文件的下载器
+type TorrentRemover interface {
+	// RemoveTorrent 移除种子任务，deleteFiles 为 true 时同时删除已下载的文件
+	RemoveTorrent(ctx context.Context, hash string, deleteFiles bool) error
+}
+
+// DefaultInstance 默认下载器实例名称
+const DefaultInstance = "default"
+
+// Instances 下载器实例集合，支持同时使用多个下载器
+type Instances interface {
+	// Get 获取指定名称的下载器实例，名称为空时返回默认实例
+	Get(name string) (Downloader, error)
+	// Names 列出所有下载器实例名称
+	Names() []string
+	// Route 根据路由规则选择下载器实例，没有匹配的规则时返回默认实例
+	Route(req RouteReq) string
+}
+
+// MediaLibrary 媒体库配置，定期检查磁盘空间时与保存路径一起检查媒体库路径
+type MediaLibrary interface {
+	// GetMediaLibraryPaths 获取剧集和电影的媒体库路径
+	GetMediaLibraryPaths() ([]string, error)
+}
+
+// InstanceName 返回规范化的下载器实例名称，为空时表示默认实例
+func InstanceName(name string) string {
+	if name == "" {
+		return DefaultInstance
+	}
+	return name
+}
+
+// instanceOf 获取种子所属的下载器实例名称，未记录的种子使用默认实例
+func (m *Manager) instanceOf(ctx context.Context, hash string) (string, error) {
+	torrent, err := m.torrentOp.Get(ctx, hash)
+	if err != nil {
+		if errors.Is(err, ErrTorrentNotFound) {
+			return DefaultInstance, nil
+		}
+		return "", fmt.Errorf("获取种子信息失败: %w", err)
+	}
+	return InstanceName(torrent.Downloader), nil
+}
+
+// downloaderOf 获取种子所属的下载器实例
+func (m *Manager) downloaderOf(ctx context.Context, hash string) (Downloader, error) {
+	name, err := m.instanceOf(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	return m.downloaders.Get(name)
+}
+
 func (m *Manager) GetTorrentFileNames(ctx context.Context, hash string) ([]string, error) {
-	return m.downloader.GetTorrentFileNames(ctx, hash)
+	d, err := m.downloaderOf(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	return d.GetTorrentFileNames(ctx, hash)
 }
 
 func (m *Manager) SetTorrentFilePriorities(ctx context.Context, hash string, files []TorrentFileSelection) error {
-	return m.downloader.SetTorrentFilePriorities(ctx, hash, files)
+	d, err := m.downloaderOf(ctx, hash)
+	if err != nil {
+		return err
+	}
+	return d.SetTorrentFilePriorities(ctx, hash, files)
+}
+
+// selectInstance 选择下载请求使用的下载器实例，优先级：已记录的实例 > 请求指定 > 路由规则，
+// 已添加的种子始终由原来的实例处理，避免同一个种子在多个实例中下载
+func (m *Manager) selectInstance(ctx context.Context, req DownloadReq) (string, error) {
+	torrent, err := m.torrentOp.Get(ctx, req.Hash)
+	if err == nil {
+		return InstanceName(torrent.Downloader), nil
+	}
+	if !errors.Is(err, ErrTorrentNotFound) {
+		return "", fmt.Errorf("获取种子信息失败: %w", err)
+	}
+	if req.Downloader != "" {
+		return req.Downloader, nil
+	}
+	return InstanceName(m.downloaders.Route(RouteReq{
+		DownloadType: req.DownloadType,
+		Size:         req.Size,
+		ReleaseGroup: req.ReleaseGroup,
+	})), nil
 }
 
 func (m *Manager) Download(ctx context.Context, req DownloadReq) error {
+	instance, err := m.selectInstance(ctx, req)
+	if err != nil {
+		return err
+	}
+	d, err := m.downloaders.Get(instance)
+	if err != nil {
+		return err
+	}
+
 	// 获取种子当前状态
-	statuses, err := m.GetDownloadStatuses(ctx, []string{req.Hash})
+	statuses, err := d.GetDownloadStatuses(ctx, []string{req.Hash})
 	if err != nil {
 		return fmt.Errorf("获取种子状态失败: %w", err)
 	}
 
 	// 如果没有找到种子，添加新种子
 	if len(statuses) == 0 {
-		return m.addNewTorrent(ctx, req)
+		return m.addNewTorrent(ctx, d, instance, req)
 	}
 	savePath := m.getSavePath(req.SavePath, req.DownloadType)
-	if err := m.downloader.SetLocation(ctx, req.Hash, savePath); err != nil {
+	if err := d.SetLocation(ctx, req.Hash, savePath); err != nil {
 		return fmt.Errorf("更新种子保存路径失败: %w", err)
 	}
+	if err := setLabels(ctx, d, req); err != nil {
+		return err
+	}
 
-	statuses, err = m.GetDownloadStatuses(ctx, []string{req.Hash})
+	statuses, err = d.GetDownloadStatuses(ctx, []string{req.Hash})
 	if err != nil {
 		return fmt.Errorf("更新种子保存路径后，获取种子状态失败: %w", err)
 	}
@@ -99,6 +224,10 @@ func (m *Manager) Download(ctx context.Context, req DownloadReq) error {
 		TaskID:         req.TaskID,
 		Name:           status.Name,
 		RSSGUID:        req.RSSGUID,
+		PublishedAt:    req.PublishedAt,
+		Downloader:     instance,
+		SeedingPolicy:  req.SeedingPolicy,
+		QualityScore:   req.QualityScore,
 	}
 
 	// 根据下载状态设置种子状态
@@ -116,27 +245,40 @@ func (m *Manager) Download(ctx context.Context, req DownloadReq) error {
 }
 
 // addNewTorrent 添加新的种子下载任务
-func (m *Manager) addNewTorrent(ctx context.Context, req DownloadReq) error {
+func (m *Manager) addNewTorrent(ctx context.Context, d Downloader, instance string, req DownloadReq) error {
 	if req.TorrentLink == "" {
 		return errors.New("未提供种子链接")
 	}
 	savePath := m.getSavePath(req.SavePath, req.DownloadType)
 	stopCondition := ""
-	if req.NotStart {
+	if err := m.checkStorage(ctx, savePath); err != nil {
+		return err
+	}
+	queued := false
+	if !req.NotStart {
+		var err error
+		if queued, err = m.shouldQueue(ctx); err != nil {
+			return err
+		}
+	}
+	if req.NotStart || queued {
 		stopCondition = "MetadataReceived"
 	}
-	if err := m.downloader.AddTorrent(ctx, req.TorrentLink, savePath, stopCondition); err != nil {
+	if err := d.AddTorrent(ctx, req.TorrentLink, savePath, stopCondition); err != nil {
 		return fmt.Errorf("添加种子下载任务失败: %w", err)
 	}
 
 	// 获取种子信息
-	name, err := m.downloader.GetTorrentName(ctx, req.Hash)
+	name, err := d.GetTorrentName(ctx, req.Hash)
 	if err != nil {
 		return fmt.Errorf("获取种子信息失败: %w", err)
 	}
+	if err := setLabels(ctx, d, req); err != nil {
+		return err
+	}
 
 	status := TorrentStatusDownloading
-	if req.NotStart {
+	if req.NotStart || queued {
 		status = TorrentStatusDownloadPaused
 	}
 
@@ -149,6 +291,10 @@ func (m *Manager) addNewTorrent(ctx context.Context, req DownloadReq) error {
 		TaskID:         req.TaskID,
 		Name:           name,
 		RSSGUID:        req.RSSGUID,
+		PublishedAt:    req.PublishedAt,
+		Downloader:     instance,
+		SeedingPolicy:  req.SeedingPolicy,
+		QualityScore:   req.QualityScore,
 	}
 
 	// 保存种子信息
@@ -156,6 +302,29 @@ func (m *Manager) addNewTorrent(ctx context.Context, req DownloadReq) error {
 		return fmt.Errorf("保存种子信息失败: %w", err)
 	}
 
+	if queued {
+		return m.enqueue(ctx, req, false)
+	}
+	// 直接开始的下载也记录在队列中，时间窗口外按队列顺序暂停和恢复
+	if !req.NotStart && m.queueRepo != nil && m.config.Queue.enabled() {
+		return m.enqueue(ctx, req, true)
+	}
+	return nil
+}
+
+// setLabels 设置种子分类和标签，下载器不支持时忽略
+func setLabels(ctx context.Context, d Downloader, req DownloadReq) error {
+	if req.Category == "" && len(req.Tags) == 0 {
+		return nil
+	}
+	labeler, ok := d.(TorrentLabeler)
+	if !ok {
+		log.Debugf(ctx, "下载器不支持分类和标签，忽略 [%s]", req.Hash)
+		return nil
+	}
+	if err := labeler.SetTorrentLabels(ctx, req.Hash, req.Category, req.Tags); err != nil {
+		return fmt.Errorf("设置种子分类和标签失败: %w", err)
+	}
 	return nil
 }
 
@@ -170,9 +339,57 @@ func (m *Manager) getSavePath(savePath string, downloadType DownloadType) string
 	}
 }
 
-// GetDownloadStatuses 实现Downloader接口的GetDownloadStatuses方法
+// GetDownloadStatuses 实现Downloader接口的GetDownloadStatuses方法，按种子所属实例分别查询
 func (m *Manager) GetDownloadStatuses(ctx context.Context, hashes []string) ([]DownloadStatus, error) {
-	return m.downloader.GetDownloadStatuses(ctx, hashes)
+	if len(hashes) == 0 {
+		var (
+			statuses []DownloadStatus
+			errs     []error
+		)
+		for _, name := range m.downloaders.Names() {
+			instanceStatuses, err := m.listInstanceStatuses(ctx, name)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			statuses = append(statuses, instanceStatuses...)
+		}
+		return statuses, errors.Join(errs...)
+	}
+
+	groups := make(map[string][]string)
+	for _, hash := range hashes {
+		name, err := m.instanceOf(ctx, hash)
+		if err != nil {
+			return nil, err
+		}
+		groups[name] = append(groups[name], hash)
+	}
+	statuses := make([]DownloadStatus, 0, len(hashes))
+	for name, group := range groups {
+		d, err := m.downloaders.Get(name)
+		if err != nil {
+			return nil, err
+		}
+		instanceStatuses, err := d.GetDownloadStatuses(ctx, group)
+		if err != nil {
+			return nil, fmt.Errorf("获取下载器 %s 种子状态失败: %w", name, err)
+		}
+		statuses = append(statuses, instanceStatuses...)
+	}
+	return statuses, nil
+}
+
+func (m *Manager) listInstanceStatuses(ctx context.Context, name string) ([]DownloadStatus, error) {
+	d, err := m.downloaders.Get(name)
+	if err != nil {
+		return nil, err
+	}
+	statuses, err := d.ListTorrentsStatus(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("获取下载器 %s 种子列表失败: %w", name, err)
+	}
+	return statuses, nil
 }
 
 // runMonitor 启动监控任务
@@ -183,7 +400,9 @@ func (m *Manager) runMonitor(ctx context.Context) {
 	for {
 		select {
 		case <-ticker.C:
+			m.monitorStorage()
 			m.checkDownloadStatus()
+			m.processQueue()
 		case <-ctx.Done():
 			return
 		}
@@ -208,17 +427,31 @@ var (
 	}
 )
 
-// checkDownloadStatus 检查下载状态并更新数据库
+// checkDownloadStatus 检查所有下载器实例的下载状态并更新数据库
 func (m *Manager) checkDownloadStatus() {
 	ctx := log.NewContext()
-	statuses, err := m.downloader.ListTorrentsStatus(ctx)
-	if err != nil {
-		log.Errorf(ctx, "获取种子列表失败: %v", err)
-		return
+	for _, name := range m.downloaders.Names() {
+		statuses, err := m.listInstanceStatuses(ctx, name)
+		if err != nil {
+			log.Errorf(ctx, "获取种子列表失败: %v", err)
+			continue
+		}
+		m.checkInstanceDownloadStatus(ctx, name, statuses)
+		m.guardInstanceStorage(ctx, name, statuses)
+		m.detectInstanceStalls(ctx, name, statuses)
 	}
+}
+
+// checkInstanceDownloadStatus 检查单个下载器实例的下载状态
+func (m *Manager) checkInstanceDownloadStatus(ctx context.Context, instance string, statuses []DownloadStatus) {
 	if len(statuses) == 0 {
 		return
 	}
+	d, err := m.downloaders.Get(instance)
+	if err != nil {
+		log.Errorf(ctx, "获取下载器 %s 失败: %v", instance, err)
+		return
+	}
 	// 使用WaitGroup等待所有goroutine完成
 	var wg sync.WaitGroup
 
@@ -237,7 +470,16 @@ func (m *Manager) checkDownloadStatus() {
 				return
 			}
 
-			if _, ok := downloadStatusTranslate[torrent.Status]; !ok {
+			if _, ok := downloadStatusTranslate[torrent.Status]; !ok && torrent.Status != TorrentStatusStalled {
+				return
+			}
+			// 停滞的种子仍在下载时保持停滞状态，由停滞检测在进度变化后恢复，下载完成或暂停后按下载器状态更新
+			if torrent.Status == TorrentStatusStalled &&
+				(status.Status == TorrentStatusDownloading || status.Status == TorrentStatusDownloadError) {
+				return
+			}
+			// 同一个种子可能同时存在于多个下载器中，只处理所属实例的状态
+			if InstanceName(torrent.Downloader) != instance {
 				return
 			}
 
@@ -249,7 +491,7 @@ func (m *Manager) checkDownloadStatus() {
 				(currentStatus == TorrentStatusDownloadError && torrent.StatusDetail != statusDetail) {
 				var fileNames []string
 				if currentStatus == TorrentStatusDownloaded {
-					fileNames, err = m.downloader.GetTorrentFileNames(ctx, torrent.Hash)
+					fileNames, err = d.GetTorrentFileNames(ctx, torrent.Hash)
 					if err != nil {
 						log.Errorf(ctx, "获取种子文件名失败 [%s]: %v", status.Hash, err)
 						return
@@ -282,9 +524,18 @@ func (m *Manager) checkDownloadStatus() {
 
 // DeleteTorrent 删除种子文件
 func (m *Manager) DeleteTorrent(ctx context.Context, hash string) error {
-	if err := m.downloader.DeleteTorrent(ctx, hash); err != nil {
+	d, err := m.downloaderOf(ctx, hash)
+	if err != nil {
+		return err
+	}
+	if err := d.DeleteTorrent(ctx, hash); err != nil {
 		return fmt.Errorf("删除种子文件失败: %w", err)
 	}
+	if m.queueRepo != nil {
+		if err := m.queueRepo.Delete(ctx, hash); err != nil {
+			return fmt.Errorf("删除下载队列条目失败: %w", err)
+		}
+	}
 	return m.torrentOp.Delete(ctx, hash)
 }
 
@@ -293,10 +544,21 @@ func (m *Manager) Reload(config interface{}) error {
 	if !ok {
 		return errors.New("配置类型错误")
 	}
+	if err := cfg.Queue.validate(); err != nil {
+		return err
+	}
 	m.config = *cfg
 	return nil
 }
 
+// ContinueDownload 继续下载种子，启用下载队列时由队列按并发限制和时间窗口开始下载
 func (m *Manager) ContinueDownload(ctx context.Context, hash string) error {
-	return m.downloader.ContinueDownload(ctx, hash)
+	d, err := m.downloaderOf(ctx, hash)
+	if err != nil {
+		return err
+	}
+	if m.queueRepo == nil || !m.config.Queue.enabled() {
+		return d.ContinueDownload(ctx, hash)
+	}
+	return m.continueQueued(ctx, d, hash)
 }
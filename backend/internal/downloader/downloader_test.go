@@ -0,0 +1,177 @@
+package downloader
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MangataL/BangumiBuddy/internal/notice"
+)
+
+type fakeInstances struct {
+	downloaders map[string]Downloader
+	names       []string
+	route       string
+}
+
+func (f *fakeInstances) Get(name string) (Downloader, error) {
+	d, ok := f.downloaders[InstanceName(name)]
+	if !ok {
+		return nil, fmt.Errorf("下载器实例 %s 不存在", name)
+	}
+	return d, nil
+}
+
+func (f *fakeInstances) Names() []string {
+	return f.names
+}
+
+func (f *fakeInstances) Route(req RouteReq) string {
+	return f.route
+}
+
+type fakeDownloader struct {
+	Empty
+	statuses []DownloadStatus
+	added    []string
+	removed  map[string]bool
+}
+
+func (f *fakeDownloader) RemoveTorrent(ctx context.Context, hash string, deleteFiles bool) error {
+	if f.removed == nil {
+		f.removed = make(map[string]bool)
+	}
+	f.removed[hash] = deleteFiles
+	return nil
+}
+
+func (f *fakeDownloader) ListTorrentsStatus(ctx context.Context) ([]DownloadStatus, error) {
+	return f.statuses, nil
+}
+
+func (f *fakeDownloader) GetDownloadStatuses(ctx context.Context, hashes []string) ([]DownloadStatus, error) {
+	var statuses []DownloadStatus
+	for _, status := range f.statuses {
+		for _, hash := range hashes {
+			if status.Hash == hash {
+				statuses = append(statuses, status)
+			}
+		}
+	}
+	return statuses, nil
+}
+
+func (f *fakeDownloader) AddTorrent(ctx context.Context, torrentLink, savePath, stopCondition string) error {
+	f.added = append(f.added, torrentLink)
+	return nil
+}
+
+func (f *fakeDownloader) GetTorrentName(ctx context.Context, hash string) (string, error) {
+	return hash, nil
+}
+
+func (f *fakeDownloader) GetTorrentFileNames(ctx context.Context, hash string) ([]string, error) {
+	return []string{hash + ".mkv"}, nil
+}
+
+func TestManagerCheckDownloadStatusPollsAllInstances(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	torrentOp := NewMockTorrentOperator(ctrl)
+	instances := &fakeInstances{
+		names: []string{DefaultInstance, "seedbox"},
+		downloaders: map[string]Downloader{
+			DefaultInstance: &fakeDownloader{statuses: []DownloadStatus{
+				{Hash: "local", Status: TorrentStatusDownloaded},
+			}},
+			"seedbox": &fakeDownloader{statuses: []DownloadStatus{
+				{Hash: "remote", Status: TorrentStatusDownloaded},
+				// 同一个种子也存在于非所属实例中，不应更新状态
+				{Hash: "local", Status: TorrentStatusDownloadError},
+			}},
+		},
+	}
+	m := &Manager{downloaders: instances, torrentOp: torrentOp, notifier: &notice.Empty{}}
+
+	torrentOp.EXPECT().Get(gomock.Any(), "local").
+		Return(Torrent{Hash: "local", Status: TorrentStatusDownloading}, nil).Times(2)
+	torrentOp.EXPECT().Get(gomock.Any(), "remote").
+		Return(Torrent{Hash: "remote", Status: TorrentStatusDownloading, Downloader: "seedbox"}, nil)
+	torrentOp.EXPECT().SetTorrentStatus(gomock.Any(), "local", TorrentStatusDownloaded, "", &SetTorrentStatusOptions{
+		FileNames: []string{"local.mkv"},
+	})
+	torrentOp.EXPECT().SetTorrentStatus(gomock.Any(), "remote", TorrentStatusDownloaded, "", &SetTorrentStatusOptions{
+		FileNames: []string{"remote.mkv"},
+	})
+
+	m.checkDownloadStatus()
+}
+
+func TestManagerDownloadSelectsInstance(t *testing.T) {
+	tests := []struct {
+		name   string
+		req    DownloadReq
+		stored *Torrent
+		route  string
+		want   string
+	}{
+		{
+			name:  "请求指定实例",
+			req:   DownloadReq{Hash: "hash", TorrentLink: "link", Downloader: "seedbox"},
+			route: DefaultInstance,
+			want:  "seedbox",
+		},
+		{
+			name:   "使用已记录的实例",
+			req:    DownloadReq{Hash: "hash", TorrentLink: "link"},
+			stored: &Torrent{Hash: "hash", Downloader: "seedbox"},
+			route:  DefaultInstance,
+			want:   "seedbox",
+		},
+		{
+			name:   "已记录的实例优先于请求指定的实例",
+			req:    DownloadReq{Hash: "hash", TorrentLink: "link", Downloader: DefaultInstance},
+			stored: &Torrent{Hash: "hash", Downloader: "seedbox"},
+			route:  DefaultInstance,
+			want:   "seedbox",
+		},
+		{
+			name:  "按路由规则选择",
+			req:   DownloadReq{Hash: "hash", TorrentLink: "link"},
+			route: "seedbox",
+			want:  "seedbox",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			torrentOp := NewMockTorrentOperator(ctrl)
+			downloaders := map[string]Downloader{
+				DefaultInstance: &fakeDownloader{},
+				"seedbox":       &fakeDownloader{},
+			}
+			m := &Manager{
+				downloaders: &fakeInstances{downloaders: downloaders, route: tt.route},
+				torrentOp:   torrentOp,
+			}
+
+			if tt.stored != nil {
+				torrentOp.EXPECT().Get(gomock.Any(), tt.req.Hash).Return(*tt.stored, nil)
+			} else {
+				torrentOp.EXPECT().Get(gomock.Any(), tt.req.Hash).Return(Torrent{}, ErrTorrentNotFound)
+			}
+			var saved Torrent
+			torrentOp.EXPECT().Save(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, torrent Torrent) error {
+				saved = torrent
+				return nil
+			})
+
+			require.NoError(t, m.Download(context.Background(), tt.req))
+			assert.Equal(t, tt.want, saved.Downloader)
+			assert.Equal(t, []string{"link"}, downloaders[tt.want].(*fakeDownloader).added)
+		})
+	}
+}
@@ -56,8 +56,24 @@ type TorrentOperator interface {
 	DeleteByTaskID(ctx context.Context, taskID string) error
 }
 
+// QueueRepository 下载队列存储接口，保证重启后不丢失排队中的下载任务
+type QueueRepository interface {
+	// Add 添加队列条目，已存在时更新
+	Add(ctx context.Context, item QueueItem) error
+
+	// List 按出队顺序列出所有队列条目
+	List(ctx context.Context) ([]QueueItem, error)
+
+	// SetStarted 设置队列条目是否已开始下载
+	SetStarted(ctx context.Context, hash string, started bool) error
+
+	// Delete 删除队列条目
+	Delete(ctx context.Context, hash string) error
+}
+
 // SetTorrentStatusOptions 设置种子文件状态选项
 type SetTorrentStatusOptions struct {
-	TransferType string
-	FileNames    []string
+	TransferType  string
+	FileNames     []string
+	SelectedFiles []string // 合集种子中选择下载的文件
 }
@@ -278,3 +278,83 @@ func (mr *MockTorrentOperatorMockRecorder) SetTorrentStatus(ctx, hash, status, d
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTorrentStatus", reflect.TypeOf((*MockTorrentOperator)(nil).SetTorrentStatus), ctx, hash, status, detail, opts)
 }
+
+// MockQueueRepository is a mock of QueueRepository interface.
+type MockQueueRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockQueueRepositoryMockRecorder
+}
+
+// MockQueueRepositoryMockRecorder is the mock recorder for MockQueueRepository.
+type MockQueueRepositoryMockRecorder struct {
+	mock *MockQueueRepository
+}
+
+// NewMockQueueRepository creates a new mock instance.
+func NewMockQueueRepository(ctrl *gomock.Controller) *MockQueueRepository {
+	mock := &MockQueueRepository{ctrl: ctrl}
+	mock.recorder = &MockQueueRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockQueueRepository) EXPECT() *MockQueueRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Add mocks base method.
+func (m *MockQueueRepository) Add(ctx context.Context, item QueueItem) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Add", ctx, item)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Add indicates an expected call of Add.
+func (mr *MockQueueRepositoryMockRecorder) Add(ctx, item interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Add", reflect.TypeOf((*MockQueueRepository)(nil).Add), ctx, item)
+}
+
+// Delete mocks base method.
+func (m *MockQueueRepository) Delete(ctx context.Context, hash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, hash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockQueueRepositoryMockRecorder) Delete(ctx, hash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockQueueRepository)(nil).Delete), ctx, hash)
+}
+
+// List mocks base method.
+func (m *MockQueueRepository) List(ctx context.Context) ([]QueueItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx)
+	ret0, _ := ret[0].([]QueueItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockQueueRepositoryMockRecorder) List(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockQueueRepository)(nil).List), ctx)
+}
+
+// SetStarted mocks base method.
+func (m *MockQueueRepository) SetStarted(ctx context.Context, hash string, started bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetStarted", ctx, hash, started)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetStarted indicates an expected call of SetStarted.
+func (mr *MockQueueRepositoryMockRecorder) SetStarted(ctx, hash, started interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetStarted", reflect.TypeOf((*MockQueueRepository)(nil).SetStarted), ctx, hash, started)
+}
@@ -17,7 +17,12 @@ import (
 )
 
 // 确保QBittorrent实现了Downloader接口
-var _ downloader.Downloader = (*QBittorrent)(nil)
+var (
+	_ downloader.Downloader     = (*QBittorrent)(nil)
+	_ downloader.TorrentLabeler = (*QBittorrent)(nil)
+	_ downloader.TorrentRemover = (*QBittorrent)(nil)
+	_ downloader.TorrentPauser  = (*QBittorrent)(nil)
+)
 
 const (
 	// 标签名称
@@ -26,13 +31,16 @@ const (
 
 // Config qBittorrent配置接口
 type Config struct {
-	Host     string `mapstructure:"host" json:"host"`
-	Username string `mapstructure:"username" json:"username"`
-	Password string `mapstructure:"password" json:"password"`
+	Host     string   `mapstructure:"host" json:"host"`
+	Username string   `mapstructure:"username" json:"username"`
+	Password string   `mapstructure:"password" json:"password"`
+	Category string   `mapstructure:"category" json:"category"` // 默认分类，订阅设置了分类时会被覆盖
+	Tags     []string `mapstructure:"tags" json:"tags"`         // 额外标签，BangumiBuddy 标签总会添加
 }
 
 // QBittorrent 实现Downloader接口
 type QBittorrent struct {
+	config      Config
 	client      *qbittorrent.Client
 	initMu      sync.Mutex
 	initialized bool
@@ -47,6 +55,7 @@ func NewQBittorrent(config Config) *QBittorrent {
 		Password: config.Password,
 	})
 	return &QBittorrent{
+		config: config,
 		client: client,
 	}
 }
@@ -174,6 +183,9 @@ func (q *QBittorrent) convertTorrentsToDownloadStatuses(ctx context.Context, tor
 			Progress:      t.Progress,
 			DownloadSpeed: t.DlSpeed,
 			Size:          t.Size,
+			Uploaded:      t.Uploaded,
+			Ratio:         t.Ratio,
+			SeedingTime:   time.Duration(t.SeedingTime) * time.Second,
 		}
 
 		switch {
@@ -206,7 +218,8 @@ func (q *QBittorrent) AddTorrent(ctx context.Context, torrentLink, savePath, sto
 	}
 	options := &qbittorrent.TorrentAddOptions{
 		SavePath: savePath,
-		Tags:     tag,
+		Category: q.config.Category,
+		Tags:     strings.Join(q.tags(), ","),
 	}
 	opts := options.Prepare()
 
@@ -221,12 +234,52 @@ func (q *QBittorrent) SetLocation(ctx context.Context, hash, savePath string) er
 	if err := q.init(); err != nil {
 		return err
 	}
-	if err := q.client.AddTagsCtx(ctx, []string{hash}, tag); err != nil {
+	if err := q.client.AddTagsCtx(ctx, []string{hash}, strings.Join(q.tags(), ",")); err != nil {
 		return fmt.Errorf("添加标签失败: %w", err)
 	}
 	return q.client.SetLocationCtx(ctx, []string{hash}, savePath)
 }
 
+// tags 添加种子时使用的标签
+func (q *QBittorrent) tags() []string {
+	return append([]string{tag}, q.config.Tags...)
+}
+
+// SetTorrentLabels 设置种子分类并追加标签，分类不存在时会先创建
+func (q *QBittorrent) SetTorrentLabels(ctx context.Context, hash, category string, tags []string) error {
+	if err := q.init(); err != nil {
+		return err
+	}
+	if category != "" {
+		if err := q.ensureCategory(ctx, category); err != nil {
+			return err
+		}
+		if err := q.client.SetCategoryCtx(ctx, []string{hash}, category); err != nil {
+			return fmt.Errorf("设置分类失败: %w", err)
+		}
+	}
+	if len(tags) > 0 {
+		if err := q.client.AddTagsCtx(ctx, []string{hash}, strings.Join(tags, ",")); err != nil {
+			return fmt.Errorf("添加标签失败: %w", err)
+		}
+	}
+	return nil
+}
+
+func (q *QBittorrent) ensureCategory(ctx context.Context, category string) error {
+	categories, err := q.client.GetCategoriesCtx(ctx)
+	if err != nil {
+		return fmt.Errorf("获取分类失败: %w", err)
+	}
+	if _, ok := categories[category]; ok {
+		return nil
+	}
+	if err := q.client.CreateCategoryCtx(ctx, category, ""); err != nil {
+		return fmt.Errorf("创建分类失败: %w", err)
+	}
+	return nil
+}
+
 func (q *QBittorrent) GetTorrentName(ctx context.Context, hash string) (string, error) {
 	if err := q.init(); err != nil {
 		return "", err
@@ -270,10 +323,15 @@ func (q *QBittorrent) ListTorrentsStatus(ctx context.Context) ([]downloader.Down
 
 // DeleteTorrent 删除种子文件
 func (q *QBittorrent) DeleteTorrent(ctx context.Context, hash string) error {
+	return q.RemoveTorrent(ctx, hash, true)
+}
+
+// RemoveTorrent 移除种子任务，deleteFiles 为 true 时同时删除已下载的文件
+func (q *QBittorrent) RemoveTorrent(ctx context.Context, hash string, deleteFiles bool) error {
 	if err := q.init(); err != nil {
 		return err
 	}
-	if err := q.client.DeleteTorrentsCtx(ctx, []string{hash}, true); err != nil {
+	if err := q.client.DeleteTorrentsCtx(ctx, []string{hash}, deleteFiles); err != nil {
 		return fmt.Errorf("删除种子文件失败: %w", err)
 	}
 	return nil
@@ -285,3 +343,11 @@ func (q *QBittorrent) ContinueDownload(ctx context.Context, hash string) error {
 	}
 	return q.client.ResumeCtx(ctx, []string{hash})
 }
+
+// PauseTorrent 暂停种子下载
+func (q *QBittorrent) PauseTorrent(ctx context.Context, hash string) error {
+	if err := q.init(); err != nil {
+		return err
+	}
+	return q.client.StopCtx(ctx, []string{hash})
+}
@@ -0,0 +1,385 @@
+package downloader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/MangataL/BangumiBuddy/pkg/log"
+)
+
+const clockLayout = "15:04"
+
+// QueueConfig 下载队列配置，未设置并发限制和时间窗口时不排队
+type QueueConfig struct {
+	MaxActiveDownloads int          `mapstructure:"max_active_downloads" json:"maxActiveDownloads"` // 最大同时下载数，0表示不限制
+	TimeWindows        []TimeWindow `mapstructure:"time_windows" json:"timeWindows"`                // 允许下载的时间窗口，为空表示不限制
+}
+
+// TimeWindow 允许下载的时间窗口，结束时间早于开始时间时表示跨越午夜
+type TimeWindow struct {
+	Weekdays []time.Weekday `mapstructure:"weekdays" json:"weekdays"` // 生效的星期，0表示周日，为空表示每天
+	Start    string         `mapstructure:"start" json:"start"`       // 开始时间，格式为 15:04
+	End      string         `mapstructure:"end" json:"end"`           // 结束时间，格式为 15:04
+}
+
+func (c QueueConfig) enabled() bool {
+	return c.MaxActiveDownloads > 0 || len(c.TimeWindows) > 0
+}
+
+func (c QueueConfig) validate() error {
+	if c.MaxActiveDownloads < 0 {
+		return errors.New("最大同时下载数不能小于0")
+	}
+	for _, w := range c.TimeWindows {
+		if _, err := time.Parse(clockLayout, w.Start); err != nil {
+			return fmt.Errorf("时间窗口开始时间 %s 格式错误，应为 HH:MM", w.Start)
+		}
+		if _, err := time.Parse(clockLayout, w.End); err != nil {
+			return fmt.Errorf("时间窗口结束时间 %s 格式错误，应为 HH:MM", w.End)
+		}
+		for _, weekday := range w.Weekdays {
+			if weekday < time.Sunday || weekday > time.Saturday {
+				return fmt.Errorf("时间窗口星期 %d 错误，应为0-6", weekday)
+			}
+		}
+	}
+	return nil
+}
+
+// inWindow 判断当前时间是否允许下载
+func (c QueueConfig) inWindow(now time.Time) bool {
+	if len(c.TimeWindows) == 0 {
+		return true
+	}
+	for _, w := range c.TimeWindows {
+		if w.contains(now) {
+			return true
+		}
+	}
+	return false
+}
+
+func (w TimeWindow) contains(now time.Time) bool {
+	start, err := time.Parse(clockLayout, w.Start)
+	if err != nil {
+		return false
+	}
+	end, err := time.Parse(clockLayout, w.End)
+	if err != nil {
+		return false
+	}
+	minutes := now.Hour()*60 + now.Minute()
+	startMinutes := start.Hour()*60 + start.Minute()
+	endMinutes := end.Hour()*60 + end.Minute()
+
+	if startMinutes <= endMinutes {
+		return minutes >= startMinutes && minutes < endMinutes && w.onDay(now.Weekday())
+	}
+	// 跨越午夜的时间窗口，午夜后的部分属于前一天的窗口
+	if minutes >= startMinutes {
+		return w.onDay(now.Weekday())
+	}
+	return minutes < endMinutes && w.onDay((now.Weekday()+6)%7)
+}
+
+func (w TimeWindow) onDay(weekday time.Weekday) bool {
+	if len(w.Weekdays) == 0 {
+		return true
+	}
+	for _, d := range w.Weekdays {
+		if d == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+// shouldQueue 判断新的下载是否需要排队
+func (m *Manager) shouldQueue(ctx context.Context) (bool, error) {
+	config := m.config.Queue
+	if m.queueRepo == nil || !config.enabled() {
+		return false, nil
+	}
+	if !config.inWindow(time.Now()) {
+		return true, nil
+	}
+	items, err := m.queueRepo.List(ctx)
+	if err != nil {
+		return false, fmt.Errorf("获取下载队列失败: %w", err)
+	}
+	waiting := make(map[string]struct{})
+	for _, item := range items {
+		if !item.Started {
+			waiting[item.Hash] = struct{}{}
+		}
+	}
+	// 已有排队的任务时新任务也需要排队，保证按优先级下载
+	if len(waiting) > 0 {
+		return true, nil
+	}
+	if config.MaxActiveDownloads == 0 {
+		return false, nil
+	}
+	active, err := m.activeDownloads(ctx, waiting)
+	if err != nil {
+		return false, err
+	}
+	return active >= config.MaxActiveDownloads, nil
+}
+
+// activeDownloads 统计正在下载的种子数量，排队中正在获取元数据的种子不计入
+func (m *Manager) activeDownloads(ctx context.Context, waiting map[string]struct{}) (int, error) {
+	torrents, _, err := m.torrentOp.List(ctx, TorrentFilter{
+		Statuses: []TorrentStatus{TorrentStatusDownloading},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("获取下载中的种子失败: %w", err)
+	}
+	active := 0
+	for _, torrent := range torrents {
+		if _, ok := waiting[torrent.Hash]; !ok {
+			active++
+		}
+	}
+	return active, nil
+}
+
+// enqueue 将下载请求加入队列，started 为 true 时表示已直接开始下载，由队列在时间窗口外暂停
+func (m *Manager) enqueue(ctx context.Context, req DownloadReq, started bool) error {
+	if err := m.queueRepo.Add(ctx, QueueItem{
+		Hash:           req.Hash,
+		Priority:       req.Priority,
+		SubscriptionID: req.SubscriptionID,
+		Episode:        req.Episode,
+		PublishedAt:    req.PublishedAt,
+		Started:        started,
+	}); err != nil {
+		return fmt.Errorf("加入下载队列失败: %w", err)
+	}
+	if !started {
+		log.Infof(ctx, "下载任务已加入队列 [%s]", req.Hash)
+	}
+	return nil
+}
+
+// continueQueued 继续下载已暂停的种子，需要排队时加入队列等待，已在队列中的种子保持原来的排队顺序
+func (m *Manager) continueQueued(ctx context.Context, d Downloader, hash string) error {
+	torrent, err := m.torrentOp.Get(ctx, hash)
+	if err != nil {
+		return fmt.Errorf("获取种子信息失败: %w", err)
+	}
+	items, err := m.queueRepo.List(ctx)
+	if err != nil {
+		return fmt.Errorf("获取下载队列失败: %w", err)
+	}
+	req := DownloadReq{Hash: hash, SubscriptionID: torrent.SubscriptionID, PublishedAt: torrent.PublishedAt}
+	for _, item := range items {
+		if item.Hash != hash {
+			continue
+		}
+		if !item.Started {
+			log.Infof(ctx, "下载任务已在队列中 [%s]", torrent.Name)
+			return nil
+		}
+		req.Priority, req.Episode = item.Priority, item.Episode
+	}
+	queued, err := m.shouldQueue(ctx)
+	if err != nil {
+		return err
+	}
+	if queued {
+		return m.enqueue(ctx, req, false)
+	}
+	if err := d.ContinueDownload(ctx, hash); err != nil {
+		return err
+	}
+	if err := m.torrentOp.SetTorrentStatus(ctx, hash, TorrentStatusDownloading, "", nil); err != nil {
+		return fmt.Errorf("更新种子状态失败: %w", err)
+	}
+	return m.enqueue(ctx, req, true)
+}
+
+// sortQueueItems 按优先级倒序排列，同优先级时新的集数先下载，集数相同时按发布时间从新到旧，最后按入队顺序
+func sortQueueItems(items []QueueItem) {
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		if a.Priority != b.Priority {
+			return a.Priority > b.Priority
+		}
+		if a.Episode != b.Episode {
+			return a.Episode > b.Episode
+		}
+		if !a.PublishedAt.Equal(b.PublishedAt) {
+			return a.PublishedAt.After(b.PublishedAt)
+		}
+		return a.CreatedAt.Before(b.CreatedAt)
+	})
+}
+
+// processQueue 清理已结束的队列条目，时间窗口外暂停正在下载的种子，有空闲下载位时按顺序开始下载
+func (m *Manager) processQueue() {
+	if m.queueRepo == nil {
+		return
+	}
+	ctx := log.NewContext()
+	items, err := m.queueRepo.List(ctx)
+	if err != nil {
+		log.Errorf(ctx, "获取下载队列失败: %v", err)
+		return
+	}
+
+	config := m.config.Queue
+	open := !config.enabled() || config.inWindow(time.Now())
+	if !open {
+		m.pauseUnqueued(ctx, items)
+	}
+	var waiting []Torrent
+	for _, item := range items {
+		torrent, err := m.torrentOp.Get(ctx, item.Hash)
+		if err != nil {
+			if errors.Is(err, ErrTorrentNotFound) {
+				m.dequeue(ctx, item.Hash)
+			} else {
+				log.Errorf(ctx, "获取种子信息失败 [%s]: %v", item.Hash, err)
+			}
+			continue
+		}
+		if item.Started {
+			// 下载结束或被手动暂停后不再由队列管理
+			if torrent.Status != TorrentStatusDownloading {
+				m.dequeue(ctx, item.Hash)
+				continue
+			}
+			if !open {
+				m.pauseQueued(ctx, torrent)
+			}
+			continue
+		}
+		if !torrent.Status.IsDownloading() {
+			m.dequeue(ctx, item.Hash)
+			continue
+		}
+		waiting = append(waiting, torrent)
+	}
+	if !open || len(waiting) == 0 {
+		return
+	}
+
+	slots := len(waiting)
+	if config.MaxActiveDownloads > 0 {
+		waitingHashes := make(map[string]struct{}, len(waiting))
+		for _, torrent := range waiting {
+			waitingHashes[torrent.Hash] = struct{}{}
+		}
+		active, err := m.activeDownloads(ctx, waitingHashes)
+		if err != nil {
+			log.Errorf(ctx, "%v", err)
+			return
+		}
+		slots = config.MaxActiveDownloads - active
+	}
+	for _, torrent := range waiting {
+		if slots <= 0 {
+			return
+		}
+		if err := m.startQueued(ctx, torrent); err != nil {
+			log.Errorf(ctx, "开始下载队列任务失败 [%s]: %v", torrent.Name, err)
+			continue
+		}
+		slots--
+	}
+}
+
+// startQueued 开始下载排队中的种子
+func (m *Manager) startQueued(ctx context.Context, torrent Torrent) error {
+	d, err := m.downloaders.Get(InstanceName(torrent.Downloader))
+	if err != nil {
+		return err
+	}
+	if err := d.ContinueDownload(ctx, torrent.Hash); err != nil {
+		return err
+	}
+	if err := m.torrentOp.SetTorrentStatus(ctx, torrent.Hash, TorrentStatusDownloading, "", nil); err != nil {
+		return fmt.Errorf("更新种子状态失败: %w", err)
+	}
+	if err := m.queueRepo.SetStarted(ctx, torrent.Hash, true); err != nil {
+		return fmt.Errorf("更新下载队列失败: %w", err)
+	}
+	log.Infof(ctx, "开始下载队列任务 [%s]", torrent.Name)
+	return nil
+}
+
+// pauseUnqueued 时间窗口外暂停不在队列中的下载，如启用队列前或手动开始的下载，并加入队列等待时间窗口开始
+func (m *Manager) pauseUnqueued(ctx context.Context, items []QueueItem) {
+	queued := make(map[string]struct{}, len(items))
+	for _, item := range items {
+		queued[item.Hash] = struct{}{}
+	}
+	torrents, _, err := m.torrentOp.List(ctx, TorrentFilter{
+		Statuses: []TorrentStatus{TorrentStatusDownloading},
+	})
+	if err != nil {
+		log.Errorf(ctx, "获取下载中的种子失败: %v", err)
+		return
+	}
+	for _, torrent := range torrents {
+		if _, ok := queued[torrent.Hash]; ok {
+			continue
+		}
+		d, err := m.downloaders.Get(InstanceName(torrent.Downloader))
+		if err != nil {
+			log.Errorf(ctx, "获取下载器失败 [%s]: %v", torrent.Name, err)
+			continue
+		}
+		if _, ok := d.(TorrentPauser); !ok {
+			log.Debugf(ctx, "下载器不支持暂停，时间窗口外继续下载 [%s]", torrent.Name)
+			continue
+		}
+		if err := m.queueRepo.Add(ctx, QueueItem{
+			Hash:           torrent.Hash,
+			SubscriptionID: torrent.SubscriptionID,
+			PublishedAt:    torrent.PublishedAt,
+			Started:        true,
+		}); err != nil {
+			log.Errorf(ctx, "加入下载队列失败 [%s]: %v", torrent.Name, err)
+			continue
+		}
+		m.pauseQueued(ctx, torrent)
+	}
+}
+
+// pauseQueued 时间窗口外暂停由队列开始的下载，并重新排队
+func (m *Manager) pauseQueued(ctx context.Context, torrent Torrent) {
+	d, err := m.downloaders.Get(InstanceName(torrent.Downloader))
+	if err != nil {
+		log.Errorf(ctx, "获取下载器失败 [%s]: %v", torrent.Name, err)
+		return
+	}
+	pauser, ok := d.(TorrentPauser)
+	if !ok {
+		// 无法暂停时移出队列，避免在时间窗口外一直占用下载位
+		log.Warnf(ctx, "下载器不支持暂停，时间窗口外继续下载并移出队列 [%s]", torrent.Name)
+		m.dequeue(ctx, torrent.Hash)
+		return
+	}
+	if err := pauser.PauseTorrent(ctx, torrent.Hash); err != nil {
+		log.Errorf(ctx, "暂停队列任务失败 [%s]: %v", torrent.Name, err)
+		return
+	}
+	if err := m.torrentOp.SetTorrentStatus(ctx, torrent.Hash, TorrentStatusDownloadPaused, "", nil); err != nil {
+		log.Errorf(ctx, "更新种子状态失败 [%s]: %v", torrent.Name, err)
+	}
+	if err := m.queueRepo.SetStarted(ctx, torrent.Hash, false); err != nil {
+		log.Errorf(ctx, "更新下载队列失败 [%s]: %v", torrent.Name, err)
+	}
+	log.Infof(ctx, "不在下载时间窗口内，暂停队列任务 [%s]", torrent.Name)
+}
+
+func (m *Manager) dequeue(ctx context.Context, hash string) {
+	if err := m.queueRepo.Delete(ctx, hash); err != nil {
+		log.Errorf(ctx, "删除下载队列条目失败 [%s]: %v", hash, err)
+	}
+}
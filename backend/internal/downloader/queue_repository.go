@@ -0,0 +1,93 @@
+package downloader
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// queueItemSchema 是QueueItem的数据库模型
+type queueItemSchema struct {
+	ID             int       `gorm:"type:int;primaryKey;autoIncrement"`
+	Hash           string    `gorm:"type:varchar(64);uniqueIndex"`
+	Priority       int       `gorm:"type:int;not null;default:0"`
+	SubscriptionID string    `gorm:"type:varchar(64);not null;default:''"`
+	Episode        int       `gorm:"type:int;not null;default:0"`
+	PublishedAt    time.Time `gorm:"type:datetime"`
+	Started        bool      `gorm:"type:boolean;not null;default:false"`
+	CreatedAt      time.Time `gorm:"type:datetime;autoCreateTime"`
+}
+
+// TableName 指定表名
+func (queueItemSchema) TableName() string {
+	return "download_queue"
+}
+
+func NewQueueRepository(db *gorm.DB) QueueRepository {
+	// 自动迁移表结构
+	db.AutoMigrate(&queueItemSchema{})
+	return &queueRepository{db: db}
+}
+
+type queueRepository struct {
+	db *gorm.DB
+}
+
+// Add 添加队列条目，已存在时更新优先级并重新排队
+func (q *queueRepository) Add(ctx context.Context, item QueueItem) error {
+	if item.Hash == "" {
+		return errors.New("queue item hash cannot be empty")
+	}
+	model := &queueItemSchema{
+		Hash:           item.Hash,
+		Priority:       item.Priority,
+		SubscriptionID: item.SubscriptionID,
+		Episode:        item.Episode,
+		PublishedAt:    item.PublishedAt,
+		Started:        item.Started,
+	}
+	return q.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "hash"}},
+		DoUpdates: clause.AssignmentColumns([]string{"priority", "subscription_id", "episode", "published_at", "started"}),
+	}).Create(model).Error
+}
+
+// List 按下载顺序列出队列条目，见 sortQueueItems
+func (q *queueRepository) List(ctx context.Context) ([]QueueItem, error) {
+	var models []queueItemSchema
+	if err := q.db.WithContext(ctx).
+		Order("priority DESC").
+		Order("episode DESC").
+		Order("published_at DESC").
+		Order("created_at ASC").
+		Find(&models).Error; err != nil {
+		return nil, err
+	}
+	items := make([]QueueItem, len(models))
+	for i, m := range models {
+		items[i] = QueueItem{
+			Hash:           m.Hash,
+			Priority:       m.Priority,
+			SubscriptionID: m.SubscriptionID,
+			Episode:        m.Episode,
+			PublishedAt:    m.PublishedAt,
+			Started:        m.Started,
+			CreatedAt:      m.CreatedAt,
+		}
+	}
+	sortQueueItems(items)
+	return items, nil
+}
+
+// SetStarted 设置队列条目是否已开始下载
+func (q *queueRepository) SetStarted(ctx context.Context, hash string, started bool) error {
+	return q.db.WithContext(ctx).Model(&queueItemSchema{}).Where("hash = ?", hash).Update("started", started).Error
+}
+
+// Delete 删除队列条目
+func (q *queueRepository) Delete(ctx context.Context, hash string) error {
+	return q.db.WithContext(ctx).Where("hash = ?", hash).Delete(&queueItemSchema{}).Error
+}
@@ -0,0 +1,238 @@
+package downloader
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeWindowContains(t *testing.T) {
+	// 2025-01-06 是周一
+	monday := func(clock string) time.Time {
+		tm, _ := time.ParseInLocation("2006-01-02 15:04", "2025-01-06 "+clock, time.Local)
+		return tm
+	}
+	tests := []struct {
+		name   string
+		window TimeWindow
+		now    time.Time
+		want   bool
+	}{
+		{
+			name:   "同一天内",
+			window: TimeWindow{Start: "09:00", End: "18:00"},
+			now:    monday("12:00"),
+			want:   true,
+		},
+		{
+			name:   "结束时间不包含",
+			window: TimeWindow{Start: "09:00", End: "18:00"},
+			now:    monday("18:00"),
+			want:   false,
+		},
+		{
+			name:   "跨越午夜的前半段",
+			window: TimeWindow{Start: "23:00", End: "07:00", Weekdays: []time.Weekday{time.Monday}},
+			now:    monday("23:30"),
+			want:   true,
+		},
+		{
+			name:   "跨越午夜的后半段属于前一天",
+			window: TimeWindow{Start: "23:00", End: "07:00", Weekdays: []time.Weekday{time.Sunday}},
+			now:    monday("06:00"),
+			want:   true,
+		},
+		{
+			name:   "星期不匹配",
+			window: TimeWindow{Start: "23:00", End: "07:00", Weekdays: []time.Weekday{time.Monday}},
+			now:    monday("06:00"),
+			want:   false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.window.contains(tt.now))
+		})
+	}
+}
+
+func TestQueueConfigValidate(t *testing.T) {
+	assert.NoError(t, QueueConfig{TimeWindows: []TimeWindow{{Start: "01:00", End: "08:30"}}}.validate())
+	assert.Error(t, QueueConfig{TimeWindows: []TimeWindow{{Start: "1点", End: "08:30"}}}.validate())
+	assert.Error(t, QueueConfig{MaxActiveDownloads: -1}.validate())
+}
+
+func TestManagerDownloadQueuedWhenSlotsFull(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	torrentOp := NewMockTorrentOperator(ctrl)
+	queueRepo := NewMockQueueRepository(ctrl)
+	d := &fakeDownloader{}
+	m := &Manager{
+		downloaders: &fakeInstances{downloaders: map[string]Downloader{DefaultInstance: d}},
+		torrentOp:   torrentOp,
+		queueRepo:   queueRepo,
+		config:      Config{Queue: QueueConfig{MaxActiveDownloads: 1}},
+	}
+	torrentOp.EXPECT().Get(gomock.Any(), "hash").Return(Torrent{}, ErrTorrentNotFound)
+	queueRepo.EXPECT().List(gomock.Any()).Return(nil, nil)
+	torrentOp.EXPECT().List(gomock.Any(), gomock.Any()).Return([]Torrent{{Hash: "active"}}, 1, nil)
+	torrentOp.EXPECT().Save(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, torrent Torrent) error {
+		assert.Equal(t, TorrentStatusDownloadPaused, torrent.Status)
+		return nil
+	})
+	queueRepo.EXPECT().Add(gomock.Any(), QueueItem{Hash: "hash", Priority: 2, SubscriptionID: "sub-1", Episode: 3})
+
+	err := m.Download(context.Background(), DownloadReq{
+		Hash:           "hash",
+		TorrentLink:    "link",
+		Priority:       2,
+		SubscriptionID: "sub-1",
+		Episode:        3,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"link"}, d.added)
+}
+
+func TestManagerContinueDownloadThroughQueue(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	torrentOp := NewMockTorrentOperator(ctrl)
+	queueRepo := NewMockQueueRepository(ctrl)
+	d := &pausableDownloader{}
+	m := &Manager{
+		downloaders: &fakeInstances{downloaders: map[string]Downloader{DefaultInstance: d}},
+		torrentOp:   torrentOp,
+		queueRepo:   queueRepo,
+		config:      Config{Queue: QueueConfig{MaxActiveDownloads: 1}},
+	}
+	torrent := Torrent{Hash: "hash", SubscriptionID: "sub-1", Status: TorrentStatusDownloadPaused}
+	torrentOp.EXPECT().Get(gomock.Any(), "hash").Return(torrent, nil).AnyTimes()
+	queueRepo.EXPECT().List(gomock.Any()).Return(nil, nil).Times(4)
+
+	// 没有空闲下载位时加入队列
+	torrentOp.EXPECT().List(gomock.Any(), gomock.Any()).Return([]Torrent{{Hash: "active"}}, 1, nil)
+	queueRepo.EXPECT().Add(gomock.Any(), QueueItem{Hash: "hash", SubscriptionID: "sub-1"})
+	require.NoError(t, m.ContinueDownload(context.Background(), "hash"))
+	assert.Empty(t, d.continued)
+
+	// 有空闲下载位时直接开始下载并记录在队列中
+	torrentOp.EXPECT().List(gomock.Any(), gomock.Any()).Return(nil, 0, nil)
+	torrentOp.EXPECT().SetTorrentStatus(gomock.Any(), "hash", TorrentStatusDownloading, "", nil)
+	queueRepo.EXPECT().Add(gomock.Any(), QueueItem{Hash: "hash", SubscriptionID: "sub-1", Started: true})
+	require.NoError(t, m.ContinueDownload(context.Background(), "hash"))
+	assert.Equal(t, []string{"hash"}, d.continued)
+}
+
+func TestManagerProcessQueue(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	torrentOp := NewMockTorrentOperator(ctrl)
+	queueRepo := NewMockQueueRepository(ctrl)
+	d := &pausableDownloader{}
+	m := &Manager{
+		downloaders: &fakeInstances{downloaders: map[string]Downloader{DefaultInstance: d}},
+		torrentOp:   torrentOp,
+		queueRepo:   queueRepo,
+		config:      Config{Queue: QueueConfig{MaxActiveDownloads: 2}},
+	}
+	queueRepo.EXPECT().List(gomock.Any()).Return([]QueueItem{
+		{Hash: "finished", Started: true},
+		{Hash: "high", Priority: 1},
+		{Hash: "low"},
+		{Hash: "deleted"},
+	}, nil)
+	torrentOp.EXPECT().Get(gomock.Any(), "finished").Return(Torrent{Hash: "finished", Status: TorrentStatusDownloaded}, nil)
+	torrentOp.EXPECT().Get(gomock.Any(), "high").Return(Torrent{Hash: "high", Status: TorrentStatusDownloadPaused}, nil)
+	torrentOp.EXPECT().Get(gomock.Any(), "low").Return(Torrent{Hash: "low", Status: TorrentStatusDownloadPaused}, nil)
+	torrentOp.EXPECT().Get(gomock.Any(), "deleted").Return(Torrent{}, ErrTorrentNotFound)
+	queueRepo.EXPECT().Delete(gomock.Any(), "finished")
+	queueRepo.EXPECT().Delete(gomock.Any(), "deleted")
+	torrentOp.EXPECT().List(gomock.Any(), gomock.Any()).Return([]Torrent{{Hash: "active"}}, 1, nil)
+	torrentOp.EXPECT().SetTorrentStatus(gomock.Any(), "high", TorrentStatusDownloading, "", nil)
+	queueRepo.EXPECT().SetStarted(gomock.Any(), "high", true)
+
+	m.processQueue()
+
+	assert.Equal(t, []string{"high"}, d.continued)
+}
+
+func TestManagerProcessQueuePausesOutsideWindow(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	torrentOp := NewMockTorrentOperator(ctrl)
+	queueRepo := NewMockQueueRepository(ctrl)
+	d := &pausableDownloader{}
+	now := time.Now()
+	// 只在当前时间之后的一小时内允许下载
+	start := now.Add(time.Hour)
+	window := TimeWindow{Start: start.Format(clockLayout), End: start.Add(time.Hour).Format(clockLayout)}
+	m := &Manager{
+		downloaders: &fakeInstances{downloaders: map[string]Downloader{DefaultInstance: d}},
+		torrentOp:   torrentOp,
+		queueRepo:   queueRepo,
+		config:      Config{Queue: QueueConfig{TimeWindows: []TimeWindow{window}}},
+	}
+	queueRepo.EXPECT().List(gomock.Any()).Return([]QueueItem{{Hash: "queued", Started: true}}, nil)
+	torrentOp.EXPECT().List(gomock.Any(), gomock.Any()).Return([]Torrent{
+		{Hash: "queued", Status: TorrentStatusDownloading},
+		{Hash: "direct", SubscriptionID: "sub-1", Status: TorrentStatusDownloading},
+	}, 2, nil)
+	// 不在队列中的下载加入队列后暂停
+	queueRepo.EXPECT().Add(gomock.Any(), QueueItem{Hash: "direct", SubscriptionID: "sub-1", Started: true})
+	torrentOp.EXPECT().Get(gomock.Any(), "queued").Return(Torrent{Hash: "queued", Status: TorrentStatusDownloading}, nil)
+	for _, hash := range []string{"direct", "queued"} {
+		torrentOp.EXPECT().SetTorrentStatus(gomock.Any(), hash, TorrentStatusDownloadPaused, "", nil)
+		queueRepo.EXPECT().SetStarted(gomock.Any(), hash, false)
+	}
+
+	m.processQueue()
+
+	assert.Equal(t, []string{"direct", "queued"}, d.paused)
+}
+
+func TestManagerProcessQueueOutsideWindowWithoutPauser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	torrentOp := NewMockTorrentOperator(ctrl)
+	queueRepo := NewMockQueueRepository(ctrl)
+	start := time.Now().Add(time.Hour)
+	window := TimeWindow{Start: start.Format(clockLayout), End: start.Add(time.Hour).Format(clockLayout)}
+	m := &Manager{
+		downloaders: &fakeInstances{downloaders: map[string]Downloader{DefaultInstance: &fakeDownloader{}}},
+		torrentOp:   torrentOp,
+		queueRepo:   queueRepo,
+		config:      Config{Queue: QueueConfig{TimeWindows: []TimeWindow{window}}},
+	}
+	queueRepo.EXPECT().List(gomock.Any()).Return([]QueueItem{{Hash: "queued", Started: true}}, nil)
+	torrentOp.EXPECT().List(gomock.Any(), gomock.Any()).Return([]Torrent{
+		{Hash: "queued", Status: TorrentStatusDownloading},
+		{Hash: "direct", Status: TorrentStatusDownloading},
+	}, 2, nil)
+	torrentOp.EXPECT().Get(gomock.Any(), "queued").Return(Torrent{Hash: "queued", Status: TorrentStatusDownloading}, nil)
+	// 无法暂停的下载不加入队列，已在队列中的移出队列，不再占用下载位
+	queueRepo.EXPECT().Delete(gomock.Any(), "queued")
+
+	m.processQueue()
+}
+
+func TestSortQueueItems(t *testing.T) {
+	now := time.Now()
+	items := []QueueItem{
+		{Hash: "b-2", SubscriptionID: "b", Episode: 2, CreatedAt: now},
+		{Hash: "a-3", SubscriptionID: "a", Episode: 3, CreatedAt: now.Add(time.Second)},
+		{Hash: "a-1", SubscriptionID: "a", Episode: 1, CreatedAt: now.Add(3 * time.Second)},
+		{Hash: "b-1", SubscriptionID: "b", Episode: 1, CreatedAt: now.Add(2 * time.Second)},
+		{Hash: "magnet-old", PublishedAt: now.Add(-time.Hour), CreatedAt: now.Add(-2 * time.Second)},
+		{Hash: "magnet-new", PublishedAt: now, CreatedAt: now.Add(-time.Second)},
+		{Hash: "high", SubscriptionID: "c", Priority: 1, Episode: 1, CreatedAt: now.Add(4 * time.Second)},
+	}
+
+	sortQueueItems(items)
+
+	hashes := make([]string, 0, len(items))
+	for _, item := range items {
+		hashes = append(hashes, item.Hash)
+	}
+	assert.Equal(t, []string{"high", "a-3", "b-2", "b-1", "a-1", "magnet-new", "magnet-old"}, hashes)
+}
@@ -0,0 +1,120 @@
+package downloader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/MangataL/BangumiBuddy/pkg/log"
+)
+
+const (
+	seedingCheckInterval = 5 * time.Minute
+	// hardlinkTransferType 硬链接转移，媒体库文件不依赖源文件
+	hardlinkTransferType = "hardlink"
+)
+
+// SeedingPolicy 做种策略，已转移的种子满足任一条件后从下载器中移除
+type SeedingPolicy struct {
+	MaxRatio            float64 `mapstructure:"max_ratio" json:"maxRatio"`                        // 最大分享率，0表示不限制
+	MaxSeedingMinutes   int     `mapstructure:"max_seeding_minutes" json:"maxSeedingMinutes"`     // 最长做种时间，单位分钟，0表示不限制
+	RemoveAfterTransfer bool    `mapstructure:"remove_after_transfer" json:"removeAfterTransfer"` // 转移完成后立即移除
+	DeleteFiles         bool    `mapstructure:"delete_files" json:"deleteFiles"`                  // 移除时同时删除源文件，仅硬链接转移的种子生效
+}
+
+// reached 判断种子是否满足移除条件，返回满足的条件描述
+func (p SeedingPolicy) reached(status DownloadStatus) (string, bool) {
+	switch {
+	case p.RemoveAfterTransfer:
+		return "转移完成", true
+	case p.MaxRatio > 0 && status.Ratio >= p.MaxRatio:
+		return fmt.Sprintf("分享率达到 %.2f", status.Ratio), true
+	case p.MaxSeedingMinutes > 0 && status.SeedingTime >= time.Duration(p.MaxSeedingMinutes)*time.Minute:
+		return fmt.Sprintf("做种时间达到 %s", status.SeedingTime.Truncate(time.Minute)), true
+	default:
+		return "", false
+	}
+}
+
+// seedingPolicy 获取种子使用的做种策略，种子未单独设置时使用全局配置
+func (m *Manager) seedingPolicy(torrent Torrent) SeedingPolicy {
+	if torrent.SeedingPolicy != nil {
+		return *torrent.SeedingPolicy
+	}
+	return m.config.Seeding
+}
+
+// runSeedingEnforcer 定期执行做种策略
+func (m *Manager) runSeedingEnforcer(ctx context.Context) {
+	ticker := time.NewTicker(seedingCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			m.enforceSeedingPolicies()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// enforceSeedingPolicies 检查所有下载器实例中已转移的种子，移除满足做种策略的种子
+func (m *Manager) enforceSeedingPolicies() {
+	ctx := log.NewContext()
+	for _, name := range m.downloaders.Names() {
+		statuses, err := m.listInstanceStatuses(ctx, name)
+		if err != nil {
+			log.Errorf(ctx, "获取种子列表失败: %v", err)
+			continue
+		}
+		m.enforceInstanceSeedingPolicies(ctx, name, statuses)
+	}
+}
+
+func (m *Manager) enforceInstanceSeedingPolicies(ctx context.Context, instance string, statuses []DownloadStatus) {
+	if len(statuses) == 0 {
+		return
+	}
+	d, err := m.downloaders.Get(instance)
+	if err != nil {
+		log.Errorf(ctx, "获取下载器 %s 失败: %v", instance, err)
+		return
+	}
+	remover, ok := d.(TorrentRemover)
+	if !ok {
+		log.Debugf(ctx, "下载器 %s 不支持移除种子，跳过做种策略", instance)
+		return
+	}
+
+	for _, status := range statuses {
+		torrent, err := m.torrentOp.Get(ctx, status.Hash)
+		if err != nil {
+			// 不是通过本程序添加的种子，不处理
+			if !errors.Is(err, ErrTorrentNotFound) {
+				log.Errorf(ctx, "获取种子信息失败 [%s]: %v", status.Hash, err)
+			}
+			continue
+		}
+		// 只处理已转移到媒体库的种子，避免媒体库缺失文件
+		if torrent.Status != TorrentStatusTransferred || InstanceName(torrent.Downloader) != instance {
+			continue
+		}
+		policy := m.seedingPolicy(torrent)
+		reason, ok := policy.reached(status)
+		if !ok {
+			continue
+		}
+		// 只有硬链接转移的文件在删除源文件后仍然存在于媒体库中
+		deleteFiles := policy.DeleteFiles && torrent.TransferType == hardlinkTransferType
+		if policy.DeleteFiles && !deleteFiles {
+			log.Infof(ctx, "种子 %s 的转移方式为 %s，保留源文件", torrent.Name, torrent.TransferType)
+		}
+		if err := remover.RemoveTorrent(ctx, torrent.Hash, deleteFiles); err != nil {
+			log.Errorf(ctx, "移除种子失败 [%s]: %v", torrent.Hash, err)
+			continue
+		}
+		log.Infof(ctx, "种子 %s %s，已从下载器 %s 中移除", torrent.Name, reason, instance)
+	}
+}
@@ -0,0 +1,100 @@
+package downloader
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManagerEnforceSeedingPolicies(t *testing.T) {
+	tests := []struct {
+		name        string
+		global      SeedingPolicy
+		status      DownloadStatus
+		torrent     Torrent
+		wantRemoved bool
+		wantDelete  bool
+	}{
+		{
+			name:        "分享率达到上限，硬链接转移时删除源文件",
+			global:      SeedingPolicy{MaxRatio: 2, DeleteFiles: true},
+			status:      DownloadStatus{Hash: "hash", Ratio: 2.1},
+			torrent:     Torrent{Hash: "hash", Status: TorrentStatusTransferred, TransferType: "hardlink"},
+			wantRemoved: true,
+			wantDelete:  true,
+		},
+		{
+			name:        "做种时间达到上限，软链接转移时保留源文件",
+			global:      SeedingPolicy{MaxSeedingMinutes: 60, DeleteFiles: true},
+			status:      DownloadStatus{Hash: "hash", SeedingTime: 2 * time.Hour},
+			torrent:     Torrent{Hash: "hash", Status: TorrentStatusTransferred, TransferType: "softlink"},
+			wantRemoved: true,
+		},
+		{
+			name:    "未达到限制",
+			global:  SeedingPolicy{MaxRatio: 2, MaxSeedingMinutes: 60},
+			status:  DownloadStatus{Hash: "hash", Ratio: 1, SeedingTime: time.Minute},
+			torrent: Torrent{Hash: "hash", Status: TorrentStatusTransferred},
+		},
+		{
+			name:    "未转移的种子不移除",
+			global:  SeedingPolicy{RemoveAfterTransfer: true},
+			status:  DownloadStatus{Hash: "hash"},
+			torrent: Torrent{Hash: "hash", Status: TorrentStatusDownloaded},
+		},
+		{
+			name:   "种子策略覆盖全局配置",
+			global: SeedingPolicy{},
+			status: DownloadStatus{Hash: "hash"},
+			torrent: Torrent{
+				Hash:          "hash",
+				Status:        TorrentStatusTransferred,
+				SeedingPolicy: &SeedingPolicy{RemoveAfterTransfer: true},
+			},
+			wantRemoved: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			torrentOp := NewMockTorrentOperator(ctrl)
+			d := &fakeDownloader{statuses: []DownloadStatus{tt.status}}
+			m := &Manager{
+				downloaders: &fakeInstances{
+					names:       []string{DefaultInstance},
+					downloaders: map[string]Downloader{DefaultInstance: d},
+				},
+				torrentOp: torrentOp,
+				config:    Config{Seeding: tt.global},
+			}
+			torrentOp.EXPECT().Get(gomock.Any(), tt.status.Hash).Return(tt.torrent, nil)
+
+			m.enforceSeedingPolicies()
+
+			deleteFiles, removed := d.removed[tt.status.Hash]
+			assert.Equal(t, tt.wantRemoved, removed)
+			assert.Equal(t, tt.wantDelete, deleteFiles)
+		})
+	}
+}
+
+func TestManagerEnforceSeedingPoliciesSkipsUnknownTorrents(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	torrentOp := NewMockTorrentOperator(ctrl)
+	d := &fakeDownloader{statuses: []DownloadStatus{{Hash: "other"}}}
+	m := &Manager{
+		downloaders: &fakeInstances{
+			names:       []string{DefaultInstance},
+			downloaders: map[string]Downloader{DefaultInstance: d},
+		},
+		torrentOp: torrentOp,
+		config:    Config{Seeding: SeedingPolicy{RemoveAfterTransfer: true}},
+	}
+	torrentOp.EXPECT().Get(gomock.Any(), "other").Return(Torrent{}, ErrTorrentNotFound)
+
+	m.enforceSeedingPolicies()
+
+	assert.Empty(t, d.removed)
+}
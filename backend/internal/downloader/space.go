@@ -0,0 +1,123 @@
+package downloader
+
+import (
+	"context"
+	"errors"
+
+	"github.com/MangataL/BangumiBuddy/internal/storage"
+	"github.com/MangataL/BangumiBuddy/pkg/log"
+)
+
+// spacePausedDetail 因磁盘空间不足被暂停的种子的状态详情，用于在空间恢复后区分用户手动暂停的种子
+const spacePausedDetail = "磁盘空间不足，已自动暂停"
+
+// checkStorage 检查保存路径的剩余空间，空间不足时拒绝添加新的下载
+func (m *Manager) checkStorage(ctx context.Context, path string) error {
+	if m.storage == nil {
+		return nil
+	}
+	return m.storage.Check(ctx, path)
+}
+
+// monitorStorage 定期检查下载保存路径和媒体库路径的剩余空间，空间不足时发送通知，与是否有正在下载的种子无关
+func (m *Manager) monitorStorage() {
+	if m.storage == nil {
+		return
+	}
+	ctx := log.NewContext()
+	paths := []string{m.config.TVSavePath, m.config.MovieSavePath}
+	if m.library != nil {
+		libraryPaths, err := m.library.GetMediaLibraryPaths()
+		if err != nil {
+			log.Errorf(ctx, "获取媒体库路径失败: %v", err)
+		}
+		paths = append(paths, libraryPaths...)
+	}
+	checked := make(map[string]struct{}, len(paths))
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		if _, ok := checked[path]; ok {
+			continue
+		}
+		checked[path] = struct{}{}
+		if _, err := m.storage.Monitor(ctx, path); err != nil {
+			log.Warnf(ctx, "检查磁盘剩余空间失败: %v", err)
+		}
+	}
+}
+
+// guardInstanceStorage 磁盘空间严重不足时暂停下载器实例中正在下载的种子，空间恢复后继续下载
+func (m *Manager) guardInstanceStorage(ctx context.Context, instance string, statuses []DownloadStatus) {
+	if m.storage == nil || len(statuses) == 0 {
+		return
+	}
+	d, err := m.downloaders.Get(instance)
+	if err != nil {
+		log.Errorf(ctx, "获取下载器 %s 失败: %v", instance, err)
+		return
+	}
+	pauser, ok := d.(TorrentPauser)
+	if !ok {
+		return
+	}
+
+	levels := make(map[string]storage.Level)
+	levelOf := func(path string) (storage.Level, bool) {
+		if level, ok := levels[path]; ok {
+			return level, true
+		}
+		usage, err := m.storage.Monitor(ctx, path)
+		if err != nil {
+			log.Warnf(ctx, "检查磁盘剩余空间失败: %v", err)
+			return "", false
+		}
+		levels[path] = usage.Level
+		return usage.Level, true
+	}
+
+	for _, status := range statuses {
+		if status.Status != TorrentStatusDownloading && status.Status != TorrentStatusDownloadPaused {
+			continue
+		}
+		torrent, err := m.torrentOp.Get(ctx, status.Hash)
+		if err != nil {
+			if !errors.Is(err, ErrTorrentNotFound) {
+				log.Errorf(ctx, "获取种子信息失败 [%s]: %v", status.Hash, err)
+			}
+			continue
+		}
+		if InstanceName(torrent.Downloader) != instance || torrent.Path == "" {
+			continue
+		}
+		if status.Status == TorrentStatusDownloadPaused && torrent.StatusDetail != spacePausedDetail {
+			continue
+		}
+		level, ok := levelOf(torrent.Path)
+		if !ok {
+			continue
+		}
+
+		switch {
+		case status.Status == TorrentStatusDownloading && level == storage.LevelCritical:
+			if err := pauser.PauseTorrent(ctx, torrent.Hash); err != nil {
+				log.Errorf(ctx, "磁盘空间不足，暂停种子失败 [%s]: %v", torrent.Name, err)
+				continue
+			}
+			if err := m.torrentOp.SetTorrentStatus(ctx, torrent.Hash, TorrentStatusDownloadPaused, spacePausedDetail, nil); err != nil {
+				log.Errorf(ctx, "更新种子状态失败 [%s]: %v", torrent.Hash, err)
+			}
+			log.Warnf(ctx, "磁盘空间不足，暂停种子 [%s]", torrent.Name)
+		case status.Status == TorrentStatusDownloadPaused && level == storage.LevelOK:
+			if err := d.ContinueDownload(ctx, torrent.Hash); err != nil {
+				log.Errorf(ctx, "磁盘空间恢复，继续下载种子失败 [%s]: %v", torrent.Name, err)
+				continue
+			}
+			if err := m.torrentOp.SetTorrentStatus(ctx, torrent.Hash, TorrentStatusDownloading, "", nil); err != nil {
+				log.Errorf(ctx, "更新种子状态失败 [%s]: %v", torrent.Hash, err)
+			}
+			log.Infof(ctx, "磁盘空间恢复，继续下载种子 [%s]", torrent.Name)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package downloader
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MangataL/BangumiBuddy/internal/storage"
+)
+
+type fakeStorage struct {
+	level     storage.Level
+	monitored []string
+}
+
+func (f *fakeStorage) Check(ctx context.Context, path string) error {
+	if f.level != storage.LevelOK {
+		return storage.ErrInsufficientSpace
+	}
+	return nil
+}
+
+func (f *fakeStorage) Stat(ctx context.Context, path string) (storage.Usage, error) {
+	return storage.Usage{Path: path, Level: f.level}, nil
+}
+
+func (f *fakeStorage) Monitor(ctx context.Context, path string) (storage.Usage, error) {
+	f.monitored = append(f.monitored, path)
+	return f.Stat(ctx, path)
+}
+
+type fakeMediaLibrary []string
+
+func (f fakeMediaLibrary) GetMediaLibraryPaths() ([]string, error) {
+	return f, nil
+}
+
+type pausableDownloader struct {
+	fakeDownloader
+	paused    []string
+	continued []string
+}
+
+func (p *pausableDownloader) PauseTorrent(ctx context.Context, hash string) error {
+	p.paused = append(p.paused, hash)
+	return nil
+}
+
+func (p *pausableDownloader) ContinueDownload(ctx context.Context, hash string) error {
+	p.continued = append(p.continued, hash)
+	return nil
+}
+
+func TestManagerDownloadRefusedWhenStorageLow(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	torrentOp := NewMockTorrentOperator(ctrl)
+	d := &fakeDownloader{}
+	m := &Manager{
+		downloaders: &fakeInstances{downloaders: map[string]Downloader{DefaultInstance: d}},
+		torrentOp:   torrentOp,
+		storage:     &fakeStorage{level: storage.LevelLow},
+	}
+	torrentOp.EXPECT().Get(gomock.Any(), "hash").Return(Torrent{}, ErrTorrentNotFound)
+
+	err := m.Download(context.Background(), DownloadReq{Hash: "hash", TorrentLink: "link"})
+
+	assert.ErrorIs(t, err, storage.ErrInsufficientSpace)
+	assert.Empty(t, d.added)
+}
+
+func TestManagerGuardInstanceStorage(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	torrentOp := NewMockTorrentOperator(ctrl)
+	d := &pausableDownloader{}
+	guard := &fakeStorage{level: storage.LevelCritical}
+	m := &Manager{
+		downloaders: &fakeInstances{downloaders: map[string]Downloader{DefaultInstance: d}},
+		torrentOp:   torrentOp,
+		storage:     guard,
+	}
+	torrentOp.EXPECT().Get(gomock.Any(), "downloading").
+		Return(Torrent{Hash: "downloading", Path: "/downloads/tv"}, nil)
+	torrentOp.EXPECT().Get(gomock.Any(), "seeding").Times(0)
+	torrentOp.EXPECT().SetTorrentStatus(gomock.Any(), "downloading", TorrentStatusDownloadPaused, spacePausedDetail, nil)
+
+	m.guardInstanceStorage(ctx, DefaultInstance, []DownloadStatus{
+		{Hash: "downloading", Status: TorrentStatusDownloading},
+		{Hash: "seeding", Status: TorrentStatusDownloaded},
+	})
+	require.Equal(t, []string{"downloading"}, d.paused)
+
+	// 用户手动暂停的种子不会在空间恢复后继续下载
+	guard.level = storage.LevelOK
+	torrentOp.EXPECT().Get(gomock.Any(), "downloading").Return(Torrent{
+		Hash:         "downloading",
+		Path:         "/downloads/tv",
+		Status:       TorrentStatusDownloadPaused,
+		StatusDetail: spacePausedDetail,
+	}, nil)
+	torrentOp.EXPECT().Get(gomock.Any(), "manual").Return(Torrent{
+		Hash:   "manual",
+		Path:   "/downloads/tv",
+		Status: TorrentStatusDownloadPaused,
+	}, nil)
+	torrentOp.EXPECT().SetTorrentStatus(gomock.Any(), "downloading", TorrentStatusDownloading, "", nil)
+
+	m.guardInstanceStorage(ctx, DefaultInstance, []DownloadStatus{
+		{Hash: "downloading", Status: TorrentStatusDownloadPaused},
+		{Hash: "manual", Status: TorrentStatusDownloadPaused},
+	})
+	assert.Equal(t, []string{"downloading"}, d.continued)
+}
+
+func TestManagerMonitorStorage(t *testing.T) {
+	guard := &fakeStorage{level: storage.LevelOK}
+	m := &Manager{
+		storage: guard,
+		library: fakeMediaLibrary{"/media/tv", ""},
+		config:  Config{TVSavePath: "/downloads/tv", MovieSavePath: "/downloads/tv"},
+	}
+
+	// 没有正在下载的种子时也检查所有配置的路径，未配置和重复的路径跳过
+	m.monitorStorage()
+
+	assert.Equal(t, []string{"/downloads/tv", "/media/tv"}, guard.monitored)
+}
@@ -0,0 +1,133 @@
+package downloader
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/MangataL/BangumiBuddy/internal/notice"
+	"github.com/MangataL/BangumiBuddy/pkg/log"
+)
+
+// StallConfig 下载停滞检测配置
+type StallConfig struct {
+	TimeoutMinutes int  `mapstructure:"timeout_minutes" json:"timeoutMinutes" default:"360"` // 下载进度无变化超过该时间视为停滞，单位分钟，0表示不检测
+	Fallback       bool `mapstructure:"fallback" json:"fallback"`                            // 订阅下载停滞后自动切换到其他资源
+}
+
+// stallMark 种子下载进度记录
+type stallMark struct {
+	instance string
+	progress float64
+	since    time.Time // 进度最后一次变化的时间
+	stalled  bool      // 是否已作为停滞处理
+}
+
+// detectInstanceStalls 检测下载器实例中进度长时间没有变化的种子
+func (m *Manager) detectInstanceStalls(ctx context.Context, instance string, statuses []DownloadStatus) {
+	timeout := time.Duration(m.config.Stall.TimeoutMinutes) * time.Minute
+	if timeout <= 0 {
+		return
+	}
+	now := time.Now()
+	m.stallMu.Lock()
+	defer m.stallMu.Unlock()
+
+	seen := make(map[string]struct{}, len(statuses))
+	for _, status := range statuses {
+		if status.Status != TorrentStatusDownloading && status.Status != TorrentStatusDownloadError {
+			continue
+		}
+		seen[status.Hash] = struct{}{}
+		mark, ok := m.stallMarks[status.Hash]
+		if !ok {
+			// 重启前已停滞的种子，进度变化后才恢复
+			m.stallMarks[status.Hash] = &stallMark{
+				instance: instance,
+				progress: status.Progress,
+				since:    now,
+				stalled:  m.isStalled(ctx, status.Hash),
+			}
+			continue
+		}
+		if status.Progress > mark.progress {
+			if mark.stalled {
+				m.recoverStalled(ctx, status)
+			}
+			m.stallMarks[status.Hash] = &stallMark{
+				instance: instance,
+				progress: status.Progress,
+				since:    now,
+			}
+			continue
+		}
+		if mark.stalled || now.Sub(mark.since) < timeout {
+			continue
+		}
+		mark.stalled = m.handleStalled(ctx, instance, status, now.Sub(mark.since))
+	}
+	// 暂停、完成或已删除的种子不再检测
+	for hash, mark := range m.stallMarks {
+		if _, ok := seen[hash]; !ok && mark.instance == instance {
+			delete(m.stallMarks, hash)
+		}
+	}
+}
+
+// handleStalled 处理停滞的种子，返回是否已处理完成
+func (m *Manager) handleStalled(ctx context.Context, instance string, status DownloadStatus, duration time.Duration) bool {
+	torrent, err := m.torrentOp.Get(ctx, status.Hash)
+	if err != nil {
+		log.Errorf(ctx, "获取种子信息失败 [%s]: %v", status.Hash, err)
+		return false
+	}
+	if InstanceName(torrent.Downloader) != instance || torrent.Status == TorrentStatusStalled {
+		return true
+	}
+	if torrent.Status != TorrentStatusDownloading && torrent.Status != TorrentStatusDownloadError {
+		return false
+	}
+
+	detail := fmt.Sprintf("下载停滞超过 %s", duration.Truncate(time.Minute))
+	if status.Error != "" {
+		detail = fmt.Sprintf("%s: %s", detail, status.Error)
+	}
+	log.Warnf(ctx, "种子%s [%s]", detail, torrent.Name)
+	if m.config.Stall.Fallback && torrent.SubscriptionID != "" {
+		// 交由订阅切换到其他资源，停滞状态的种子不再同步下载器状态
+		if err := m.torrentOp.SetTorrentStatus(ctx, torrent.Hash, TorrentStatusStalled, detail, nil); err != nil {
+			log.Errorf(ctx, "更新种子状态失败 [%s]: %v", torrent.Name, err)
+			return false
+		}
+	}
+	if err := m.notifier.NoticeDownloaded(ctx, notice.NoticeDownloadedReq{
+		RSSGUID:     torrent.RSSGUID,
+		TorrentName: torrent.Name,
+		Failed:      true,
+		FailDetail:  detail,
+		Cost:        status.Cost,
+		Size:        status.Size,
+	}); err != nil {
+		log.Errorf(ctx, "通知种子状态失败 [%s-%s]: %v", status.Hash, torrent.Name, err)
+	}
+	return true
+}
+
+// isStalled 种子是否已被标记为停滞
+func (m *Manager) isStalled(ctx context.Context, hash string) bool {
+	torrent, err := m.torrentOp.Get(ctx, hash)
+	return err == nil && torrent.Status == TorrentStatusStalled
+}
+
+// recoverStalled 停滞的种子恢复下载后，恢复种子状态
+func (m *Manager) recoverStalled(ctx context.Context, status DownloadStatus) {
+	torrent, err := m.torrentOp.Get(ctx, status.Hash)
+	if err != nil || torrent.Status != TorrentStatusStalled {
+		return
+	}
+	if err := m.torrentOp.SetTorrentStatus(ctx, torrent.Hash, status.Status, status.Error, nil); err != nil {
+		log.Errorf(ctx, "更新种子状态失败 [%s]: %v", torrent.Name, err)
+		return
+	}
+	log.Infof(ctx, "种子恢复下载 [%s]", torrent.Name)
+}
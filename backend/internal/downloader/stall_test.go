@@ -0,0 +1,81 @@
+package downloader
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/MangataL/BangumiBuddy/internal/notice"
+)
+
+func TestManagerDetectInstanceStalls(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	torrentOp := NewMockTorrentOperator(ctrl)
+	m := &Manager{
+		torrentOp:  torrentOp,
+		notifier:   &notice.Empty{},
+		config:     Config{Stall: StallConfig{TimeoutMinutes: 60, Fallback: true}},
+		stallMarks: make(map[string]*stallMark),
+	}
+	torrent := Torrent{Hash: "hash", SubscriptionID: "sub", Status: TorrentStatusDownloading}
+	statuses := []DownloadStatus{{Hash: "hash", Status: TorrentStatusDownloading, Progress: 0.5}}
+
+	torrentOp.EXPECT().Get(gomock.Any(), "hash").Return(torrent, nil)
+	m.detectInstanceStalls(ctx, DefaultInstance, statuses)
+
+	// 进度超过停滞时间没有变化，交由订阅切换资源
+	m.stallMarks["hash"].since = time.Now().Add(-2 * time.Hour)
+	torrentOp.EXPECT().Get(gomock.Any(), "hash").Return(torrent, nil)
+	torrentOp.EXPECT().SetTorrentStatus(gomock.Any(), "hash", TorrentStatusStalled, gomock.Any(), nil)
+	m.detectInstanceStalls(ctx, DefaultInstance, statuses)
+	assert.True(t, m.stallMarks["hash"].stalled)
+
+	// 已处理的停滞不会重复通知
+	m.detectInstanceStalls(ctx, DefaultInstance, statuses)
+
+	// 进度恢复后恢复种子状态
+	torrent.Status = TorrentStatusStalled
+	torrentOp.EXPECT().Get(gomock.Any(), "hash").Return(torrent, nil)
+	torrentOp.EXPECT().SetTorrentStatus(gomock.Any(), "hash", TorrentStatusDownloading, "", nil)
+	m.detectInstanceStalls(ctx, DefaultInstance, []DownloadStatus{
+		{Hash: "hash", Status: TorrentStatusDownloading, Progress: 0.6},
+	})
+	assert.False(t, m.stallMarks["hash"].stalled)
+
+	// 暂停的种子不再检测
+	m.detectInstanceStalls(ctx, DefaultInstance, []DownloadStatus{
+		{Hash: "hash", Status: TorrentStatusDownloadPaused, Progress: 0.6},
+	})
+	assert.Empty(t, m.stallMarks)
+}
+
+func TestManagerCheckInstanceDownloadStatusStalled(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	torrentOp := NewMockTorrentOperator(ctrl)
+	d := &fakeDownloader{}
+	m := &Manager{
+		downloaders: &fakeInstances{downloaders: map[string]Downloader{DefaultInstance: d}},
+		torrentOp:   torrentOp,
+		notifier:    &notice.Empty{},
+	}
+	torrentOp.EXPECT().Get(gomock.Any(), "hash").
+		Return(Torrent{Hash: "hash", Status: TorrentStatusStalled}, nil).Times(2)
+
+	// 仍在下载时保持停滞状态
+	m.checkInstanceDownloadStatus(ctx, DefaultInstance, []DownloadStatus{
+		{Hash: "hash", Status: TorrentStatusDownloading},
+	})
+
+	// 下载完成后更新状态
+	torrentOp.EXPECT().SetTorrentStatus(gomock.Any(), "hash", TorrentStatusDownloaded, "", &SetTorrentStatusOptions{
+		FileNames: []string{"hash.mkv"},
+	})
+	m.checkInstanceDownloadStatus(ctx, DefaultInstance, []DownloadStatus{
+		{Hash: "hash", Status: TorrentStatusDownloaded},
+	})
+}
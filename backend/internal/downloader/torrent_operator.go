@@ -2,6 +2,7 @@ package downloader
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"strings"
@@ -11,6 +12,9 @@ import (
 	"gorm.io/gorm/clause"
 )
 
+// ErrTorrentNotFound 种子不存在
+var ErrTorrentNotFound = errors.New("torrent not found")
+
 // torrentSchema 是Torrent的数据库模型
 type torrentSchema struct {
 	ID             int       `gorm:"type:int;primaryKey;autoIncrement"`
@@ -26,7 +30,12 @@ type torrentSchema struct {
 	RSSGUID        string    `gorm:"type:varchar(255)"`
 	CreatedAt      time.Time `gorm:"type:datetime;autoCreateTime;index"`
 	UpdatedAt      time.Time `gorm:"type:datetime;autoUpdateTime"`
+	PublishedAt    time.Time `gorm:"type:datetime"`
 	FileNames      string    `gorm:"type:text"`
+	Downloader     string    `gorm:"type:varchar(64);not null;default:''"`
+	SeedingPolicy  string    `gorm:"type:text"` // JSON 格式存储做种策略，为空时使用全局配置
+	QualityScore   int       `gorm:"type:int;not null;default:0"`
+	SelectedFiles  string    `gorm:"type:text"` // 合集种子中选择下载的文件，为空时下载全部文件
 }
 
 // TableName 指定表名
@@ -50,8 +59,32 @@ func (m *torrentSchema) ToTorrent() Torrent {
 		RSSGUID:        m.RSSGUID,
 		CreatedAt:      m.CreatedAt,
 		UpdatedAt:      m.UpdatedAt,
+		PublishedAt:    m.PublishedAt,
 		FileNames:      strings.Split(m.FileNames, fileNamesSeparator),
+		Downloader:     m.Downloader,
+		SeedingPolicy:  parseSeedingPolicy(m.SeedingPolicy),
+		QualityScore:   m.QualityScore,
+		SelectedFiles:  splitFileNames(m.SelectedFiles),
+	}
+}
+
+// splitFileNames 拆分存储的文件名列表，空字符串返回nil
+func splitFileNames(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, fileNamesSeparator)
+}
+
+func parseSeedingPolicy(s string) *SeedingPolicy {
+	if s == "" {
+		return nil
+	}
+	var policy SeedingPolicy
+	if err := json.Unmarshal([]byte(s), &policy); err != nil {
+		return nil
 	}
+	return &policy
 }
 
 // FromTorrent 将业务模型转换为数据库模型
@@ -65,7 +98,16 @@ func (m *torrentSchema) FromTorrent(t Torrent) {
 	m.TransferType = t.TransferType
 	m.Name = t.Name
 	m.RSSGUID = t.RSSGUID
+	m.PublishedAt = t.PublishedAt
 	m.FileNames = strings.Join(t.FileNames, fileNamesSeparator)
+	m.Downloader = t.Downloader
+	m.QualityScore = t.QualityScore
+	m.SelectedFiles = strings.Join(t.SelectedFiles, fileNamesSeparator)
+	m.SeedingPolicy = ""
+	if t.SeedingPolicy != nil {
+		policy, _ := json.Marshal(t.SeedingPolicy)
+		m.SeedingPolicy = string(policy)
+	}
 }
 
 func NewTorrentOperator(db *gorm.DB) TorrentOperator {
@@ -90,6 +132,9 @@ func (t *torrentOperator) SetTorrentStatus(ctx context.Context, hash string, sta
 	if opts != nil && len(opts.FileNames) > 0 {
 		updates["file_names"] = strings.Join(opts.FileNames, fileNamesSeparator)
 	}
+	if opts != nil && len(opts.SelectedFiles) > 0 {
+		updates["selected_files"] = strings.Join(opts.SelectedFiles, fileNamesSeparator)
+	}
 	return t.db.WithContext(ctx).Model(&torrentSchema{}).Where("hash = ?", hash).Updates(updates).Error
 }
 
@@ -118,6 +163,10 @@ func (t *torrentOperator) Save(ctx context.Context, torrent Torrent) error {
 			"task_id":         model.TaskID,
 			"transfer_type":   model.TransferType,
 			"rss_guid":        model.RSSGUID,
+			"published_at":    model.PublishedAt,
+			"downloader":      model.Downloader,
+			"seeding_policy":  model.SeedingPolicy,
+			"quality_score":   model.QualityScore,
 		}),
 	}).Create(model).Error
 }
@@ -132,7 +181,7 @@ func (t *torrentOperator) Get(ctx context.Context, hash string) (Torrent, error)
 	err := t.db.WithContext(ctx).Where("hash = ?", hash).First(&model).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return Torrent{}, fmt.Errorf("torrent with hash %s not found", hash)
+			return Torrent{}, fmt.Errorf("%w: %s", ErrTorrentNotFound, hash)
 		}
 		return Torrent{}, err
 	}
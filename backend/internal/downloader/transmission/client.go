@@ -0,0 +1,135 @@
+package transmission
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	rpcPath         = "/transmission/rpc"
+	sessionIDHeader = "X-Transmission-Session-Id"
+)
+
+var errSessionConflict = errors.New("transmission session id 已失效")
+
+// client Transmission JSON-RPC客户端
+type client struct {
+	endpoint   string
+	username   string
+	password   string
+	httpClient *http.Client
+
+	mu        sync.RWMutex
+	sessionID string
+}
+
+func newClient(config Config) *client {
+	return &client{
+		endpoint:   buildEndpoint(config.Host),
+		username:   config.Username,
+		password:   config.Password,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// buildEndpoint 未指定RPC路径时补全默认路径
+func buildEndpoint(host string) string {
+	host = strings.TrimRight(host, "/")
+	if strings.HasSuffix(host, "/rpc") {
+		return host
+	}
+	return host + rpcPath
+}
+
+type rpcRequest struct {
+	Method    string      `json:"method"`
+	Arguments interface{} `json:"arguments,omitempty"`
+}
+
+type rpcResponse struct {
+	Result    string          `json:"result"`
+	Arguments json.RawMessage `json:"arguments"`
+}
+
+// call 调用RPC方法，session id失效时会自动握手后重试一次
+func (c *client) call(ctx context.Context, method string, arguments, result interface{}) error {
+	body, err := json.Marshal(rpcRequest{Method: method, Arguments: arguments})
+	if err != nil {
+		return fmt.Errorf("序列化请求失败: %w", err)
+	}
+	resp, err := c.do(ctx, body)
+	if errors.Is(err, errSessionConflict) {
+		resp, err = c.do(ctx, body)
+	}
+	if err != nil {
+		return err
+	}
+	if resp.Result != "success" {
+		return fmt.Errorf("transmission %s 调用失败: %s", method, resp.Result)
+	}
+	if result == nil || len(resp.Arguments) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(resp.Arguments, result); err != nil {
+		return fmt.Errorf("解析 transmission %s 响应失败: %w", method, err)
+	}
+	return nil
+}
+
+func (c *client) do(ctx context.Context, body []byte) (rpcResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return rpcResponse{}, fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if sessionID := c.getSessionID(); sessionID != "" {
+		req.Header.Set(sessionIDHeader, sessionID)
+	}
+	if c.username != "" || c.password != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return rpcResponse{}, fmt.Errorf("请求 transmission 失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusConflict:
+		c.setSessionID(resp.Header.Get(sessionIDHeader))
+		return rpcResponse{}, errSessionConflict
+	case http.StatusUnauthorized:
+		return rpcResponse{}, errors.New("transmission 认证失败，请检查配置的账号密码是否正确")
+	default:
+		respBody, _ := io.ReadAll(resp.Body)
+		return rpcResponse{}, fmt.Errorf("请求 transmission 失败: %d, %s", resp.StatusCode, string(respBody))
+	}
+
+	var rpcResp rpcResponse
+	if err := json.NewDecoder(resp.Body).Decode(&rpcResp); err != nil {
+		return rpcResponse{}, fmt.Errorf("解析 transmission 响应失败: %w", err)
+	}
+	return rpcResp, nil
+}
+
+func (c *client) getSessionID() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.sessionID
+}
+
+func (c *client) setSessionID(sessionID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sessionID = sessionID
+}
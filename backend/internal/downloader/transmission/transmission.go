@@ -0,0 +1,385 @@
+package transmission
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"github.com/MangataL/BangumiBuddy/internal/downloader"
+	"github.com/MangataL/BangumiBuddy/pkg/log"
+)
+
+// 确保Transmission实现了Downloader接口
+var (
+	_ downloader.Downloader     = (*Transmission)(nil)
+	_ downloader.TorrentRemover = (*Transmission)(nil)
+	_ downloader.TorrentPauser  = (*Transmission)(nil)
+)
+
+const (
+	// 标签名称
+	tag = "BangumiBuddy"
+)
+
+// Transmission 种子状态，参考 tr_torrent_activity
+const (
+	statusStopped = 0
+)
+
+var torrentFields = []string{
+	"hashString",
+	"name",
+	"percentDone",
+	"rateDownload",
+	"status",
+	"error",
+	"errorString",
+	"leftUntilDone",
+	"sizeWhenDone",
+	"addedDate",
+	"doneDate",
+	"metadataPercentComplete",
+	"labels",
+	"uploadedEver",
+	"uploadRatio",
+	"secondsSeeding",
+}
+
+// Config Transmission配置
+type Config struct {
+	Host     string `mapstructure:"host" json:"host"`
+	Username string `mapstructure:"username" json:"username"`
+	Password string `mapstructure:"password" json:"password"`
+}
+
+// Transmission 实现Downloader接口
+type Transmission struct {
+	client *client
+}
+
+// NewTransmission 创建一个新的Transmission实例
+func NewTransmission(config Config) *Transmission {
+	return &Transmission{
+		client: newClient(config),
+	}
+}
+
+type torrent struct {
+	HashString              string   `json:"hashString"`
+	Name                    string   `json:"name"`
+	PercentDone             float64  `json:"percentDone"`
+	RateDownload            int64    `json:"rateDownload"`
+	Status                  int      `json:"status"`
+	Error                   int      `json:"error"`
+	ErrorString             string   `json:"errorString"`
+	LeftUntilDone           int64    `json:"leftUntilDone"`
+	SizeWhenDone            int64    `json:"sizeWhenDone"`
+	AddedDate               int64    `json:"addedDate"`
+	DoneDate                int64    `json:"doneDate"`
+	MetadataPercentComplete float64  `json:"metadataPercentComplete"`
+	Labels                  []string `json:"labels"`
+	Files                   []file   `json:"files"`
+	UploadedEver            int64    `json:"uploadedEver"`
+	UploadRatio             float64  `json:"uploadRatio"`
+	SecondsSeeding          int64    `json:"secondsSeeding"`
+}
+
+type file struct {
+	Name   string `json:"name"`
+	Length int64  `json:"length"`
+}
+
+type torrentGetResp struct {
+	Torrents []torrent `json:"torrents"`
+}
+
+type torrentAddResp struct {
+	TorrentAdded     *torrent `json:"torrent-added"`
+	TorrentDuplicate *torrent `json:"torrent-duplicate"`
+}
+
+func (t *Transmission) getTorrents(ctx context.Context, hashes []string, fields []string) ([]torrent, error) {
+	args := map[string]interface{}{
+		"fields": fields,
+	}
+	if len(hashes) > 0 {
+		args["ids"] = hashes
+	}
+	var resp torrentGetResp
+	if err := t.client.call(ctx, "torrent-get", args, &resp); err != nil {
+		return nil, fmt.Errorf("获取种子列表失败: %w", err)
+	}
+	return resp.Torrents, nil
+}
+
+func (t *Transmission) getTorrent(ctx context.Context, hash string, fields []string) (torrent, error) {
+	torrents, err := t.getTorrents(ctx, []string{hash}, fields)
+	if err != nil {
+		return torrent{}, err
+	}
+	if len(torrents) == 0 {
+		return torrent{}, fmt.Errorf("种子 %s 不存在", hash)
+	}
+	return torrents[0], nil
+}
+
+// GetTorrentFileNames implements downloader.Downloader.
+func (t *Transmission) GetTorrentFileNames(ctx context.Context, hash string) ([]string, error) {
+	tt, err := t.getTorrent(ctx, hash, []string{"hashString", "files"})
+	if err != nil {
+		return nil, fmt.Errorf("获取种子文件列表失败: %w", err)
+	}
+	fileNames := make([]string, 0, len(tt.Files))
+	for _, f := range tt.Files {
+		fileNames = append(fileNames, f.Name)
+	}
+	return fileNames, nil
+}
+
+// GetDownloadStatuses 实现Downloader接口的GetDownloadStatuses方法
+func (t *Transmission) GetDownloadStatuses(ctx context.Context, hashes []string) ([]downloader.DownloadStatus, error) {
+	torrents, err := t.getTorrents(ctx, hashes, torrentFields)
+	if err != nil {
+		return nil, err
+	}
+	return convertTorrentsToDownloadStatuses(torrents), nil
+}
+
+// convertTorrentsToDownloadStatuses 将transmission的种子信息转换为DownloadStatus
+func convertTorrentsToDownloadStatuses(torrents []torrent) []downloader.DownloadStatus {
+	statuses := make([]downloader.DownloadStatus, 0, len(torrents))
+	for _, tt := range torrents {
+		status := downloader.DownloadStatus{
+			Hash:          tt.HashString,
+			Name:          tt.Name,
+			Progress:      tt.PercentDone,
+			DownloadSpeed: tt.RateDownload,
+			Size:          tt.SizeWhenDone,
+			Uploaded:      tt.UploadedEver,
+			SeedingTime:   time.Duration(tt.SecondsSeeding) * time.Second,
+		}
+		// 没有下载数据时 Transmission 返回负数
+		if tt.UploadRatio > 0 {
+			status.Ratio = tt.UploadRatio
+		}
+		metadataReceived := tt.MetadataPercentComplete >= 1
+		switch {
+		case tt.Error != 0:
+			status.Status = downloader.TorrentStatusDownloadError
+			status.Error = tt.ErrorString
+		case metadataReceived && tt.LeftUntilDone == 0 && tt.SizeWhenDone > 0:
+			status.Status = downloader.TorrentStatusDownloaded
+			status.Cost = time.Duration(tt.DoneDate-tt.AddedDate) * time.Second
+		case tt.Status == statusStopped:
+			status.Status = downloader.TorrentStatusDownloadPaused
+		default:
+			status.Status = downloader.TorrentStatusDownloading
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+func (t *Transmission) SetTorrentFilePriorities(ctx context.Context, hash string, files []downloader.TorrentFileSelection) error {
+	if len(files) == 0 {
+		return fmt.Errorf("未提供文件选择信息")
+	}
+	fileSelections := make(map[string]bool, len(files))
+	for _, f := range files {
+		fileSelections[f.FileName] = f.Download
+	}
+
+	tt, err := t.getTorrent(ctx, hash, []string{"hashString", "files"})
+	if err != nil {
+		return fmt.Errorf("获取种子文件信息失败: %w", err)
+	}
+
+	usedSelections := make(map[string]struct{}, len(files))
+	wanted := make([]int, 0, len(tt.Files))
+	unwanted := make([]int, 0, len(tt.Files))
+	for index, info := range tt.Files {
+		download, ok := fileSelections[info.Name]
+		if !ok {
+			return fmt.Errorf("文件 %s 不在任务选择列表中", info.Name)
+		}
+		usedSelections[info.Name] = struct{}{}
+		if download {
+			wanted = append(wanted, index)
+		} else {
+			unwanted = append(unwanted, index)
+		}
+	}
+	if len(usedSelections) != len(fileSelections) {
+		return fmt.Errorf("任务选择列表包含未匹配的文件")
+	}
+	if len(wanted) == 0 {
+		return fmt.Errorf("未选择任何文件，无法开始下载")
+	}
+
+	args := map[string]interface{}{
+		"ids":          []string{hash},
+		"files-wanted": wanted,
+	}
+	if len(unwanted) > 0 {
+		args["files-unwanted"] = unwanted
+	}
+	if err := t.client.call(ctx, "torrent-set", args, nil); err != nil {
+		return fmt.Errorf("设置文件下载失败: %w", err)
+	}
+	return nil
+}
+
+// AddTorrent 添加种子，stopCondition 不为空时在获取到元数据后暂停下载
+//
+// Transmission 不支持获取元数据后停止，种子文件自带元数据，直接以暂停状态添加；
+// 暂停的磁力链接不会获取元数据，添加后等待元数据获取完成再立即暂停
+func (t *Transmission) AddTorrent(ctx context.Context, torrentLink, savePath, stopCondition string) error {
+	magnet := strings.HasPrefix(torrentLink, "magnet:")
+	args := map[string]interface{}{
+		"filename": torrentLink,
+		"labels":   []string{tag},
+		"paused":   stopCondition != "" && !magnet,
+	}
+	if savePath != "" {
+		args["download-dir"] = savePath
+	}
+	var resp torrentAddResp
+	if err := t.client.call(ctx, "torrent-add", args, &resp); err != nil {
+		return fmt.Errorf("添加种子失败: %w", err)
+	}
+	added := resp.TorrentAdded
+	if added == nil {
+		added = resp.TorrentDuplicate
+	}
+	if added == nil {
+		return errors.New("添加种子失败: transmission 未返回种子信息")
+	}
+	if stopCondition != "" && magnet {
+		return t.stopAfterMetadata(ctx, added.HashString, resp.TorrentAdded != nil)
+	}
+	return nil
+}
+
+// stopAfterMetadata 等待磁力链接获取到元数据后暂停，避免种子不受控制地开始下载
+//
+// 超时时移除本次新添加的种子，调用方不会记录添加失败的种子，下次添加时重新获取元数据；已存在的种子只暂停
+func (t *Transmission) stopAfterMetadata(ctx context.Context, hash string, added bool) error {
+	waitErr := wait.PollUntilContextTimeout(ctx, 500*time.Millisecond, 30*time.Second, true, func(ctx context.Context) (bool, error) {
+		tt, err := t.getTorrent(ctx, hash, []string{"hashString", "metadataPercentComplete"})
+		if err != nil {
+			log.Errorf(ctx, "获取种子属性信息失败: %s", err)
+			return false, nil
+		}
+		return tt.MetadataPercentComplete >= 1, nil
+	})
+	if waitErr != nil && added {
+		if err := t.RemoveTorrent(context.WithoutCancel(ctx), hash, true); err != nil {
+			log.Errorf(ctx, "移除获取元数据超时的种子失败 [%s]: %s", hash, err)
+		}
+		return fmt.Errorf("等待种子元数据失败: %w", waitErr)
+	}
+	if err := t.stop(ctx, hash); err != nil {
+		return fmt.Errorf("暂停种子失败: %w", err)
+	}
+	if waitErr != nil {
+		return fmt.Errorf("等待种子元数据失败: %w", waitErr)
+	}
+	return nil
+}
+
+func (t *Transmission) SetLocation(ctx context.Context, hash, savePath string) error {
+	if err := t.addLabel(ctx, hash); err != nil {
+		return fmt.Errorf("添加标签失败: %w", err)
+	}
+	return t.client.call(ctx, "torrent-set-location", map[string]interface{}{
+		"ids":      []string{hash},
+		"location": savePath,
+		"move":     true,
+	}, nil)
+}
+
+func (t *Transmission) addLabel(ctx context.Context, hash string) error {
+	tt, err := t.getTorrent(ctx, hash, []string{"hashString", "labels"})
+	if err != nil {
+		return err
+	}
+	if slices.Contains(tt.Labels, tag) {
+		return nil
+	}
+	return t.client.call(ctx, "torrent-set", map[string]interface{}{
+		"ids":    []string{hash},
+		"labels": append(tt.Labels, tag),
+	}, nil)
+}
+
+func (t *Transmission) GetTorrentName(ctx context.Context, hash string) (string, error) {
+	var name string
+	if err := wait.PollUntilContextTimeout(ctx, 500*time.Millisecond, 30*time.Second, true, func(ctx context.Context) (bool, error) {
+		tt, err := t.getTorrent(ctx, hash, []string{"hashString", "name", "metadataPercentComplete"})
+		if err != nil {
+			log.Errorf(ctx, "获取种子属性信息失败: %s", err)
+			return false, nil
+		}
+		if tt.MetadataPercentComplete >= 1 && !strings.EqualFold(tt.Name, hash) {
+			name = tt.Name
+			return true, nil
+		}
+		return false, nil
+	}); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+func (t *Transmission) ListTorrentsStatus(ctx context.Context) ([]downloader.DownloadStatus, error) {
+	torrents, err := t.getTorrents(ctx, nil, torrentFields)
+	if err != nil {
+		return nil, err
+	}
+	// 只处理带有BangumiBuddy标签的种子
+	tagged := make([]torrent, 0, len(torrents))
+	for _, tt := range torrents {
+		if slices.Contains(tt.Labels, tag) {
+			tagged = append(tagged, tt)
+		}
+	}
+	return convertTorrentsToDownloadStatuses(tagged), nil
+}
+
+// DeleteTorrent 删除种子文件
+func (t *Transmission) DeleteTorrent(ctx context.Context, hash string) error {
+	return t.RemoveTorrent(ctx, hash, true)
+}
+
+// RemoveTorrent 移除种子任务，deleteFiles 为 true 时同时删除已下载的文件
+func (t *Transmission) RemoveTorrent(ctx context.Context, hash string, deleteFiles bool) error {
+	if err := t.client.call(ctx, "torrent-remove", map[string]interface{}{
+		"ids":               []string{hash},
+		"delete-local-data": deleteFiles,
+	}, nil); err != nil {
+		return fmt.Errorf("删除种子文件失败: %w", err)
+	}
+	return nil
+}
+
+func (t *Transmission) ContinueDownload(ctx context.Context, hash string) error {
+	return t.client.call(ctx, "torrent-start", map[string]interface{}{
+		"ids": []string{hash},
+	}, nil)
+}
+
+// PauseTorrent 暂停种子下载
+func (t *Transmission) PauseTorrent(ctx context.Context, hash string) error {
+	return t.stop(ctx, hash)
+}
+
+func (t *Transmission) stop(ctx context.Context, hash string) error {
+	return t.client.call(ctx, "torrent-stop", map[string]interface{}{
+		"ids": []string{hash},
+	}, nil)
+}
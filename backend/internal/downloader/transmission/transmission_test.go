@@ -0,0 +1,225 @@
+package transmission
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MangataL/BangumiBuddy/internal/downloader"
+)
+
+const testSessionID = "test-session"
+
+type fakeServer struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	torrents  map[string]map[string]interface{}
+	calls     []string
+	lastArgs  map[string]map[string]interface{}
+	conflicts atomic.Int32
+}
+
+func newFakeServer(t *testing.T) *fakeServer {
+	t.Helper()
+
+	fs := &fakeServer{
+		torrents: make(map[string]map[string]interface{}),
+		lastArgs: make(map[string]map[string]interface{}),
+	}
+	fs.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != rpcPath {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get(sessionIDHeader) != testSessionID {
+			fs.conflicts.Add(1)
+			w.Header().Set(sessionIDHeader, testSessionID)
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		var req struct {
+			Method    string                 `json:"method"`
+			Arguments map[string]interface{} `json:"arguments"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+		fs.mu.Lock()
+		defer fs.mu.Unlock()
+		fs.calls = append(fs.calls, req.Method)
+		fs.lastArgs[req.Method] = req.Arguments
+
+		arguments := map[string]interface{}{}
+		switch req.Method {
+		case "torrent-add":
+			tt := fs.torrents["abc"]
+			arguments["torrent-added"] = map[string]interface{}{"hashString": tt["hashString"], "name": tt["name"]}
+		case "torrent-get":
+			torrents := make([]map[string]interface{}, 0, len(fs.torrents))
+			for _, tt := range fs.torrents {
+				torrents = append(torrents, tt)
+			}
+			arguments["torrents"] = torrents
+		case "torrent-stop":
+			fs.torrents["abc"]["status"] = 0
+		}
+		w.Header().Set("Content-Type", "application/json")
+		require.NoError(t, json.NewEncoder(w).Encode(map[string]interface{}{
+			"result":    "success",
+			"arguments": arguments,
+		}))
+	}))
+	t.Cleanup(fs.Close)
+	return fs
+}
+
+func (fs *fakeServer) setTorrent(hash string, fields map[string]interface{}) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	fields["hashString"] = hash
+	fs.torrents[hash] = fields
+}
+
+func (fs *fakeServer) calledMethods() []string {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return append([]string(nil), fs.calls...)
+}
+
+func (fs *fakeServer) argsOf(method string) map[string]interface{} {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return fs.lastArgs[method]
+}
+
+func TestCallHandshakesSessionIDOnce(t *testing.T) {
+	fs := newFakeServer(t)
+	tr := NewTransmission(Config{Host: fs.URL})
+
+	_, err := tr.ListTorrentsStatus(context.Background())
+	require.NoError(t, err)
+	_, err = tr.ListTorrentsStatus(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, int32(1), fs.conflicts.Load())
+	assert.Equal(t, []string{"torrent-get", "torrent-get"}, fs.calledMethods())
+}
+
+func TestAddTorrentStopsAfterMetadataReceived(t *testing.T) {
+	fs := newFakeServer(t)
+	fs.setTorrent("abc", map[string]interface{}{
+		"name":                    "[ANi] Test - 01.mkv",
+		"status":                  4,
+		"metadataPercentComplete": 1,
+		"labels":                  []string{tag},
+	})
+	tr := NewTransmission(Config{Host: fs.URL})
+	ctx := context.Background()
+
+	// 磁力链接添加后立即开始获取元数据，获取完成后在添加时就暂停
+	require.NoError(t, tr.AddTorrent(ctx, "magnet:?xt=urn:btih:abc", "/downloads", "MetadataReceived"))
+	addArgs := fs.argsOf("torrent-add")
+	assert.Equal(t, "/downloads", addArgs["download-dir"])
+	assert.Equal(t, []interface{}{tag}, addArgs["labels"])
+	assert.Equal(t, false, addArgs["paused"])
+	assert.Equal(t, []string{"torrent-add", "torrent-get", "torrent-stop"}, fs.calledMethods())
+
+	statuses, err := tr.GetDownloadStatuses(ctx, []string{"abc"})
+	require.NoError(t, err)
+	require.Len(t, statuses, 1)
+	assert.Equal(t, downloader.TorrentStatusDownloadPaused, statuses[0].Status)
+}
+
+func TestAddTorrentRemovesWhenMetadataTimeout(t *testing.T) {
+	fs := newFakeServer(t)
+	fs.setTorrent("abc", map[string]interface{}{
+		"name":                    "[ANi] Test - 01.mkv",
+		"status":                  4,
+		"metadataPercentComplete": 0,
+		"labels":                  []string{tag},
+	})
+	tr := NewTransmission(Config{Host: fs.URL})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	// 获取元数据超时时移除新添加的种子，不留下没有记录的暂停种子
+	require.Error(t, tr.AddTorrent(ctx, "magnet:?xt=urn:btih:abc", "/downloads", "MetadataReceived"))
+	assert.Contains(t, fs.calledMethods(), "torrent-remove")
+	assert.NotContains(t, fs.calledMethods(), "torrent-stop")
+	assert.Equal(t, []interface{}{"abc"}, fs.argsOf("torrent-remove")["ids"])
+}
+
+func TestAddTorrentFileAddsPaused(t *testing.T) {
+	fs := newFakeServer(t)
+	fs.setTorrent("abc", map[string]interface{}{"name": "Test", "status": 0})
+	tr := NewTransmission(Config{Host: fs.URL})
+
+	require.NoError(t, tr.AddTorrent(context.Background(), "https://example.com/test.torrent", "/downloads", "MetadataReceived"))
+	assert.Equal(t, true, fs.argsOf("torrent-add")["paused"])
+	assert.Equal(t, []string{"torrent-add"}, fs.calledMethods())
+}
+
+func TestConvertTorrentsToDownloadStatuses(t *testing.T) {
+	statuses := convertTorrentsToDownloadStatuses([]torrent{
+		{HashString: "a", Error: 3, ErrorString: "No data found", MetadataPercentComplete: 1},
+		{HashString: "b", Status: 6, MetadataPercentComplete: 1, SizeWhenDone: 10, AddedDate: 100, DoneDate: 160},
+		{HashString: "c", Status: 0, MetadataPercentComplete: 1, LeftUntilDone: 5, SizeWhenDone: 10},
+		{HashString: "d", Status: 4, MetadataPercentComplete: 0},
+	})
+	require.Len(t, statuses, 4)
+	assert.Equal(t, downloader.TorrentStatusDownloadError, statuses[0].Status)
+	assert.Equal(t, "No data found", statuses[0].Error)
+	assert.Equal(t, downloader.TorrentStatusDownloaded, statuses[1].Status)
+	assert.Equal(t, int64(60), int64(statuses[1].Cost.Seconds()))
+	assert.Equal(t, downloader.TorrentStatusDownloadPaused, statuses[2].Status)
+	assert.Equal(t, downloader.TorrentStatusDownloading, statuses[3].Status)
+}
+
+func TestSetTorrentFilePriorities(t *testing.T) {
+	fs := newFakeServer(t)
+	fs.setTorrent("abc", map[string]interface{}{
+		"files": []map[string]interface{}{
+			{"name": "Test/01.mkv", "length": 1},
+			{"name": "Test/SPs/01.mkv", "length": 1},
+		},
+	})
+	tr := NewTransmission(Config{Host: fs.URL})
+
+	require.NoError(t, tr.SetTorrentFilePriorities(context.Background(), "abc", []downloader.TorrentFileSelection{
+		{FileName: "Test/01.mkv", Download: true},
+		{FileName: "Test/SPs/01.mkv", Download: false},
+	}))
+	args := fs.argsOf("torrent-set")
+	assert.Equal(t, []interface{}{float64(0)}, args["files-wanted"])
+	assert.Equal(t, []interface{}{float64(1)}, args["files-unwanted"])
+
+	err := tr.SetTorrentFilePriorities(context.Background(), "abc", []downloader.TorrentFileSelection{
+		{FileName: "Test/01.mkv", Download: true},
+	})
+	assert.Error(t, err)
+}
+
+func TestListTorrentsStatusFiltersByLabel(t *testing.T) {
+	fs := newFakeServer(t)
+	fs.setTorrent("abc", map[string]interface{}{"labels": []string{tag}, "status": 4, "metadataPercentComplete": 1})
+	fs.setTorrent("def", map[string]interface{}{"labels": []string{"other"}, "status": 4, "metadataPercentComplete": 1})
+	tr := NewTransmission(Config{Host: fs.URL})
+
+	statuses, err := tr.ListTorrentsStatus(context.Background())
+	require.NoError(t, err)
+	require.Len(t, statuses, 1)
+	assert.Equal(t, "abc", statuses[0].Hash)
+}
+
+func TestBuildEndpoint(t *testing.T) {
+	assert.Equal(t, "http://nas:9091/transmission/rpc", buildEndpoint("http://nas:9091/"))
+	assert.Equal(t, "http://nas:9091/custom/rpc", buildEndpoint("http://nas:9091/custom/rpc"))
+}
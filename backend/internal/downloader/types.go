@@ -8,14 +8,42 @@ import (
 
 // DownloadReq 下载请求参数
 type DownloadReq struct {
-	TorrentLink    string       // 种子链接
-	Hash           string       // 种子哈希值
-	SavePath       string       // 保存路径
-	SubscriptionID string       // 订阅ID，通过订阅下载的会设置这个字段
-	TaskID         string       // 任务ID，直接下载的会设置这个字段
-	DownloadType   DownloadType // 下载类型
-	RSSGUID        string       // 标记是哪个RSS项
-	NotStart       bool         // 是否不启动下载
+	TorrentLink    string         // 种子链接
+	Hash           string         // 种子哈希值
+	SavePath       string         // 保存路径
+	SubscriptionID string         // 订阅ID，通过订阅下载的会设置这个字段
+	TaskID         string         // 任务ID，直接下载的会设置这个字段
+	DownloadType   DownloadType   // 下载类型
+	RSSGUID        string         // 标记是哪个RSS项
+	NotStart       bool           // 是否不启动下载
+	Downloader     string         // 下载器实例名称，为空时按路由规则选择
+	Size           int64          // 种子大小，单位 bytes，用于路由规则匹配，未知时为0
+	ReleaseGroup   string         // 发布组，用于路由规则匹配
+	Category       string         // 分类，下载器支持分类时生效
+	Tags           []string       // 额外标签，下载器支持标签时生效
+	SeedingPolicy  *SeedingPolicy // 做种策略，为空时使用全局配置
+	Priority       int            // 队列优先级，数值越大越先下载
+	PublishedAt    time.Time      // 资源发布时间
+	Episode        int            // 集数，队列中新的集数先下载，未知时为0
+	QualityScore   int            // 质量评分，转移时同优先级的订阅按评分决定是否覆盖已有文件
+}
+
+// QueueItem 下载队列条目
+type QueueItem struct {
+	Hash           string    // 种子哈希值
+	Priority       int       // 优先级，数值越大越先下载
+	SubscriptionID string    // 订阅ID
+	Episode        int       // 集数，同优先级时集数越大越先下载，未知时为0
+	PublishedAt    time.Time // 资源发布时间，集数相同时越新越先下载，未知时为零值
+	Started        bool      // 是否已由队列开始下载
+	CreatedAt      time.Time // 入队时间
+}
+
+// RouteReq 下载器路由请求
+type RouteReq struct {
+	DownloadType DownloadType // 下载类型
+	Size         int64        // 种子大小，单位 bytes，未知时为0
+	ReleaseGroup string       // 发布组
 }
 
 // DownloadType 下载类型
@@ -36,6 +64,9 @@ type DownloadStatus struct {
 	Error         string        // 错误信息，如果有的话
 	Cost          time.Duration // 下载耗时
 	Size          int64         // 种子大小，单位 bytes
+	Uploaded      int64         // 已上传大小，单位 bytes
+	Ratio         float64       // 分享率
+	SeedingTime   time.Duration // 做种时长
 }
 
 // TorrentFileSelection 种子文件下载选择
@@ -58,18 +89,23 @@ type TorrentFilter struct {
 
 // Torrent 种子文件
 type Torrent struct {
-	Hash           string        // 哈希值
-	Name           string        // 种子名称
-	Path           string        // 文件路径
-	Status         TorrentStatus // 种子状态
-	StatusDetail   string        // 种子状态详情，一般用于存储错误信息
-	SubscriptionID string        // 订阅ID，通过订阅下载的会设置这个字段
-	TaskID         string        // 任务ID，直接下载的会设置这个字段
-	TransferType   string        // 转移类型，用于获取转移文件
-	RSSGUID        string        // 标记是哪个RSS项
-	CreatedAt      time.Time     // 创建时间
-	UpdatedAt      time.Time     // 更新时间
-	FileNames      []string      // 种子文件名
+	Hash           string         // 哈希值
+	Name           string         // 种子名称
+	Path           string         // 文件路径
+	Status         TorrentStatus  // 种子状态
+	StatusDetail   string         // 种子状态详情，一般用于存储错误信息
+	SubscriptionID string         // 订阅ID，通过订阅下载的会设置这个字段
+	TaskID         string         // 任务ID，直接下载的会设置这个字段
+	TransferType   string         // 转移类型，用于获取转移文件
+	RSSGUID        string         // 标记是哪个RSS项
+	CreatedAt      time.Time      // 创建时间
+	UpdatedAt      time.Time      // 更新时间
+	PublishedAt    time.Time      // 资源发布时间，未知时为零值
+	FileNames      []string       // 种子文件名
+	Downloader     string         // 下载器实例名称
+	SeedingPolicy  *SeedingPolicy // 做种策略，为空时使用全局配置
+	QualityScore   int            // 质量评分
+	SelectedFiles  []string       // 合集种子中选择下载的文件，为空时下载全部文件
 }
 
 // TorrentStatus 种子状态
@@ -88,6 +124,8 @@ const (
 	TorrentStatusTransferred TorrentStatus = "transferred"
 	// TorrentStatusTransferredError 转移错误
 	TorrentStatusTransferredError TorrentStatus = "transferredError"
+	// TorrentStatusStalled 下载停滞，等待订阅切换到其他资源
+	TorrentStatusStalled TorrentStatus = "stalled"
 )
 
 // IsDownloading 是否正在下载
@@ -2,6 +2,8 @@ package magnet
 
 import "context"
 
+//go:generate mockgen -destination interface_mock.go -source $GOFILE -package $GOPACKAGE
+
 // Interface 种子下载功能接口
 type Interface interface {
 	AddTask(ctx context.Context, req AddTaskReq) (Task, error)
@@ -0,0 +1,168 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: interface.go
+
+// Package magnet is a generated GoMock package.
+package magnet
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockInterface is a mock of Interface interface.
+type MockInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockInterfaceMockRecorder
+}
+
+// MockInterfaceMockRecorder is the mock recorder for MockInterface.
+type MockInterfaceMockRecorder struct {
+	mock *MockInterface
+}
+
+// NewMockInterface creates a new mock instance.
+func NewMockInterface(ctrl *gomock.Controller) *MockInterface {
+	mock := &MockInterface{ctrl: ctrl}
+	mock.recorder = &MockInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockInterface) EXPECT() *MockInterfaceMockRecorder {
+	return m.recorder
+}
+
+// AddSubtitles mocks base method.
+func (m *MockInterface) AddSubtitles(ctx context.Context, req AddSubtitlesReq) AddSubtitlesResp {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddSubtitles", ctx, req)
+	ret0, _ := ret[0].(AddSubtitlesResp)
+	return ret0
+}
+
+// AddSubtitles indicates an expected call of AddSubtitles.
+func (mr *MockInterfaceMockRecorder) AddSubtitles(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddSubtitles", reflect.TypeOf((*MockInterface)(nil).AddSubtitles), ctx, req)
+}
+
+// AddTask mocks base method.
+func (m *MockInterface) AddTask(ctx context.Context, req AddTaskReq) (Task, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddTask", ctx, req)
+	ret0, _ := ret[0].(Task)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddTask indicates an expected call of AddTask.
+func (mr *MockInterfaceMockRecorder) AddTask(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddTask", reflect.TypeOf((*MockInterface)(nil).AddTask), ctx, req)
+}
+
+// DeleteTask mocks base method.
+func (m *MockInterface) DeleteTask(ctx context.Context, taskID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTask", ctx, taskID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteTask indicates an expected call of DeleteTask.
+func (mr *MockInterfaceMockRecorder) DeleteTask(ctx, taskID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTask", reflect.TypeOf((*MockInterface)(nil).DeleteTask), ctx, taskID)
+}
+
+// FindTaskSimilarFiles mocks base method.
+func (m *MockInterface) FindTaskSimilarFiles(ctx context.Context, taskID, filePath string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindTaskSimilarFiles", ctx, taskID, filePath)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindTaskSimilarFiles indicates an expected call of FindTaskSimilarFiles.
+func (mr *MockInterfaceMockRecorder) FindTaskSimilarFiles(ctx, taskID, filePath interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindTaskSimilarFiles", reflect.TypeOf((*MockInterface)(nil).FindTaskSimilarFiles), ctx, taskID, filePath)
+}
+
+// GetTask mocks base method.
+func (m *MockInterface) GetTask(ctx context.Context, taskID string) (Task, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTask", ctx, taskID)
+	ret0, _ := ret[0].(Task)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTask indicates an expected call of GetTask.
+func (mr *MockInterfaceMockRecorder) GetTask(ctx, taskID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTask", reflect.TypeOf((*MockInterface)(nil).GetTask), ctx, taskID)
+}
+
+// InitTask mocks base method.
+func (m *MockInterface) InitTask(ctx context.Context, taskID string, tmdbID int) (Task, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InitTask", ctx, taskID, tmdbID)
+	ret0, _ := ret[0].(Task)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// InitTask indicates an expected call of InitTask.
+func (mr *MockInterfaceMockRecorder) InitTask(ctx, taskID, tmdbID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InitTask", reflect.TypeOf((*MockInterface)(nil).InitTask), ctx, taskID, tmdbID)
+}
+
+// ListTasks mocks base method.
+func (m *MockInterface) ListTasks(ctx context.Context, req ListTasksReq) ([]Task, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListTasks", ctx, req)
+	ret0, _ := ret[0].([]Task)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListTasks indicates an expected call of ListTasks.
+func (mr *MockInterfaceMockRecorder) ListTasks(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListTasks", reflect.TypeOf((*MockInterface)(nil).ListTasks), ctx, req)
+}
+
+// PreviewAddSubtitles mocks base method.
+func (m *MockInterface) PreviewAddSubtitles(ctx context.Context, req PreviewAddSubtitlesReq) (PreviewAddSubtitlesResp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PreviewAddSubtitles", ctx, req)
+	ret0, _ := ret[0].(PreviewAddSubtitlesResp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PreviewAddSubtitles indicates an expected call of PreviewAddSubtitles.
+func (mr *MockInterfaceMockRecorder) PreviewAddSubtitles(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PreviewAddSubtitles", reflect.TypeOf((*MockInterface)(nil).PreviewAddSubtitles), ctx, req)
+}
+
+// UpdateTask mocks base method.
+func (m *MockInterface) UpdateTask(ctx context.Context, req UpdateTaskReq) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateTask", ctx, req)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateTask indicates an expected call of UpdateTask.
+func (mr *MockInterfaceMockRecorder) UpdateTask(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTask", reflect.TypeOf((*MockInterface)(nil).UpdateTask), ctx, req)
+}
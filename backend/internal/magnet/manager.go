@@ -165,6 +165,7 @@ func (m *Manager) AddTask(ctx context.Context, req AddTaskReq) (Task, error) {
 		TaskID:       task.TaskID,
 		DownloadType: req.Type,
 		NotStart:     true, // 不立即开始下载
+		Downloader:   req.Downloader,
 	}
 
 	if err := m.downloader.Download(ctx, downloadReq); err != nil {
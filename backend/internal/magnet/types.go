@@ -44,6 +44,7 @@ type TorrentFileMeta struct {
 type AddTaskReq struct {
 	MagnetLink string                  `json:"magnetLink"`
 	Type       downloader.DownloadType `json:"type"`
+	Downloader string                  `json:"downloader"` // 下载器实例名称，为空时按路由规则选择
 }
 
 // UpdateTaskReq 确认下载任务请求
@@ -17,6 +17,10 @@ type Parser interface {
 	ParseMovie(ctx context.Context, id int) (Meta, error)
 	GetSeasonEpisodeTotalNum(ctx context.Context, tmdbID, season int, opts ...MetaOption) (int, error)
 	GetEpisodeDetails(ctx context.Context, tmdbID, season, episode int) (EpisodeDetails, error)
+	// GetEpisodeMapping 获取绝对集数到季和集的映射，episodeGroupID 为空时按各季的集数顺序映射
+	GetEpisodeMapping(ctx context.Context, tmdbID int, episodeGroupID string, opts ...MetaOption) (EpisodeMapping, error)
+	// GetSeasonEpisodes 获取一季中所有单集的信息，season 为0时获取特别篇
+	GetSeasonEpisodes(ctx context.Context, tmdbID, season int) ([]EpisodeInfo, error)
 }
 
 type Options struct {
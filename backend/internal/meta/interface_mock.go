@@ -49,6 +49,26 @@ func (mr *MockParserMockRecorder) GetEpisodeDetails(ctx, tmdbID, season, episode
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEpisodeDetails", reflect.TypeOf((*MockParser)(nil).GetEpisodeDetails), ctx, tmdbID, season, episode)
 }
 
+// GetEpisodeMapping mocks base method.
+func (m *MockParser) GetEpisodeMapping(ctx context.Context, tmdbID int, episodeGroupID string, opts ...MetaOption) (EpisodeMapping, error) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, tmdbID, episodeGroupID}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetEpisodeMapping", varargs...)
+	ret0, _ := ret[0].(EpisodeMapping)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEpisodeMapping indicates an expected call of GetEpisodeMapping.
+func (mr *MockParserMockRecorder) GetEpisodeMapping(ctx, tmdbID, episodeGroupID interface{}, opts ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, tmdbID, episodeGroupID}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEpisodeMapping", reflect.TypeOf((*MockParser)(nil).GetEpisodeMapping), varargs...)
+}
+
 // GetSeasonEpisodeTotalNum mocks base method.
 func (m *MockParser) GetSeasonEpisodeTotalNum(ctx context.Context, tmdbID, season int, opts ...MetaOption) (int, error) {
 	m.ctrl.T.Helper()
@@ -69,6 +89,21 @@ func (mr *MockParserMockRecorder) GetSeasonEpisodeTotalNum(ctx, tmdbID, season i
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSeasonEpisodeTotalNum", reflect.TypeOf((*MockParser)(nil).GetSeasonEpisodeTotalNum), varargs...)
 }
 
+// GetSeasonEpisodes mocks base method.
+func (m *MockParser) GetSeasonEpisodes(ctx context.Context, tmdbID, season int) ([]EpisodeInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSeasonEpisodes", ctx, tmdbID, season)
+	ret0, _ := ret[0].([]EpisodeInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSeasonEpisodes indicates an expected call of GetSeasonEpisodes.
+func (mr *MockParserMockRecorder) GetSeasonEpisodes(ctx, tmdbID, season interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSeasonEpisodes", reflect.TypeOf((*MockParser)(nil).GetSeasonEpisodes), ctx, tmdbID, season)
+}
+
 // ParseMovie mocks base method.
 func (m *MockParser) ParseMovie(ctx context.Context, id int) (Meta, error) {
 	m.ctrl.T.Helper()
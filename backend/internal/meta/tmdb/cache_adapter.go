@@ -25,19 +25,31 @@ type seasonEpisodeTotalCacheEntry struct {
 	expiresAt time.Time
 }
 
+type episodeMappingCacheKey struct {
+	tmdbID         int
+	episodeGroupID string
+}
+
+type episodeMappingCacheEntry struct {
+	mapping   meta.EpisodeMapping
+	expiresAt time.Time
+}
+
 type cacheAdapter struct {
 	reloaderParser
 	now func() time.Time
 
-	mu               sync.RWMutex
-	seasonTotalCache map[seasonEpisodeTotalCacheKey]seasonEpisodeTotalCacheEntry
+	mu                  sync.RWMutex
+	seasonTotalCache    map[seasonEpisodeTotalCacheKey]seasonEpisodeTotalCacheEntry
+	episodeMappingCache map[episodeMappingCacheKey]episodeMappingCacheEntry
 }
 
 func newCacheAdapter(reloaderParser reloaderParser) *cacheAdapter {
 	return &cacheAdapter{
-		reloaderParser:   reloaderParser,
-		now:              time.Now,
-		seasonTotalCache: make(map[seasonEpisodeTotalCacheKey]seasonEpisodeTotalCacheEntry),
+		reloaderParser:      reloaderParser,
+		now:                 time.Now,
+		seasonTotalCache:    make(map[seasonEpisodeTotalCacheKey]seasonEpisodeTotalCacheEntry),
+		episodeMappingCache: make(map[episodeMappingCacheKey]episodeMappingCacheEntry),
 	}
 }
 
@@ -88,3 +100,35 @@ func (c *cacheAdapter) setCachedSeasonTotal(key seasonEpisodeTotalCacheKey, tota
 		expiresAt: c.now().Add(ttl),
 	}
 }
+
+func (c *cacheAdapter) GetEpisodeMapping(
+	ctx context.Context,
+	tmdbID int,
+	episodeGroupID string,
+	opts ...meta.MetaOption,
+) (meta.EpisodeMapping, error) {
+	key := episodeMappingCacheKey{tmdbID: tmdbID, episodeGroupID: episodeGroupID}
+	options := meta.NewOptions(opts...)
+	if options.CacheTTL > 0 {
+		c.mu.RLock()
+		entry, ok := c.episodeMappingCache[key]
+		c.mu.RUnlock()
+		if ok && c.now().Before(entry.expiresAt) {
+			return entry.mapping, nil
+		}
+	}
+
+	mapping, err := c.reloaderParser.GetEpisodeMapping(ctx, tmdbID, episodeGroupID, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if options.CacheTTL > 0 && len(mapping) > 0 {
+		c.mu.Lock()
+		c.episodeMappingCache[key] = episodeMappingCacheEntry{
+			mapping:   mapping,
+			expiresAt: c.now().Add(options.CacheTTL),
+		}
+		c.mu.Unlock()
+	}
+	return mapping, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -417,3 +418,97 @@ func traditionalToSimplified(text string) (string, error) {
 	}
 	return t2sConverter.Convert(text)
 }
+
+// GetEpisodeMapping implements meta.Parser.
+func (t *Client) GetEpisodeMapping(ctx context.Context, tmdbID int, episodeGroupID string, opts ...meta.MetaOption) (meta.EpisodeMapping, error) {
+	client := t.currentClient()
+	if client == nil {
+		return nil, ErrTMDBTokenNotSet
+	}
+	if episodeGroupID != "" {
+		return getEpisodeGroupMapping(ctx, client, episodeGroupID)
+	}
+	tv, err := client.GetTVDetails(tmdbID, map[string]string{
+		"language": "zh",
+	})
+	if err != nil {
+		return nil, err
+	}
+	seasons := make([]int, 0, len(tv.Seasons))
+	counts := make(map[int]int, len(tv.Seasons))
+	for _, season := range tv.Seasons {
+		// 特别篇不计入绝对集数
+		if season.SeasonNumber <= 0 {
+			continue
+		}
+		seasons = append(seasons, season.SeasonNumber)
+		counts[season.SeasonNumber] = season.EpisodeCount
+	}
+	sort.Ints(seasons)
+	var mapping meta.EpisodeMapping
+	for _, season := range seasons {
+		for episode := 1; episode <= counts[season]; episode++ {
+			mapping = append(mapping, meta.SeasonEpisode{Season: season, Episode: episode})
+		}
+	}
+	log.Debugf(ctx, "按各季集数生成绝对集数映射: tmdbID=%d seasons=%v total=%d", tmdbID, seasons, len(mapping))
+	return mapping, nil
+}
+
+// getEpisodeGroupMapping 按剧集组中分组和单集的顺序生成绝对集数映射
+func getEpisodeGroupMapping(ctx context.Context, client *tmdb.Client, episodeGroupID string) (meta.EpisodeMapping, error) {
+	group, err := client.GetTVEpisodeGroupsDetails(episodeGroupID, map[string]string{
+		"language": "zh",
+	})
+	if err != nil {
+		return nil, err
+	}
+	groups := group.Groups
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i].Order < groups[j].Order
+	})
+	var mapping meta.EpisodeMapping
+	for _, g := range groups {
+		episodes := g.Episodes
+		sort.SliceStable(episodes, func(i, j int) bool {
+			return episodes[i].Order < episodes[j].Order
+		})
+		for _, episode := range episodes {
+			if episode.SeasonNumber <= 0 {
+				continue
+			}
+			mapping = append(mapping, meta.SeasonEpisode{Season: episode.SeasonNumber, Episode: episode.EpisodeNumber})
+		}
+	}
+	log.Debugf(ctx, "按剧集组 %s(%s) 生成绝对集数映射: total=%d", group.Name, episodeGroupID, len(mapping))
+	return mapping, nil
+}
+
+// GetSeasonEpisodes implements meta.Parser.
+func (t *Client) GetSeasonEpisodes(ctx context.Context, tmdbID, season int) ([]meta.EpisodeInfo, error) {
+	client := t.currentClient()
+	if client == nil {
+		return nil, ErrTMDBTokenNotSet
+	}
+	details, err := client.GetTVSeasonDetails(tmdbID, season, map[string]string{
+		"language": "zh",
+	})
+	if err != nil {
+		return nil, err
+	}
+	episodes := make([]meta.EpisodeInfo, 0, len(details.Episodes))
+	for _, episode := range details.Episodes {
+		name := episode.Name
+		// "第 x 集"格式的名称无法用于匹配
+		if utils.EpisodeNameInvalid(name) {
+			name = ""
+		}
+		episodes = append(episodes, meta.EpisodeInfo{
+			Episode: episode.EpisodeNumber,
+			Name:    name,
+			AirDate: episode.AirDate,
+		})
+	}
+	log.Debugf(ctx, "获取第%d季的单集信息: tmdbID=%d total=%d", season, tmdbID, len(episodes))
+	return episodes, nil
+}
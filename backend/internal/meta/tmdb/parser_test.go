@@ -230,6 +230,84 @@ func Test_cacheAdapter_GetSeasonEpisodeTotalNum(t *testing.T) {
 	}
 }
 
+func TestClient_GetEpisodeMapping(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "episode_group") {
+			rsp := `{"id":"group","name":"绝对顺序","groups":[
+				{"order":1,"episodes":[{"order":1,"season_number":2,"episode_number":2},{"order":0,"season_number":2,"episode_number":1}]},
+				{"order":0,"episodes":[{"order":0,"season_number":0,"episode_number":1},{"order":1,"season_number":1,"episode_number":1}]}
+			]}`
+			_, _ = w.Write([]byte(rsp))
+			return
+		}
+		rsp := `{"id":1,"name":"test","seasons":[{"season_number":2,"episode_count":1},{"season_number":0,"episode_count":3},{"season_number":1,"episode_count":2}]}`
+		_, _ = w.Write([]byte(rsp))
+	}))
+	defer ts.Close()
+	certPool := x509.NewCertPool()
+	certPool.AddCert(ts.Certificate())
+	httpClient := http.Client{
+		Transport: &CustomRoundTripper{
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{
+					RootCAs: certPool,
+				},
+			},
+			NewURL: ts.URL[len("https://"):],
+		},
+	}
+	c, _ := tmdb.Init("test")
+	c.SetClientConfig(httpClient)
+	p := &Client{client: c}
+
+	got, err := p.GetEpisodeMapping(context.Background(), 1, "")
+	assert.NoError(t, err)
+	assert.Equal(t, meta.EpisodeMapping{{Season: 1, Episode: 1}, {Season: 1, Episode: 2}, {Season: 2, Episode: 1}}, got)
+
+	got, err = p.GetEpisodeMapping(context.Background(), 1, "group")
+	assert.NoError(t, err)
+	assert.Equal(t, meta.EpisodeMapping{{Season: 1, Episode: 1}, {Season: 2, Episode: 1}, {Season: 2, Episode: 2}}, got)
+	seasonEpisode, ok := got.Map(3)
+	assert.True(t, ok)
+	assert.Equal(t, meta.SeasonEpisode{Season: 2, Episode: 2}, seasonEpisode)
+	_, ok = got.Map(4)
+	assert.False(t, ok)
+}
+
+func TestClient_GetSeasonEpisodes(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Contains(t, r.URL.Path, "/tv/1/season/0")
+		rsp := `{"id":1,"season_number":0,"episodes":[
+			{"episode_number":1,"name":"第 1 集","air_date":"2023-03-01"},
+			{"episode_number":2,"name":"总集篇","air_date":"2023-06-20"}
+		]}`
+		_, _ = w.Write([]byte(rsp))
+	}))
+	defer ts.Close()
+	certPool := x509.NewCertPool()
+	certPool.AddCert(ts.Certificate())
+	httpClient := http.Client{
+		Transport: &CustomRoundTripper{
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{
+					RootCAs: certPool,
+				},
+			},
+			NewURL: ts.URL[len("https://"):],
+		},
+	}
+	c, _ := tmdb.Init("test")
+	c.SetClientConfig(httpClient)
+	p := &Client{client: c}
+
+	got, err := p.GetSeasonEpisodes(context.Background(), 1, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []meta.EpisodeInfo{
+		{Episode: 1, AirDate: "2023-03-01"},
+		{Episode: 2, Name: "总集篇", AirDate: "2023-06-20"},
+	}, got)
+}
+
 type reloadableTestParser struct {
 	meta.Parser
 }
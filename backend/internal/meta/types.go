@@ -45,3 +45,27 @@ func (e *EpisodeDetails) Merge(next EpisodeDetails) {
 		e.AirDate = next.AirDate
 	}
 }
+
+// SeasonEpisode 季和集
+type SeasonEpisode struct {
+	Season  int `json:"season"`
+	Episode int `json:"episode"`
+}
+
+// EpisodeMapping 绝对集数到季和集的映射，第N个元素对应绝对集数N+1
+type EpisodeMapping []SeasonEpisode
+
+// Map 将绝对集数转换为季和集，超出映射范围时返回false
+func (m EpisodeMapping) Map(absolute int) (SeasonEpisode, bool) {
+	if absolute <= 0 || absolute > len(m) {
+		return SeasonEpisode{}, false
+	}
+	return m[absolute-1], true
+}
+
+// EpisodeInfo 季中单集的集数、名称和播出日期
+type EpisodeInfo struct {
+	Episode int    `json:"episode"`
+	Name    string `json:"name"`
+	AirDate string `json:"airDate"` // 格式为 2006-01-02
+}
@@ -3,41 +3,74 @@ package adapter
 import (
 	"context"
 	"errors"
+	"fmt"
 	"sync"
 
+	"github.com/hashicorp/go-multierror"
+
 	"github.com/MangataL/BangumiBuddy/internal/network"
 	"github.com/MangataL/BangumiBuddy/internal/notice"
 	"github.com/MangataL/BangumiBuddy/internal/notice/bark"
+	"github.com/MangataL/BangumiBuddy/internal/notice/dingtalk"
+	"github.com/MangataL/BangumiBuddy/internal/notice/discord"
 	"github.com/MangataL/BangumiBuddy/internal/notice/email"
+	"github.com/MangataL/BangumiBuddy/internal/notice/feishu"
+	"github.com/MangataL/BangumiBuddy/internal/notice/gotify"
+	"github.com/MangataL/BangumiBuddy/internal/notice/message"
+	"github.com/MangataL/BangumiBuddy/internal/notice/ntfy"
+	"github.com/MangataL/BangumiBuddy/internal/notice/robot"
+	"github.com/MangataL/BangumiBuddy/internal/notice/slack"
 	"github.com/MangataL/BangumiBuddy/internal/notice/telegram"
+	"github.com/MangataL/BangumiBuddy/internal/notice/webhook"
+	"github.com/MangataL/BangumiBuddy/internal/notice/wecom"
 	"github.com/MangataL/BangumiBuddy/pkg/log"
 )
 
+const defaultChannelName = "default"
+
 func NewAdapter(config Config, provider network.HTTPClientProvider) *Adapter {
 	adapter := &Adapter{
 		network: provider,
 	}
 	if err := adapter.Reload(&config); err != nil {
 		log.Errorf(context.Background(), "初始化消息通知器失败: %v", err)
-		adapter.notifier = &notice.Empty{}
 	}
 	return adapter
 }
 
+// Adapter 将通知分发到所有配置的通知渠道，每个渠道按自己的通知点过滤
 type Adapter struct {
 	mu       sync.RWMutex
-	notifier notice.Notifier
-	config   Config
+	enabled  bool
+	channels []channel
 	network  network.HTTPClientProvider
 }
 
+// Config 通知配置，顶层字段为默认渠道的配置
 type Config struct {
-	Enabled      bool            `mapstructure:"enabled" json:"enabled"`
+	Enabled       bool `mapstructure:"enabled" json:"enabled"`
+	ChannelConfig `mapstructure:",squash"`
+	Channels      []ChannelConfig `mapstructure:"channels" json:"channels"` // 其他通知渠道，与默认渠道同时发送
+}
+
+// ChannelConfig 通知渠道配置
+type ChannelConfig struct {
+	Name         string          `mapstructure:"name" json:"name"` // 渠道名称，默认渠道可以为空
 	Type         string          `mapstructure:"type" json:"type"`
 	Telegram     telegram.Config `mapstructure:"telegram" json:"telegram"`
 	Email        email.Config    `mapstructure:"email" json:"email"`
 	Bark         bark.Config     `mapstructure:"bark" json:"bark"`
+	Webhook      webhook.Config  `mapstructure:"webhook" json:"webhook"`
+	WeCom        wecom.Config    `mapstructure:"wecom" json:"wecom"`
+	DingTalk     dingtalk.Config `mapstructure:"dingtalk" json:"dingtalk"`
+	Feishu       feishu.Config   `mapstructure:"feishu" json:"feishu"`
+	Discord      discord.Config  `mapstructure:"discord" json:"discord"`
+	Slack        slack.Config    `mapstructure:"slack" json:"slack"`
+	Ntfy         ntfy.Config     `mapstructure:"ntfy" json:"ntfy"`
+	Gotify       gotify.Config   `mapstructure:"gotify" json:"gotify"`
 	NoticePoints NoticePoints    `mapstructure:"notice_points" json:"noticePoints"`
+	// Templates 自定义消息模板，Webhook渠道使用自己的请求体模板
+	Templates message.Templates `mapstructure:"templates" json:"templates"`
 }
 
 // NoticePoints 消息通知点
@@ -46,94 +79,283 @@ type NoticePoints struct {
 	Downloaded          *bool `mapstructure:"downloaded" json:"downloaded"`
 	Transferred         *bool `mapstructure:"transferred" json:"transferred" default:"true"`
 	Error               *bool `mapstructure:"error" json:"error" default:"true"`
+	StorageLow          *bool `mapstructure:"storage_low" json:"storageLow" default:"true"`
 }
 
-func (a *Adapter) Reload(config interface{}) error {
-	cfg, ok := config.(*Config)
-	if !ok {
-		return errors.New("配置类型错误")
+// channel 已初始化的通知渠道
+type channel struct {
+	name     string
+	points   NoticePoints
+	notifier notice.Notifier
+}
+
+// channelConfigs 返回需要发送通知的渠道配置，默认渠道未设置类型时不发送，
+// 没有配置任何渠道时保留默认渠道，以便提示通知渠道未设置
+func (c *Config) channelConfigs() []ChannelConfig {
+	defaultChannel := c.ChannelConfig
+	defaultChannel.Name = c.channelName()
+	if defaultChannel.Type == "" && len(c.Channels) > 0 {
+		return c.Channels
+	}
+	return append([]ChannelConfig{defaultChannel}, c.Channels...)
+}
+
+// channelName 返回默认渠道的名称
+func (c *Config) channelName() string {
+	if c.Name == "" {
+		return defaultChannelName
+	}
+	return c.Name
+}
+
+func (c *Config) validate() error {
+	names := make(map[string]struct{})
+	if c.Type != "" {
+		names[c.channelName()] = struct{}{}
+		if err := c.ChannelConfig.validate(); err != nil {
+			return fmt.Errorf("通知渠道 %s 配置错误: %w", c.channelName(), err)
+		}
+	}
+	for _, cfg := range c.Channels {
+		if cfg.Name == "" {
+			return errors.New("通知渠道名称不能为空")
+		}
+		if _, ok := names[cfg.Name]; ok {
+			return fmt.Errorf("通知渠道名称 %s 重复", cfg.Name)
+		}
+		names[cfg.Name] = struct{}{}
+		if err := cfg.validate(); err != nil {
+			return fmt.Errorf("通知渠道 %s 配置错误: %w", cfg.Name, err)
+		}
+	}
+	return nil
+}
+
+func (c ChannelConfig) validate() error {
+	if c.Type == "webhook" && c.Templates != (message.Templates{}) {
+		return errors.New("Webhook渠道使用请求体模板，不支持自定义消息模板")
+	}
+	_, err := newNotifier(c, nil)
+	return err
+}
+
+func newNotifier(cfg ChannelConfig, provider network.HTTPClientProvider) (notice.Notifier, error) {
+	renderer, err := message.NewRenderer(cfg.Type, cfg.Templates)
+	if err != nil {
+		return nil, err
 	}
-	notifier := notice.Notifier(&notice.Empty{})
+	return newChannelNotifier(cfg, renderer, provider)
+}
+
+// newChannelNotifier 使用已创建的消息渲染器创建渠道的通知器
+func newChannelNotifier(cfg ChannelConfig, renderer *message.Renderer, provider network.HTTPClientProvider) (notice.Notifier, error) {
 	switch cfg.Type {
 	case "telegram":
-		notifier = telegram.NewTelegramNotifier(cfg.Telegram, a.network)
+		return telegram.NewTelegramNotifier(cfg.Telegram, renderer, provider), nil
 	case "email":
-		notifier = email.NewEmailNotifier(cfg.Email)
+		return email.NewEmailNotifier(cfg.Email, renderer), nil
 	case "bark":
-		notifier = bark.NewBarkNotifier(cfg.Bark)
+		return bark.NewBarkNotifier(cfg.Bark, renderer), nil
+	case "webhook":
+		if err := cfg.Webhook.Validate(); err != nil {
+			return nil, err
+		}
+		return webhook.NewWebhookNotifier(cfg.Webhook, provider), nil
+	case "wecom":
+		return wecom.NewWeComNotifier(cfg.WeCom, renderer, provider), nil
+	case "dingtalk":
+		return dingtalk.NewDingTalkNotifier(cfg.DingTalk, renderer, provider), nil
+	case "feishu":
+		return feishu.NewFeishuNotifier(cfg.Feishu, renderer, provider), nil
+	case "discord":
+		return discord.NewDiscordNotifier(cfg.Discord, renderer, provider), nil
+	case "slack":
+		return slack.NewSlackNotifier(cfg.Slack, renderer, provider), nil
+	case "ntfy":
+		return ntfy.NewNtfyNotifier(cfg.Ntfy, renderer, provider), nil
+	case "gotify":
+		return gotify.NewGotifyNotifier(cfg.Gotify, renderer, provider), nil
+	case "":
+		return nil, notice.ErrNofierNotSet
+	default:
+		return nil, fmt.Errorf("不支持的通知渠道类型 %s", cfg.Type)
+	}
+}
+
+func (a *Adapter) Reload(config interface{}) error {
+	cfg, ok := config.(*Config)
+	if !ok {
+		return errors.New("配置类型错误")
+	}
+	if err := cfg.validate(); err != nil {
+		return err
+	}
+	configs := cfg.channelConfigs()
+	channels := make([]channel, 0, len(configs))
+	for _, channelConfig := range configs {
+		notifier, err := newNotifier(channelConfig, a.network)
+		if err != nil {
+			notifier = &notice.Empty{}
+		}
+		channels = append(channels, channel{
+			name:     channelConfig.Name,
+			points:   channelConfig.NoticePoints,
+			notifier: notifier,
+		})
 	}
 	a.mu.Lock()
-	a.config = *cfg
-	a.notifier = notifier
+	a.enabled = cfg.Enabled
+	a.channels = channels
 	a.mu.Unlock()
 	return nil
 }
 
-// NoticeDownloaded implements notice.Notifier.
-func (a *Adapter) NoticeDownloaded(ctx context.Context, req notice.NoticeDownloadedReq) error {
-	config, notifier := a.snapshot()
-	if !config.Enabled {
-		return nil
+// TestChannel 使用给定的渠道配置发送测试消息，不要求渠道已保存或已启用
+func (a *Adapter) TestChannel(ctx context.Context, cfg ChannelConfig) error {
+	notifier, err := newNotifier(cfg, a.network)
+	if err != nil {
+		return err
 	}
-	if req.Failed && (config.NoticePoints.Error == nil || !*config.NoticePoints.Error) {
-		return nil
-	}
-	if !req.Failed && (config.NoticePoints.Downloaded == nil || !*config.NoticePoints.Downloaded) {
-		return nil
+	tester, ok := notifier.(notice.Tester)
+	if !ok {
+		return fmt.Errorf("通知渠道类型 %s 不支持测试", cfg.Type)
 	}
-	return notifier.NoticeDownloaded(ctx, req)
+	return tester.NoticeTest(ctx)
 }
 
-// NoticeSubscriptionUpdated implements notice.Notifier.
-func (a *Adapter) NoticeSubscriptionUpdated(ctx context.Context, req notice.NoticeSubscriptionUpdatedReq) error {
-	config, notifier := a.snapshot()
-	if !config.Enabled {
-		return nil
+// PreviewReq 通知模板预览请求
+type PreviewReq struct {
+	Type     string           `json:"type"` // 通知渠道类型
+	Event    message.Event    `json:"event"`
+	Failed   bool             `json:"failed"`   // 使用失败通知的示例数据
+	Template message.Template `json:"template"` // 为空时预览渠道的默认消息
+}
+
+// PreviewTemplate 使用示例数据渲染渠道的消息模板
+func PreviewTemplate(req PreviewReq) (message.Message, error) {
+	if req.Type == "webhook" {
+		return message.Message{}, errors.New("Webhook渠道使用请求体模板，不支持预览")
 	}
-	if req.Error != nil && (config.NoticePoints.Error == nil || !*config.NoticePoints.Error) {
-		return nil
+	data, err := message.SampleData(req.Event, req.Failed)
+	if err != nil {
+		return message.Message{}, err
 	}
-	if req.Error == nil && (config.NoticePoints.SubscriptionUpdated == nil || !*config.NoticePoints.SubscriptionUpdated) {
-		return nil
+	var templates message.Templates
+	templates.Set(req.Event, req.Template)
+	renderer, err := message.NewRenderer(req.Type, templates)
+	if err != nil {
+		return message.Message{}, err
+	}
+	// 检查渠道类型是否支持
+	if _, err := newChannelNotifier(ChannelConfig{Type: req.Type}, renderer, nil); err != nil {
+		return message.Message{}, err
+	}
+	if _, ok := message.Defaults(req.Type); ok {
+		return renderer.Render(req.Event, data)
+	}
+
+	// 群机器人默认发送消息卡片，预览卡片的标题和Markdown正文
+	card, err := eventCard(data).Render(renderer)
+	if err != nil {
+		return message.Message{}, err
 	}
-	return notifier.NoticeSubscriptionUpdated(ctx, req)
+	return message.Message{Title: card.Title, Body: card.Markdown(), Format: renderer.Format()}, nil
+}
+
+func eventCard(data interface{}) robot.Card {
+	switch req := data.(type) {
+	case notice.NoticeSubscriptionUpdatedReq:
+		return robot.SubscriptionUpdatedCard(req)
+	case notice.NoticeDownloadedReq:
+		return robot.DownloadedCard(req)
+	case notice.NoticeSubscriptionTransferredReq:
+		return robot.SubscriptionTransferredCard(req)
+	case notice.NoticeTaskTransferredReq:
+		return robot.TaskTransferredCard(req)
+	case notice.NoticeStorageLowReq:
+		return robot.StorageLowCard(req)
+	default:
+		return robot.Card{}
+	}
+}
+
+// NoticeDownloaded implements notice.Notifier.
+func (a *Adapter) NoticeDownloaded(ctx context.Context, req notice.NoticeDownloadedReq) error {
+	return a.notify(func(points NoticePoints) *bool {
+		if req.Failed {
+			return points.Error
+		}
+		return points.Downloaded
+	}, func(notifier notice.Notifier) error {
+		return notifier.NoticeDownloaded(ctx, req)
+	})
+}
+
+// NoticeSubscriptionUpdated implements notice.Notifier.
+func (a *Adapter) NoticeSubscriptionUpdated(ctx context.Context, req notice.NoticeSubscriptionUpdatedReq) error {
+	return a.notify(func(points NoticePoints) *bool {
+		if req.Error != nil {
+			return points.Error
+		}
+		return points.SubscriptionUpdated
+	}, func(notifier notice.Notifier) error {
+		return notifier.NoticeSubscriptionUpdated(ctx, req)
+	})
 }
 
 // NoticeSubscriptionTransferred implements notice.Notifier.
 func (a *Adapter) NoticeSubscriptionTransferred(ctx context.Context, req notice.NoticeSubscriptionTransferredReq) error {
-	config, notifier := a.snapshot()
-	if !config.Enabled {
-		return nil
-	}
-	if req.Error != nil && (config.NoticePoints.Error == nil || !*config.NoticePoints.Error) {
-		return nil
-	}
-	if req.Error == nil && (config.NoticePoints.Transferred == nil || !*config.NoticePoints.Transferred) {
-		return nil
-	}
-	return notifier.NoticeSubscriptionTransferred(ctx, req)
+	return a.notify(func(points NoticePoints) *bool {
+		if req.Error != nil {
+			return points.Error
+		}
+		return points.Transferred
+	}, func(notifier notice.Notifier) error {
+		return notifier.NoticeSubscriptionTransferred(ctx, req)
+	})
 }
 
 // NoticeTaskTransferred implements notice.Notifier.
 func (a *Adapter) NoticeTaskTransferred(ctx context.Context, req notice.NoticeTaskTransferredReq) error {
-	config, notifier := a.snapshot()
-	if !config.Enabled {
-		return nil
-	}
-	if req.Error != nil && (config.NoticePoints.Error == nil || !*config.NoticePoints.Error) {
+	return a.notify(func(points NoticePoints) *bool {
+		if req.Error != nil {
+			return points.Error
+		}
+		return points.Transferred
+	}, func(notifier notice.Notifier) error {
+		return notifier.NoticeTaskTransferred(ctx, req)
+	})
+}
+
+// NoticeStorageLow implements notice.Notifier.
+func (a *Adapter) NoticeStorageLow(ctx context.Context, req notice.NoticeStorageLowReq) error {
+	return a.notify(func(points NoticePoints) *bool {
+		return points.StorageLow
+	}, func(notifier notice.Notifier) error {
+		return notifier.NoticeStorageLow(ctx, req)
+	})
+}
+
+// notify 向开启了对应通知点的所有渠道发送通知，单个渠道失败不影响其他渠道
+func (a *Adapter) notify(point func(NoticePoints) *bool, send func(notice.Notifier) error) error {
+	enabled, channels := a.snapshot()
+	if !enabled {
 		return nil
 	}
-	if req.Error == nil && (config.NoticePoints.Transferred == nil || !*config.NoticePoints.Transferred) {
-		return nil
+	var errs *multierror.Error
+	for _, c := range channels {
+		if on := point(c.points); on == nil || !*on {
+			continue
+		}
+		if err := send(c.notifier); err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("通知渠道 %s 发送失败: %w", c.name, err))
+		}
 	}
-	return notifier.NoticeTaskTransferred(ctx, req)
+	return errs.ErrorOrNil()
 }
 
-func (a *Adapter) snapshot() (Config, notice.Notifier) {
+func (a *Adapter) snapshot() (bool, []channel) {
 	a.mu.RLock()
 	defer a.mu.RUnlock()
-	if a.notifier == nil {
-		return a.config, &notice.Empty{}
-	}
-	return a.config, a.notifier
+	return a.enabled, a.channels
 }
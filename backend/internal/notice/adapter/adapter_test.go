@@ -0,0 +1,165 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MangataL/BangumiBuddy/internal/notice"
+	"github.com/MangataL/BangumiBuddy/internal/notice/message"
+	"github.com/MangataL/BangumiBuddy/internal/notice/webhook"
+)
+
+type fakeNotifier struct {
+	notice.Empty
+	err      error
+	received int
+}
+
+func (f *fakeNotifier) NoticeDownloaded(ctx context.Context, req notice.NoticeDownloadedReq) error {
+	f.received++
+	return f.err
+}
+
+func TestAdapter_NoticeDownloaded(t *testing.T) {
+	on, off := true, false
+	telegram := &fakeNotifier{err: errors.New("timeout")}
+	email := &fakeNotifier{}
+	bark := &fakeNotifier{}
+	a := &Adapter{
+		enabled: true,
+		channels: []channel{
+			{name: "telegram", points: NoticePoints{Error: &on}, notifier: telegram},
+			{name: "email", points: NoticePoints{Downloaded: &on, Error: &on}, notifier: email},
+			{name: "bark", points: NoticePoints{Downloaded: &on, Error: &off}, notifier: bark},
+		},
+	}
+
+	err := a.NoticeDownloaded(context.Background(), notice.NoticeDownloadedReq{Failed: true})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "通知渠道 telegram 发送失败: timeout")
+	assert.Equal(t, 1, telegram.received)
+	assert.Equal(t, 1, email.received, "单个渠道失败不影响其他渠道")
+	assert.Equal(t, 0, bark.received)
+
+	require.NoError(t, a.NoticeDownloaded(context.Background(), notice.NoticeDownloadedReq{}))
+	assert.Equal(t, 1, telegram.received)
+	assert.Equal(t, 2, email.received)
+	assert.Equal(t, 1, bark.received)
+
+	a.enabled = false
+	require.NoError(t, a.NoticeDownloaded(context.Background(), notice.NoticeDownloadedReq{}))
+	assert.Equal(t, 2, email.received)
+}
+
+func TestConfig_channelConfigs(t *testing.T) {
+	testCases := []struct {
+		name      string
+		config    Config
+		wantNames []string
+		wantErr   bool
+	}{
+		{
+			name:      "没有配置渠道时保留默认渠道",
+			config:    Config{},
+			wantNames: []string{"default"},
+		},
+		{
+			name: "默认渠道未设置类型时只使用其他渠道",
+			config: Config{Channels: []ChannelConfig{
+				{Name: "tg", Type: "telegram"},
+			}},
+			wantNames: []string{"tg"},
+		},
+		{
+			name: "默认渠道和其他渠道同时发送",
+			config: Config{
+				ChannelConfig: ChannelConfig{Type: "bark"},
+				Channels:      []ChannelConfig{{Name: "mail", Type: "email"}},
+			},
+			wantNames: []string{"default", "mail"},
+		},
+		{
+			name: "渠道名称重复",
+			config: Config{
+				ChannelConfig: ChannelConfig{Type: "bark"},
+				Channels:      []ChannelConfig{{Name: "default", Type: "email"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "Webhook渠道可以不设置消息模板",
+			config: Config{Channels: []ChannelConfig{
+				{Name: "hook", Type: "webhook", Webhook: webhook.Config{URL: "http://localhost/hook"}},
+			}},
+			wantNames: []string{"hook"},
+		},
+		{
+			name: "Webhook渠道不支持自定义消息模板",
+			config: Config{Channels: []ChannelConfig{{
+				Name:      "hook",
+				Type:      "webhook",
+				Webhook:   webhook.Config{URL: "http://localhost/hook"},
+				Templates: message.Templates{StorageLow: message.Template{Body: "{{.Path}}"}},
+			}}},
+			wantErr: true,
+		},
+		{
+			name:    "渠道类型不支持",
+			config:  Config{Channels: []ChannelConfig{{Name: "x", Type: "unknown"}}},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			a := &Adapter{}
+			err := a.Reload(&tc.config)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			names := make([]string, 0, len(a.channels))
+			for _, c := range a.channels {
+				names = append(names, c.name)
+			}
+			assert.Equal(t, tc.wantNames, names)
+		})
+	}
+}
+
+func TestPreviewTemplate(t *testing.T) {
+	msg, err := PreviewTemplate(PreviewReq{
+		Type:     "email",
+		Event:    message.EventSubscriptionTransferred,
+		Template: message.Template{Title: "Transferred: {{.BangumiName}}"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Transferred: 葬送的芙莉莲", msg.Title)
+	assert.Contains(t, msg.Body, "转移媒体库成功")
+	assert.Equal(t, message.FormatHTML, msg.Format)
+
+	// 群机器人未设置模板时预览默认卡片
+	msg, err = PreviewTemplate(PreviewReq{Type: "dingtalk", Event: message.EventStorageLow, Failed: true})
+	require.NoError(t, err)
+	assert.Equal(t, "磁盘空间严重不足", msg.Title)
+	assert.Contains(t, msg.Body, "**路径**: /media/tv")
+
+	msg, err = PreviewTemplate(PreviewReq{
+		Type:     "dingtalk",
+		Event:    message.EventStorageLow,
+		Template: message.Template{Body: "{{.Path}} free {{fileSize .Free}}"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "磁盘空间不足", msg.Title)
+	assert.Equal(t, "/media/tv free 2.00 GB", msg.Body)
+
+	_, err = PreviewTemplate(PreviewReq{Type: "pigeon", Event: message.EventStorageLow})
+	assert.ErrorContains(t, err, "不支持的通知渠道类型")
+	_, err = PreviewTemplate(PreviewReq{Type: "bark", Event: "unknown"})
+	assert.ErrorContains(t, err, "不支持的通知事件")
+}
@@ -7,12 +7,11 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"path/filepath"
 	"sync"
 	"time"
 
 	"github.com/MangataL/BangumiBuddy/internal/notice"
-	"github.com/MangataL/BangumiBuddy/pkg/utils"
+	"github.com/MangataL/BangumiBuddy/internal/notice/message"
 )
 
 const (
@@ -22,8 +21,9 @@ const (
 
 // notifier 实现notice.Notifier接口，通过Bark发送通知
 type notifier struct {
-	mu  sync.Mutex
-	cfg Config
+	mu       sync.Mutex
+	cfg      Config
+	renderer *message.Renderer
 }
 
 // Config Bark通知配置
@@ -35,9 +35,10 @@ type Config struct {
 }
 
 // NewBarkNotifier 创建新的Bark通知器实例
-func NewBarkNotifier(cfg Config) notice.Notifier {
+func NewBarkNotifier(cfg Config, renderer *message.Renderer) notice.Notifier {
 	return &notifier{
-		cfg: cfg,
+		cfg:      cfg,
+		renderer: renderer,
 	}
 }
 
@@ -107,116 +108,41 @@ var (
 )
 
 
-// NoticeSubscriptionUpdated 实现Notifier接口，通知订阅更新状态
-func (n *notifier) NoticeSubscriptionUpdated(ctx context.Context, req notice.NoticeSubscriptionUpdatedReq) error {
-	var title, body string
-
-	title = fmt.Sprintf("番剧订阅更新：%s", req.BangumiName)
-	baseBody := fmt.Sprintf("季度: 第%d季\n字幕组: %s\nRSS订阅项: %s",
-		req.Season, req.ReleaseGroup, req.RSSGUID)
-	if req.Error != nil {
-		body = fmt.Sprintf("%s\n下载失败: %s", baseBody, req.Error.Error())
-	} else {
-		body = fmt.Sprintf("%s\n开始下载...", baseBody)
+// send 渲染事件消息并发送Bark通知
+func (n *notifier) send(event message.Event, data interface{}) error {
+	msg, err := n.renderer.Render(event, data)
+	if err != nil {
+		return err
 	}
+	return n.sendNotification(msg.Title, msg.Body)
+}
 
-	return n.sendNotification(title, body)
+// NoticeSubscriptionUpdated 实现Notifier接口，通知订阅更新状态
+func (n *notifier) NoticeSubscriptionUpdated(ctx context.Context, req notice.NoticeSubscriptionUpdatedReq) error {
+	return n.send(message.EventSubscriptionUpdated, req)
 }
 
 // NoticeDownloaded 实现Notifier接口，通知资源下载状态
 func (n *notifier) NoticeDownloaded(ctx context.Context, req notice.NoticeDownloadedReq) error {
-	var title, body, titleName string
-	if req.RSSGUID != "" {
-		titleName = "番剧"
-	} else {
-		titleName = "磁力任务"
-	}
-
-	if req.Failed {
-		title = fmt.Sprintf("%s下载失败", titleName)
-		body = fmt.Sprintf("文件名: %s\n错误: %s", req.TorrentName, req.FailDetail)
-	} else {
-		title = fmt.Sprintf("%s下载完成", titleName)
-		body = fmt.Sprintf("文件名: %s\n大小: %s\n耗时: %s\n平均速度: %s",
-			req.TorrentName,
-			utils.FormatFileSize(req.Size),
-			utils.FormatDuration(req.Cost),
-			utils.CalculateAverageSpeed(req.Size, req.Cost))
-	}
-
-	return n.sendNotification(title, body)
+	return n.send(message.EventDownloaded, req)
 }
 
 // NoticeSubscriptionTransferred 实现Notifier接口，通知资源转移状态
 func (n *notifier) NoticeSubscriptionTransferred(ctx context.Context, req notice.NoticeSubscriptionTransferredReq) error {
-	var title, body string
-
-	baseBody := fmt.Sprintf("季度: 第%d季\n字幕组: %s\n文件名: %s\nRSS订阅项: %s",
-		req.Season, req.ReleaseGroup, req.FileName, req.RSSGUID)
-
-	if req.Error != nil {
-		title = fmt.Sprintf("番剧转移失败：%s", req.BangumiName)
-
-		errorMsg := "未知错误"
-		if req.Error != nil {
-			errorMsg = req.Error.Error()
-		}
-
-		body = fmt.Sprintf("%s\n文件: %s\n错误: %s",
-			baseBody, req.FileName, errorMsg)
-	} else {
-		title = fmt.Sprintf("番剧转移成功：%s", req.BangumiName)
-		body = fmt.Sprintf("%s\n媒体库信息: %s",
-			baseBody, req.MediaFilePath)
-	}
-
-	return n.sendNotification(title, body)
+	return n.send(message.EventSubscriptionTransferred, req)
 }
 
 // NoticeTaskTransferred 实现Notifier接口，通知任务转移状态
 func (n *notifier) NoticeTaskTransferred(ctx context.Context, req notice.NoticeTaskTransferredReq) error {
-	var title, body string
-
-	// 判断转移状态
-	successCount := len(req.MediaFilePaths)
-	hasFailures := req.Error != nil
-
-	var (
-		mediaFilePath string
-		fileBody      string
-	)
-	if successCount > 1 {
-		for _, fp := range req.MediaFilePaths {
-			mediaFilePath = filepath.Dir(fp)
-			break
-		}
-		fileBody = fmt.Sprintf("媒体目录: %s\n", mediaFilePath)
-	} else {
-		for _, fp := range req.MediaFilePaths {
-			mediaFilePath = fp
-		}
-		fileBody = fmt.Sprintf("媒体文件路径: %s\n", mediaFilePath)
-	}
+	return n.send(message.EventTaskTransferred, req)
+}
 
-	if successCount == 0 && hasFailures {
-		// 全部转移失败
-		title = fmt.Sprintf("磁力任务转移失败：%s", req.BangumiName)
-		body = fmt.Sprintf("种子名: %s\n转移结果: 全部失败\n错误详情: %s", req.TorrentName, req.Error.Error())
-	} else if successCount > 0 && !hasFailures {
-		// 全部转移成功
-		title = fmt.Sprintf("磁力任务转移成功：%s", req.BangumiName)
-		body = fmt.Sprintf("种子名: %s\n转移结果: 全部成功 (%d个文件)\n", req.TorrentName, successCount)
-		body += fileBody
-	} else {
-		// 部分成功，部分失败
-		title = fmt.Sprintf("磁力任务转移部分成功：%s", req.BangumiName)
-		body = fmt.Sprintf("种子名: %s\n转移结果: %d个成功\n", req.TorrentName, successCount)
-		body += fileBody
-		// 添加失败信息
-		if hasFailures {
-			body += fmt.Sprintf("\n\n失败详情: %s", req.Error.Error())
-		}
-	}
+// NoticeStorageLow 实现Notifier接口，通知磁盘空间不足
+func (n *notifier) NoticeStorageLow(ctx context.Context, req notice.NoticeStorageLowReq) error {
+	return n.send(message.EventStorageLow, req)
+}
 
-	return n.sendNotification(title, body)
+// NoticeTest 实现Tester接口，发送测试消息
+func (n *notifier) NoticeTest(ctx context.Context) error {
+	return n.sendNotification(notice.TestTitle, notice.TestContent)
 }
@@ -0,0 +1,95 @@
+package dingtalk
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/MangataL/BangumiBuddy/internal/network"
+	"github.com/MangataL/BangumiBuddy/internal/notice"
+	"github.com/MangataL/BangumiBuddy/internal/notice/message"
+	"github.com/MangataL/BangumiBuddy/internal/notice/robot"
+)
+
+// sender 实现robot.Sender接口，通过钉钉自定义机器人发送通知
+type sender struct {
+	cfg     Config
+	network network.HTTPClientProvider
+}
+
+// Config 钉钉自定义机器人配置
+type Config struct {
+	WebhookURL string `mapstructure:"webhook_url" json:"webhookURL"` // 机器人Webhook地址，包含access_token参数
+	Secret     string `mapstructure:"secret" json:"secret"`          // 加签密钥，为空时不加签
+}
+
+// NewDingTalkNotifier 创建新的钉钉机器人通知器实例
+func NewDingTalkNotifier(cfg Config, renderer *message.Renderer, provider network.HTTPClientProvider) notice.Notifier {
+	return robot.NewNotifier(&sender{
+		cfg:     cfg,
+		network: provider,
+	}, renderer)
+}
+
+type sendRsp struct {
+	ErrCode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+}
+
+// sign 计算加签参数，签名为 HmacSHA256(secret, timestamp+"\n"+secret) 的Base64
+func sign(secret string, timestamp int64) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(fmt.Sprintf("%d\n%s", timestamp, secret)))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// signedURL 设置了加签密钥时在Webhook地址上附加时间戳和签名
+func (s *sender) signedURL() (string, error) {
+	if s.cfg.Secret == "" {
+		return s.cfg.WebhookURL, nil
+	}
+	u, err := url.Parse(s.cfg.WebhookURL)
+	if err != nil {
+		return "", fmt.Errorf("解析钉钉Webhook地址失败: %w", err)
+	}
+	timestamp := time.Now().UnixMilli()
+	query := u.Query()
+	query.Set("timestamp", strconv.FormatInt(timestamp, 10))
+	query.Set("sign", sign(s.cfg.Secret, timestamp))
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// Send 发送Markdown消息，有海报时在正文前插入海报图片
+func (s *sender) Send(ctx context.Context, card robot.Card) error {
+	text := fmt.Sprintf("### %s\n\n", card.Title)
+	if card.Poster != "" {
+		text += fmt.Sprintf("![海报](%s)\n\n", card.Poster)
+	}
+	text += card.Markdown()
+	payload := map[string]interface{}{
+		"msgtype": "markdown",
+		"markdown": map[string]string{
+			"title": card.Title,
+			"text":  text,
+		},
+	}
+
+	webhookURL, err := s.signedURL()
+	if err != nil {
+		return err
+	}
+	var rsp sendRsp
+	if err := robot.PostJSON(ctx, robot.HTTPClient(s.network), webhookURL, nil, payload, &rsp); err != nil {
+		return fmt.Errorf("发送钉钉通知失败: %w", err)
+	}
+	if rsp.ErrCode != 0 {
+		return fmt.Errorf("发送钉钉通知失败: %d, %s", rsp.ErrCode, rsp.ErrMsg)
+	}
+	return nil
+}
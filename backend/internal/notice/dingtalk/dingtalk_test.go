@@ -0,0 +1,58 @@
+package dingtalk
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MangataL/BangumiBuddy/internal/notice/robot"
+)
+
+func TestSender_Send(t *testing.T) {
+	var payload struct {
+		MsgType  string `json:"msgtype"`
+		Markdown struct {
+			Title string `json:"title"`
+			Text  string `json:"text"`
+		} `json:"markdown"`
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		assert.Equal(t, "token", query.Get("access_token"))
+		timestamp, err := strconv.ParseInt(query.Get("timestamp"), 10, 64)
+		assert.NoError(t, err)
+		assert.Equal(t, sign("secret", timestamp), query.Get("sign"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		_, _ = w.Write([]byte(`{"errcode":0,"errmsg":"ok"}`))
+	}))
+	defer ts.Close()
+
+	s := &sender{cfg: Config{WebhookURL: ts.URL + "/robot/send?access_token=token", Secret: "secret"}}
+	err := s.Send(context.Background(), robot.Card{
+		Title:  "番剧转移成功：番剧",
+		Poster: "https://example.com/poster.jpg",
+		Fields: []robot.Field{{Name: "媒体库路径", Value: "/tv/番剧/Season 1/番剧 S01E01.mkv"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "markdown", payload.MsgType)
+	assert.Equal(t, "番剧转移成功：番剧", payload.Markdown.Title)
+	assert.Contains(t, payload.Markdown.Text, "![海报](https://example.com/poster.jpg)")
+	assert.Contains(t, payload.Markdown.Text, "**媒体库路径**: /tv/番剧/Season 1/番剧 S01E01.mkv")
+}
+
+func TestSender_SendErrCode(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"errcode":310000,"errmsg":"sign not match"}`))
+	}))
+	defer ts.Close()
+
+	s := &sender{cfg: Config{WebhookURL: ts.URL}}
+	err := s.Send(context.Background(), robot.Card{Title: "番剧订阅更新：番剧", Failed: true})
+	assert.ErrorContains(t, err, "sign not match")
+}
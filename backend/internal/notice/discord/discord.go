@@ -0,0 +1,76 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MangataL/BangumiBuddy/internal/network"
+	"github.com/MangataL/BangumiBuddy/internal/notice"
+	"github.com/MangataL/BangumiBuddy/internal/notice/message"
+	"github.com/MangataL/BangumiBuddy/internal/notice/robot"
+)
+
+const (
+	colorSuccess = 0x0A84FF // 蓝色
+	colorFailed  = 0xFF3B30 // 红色
+)
+
+// sender 实现robot.Sender接口，通过Discord Webhook发送通知
+type sender struct {
+	cfg     Config
+	network network.HTTPClientProvider
+}
+
+// Config Discord Webhook配置
+type Config struct {
+	WebhookURL string `mapstructure:"webhook_url" json:"webhookURL"` // 频道Webhook地址
+	Username   string `mapstructure:"username" json:"username"`      // 覆盖Webhook的显示名称，为空时使用Webhook设置
+}
+
+// NewDiscordNotifier 创建新的Discord通知器实例
+func NewDiscordNotifier(cfg Config, renderer *message.Renderer, provider network.HTTPClientProvider) notice.Notifier {
+	return robot.NewNotifier(&sender{
+		cfg:     cfg,
+		network: provider,
+	}, renderer)
+}
+
+// Send 发送Embed消息，海报作为缩略图展示
+func (s *sender) Send(ctx context.Context, card robot.Card) error {
+	color := colorSuccess
+	if card.Failed {
+		color = colorFailed
+	}
+	embed := map[string]interface{}{
+		"title":  card.Title,
+		"color":  color,
+		"footer": map[string]string{"text": "BangumiBuddy"},
+	}
+	if card.Body != "" {
+		embed["description"] = card.Body
+	} else {
+		fields := make([]map[string]interface{}, 0, len(card.Fields))
+		for _, field := range card.Fields {
+			fields = append(fields, map[string]interface{}{
+				"name":   field.Name,
+				"value":  field.Value,
+				"inline": len([]rune(field.Value)) <= 20,
+			})
+		}
+		embed["fields"] = fields
+	}
+	if card.Poster != "" {
+		embed["thumbnail"] = map[string]string{"url": card.Poster}
+	}
+	payload := map[string]interface{}{
+		"embeds": []interface{}{embed},
+	}
+	if s.cfg.Username != "" {
+		payload["username"] = s.cfg.Username
+	}
+
+	if err := robot.PostJSON(ctx, robot.HTTPClient(s.network), s.cfg.WebhookURL, nil, payload, nil); err != nil {
+		return fmt.Errorf("发送Discord通知失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package discord
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MangataL/BangumiBuddy/internal/notice/robot"
+)
+
+type embed struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Color       int    `json:"color"`
+	Thumbnail   struct {
+		URL string `json:"url"`
+	} `json:"thumbnail"`
+	Fields []struct {
+		Name   string `json:"name"`
+		Value  string `json:"value"`
+		Inline bool   `json:"inline"`
+	} `json:"fields"`
+}
+
+func TestSender_Send(t *testing.T) {
+	var payload struct {
+		Username string  `json:"username"`
+		Embeds   []embed `json:"embeds"`
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	s := &sender{cfg: Config{WebhookURL: ts.URL, Username: "BangumiBuddy"}}
+	err := s.Send(context.Background(), robot.Card{
+		Title:  "番剧转移失败：番剧",
+		Poster: "https://example.com/poster.jpg",
+		Failed: true,
+		Fields: []robot.Field{
+			{Name: "季度", Value: "第1季"},
+			{Name: "错误", Value: "目标文件已存在，无法覆盖媒体库中原有的同名媒体文件"},
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "BangumiBuddy", payload.Username)
+	require.Len(t, payload.Embeds, 1)
+	got := payload.Embeds[0]
+	assert.Equal(t, "番剧转移失败：番剧", got.Title)
+	assert.Equal(t, colorFailed, got.Color)
+	assert.Equal(t, "https://example.com/poster.jpg", got.Thumbnail.URL)
+	require.Len(t, got.Fields, 2)
+	assert.True(t, got.Fields[0].Inline)
+	assert.False(t, got.Fields[1].Inline)
+}
+
+func TestSender_SendBody(t *testing.T) {
+	var payload struct {
+		Embeds []embed `json:"embeds"`
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	s := &sender{cfg: Config{WebhookURL: ts.URL}}
+	err := s.Send(context.Background(), robot.Card{
+		Title:  "Downloaded",
+		Body:   "番剧 - 01.mkv",
+		Fields: []robot.Field{{Name: "文件名", Value: "番剧 - 01.mkv"}},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, payload.Embeds, 1)
+	assert.Equal(t, colorSuccess, payload.Embeds[0].Color)
+	assert.Equal(t, "番剧 - 01.mkv", payload.Embeds[0].Description)
+	assert.Empty(t, payload.Embeds[0].Fields)
+}
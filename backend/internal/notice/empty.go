@@ -30,3 +30,13 @@ func (e *Empty) NoticeSubscriptionTransferred(ctx context.Context, req NoticeSub
 func (e *Empty) NoticeTaskTransferred(ctx context.Context, req NoticeTaskTransferredReq) error {
 	return ErrNofierNotSet
 }
+
+// NoticeStorageLow implements Notifier.
+func (e *Empty) NoticeStorageLow(ctx context.Context, req NoticeStorageLowReq) error {
+	return ErrNofierNotSet
+}
+
+// NoticeTest implements Tester.
+func (e *Empty) NoticeTest(ctx context.Context) error {
+	return ErrNofierNotSet
+}
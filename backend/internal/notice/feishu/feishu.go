@@ -0,0 +1,102 @@
+package feishu
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/MangataL/BangumiBuddy/internal/network"
+	"github.com/MangataL/BangumiBuddy/internal/notice"
+	"github.com/MangataL/BangumiBuddy/internal/notice/message"
+	"github.com/MangataL/BangumiBuddy/internal/notice/robot"
+)
+
+// sender 实现robot.Sender接口，通过飞书自定义机器人发送通知
+type sender struct {
+	cfg     Config
+	network network.HTTPClientProvider
+}
+
+// Config 飞书自定义机器人配置
+type Config struct {
+	WebhookURL string `mapstructure:"webhook_url" json:"webhookURL"` // 机器人Webhook地址
+	Secret     string `mapstructure:"secret" json:"secret"`          // 签名校验密钥，为空时不签名
+}
+
+// NewFeishuNotifier 创建新的飞书机器人通知器实例
+func NewFeishuNotifier(cfg Config, renderer *message.Renderer, provider network.HTTPClientProvider) notice.Notifier {
+	return robot.NewNotifier(&sender{
+		cfg:     cfg,
+		network: provider,
+	}, renderer)
+}
+
+type sendRsp struct {
+	Code int    `json:"code"`
+	Msg  string `json:"msg"`
+}
+
+// sign 计算签名，签名为以 timestamp+"\n"+secret 为密钥对空数据计算 HmacSHA256 后的Base64
+func sign(secret string, timestamp int64) string {
+	mac := hmac.New(sha256.New, []byte(fmt.Sprintf("%d\n%s", timestamp, secret)))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Send 发送消息卡片，飞书卡片中的图片需要先上传，海报以按钮链接的形式展示
+func (s *sender) Send(ctx context.Context, card robot.Card) error {
+	headerTemplate := "blue"
+	if card.Failed {
+		headerTemplate = "red"
+	}
+	elements := []interface{}{
+		map[string]interface{}{
+			"tag": "div",
+			"text": map[string]string{
+				"tag":     "lark_md",
+				"content": card.Markdown(),
+			},
+		},
+	}
+	if card.Poster != "" {
+		elements = append(elements, map[string]interface{}{
+			"tag": "action",
+			"actions": []interface{}{
+				map[string]interface{}{
+					"tag":  "button",
+					"text": map[string]string{"tag": "plain_text", "content": "查看海报"},
+					"url":  card.Poster,
+					"type": "default",
+				},
+			},
+		})
+	}
+	payload := map[string]interface{}{
+		"msg_type": "interactive",
+		"card": map[string]interface{}{
+			"config": map[string]bool{"wide_screen_mode": true},
+			"header": map[string]interface{}{
+				"title":    map[string]string{"tag": "plain_text", "content": card.Title},
+				"template": headerTemplate,
+			},
+			"elements": elements,
+		},
+	}
+	if s.cfg.Secret != "" {
+		timestamp := time.Now().Unix()
+		payload["timestamp"] = strconv.FormatInt(timestamp, 10)
+		payload["sign"] = sign(s.cfg.Secret, timestamp)
+	}
+
+	var rsp sendRsp
+	if err := robot.PostJSON(ctx, robot.HTTPClient(s.network), s.cfg.WebhookURL, nil, payload, &rsp); err != nil {
+		return fmt.Errorf("发送飞书通知失败: %w", err)
+	}
+	if rsp.Code != 0 {
+		return fmt.Errorf("发送飞书通知失败: %d, %s", rsp.Code, rsp.Msg)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package feishu
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MangataL/BangumiBuddy/internal/notice/robot"
+)
+
+func TestSender_Send(t *testing.T) {
+	var payload struct {
+		Timestamp string `json:"timestamp"`
+		Sign      string `json:"sign"`
+		MsgType   string `json:"msg_type"`
+		Card      struct {
+			Header struct {
+				Title struct {
+					Content string `json:"content"`
+				} `json:"title"`
+				Template string `json:"template"`
+			} `json:"header"`
+			Elements []json.RawMessage `json:"elements"`
+		} `json:"card"`
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		_, _ = w.Write([]byte(`{"code":0,"msg":"success"}`))
+	}))
+	defer ts.Close()
+
+	s := &sender{cfg: Config{WebhookURL: ts.URL, Secret: "secret"}}
+	err := s.Send(context.Background(), robot.Card{
+		Title:  "番剧转移成功：番剧",
+		Poster: "https://example.com/poster.jpg",
+		Fields: []robot.Field{{Name: "媒体库路径", Value: "/tv/番剧/Season 1/番剧 S01E01.mkv"}},
+	})
+	require.NoError(t, err)
+
+	timestamp, err := strconv.ParseInt(payload.Timestamp, 10, 64)
+	require.NoError(t, err)
+	assert.Equal(t, sign("secret", timestamp), payload.Sign)
+	assert.Equal(t, "interactive", payload.MsgType)
+	assert.Equal(t, "番剧转移成功：番剧", payload.Card.Header.Title.Content)
+	assert.Equal(t, "blue", payload.Card.Header.Template)
+	require.Len(t, payload.Card.Elements, 2)
+	assert.Contains(t, string(payload.Card.Elements[0]), "/tv/番剧/Season 1/番剧 S01E01.mkv")
+	assert.Contains(t, string(payload.Card.Elements[1]), "https://example.com/poster.jpg")
+}
+
+func TestSender_SendErrCode(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"code":19021,"msg":"sign match fail or timestamp is not within one hour from current time"}`))
+	}))
+	defer ts.Close()
+
+	s := &sender{cfg: Config{WebhookURL: ts.URL, Secret: "secret"}}
+	assert.ErrorContains(t, s.Send(context.Background(), robot.Card{Title: "磁盘空间不足", Failed: true}), "19021")
+}
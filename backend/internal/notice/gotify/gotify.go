@@ -0,0 +1,64 @@
+package gotify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/MangataL/BangumiBuddy/internal/network"
+	"github.com/MangataL/BangumiBuddy/internal/notice"
+	"github.com/MangataL/BangumiBuddy/internal/notice/message"
+	"github.com/MangataL/BangumiBuddy/internal/notice/robot"
+)
+
+// priorities 消息优先级对应的Gotify优先级，0~10，客户端通常在4以上才弹出通知
+var priorities = map[robot.Priority]int{
+	robot.PriorityLow:     2,
+	robot.PriorityDefault: 5,
+	robot.PriorityHigh:    8,
+}
+
+// sender 实现robot.Sender接口，通过Gotify推送通知
+type sender struct {
+	cfg     Config
+	network network.HTTPClientProvider
+}
+
+// Config Gotify推送配置
+type Config struct {
+	ServerURL string `mapstructure:"server_url" json:"serverURL"` // Gotify服务地址
+	AppToken  string `mapstructure:"app_token" json:"appToken"`   // 应用令牌
+}
+
+// NewGotifyNotifier 创建新的Gotify通知器实例
+func NewGotifyNotifier(cfg Config, renderer *message.Renderer, provider network.HTTPClientProvider) notice.Notifier {
+	return robot.NewNotifier(&sender{
+		cfg:     cfg,
+		network: provider,
+	}, renderer)
+}
+
+// Send 发送Markdown消息，海报作为通知大图
+func (s *sender) Send(ctx context.Context, card robot.Card) error {
+	extras := map[string]interface{}{
+		"client::display": map[string]string{"contentType": "text/markdown"},
+	}
+	if card.Poster != "" {
+		extras["client::notification"] = map[string]string{"bigImageUrl": card.Poster}
+	}
+	payload := map[string]interface{}{
+		"title":    card.Title,
+		"message":  card.Markdown(),
+		"priority": priorities[card.Priority],
+		"extras":   extras,
+	}
+
+	header := http.Header{}
+	header.Set("X-Gotify-Key", s.cfg.AppToken)
+	messageURL := strings.TrimSuffix(s.cfg.ServerURL, "/") + "/message"
+	if err := robot.PostJSON(ctx, robot.HTTPClient(s.network), messageURL, header, payload, nil); err != nil {
+		return fmt.Errorf("发送Gotify通知失败: %w", err)
+	}
+	return nil
+}
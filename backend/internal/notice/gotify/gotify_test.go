@@ -0,0 +1,49 @@
+package gotify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MangataL/BangumiBuddy/internal/notice/robot"
+)
+
+func TestSender_Send(t *testing.T) {
+	var (
+		payload struct {
+			Title    string                       `json:"title"`
+			Message  string                       `json:"message"`
+			Priority int                          `json:"priority"`
+			Extras   map[string]map[string]string `json:"extras"`
+		}
+		token string
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token = r.Header.Get("X-Gotify-Key")
+		assert.Equal(t, "/message", r.URL.Path)
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		_, _ = w.Write([]byte(`{"id":1}`))
+	}))
+	defer ts.Close()
+
+	s := &sender{cfg: Config{ServerURL: ts.URL + "/", AppToken: "app_token"}}
+	err := s.Send(context.Background(), robot.Card{
+		Title:    "番剧转移成功：番剧",
+		Poster:   "https://example.com/poster.jpg",
+		Priority: robot.PriorityDefault,
+		Fields:   []robot.Field{{Name: "媒体库路径", Value: "/tv/番剧/Season 1/番剧 S01E01.mkv"}},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "app_token", token)
+	assert.Equal(t, "番剧转移成功：番剧", payload.Title)
+	assert.Contains(t, payload.Message, "/tv/番剧/Season 1/番剧 S01E01.mkv")
+	assert.Equal(t, 5, payload.Priority)
+	assert.Equal(t, "text/markdown", payload.Extras["client::display"]["contentType"])
+	assert.Equal(t, "https://example.com/poster.jpg", payload.Extras["client::notification"]["bigImageUrl"])
+}
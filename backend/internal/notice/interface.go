@@ -7,4 +7,11 @@ type Notifier interface {
 	NoticeDownloaded(ctx context.Context, req NoticeDownloadedReq) error
 	NoticeSubscriptionTransferred(ctx context.Context, req NoticeSubscriptionTransferredReq) error
 	NoticeTaskTransferred(ctx context.Context, req NoticeTaskTransferredReq) error
+	NoticeStorageLow(ctx context.Context, req NoticeStorageLowReq) error
+}
+
+// Tester 通知渠道测试接口
+type Tester interface {
+	// NoticeTest 发送测试消息，用于检查通知渠道配置是否正确
+	NoticeTest(ctx context.Context) error
 }
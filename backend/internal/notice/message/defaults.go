@@ -0,0 +1,57 @@
+package message
+
+import (
+	"embed"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// defaultFS 各渠道的默认模板，文件名为事件名，第一行为标题模板，其余为正文模板
+//
+//go:embed defaults
+var defaultFS embed.FS
+
+// formats 各渠道的消息正文格式，未列出的渠道使用Markdown
+var formats = map[string]Format{
+	"telegram": FormatMarkdown,
+	"email":    FormatHTML,
+	"bark":     FormatText,
+}
+
+var defaults = mustLoadDefaults()
+
+// Defaults 获取渠道的默认模板，群机器人等没有默认模板的渠道返回false，
+// 这些渠道默认发送结构化的消息卡片，设置了模板的事件才使用模板渲染
+func Defaults(channelType string) (Templates, bool) {
+	templates, ok := defaults[channelType]
+	return templates, ok
+}
+
+// FormatOf 获取渠道的消息正文格式
+func FormatOf(channelType string) Format {
+	if format, ok := formats[channelType]; ok {
+		return format
+	}
+	return FormatMarkdown
+}
+
+func mustLoadDefaults() map[string]Templates {
+	result := make(map[string]Templates, len(formats))
+	for channelType := range formats {
+		var templates Templates
+		for _, event := range Events {
+			data, err := defaultFS.ReadFile(path.Join("defaults", channelType, string(event)+".tmpl"))
+			if err != nil {
+				panic(fmt.Sprintf("读取%s渠道的默认模板失败: %v", channelType, err))
+			}
+			title, body, _ := strings.Cut(string(data), "\n")
+			templates.Set(event, Template{
+				Title: title,
+				Body:  strings.TrimRight(body, "\n"),
+			})
+		}
+		result[channelType] = templates
+	}
+	return result
+}
@@ -0,0 +1,220 @@
+package message
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"path/filepath"
+	"text/template"
+	"time"
+
+	"github.com/MangataL/BangumiBuddy/pkg/utils"
+)
+
+// Event 通知事件，与模板配置中的键一致
+type Event string
+
+const (
+	EventSubscriptionUpdated     Event = "subscription_updated"
+	EventDownloaded              Event = "downloaded"
+	EventSubscriptionTransferred Event = "subscription_transferred"
+	EventTaskTransferred         Event = "task_transferred"
+	EventStorageLow              Event = "storage_low"
+)
+
+// Events 所有支持自定义模板的通知事件
+var Events = []Event{
+	EventSubscriptionUpdated,
+	EventDownloaded,
+	EventSubscriptionTransferred,
+	EventTaskTransferred,
+	EventStorageLow,
+}
+
+// Format 消息正文格式
+type Format string
+
+const (
+	FormatText     Format = "text"
+	FormatMarkdown Format = "markdown"
+	FormatHTML     Format = "html"
+)
+
+// Template 单个事件的消息模板，使用text/template语法，模板数据为对应的通知请求，
+// HTML格式的正文使用html/template渲染，会自动转义数据中的特殊字符
+type Template struct {
+	Title string `mapstructure:"title" json:"title"`
+	Body  string `mapstructure:"body" json:"body"`
+}
+
+// Templates 各事件的消息模板，标题或正文为空时使用渠道的默认模板
+type Templates struct {
+	SubscriptionUpdated     Template `mapstructure:"subscription_updated" json:"subscriptionUpdated"`
+	Downloaded              Template `mapstructure:"downloaded" json:"downloaded"`
+	SubscriptionTransferred Template `mapstructure:"subscription_transferred" json:"subscriptionTransferred"`
+	TaskTransferred         Template `mapstructure:"task_transferred" json:"taskTransferred"`
+	StorageLow              Template `mapstructure:"storage_low" json:"storageLow"`
+}
+
+// Get 获取事件对应的模板
+func (t Templates) Get(event Event) Template {
+	switch event {
+	case EventSubscriptionUpdated:
+		return t.SubscriptionUpdated
+	case EventDownloaded:
+		return t.Downloaded
+	case EventSubscriptionTransferred:
+		return t.SubscriptionTransferred
+	case EventTaskTransferred:
+		return t.TaskTransferred
+	case EventStorageLow:
+		return t.StorageLow
+	default:
+		return Template{}
+	}
+}
+
+// Set 设置事件对应的模板
+func (t *Templates) Set(event Event, tmpl Template) {
+	switch event {
+	case EventSubscriptionUpdated:
+		t.SubscriptionUpdated = tmpl
+	case EventDownloaded:
+		t.Downloaded = tmpl
+	case EventSubscriptionTransferred:
+		t.SubscriptionTransferred = tmpl
+	case EventTaskTransferred:
+		t.TaskTransferred = tmpl
+	case EventStorageLow:
+		t.StorageLow = tmpl
+	}
+}
+
+// Message 渲染后的消息
+type Message struct {
+	Title  string `json:"title"`
+	Body   string `json:"body"`
+	Format Format `json:"format"`
+}
+
+// funcs 模板中可以使用的函数
+var funcs = template.FuncMap{
+	"fileSize": utils.FormatFileSize,
+	"duration": utils.FormatDuration,
+	"speed":    utils.CalculateAverageSpeed,
+	"dir":      filepath.Dir,
+	"year": func() int {
+		return time.Now().Year()
+	},
+}
+
+type executor interface {
+	Execute(w io.Writer, data interface{}) error
+}
+
+type compiled struct {
+	title executor
+	body  executor
+}
+
+// Renderer 按渠道的模板渲染通知消息
+type Renderer struct {
+	format    Format
+	templates map[Event]compiled
+}
+
+// NewRenderer 创建渠道的消息渲染器，overrides中非空的标题和正文覆盖渠道的默认模板
+func NewRenderer(channelType string, overrides Templates) (*Renderer, error) {
+	defaults, _ := Defaults(channelType)
+	r := &Renderer{
+		format:    FormatOf(channelType),
+		templates: make(map[Event]compiled),
+	}
+	for _, event := range Events {
+		tmpl := defaults.Get(event)
+		override := overrides.Get(event)
+		if override.Title != "" {
+			tmpl.Title = override.Title
+		}
+		if override.Body != "" {
+			tmpl.Body = override.Body
+		}
+		if tmpl.Title == "" && tmpl.Body == "" {
+			continue
+		}
+		c, err := r.compile(event, tmpl)
+		if err != nil {
+			return nil, err
+		}
+		r.templates[event] = c
+	}
+	return r, nil
+}
+
+func (r *Renderer) compile(event Event, tmpl Template) (compiled, error) {
+	var c compiled
+	if tmpl.Title != "" {
+		title, err := template.New(string(event) + ".title").Funcs(funcs).Parse(tmpl.Title)
+		if err != nil {
+			return c, fmt.Errorf("解析%s通知标题模板失败: %w", event, err)
+		}
+		c.title = title
+	}
+	if tmpl.Body == "" {
+		return c, nil
+	}
+	var (
+		body executor
+		err  error
+	)
+	if r.format == FormatHTML {
+		body, err = htmltemplate.New(string(event) + ".body").Funcs(htmltemplate.FuncMap(funcs)).Parse(tmpl.Body)
+	} else {
+		body, err = template.New(string(event) + ".body").Funcs(funcs).Parse(tmpl.Body)
+	}
+	if err != nil {
+		return c, fmt.Errorf("解析%s通知正文模板失败: %w", event, err)
+	}
+	c.body = body
+	return c, nil
+}
+
+// Format 返回消息正文格式
+func (r *Renderer) Format() Format {
+	return r.format
+}
+
+// Has 判断事件是否设置了模板
+func (r *Renderer) Has(event Event) bool {
+	_, ok := r.templates[event]
+	return ok
+}
+
+// Render 使用事件的模板渲染消息，未设置的标题或正文渲染为空字符串
+func (r *Renderer) Render(event Event, data interface{}) (Message, error) {
+	msg := Message{Format: r.format}
+	c, ok := r.templates[event]
+	if !ok {
+		return msg, fmt.Errorf("未设置%s通知模板", event)
+	}
+	var err error
+	if msg.Title, err = execute(c.title, data); err != nil {
+		return msg, fmt.Errorf("渲染%s通知标题失败: %w", event, err)
+	}
+	if msg.Body, err = execute(c.body, data); err != nil {
+		return msg, fmt.Errorf("渲染%s通知正文失败: %w", event, err)
+	}
+	return msg, nil
+}
+
+func execute(tmpl executor, data interface{}) (string, error) {
+	if tmpl == nil {
+		return "", nil
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
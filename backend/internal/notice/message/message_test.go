@@ -0,0 +1,78 @@
+package message
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MangataL/BangumiBuddy/internal/notice"
+)
+
+func TestRenderer_Defaults(t *testing.T) {
+	for channelType := range formats {
+		renderer, err := NewRenderer(channelType, Templates{})
+		require.NoError(t, err)
+		for _, event := range Events {
+			for _, failed := range []bool{false, true} {
+				data, err := SampleData(event, failed)
+				require.NoError(t, err)
+				msg, err := renderer.Render(event, data)
+				require.NoError(t, err, "%s %s", channelType, event)
+				assert.NotEmpty(t, msg.Title, "%s %s", channelType, event)
+				assert.NotEmpty(t, msg.Body, "%s %s", channelType, event)
+				assert.Equal(t, FormatOf(channelType), msg.Format)
+			}
+		}
+	}
+}
+
+func TestRenderer_Override(t *testing.T) {
+	renderer, err := NewRenderer("bark", Templates{
+		Downloaded: Template{Body: "{{.TorrentName}} ({{fileSize .Size}})"},
+	})
+	require.NoError(t, err)
+
+	msg, err := renderer.Render(EventDownloaded, notice.NoticeDownloadedReq{
+		RSSGUID:     "guid",
+		TorrentName: "番剧 - 01.mkv",
+		Size:        1024,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "番剧下载完成", msg.Title) // 标题未覆盖，使用默认模板
+	assert.Equal(t, "番剧 - 01.mkv (1.00 KB)", msg.Body)
+}
+
+func TestRenderer_HTMLEscape(t *testing.T) {
+	renderer, err := NewRenderer("email", Templates{
+		StorageLow: Template{Title: "Low disk: {{.Path}}", Body: "<p>{{.Path}}</p>"},
+	})
+	require.NoError(t, err)
+
+	msg, err := renderer.Render(EventStorageLow, notice.NoticeStorageLowReq{Path: "/media/<tv>"})
+	require.NoError(t, err)
+	assert.Equal(t, "Low disk: /media/<tv>", msg.Title)
+	assert.Equal(t, "<p>/media/&lt;tv&gt;</p>", msg.Body)
+}
+
+func TestRenderer_Robot(t *testing.T) {
+	renderer, err := NewRenderer("wecom", Templates{
+		StorageLow: Template{Title: "Low disk space"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, FormatMarkdown, renderer.Format())
+	assert.True(t, renderer.Has(EventStorageLow))
+	assert.False(t, renderer.Has(EventDownloaded))
+
+	msg, err := renderer.Render(EventStorageLow, notice.NoticeStorageLowReq{})
+	require.NoError(t, err)
+	assert.Equal(t, "Low disk space", msg.Title)
+	assert.Empty(t, msg.Body)
+}
+
+func TestNewRenderer_InvalidTemplate(t *testing.T) {
+	_, err := NewRenderer("telegram", Templates{
+		TaskTransferred: Template{Body: "{{if .Error}}"},
+	})
+	assert.ErrorContains(t, err, "task_transferred")
+}
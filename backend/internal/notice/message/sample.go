@@ -0,0 +1,86 @@
+package message
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/MangataL/BangumiBuddy/internal/notice"
+)
+
+const (
+	sampleBangumiName  = "葬送的芙莉莲"
+	sampleReleaseGroup = "喵萌奶茶屋"
+	sampleRSSGUID      = "[喵萌奶茶屋&LoliHouse] 葬送的芙莉莲 / Sousou no Frieren - 01 [WebRip 1080p HEVC-10bit AAC][简繁日内封字幕]"
+	samplePoster       = "https://image.tmdb.org/t/p/w500/sample.jpg"
+	sampleMediaDir     = "/media/tv/葬送的芙莉莲 (2023)/Season 1"
+)
+
+var errSample = errors.New("示例错误：目标文件已存在")
+
+// SampleData 获取事件的示例通知请求，用于预览模板，failed为true时返回失败的通知
+func SampleData(event Event, failed bool) (interface{}, error) {
+	var sampleErr error
+	if failed {
+		sampleErr = errSample
+	}
+	switch event {
+	case EventSubscriptionUpdated:
+		return notice.NoticeSubscriptionUpdatedReq{
+			BangumiName:  sampleBangumiName,
+			Poster:       samplePoster,
+			Season:       1,
+			ReleaseGroup: sampleReleaseGroup,
+			RSSGUID:      sampleRSSGUID,
+			Error:        sampleErr,
+		}, nil
+	case EventDownloaded:
+		req := notice.NoticeDownloadedReq{
+			RSSGUID:     sampleRSSGUID,
+			TorrentName: sampleRSSGUID,
+			Cost:        3*time.Minute + 25*time.Second,
+			Size:        1288490188,
+		}
+		if failed {
+			req.Failed = true
+			req.FailDetail = sampleErr.Error()
+			req.Cost, req.Size = 0, 0
+		}
+		return req, nil
+	case EventSubscriptionTransferred:
+		return notice.NoticeSubscriptionTransferredReq{
+			RSSGUID:       sampleRSSGUID,
+			FileName:      sampleRSSGUID + ".mkv",
+			BangumiName:   sampleBangumiName,
+			Season:        1,
+			ReleaseGroup:  sampleReleaseGroup,
+			Poster:        samplePoster,
+			MediaFilePath: sampleMediaDir + "/葬送的芙莉莲 S01E01.mkv",
+			Error:         sampleErr,
+		}, nil
+	case EventTaskTransferred:
+		// 失败的示例为部分转移成功
+		req := notice.NoticeTaskTransferredReq{
+			BangumiName: sampleBangumiName,
+			TorrentName: "[Nekomoe kissaten] Sousou no Frieren [01-02][1080p]",
+			MediaFilePaths: map[string]string{
+				"Sousou no Frieren [01][1080p].mkv": sampleMediaDir + "/葬送的芙莉莲 S01E01.mkv",
+				"Sousou no Frieren [02][1080p].mkv": sampleMediaDir + "/葬送的芙莉莲 S01E02.mkv",
+			},
+			Error: sampleErr,
+		}
+		if failed {
+			delete(req.MediaFilePaths, "Sousou no Frieren [02][1080p].mkv")
+		}
+		return req, nil
+	case EventStorageLow:
+		return notice.NoticeStorageLowReq{
+			Path:     "/media/tv",
+			Free:     2147483648,
+			Total:    1099511627776,
+			Critical: failed,
+		}, nil
+	default:
+		return nil, fmt.Errorf("不支持的通知事件 %s", event)
+	}
+}
@@ -0,0 +1,76 @@
+package ntfy
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/MangataL/BangumiBuddy/internal/network"
+	"github.com/MangataL/BangumiBuddy/internal/notice"
+	"github.com/MangataL/BangumiBuddy/internal/notice/message"
+	"github.com/MangataL/BangumiBuddy/internal/notice/robot"
+)
+
+// priorities 消息优先级对应的ntfy优先级，1~5，默认为3
+var priorities = map[robot.Priority]int{
+	robot.PriorityLow:     2,
+	robot.PriorityDefault: 3,
+	robot.PriorityHigh:    5,
+}
+
+// sender 实现robot.Sender接口，通过ntfy推送通知
+type sender struct {
+	cfg     Config
+	network network.HTTPClientProvider
+}
+
+// Config ntfy推送配置
+type Config struct {
+	ServerURL string `mapstructure:"server_url" json:"serverURL" default:"https://ntfy.sh"` // ntfy服务地址
+	Topic     string `mapstructure:"topic" json:"topic"`                                    // 推送主题
+	Token     string `mapstructure:"token" json:"token"`                                    // 访问令牌，设置后优先于用户名密码
+	Username  string `mapstructure:"username" json:"username"`
+	Password  string `mapstructure:"password" json:"password"`
+}
+
+// NewNtfyNotifier 创建新的ntfy通知器实例
+func NewNtfyNotifier(cfg Config, renderer *message.Renderer, provider network.HTTPClientProvider) notice.Notifier {
+	return robot.NewNotifier(&sender{
+		cfg:     cfg,
+		network: provider,
+	}, renderer)
+}
+
+// Send 以JSON方式发布消息，海报作为附件图片
+func (s *sender) Send(ctx context.Context, card robot.Card) error {
+	payload := map[string]interface{}{
+		"topic":    s.cfg.Topic,
+		"title":    card.Title,
+		"message":  card.Markdown(),
+		"markdown": true,
+		"priority": priorities[card.Priority],
+	}
+	if card.Failed {
+		payload["tags"] = []string{"warning"}
+	}
+	if card.Poster != "" {
+		payload["attach"] = card.Poster
+	}
+
+	header := http.Header{}
+	switch {
+	case s.cfg.Token != "":
+		header.Set("Authorization", "Bearer "+s.cfg.Token)
+	case s.cfg.Username != "":
+		credential := base64.StdEncoding.EncodeToString([]byte(s.cfg.Username + ":" + s.cfg.Password))
+		header.Set("Authorization", "Basic "+credential)
+	}
+
+	serverURL := strings.TrimSuffix(s.cfg.ServerURL, "/")
+	if err := robot.PostJSON(ctx, robot.HTTPClient(s.network), serverURL, header, payload, nil); err != nil {
+		return fmt.Errorf("发送ntfy通知失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package ntfy
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MangataL/BangumiBuddy/internal/notice/robot"
+)
+
+type publishReq struct {
+	Topic    string   `json:"topic"`
+	Title    string   `json:"title"`
+	Message  string   `json:"message"`
+	Markdown bool     `json:"markdown"`
+	Priority int      `json:"priority"`
+	Tags     []string `json:"tags"`
+	Attach   string   `json:"attach"`
+}
+
+func TestSender_Send(t *testing.T) {
+	var (
+		payload publishReq
+		auth    string
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		assert.Equal(t, "/", r.URL.Path)
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		_, _ = w.Write([]byte(`{"id":"abc"}`))
+	}))
+	defer ts.Close()
+
+	s := &sender{cfg: Config{ServerURL: ts.URL + "/", Topic: "bangumi", Token: "tk_token"}}
+	err := s.Send(context.Background(), robot.Card{
+		Title:    "番剧转移成功：番剧",
+		Poster:   "https://example.com/poster.jpg",
+		Priority: robot.PriorityDefault,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "Bearer tk_token", auth)
+	assert.Equal(t, "bangumi", payload.Topic)
+	assert.Equal(t, "番剧转移成功：番剧", payload.Title)
+	assert.True(t, payload.Markdown)
+	assert.Equal(t, 3, payload.Priority)
+	assert.Empty(t, payload.Tags)
+	assert.Equal(t, "https://example.com/poster.jpg", payload.Attach)
+}
+
+func TestSender_SendPriority(t *testing.T) {
+	var (
+		payload            publishReq
+		username, password string
+	)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, _ = r.BasicAuth()
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+	}))
+	defer ts.Close()
+
+	s := &sender{cfg: Config{ServerURL: ts.URL, Topic: "bangumi", Username: "user", Password: "pass"}}
+	require.NoError(t, s.Send(context.Background(), robot.Card{Title: "番剧", Priority: robot.PriorityLow}))
+	assert.Equal(t, "user", username)
+	assert.Equal(t, "pass", password)
+	assert.Equal(t, 2, payload.Priority)
+
+	require.NoError(t, s.Send(context.Background(), robot.Card{Title: "番剧", Failed: true, Priority: robot.PriorityHigh}))
+	assert.Equal(t, 5, payload.Priority)
+	assert.Equal(t, []string{"warning"}, payload.Tags)
+}
@@ -0,0 +1,226 @@
+package robot
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/MangataL/BangumiBuddy/internal/notice"
+	"github.com/MangataL/BangumiBuddy/internal/notice/message"
+	"github.com/MangataL/BangumiBuddy/pkg/utils"
+)
+
+// Priority 消息优先级，由推送服务映射为自己的优先级
+type Priority int
+
+const (
+	PriorityLow Priority = iota
+	PriorityDefault
+	PriorityHigh
+)
+
+// Card 群机器人消息卡片，由各机器人转换为自己的消息格式
+type Card struct {
+	Title    string
+	Fields   []Field
+	Poster   string // 海报图片地址
+	Failed   bool
+	Priority Priority // 订阅更新和下载为低优先级，转移为默认优先级，失败和空间不足为高优先级
+	Body     string   // 自定义模板渲染的正文，不为空时替代卡片字段
+
+	event message.Event
+	data  interface{}
+}
+
+// Field 卡片中的一行信息
+type Field struct {
+	Name  string
+	Value string
+}
+
+func (c *Card) add(name, value string) {
+	if value == "" {
+		return
+	}
+	c.Fields = append(c.Fields, Field{Name: name, Value: value})
+}
+
+// Render 使用渠道的自定义模板渲染卡片标题和正文，事件未设置模板时保持默认卡片
+func (c Card) Render(renderer *message.Renderer) (Card, error) {
+	if renderer == nil || !renderer.Has(c.event) {
+		return c, nil
+	}
+	msg, err := renderer.Render(c.event, c.data)
+	if err != nil {
+		return c, err
+	}
+	if msg.Title != "" {
+		c.Title = msg.Title
+	}
+	c.Body = msg.Body
+	return c, nil
+}
+
+// Text 将卡片字段渲染为纯文本，不包含标题和海报
+func (c Card) Text() string {
+	if c.Body != "" {
+		return c.Body
+	}
+	lines := make([]string, 0, len(c.Fields))
+	for _, field := range c.Fields {
+		lines = append(lines, fmt.Sprintf("%s: %s", field.Name, field.Value))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Markdown 将卡片字段渲染为Markdown，不包含标题和海报
+func (c Card) Markdown() string {
+	if c.Body != "" {
+		return c.Body
+	}
+	lines := make([]string, 0, len(c.Fields))
+	for _, field := range c.Fields {
+		lines = append(lines, fmt.Sprintf("**%s**: %s", field.Name, field.Value))
+	}
+	return strings.Join(lines, "\n\n")
+}
+
+// priority 失败的通知使用高优先级
+func priority(p Priority, failed bool) Priority {
+	if failed {
+		return PriorityHigh
+	}
+	return p
+}
+
+// SubscriptionUpdatedCard 订阅更新通知卡片
+func SubscriptionUpdatedCard(req notice.NoticeSubscriptionUpdatedReq) Card {
+	card := Card{
+		Title:    fmt.Sprintf("番剧订阅更新：%s", req.BangumiName),
+		Poster:   req.Poster,
+		Failed:   req.Error != nil,
+		Priority: priority(PriorityLow, req.Error != nil),
+		event:    message.EventSubscriptionUpdated,
+		data:     req,
+	}
+	card.add("季度", fmt.Sprintf("第%d季", req.Season))
+	card.add("字幕组", req.ReleaseGroup)
+	card.add("RSS订阅项", req.RSSGUID)
+	if req.Error != nil {
+		card.add("状态", "下载失败")
+		card.add("错误", req.Error.Error())
+	} else {
+		card.add("状态", "开始下载")
+	}
+	return card
+}
+
+// DownloadedCard 下载通知卡片
+func DownloadedCard(req notice.NoticeDownloadedReq) Card {
+	titleName := "番剧"
+	if req.RSSGUID == "" {
+		titleName = "磁力任务"
+	}
+	card := Card{
+		Failed:   req.Failed,
+		Priority: priority(PriorityLow, req.Failed),
+		event:    message.EventDownloaded,
+		data:     req,
+	}
+	card.add("文件名", req.TorrentName)
+	if req.Failed {
+		card.Title = fmt.Sprintf("%s下载失败", titleName)
+		card.add("错误", req.FailDetail)
+		return card
+	}
+	card.Title = fmt.Sprintf("%s下载完成", titleName)
+	card.add("大小", utils.FormatFileSize(req.Size))
+	card.add("耗时", utils.FormatDuration(req.Cost))
+	card.add("平均速度", utils.CalculateAverageSpeed(req.Size, req.Cost))
+	return card
+}
+
+// SubscriptionTransferredCard 订阅转移通知卡片
+func SubscriptionTransferredCard(req notice.NoticeSubscriptionTransferredReq) Card {
+	card := Card{
+		Title:    fmt.Sprintf("番剧转移成功：%s", req.BangumiName),
+		Poster:   req.Poster,
+		Failed:   req.Error != nil,
+		Priority: priority(PriorityDefault, req.Error != nil),
+		event:    message.EventSubscriptionTransferred,
+		data:     req,
+	}
+	if req.Error != nil {
+		card.Title = fmt.Sprintf("番剧转移失败：%s", req.BangumiName)
+	}
+	card.add("季度", fmt.Sprintf("第%d季", req.Season))
+	card.add("字幕组", req.ReleaseGroup)
+	card.add("文件名", req.FileName)
+	if req.Error != nil {
+		card.add("错误", req.Error.Error())
+	} else {
+		card.add("媒体库路径", req.MediaFilePath)
+	}
+	return card
+}
+
+// TaskTransferredCard 磁力任务转移通知卡片
+func TaskTransferredCard(req notice.NoticeTaskTransferredReq) Card {
+	successCount := len(req.MediaFilePaths)
+	card := Card{
+		Failed:   req.Error != nil,
+		Priority: priority(PriorityDefault, req.Error != nil),
+		event:    message.EventTaskTransferred,
+		data:     req,
+	}
+	card.add("种子名", req.TorrentName)
+	switch {
+	case successCount == 0 && req.Error != nil:
+		card.Title = fmt.Sprintf("磁力任务转移失败：%s", req.BangumiName)
+		card.add("转移结果", "全部失败")
+	case req.Error == nil:
+		card.Title = fmt.Sprintf("磁力任务转移成功：%s", req.BangumiName)
+		card.add("转移结果", fmt.Sprintf("全部成功 (%d个文件)", successCount))
+	default:
+		card.Title = fmt.Sprintf("磁力任务转移部分成功：%s", req.BangumiName)
+		card.add("转移结果", fmt.Sprintf("%d个成功", successCount))
+	}
+	for _, path := range req.MediaFilePaths {
+		if successCount > 1 {
+			card.add("媒体目录", filepath.Dir(path))
+		} else {
+			card.add("媒体文件路径", path)
+		}
+		break
+	}
+	if req.Error != nil {
+		card.add("失败详情", req.Error.Error())
+	}
+	return card
+}
+
+// StorageLowCard 磁盘空间不足通知卡片
+func StorageLowCard(req notice.NoticeStorageLowReq) Card {
+	card := Card{
+		Title:    "磁盘空间不足",
+		Failed:   true,
+		Priority: PriorityHigh,
+		event:    message.EventStorageLow,
+		data:     req,
+	}
+	action := "新的下载和转移已暂停"
+	if req.Critical {
+		card.Title, action = "磁盘空间严重不足", "正在下载的种子已暂停"
+	}
+	card.add("路径", req.Path)
+	card.add("剩余空间", fmt.Sprintf("%s / %s", utils.FormatFileSize(req.Free), utils.FormatFileSize(req.Total)))
+	card.add("状态", action)
+	return card
+}
+
+// TestCard 测试通知卡片
+func TestCard() Card {
+	card := Card{Title: notice.TestTitle, Priority: PriorityDefault}
+	card.add("说明", notice.TestContent)
+	return card
+}
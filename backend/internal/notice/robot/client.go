@@ -0,0 +1,60 @@
+package robot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/MangataL/BangumiBuddy/internal/network"
+)
+
+const timeout = 30 * time.Second
+
+// HTTPClient 获取发送机器人消息使用的HTTP客户端，未设置网络配置时直连
+func HTTPClient(provider network.HTTPClientProvider) *http.Client {
+	if provider == nil {
+		return &http.Client{Timeout: timeout}
+	}
+	return provider.HTTPClient(timeout)
+}
+
+// PostJSON 以JSON发送请求并将响应体解析到result中，result为nil时不解析响应体
+func PostJSON(ctx context.Context, client *http.Client, url string, header http.Header, payload, result interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("序列化请求数据失败: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("发送请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("读取响应失败: %w", err)
+	}
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("请求失败: %d, %s", resp.StatusCode, string(body))
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(body, result); err != nil {
+		return fmt.Errorf("解析响应失败: %w, %s", err, string(body))
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package robot
+
+import (
+	"context"
+
+	"github.com/MangataL/BangumiBuddy/internal/notice"
+	"github.com/MangataL/BangumiBuddy/internal/notice/message"
+)
+
+// Sender 将卡片转换为渠道的消息格式并发送，收到的卡片已按渠道模板渲染
+type Sender interface {
+	Send(ctx context.Context, card Card) error
+}
+
+// notifier 实现notice.Notifier接口，构建通知卡片并交给渠道发送
+type notifier struct {
+	sender   Sender
+	renderer *message.Renderer
+}
+
+// NewNotifier 创建基于卡片的通知器，renderer为空时使用默认卡片
+func NewNotifier(sender Sender, renderer *message.Renderer) notice.Notifier {
+	return &notifier{
+		sender:   sender,
+		renderer: renderer,
+	}
+}
+
+func (n *notifier) send(ctx context.Context, card Card) error {
+	card, err := card.Render(n.renderer)
+	if err != nil {
+		return err
+	}
+	return n.sender.Send(ctx, card)
+}
+
+// NoticeSubscriptionUpdated 实现Notifier接口，通知订阅更新状态
+func (n *notifier) NoticeSubscriptionUpdated(ctx context.Context, req notice.NoticeSubscriptionUpdatedReq) error {
+	return n.send(ctx, SubscriptionUpdatedCard(req))
+}
+
+// NoticeDownloaded 实现Notifier接口，通知资源下载状态
+func (n *notifier) NoticeDownloaded(ctx context.Context, req notice.NoticeDownloadedReq) error {
+	return n.send(ctx, DownloadedCard(req))
+}
+
+// NoticeSubscriptionTransferred 实现Notifier接口，通知资源转移状态
+func (n *notifier) NoticeSubscriptionTransferred(ctx context.Context, req notice.NoticeSubscriptionTransferredReq) error {
+	return n.send(ctx, SubscriptionTransferredCard(req))
+}
+
+// NoticeTaskTransferred 实现Notifier接口，通知任务转移状态
+func (n *notifier) NoticeTaskTransferred(ctx context.Context, req notice.NoticeTaskTransferredReq) error {
+	return n.send(ctx, TaskTransferredCard(req))
+}
+
+// NoticeStorageLow 实现Notifier接口，通知磁盘空间不足
+func (n *notifier) NoticeStorageLow(ctx context.Context, req notice.NoticeStorageLowReq) error {
+	return n.send(ctx, StorageLowCard(req))
+}
+
+// NoticeTest 实现Tester接口，发送测试消息
+func (n *notifier) NoticeTest(ctx context.Context) error {
+	return n.send(ctx, TestCard())
+}
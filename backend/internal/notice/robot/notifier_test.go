@@ -0,0 +1,76 @@
+package robot
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MangataL/BangumiBuddy/internal/notice"
+	"github.com/MangataL/BangumiBuddy/internal/notice/message"
+)
+
+type fakeSender struct {
+	cards []Card
+	err   error
+}
+
+func (s *fakeSender) Send(_ context.Context, card Card) error {
+	s.cards = append(s.cards, card)
+	return s.err
+}
+
+func TestNotifier_Dispatch(t *testing.T) {
+	sender := &fakeSender{}
+	n := NewNotifier(sender, nil)
+	ctx := context.Background()
+
+	require.NoError(t, n.NoticeSubscriptionUpdated(ctx, notice.NoticeSubscriptionUpdatedReq{BangumiName: "番剧"}))
+	require.NoError(t, n.NoticeDownloaded(ctx, notice.NoticeDownloadedReq{TorrentName: "番剧 - 01.mkv", RSSGUID: "guid", Failed: true}))
+	require.NoError(t, n.NoticeSubscriptionTransferred(ctx, notice.NoticeSubscriptionTransferredReq{BangumiName: "番剧"}))
+	require.NoError(t, n.NoticeTaskTransferred(ctx, notice.NoticeTaskTransferredReq{BangumiName: "番剧"}))
+	require.NoError(t, n.NoticeStorageLow(ctx, notice.NoticeStorageLowReq{Path: "/tv"}))
+	require.NoError(t, n.(notice.Tester).NoticeTest(ctx))
+
+	require.Len(t, sender.cards, 6)
+	assert.Equal(t, "番剧订阅更新：番剧", sender.cards[0].Title)
+	assert.Equal(t, "番剧下载失败", sender.cards[1].Title)
+	assert.True(t, sender.cards[1].Failed)
+	assert.Equal(t, PriorityHigh, sender.cards[1].Priority)
+	assert.Equal(t, "番剧转移成功：番剧", sender.cards[2].Title)
+	assert.Equal(t, "磁力任务转移成功：番剧", sender.cards[3].Title)
+	for _, card := range sender.cards {
+		assert.Empty(t, card.Body)
+	}
+}
+
+func TestNotifier_Template(t *testing.T) {
+	renderer, err := message.NewRenderer("wecom", message.Templates{
+		Downloaded: message.Template{Title: "Downloaded", Body: "{{.TorrentName}}"},
+	})
+	require.NoError(t, err)
+	sender := &fakeSender{err: errors.New("发送失败")}
+	n := NewNotifier(sender, renderer)
+
+	err = n.NoticeDownloaded(context.Background(), notice.NoticeDownloadedReq{TorrentName: "番剧 - 01.mkv"})
+	assert.EqualError(t, err, "发送失败")
+	require.Len(t, sender.cards, 1)
+	assert.Equal(t, "Downloaded", sender.cards[0].Title)
+	assert.Equal(t, "番剧 - 01.mkv", sender.cards[0].Body)
+}
+
+func TestPostJSON_StatusError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`not found`))
+	}))
+	defer ts.Close()
+
+	err := PostJSON(context.Background(), HTTPClient(nil), ts.URL, nil, map[string]string{}, nil)
+	assert.ErrorContains(t, err, "404")
+	assert.ErrorContains(t, err, "not found")
+}
@@ -0,0 +1,87 @@
+package slack
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MangataL/BangumiBuddy/internal/network"
+	"github.com/MangataL/BangumiBuddy/internal/notice"
+	"github.com/MangataL/BangumiBuddy/internal/notice/message"
+	"github.com/MangataL/BangumiBuddy/internal/notice/robot"
+)
+
+// maxSectionFields Slack section块最多包含的字段数
+const maxSectionFields = 10
+
+// sender 实现robot.Sender接口，通过Slack Incoming Webhook发送通知
+type sender struct {
+	cfg     Config
+	network network.HTTPClientProvider
+}
+
+// Config Slack Incoming Webhook配置
+type Config struct {
+	WebhookURL string `mapstructure:"webhook_url" json:"webhookURL"` // Incoming Webhook地址
+}
+
+// NewSlackNotifier 创建新的Slack通知器实例
+func NewSlackNotifier(cfg Config, renderer *message.Renderer, provider network.HTTPClientProvider) notice.Notifier {
+	return robot.NewNotifier(&sender{
+		cfg:     cfg,
+		network: provider,
+	}, renderer)
+}
+
+// Send 使用Block Kit发送消息，海报作为section块的附属图片
+func (s *sender) Send(ctx context.Context, card robot.Card) error {
+	title := card.Title
+	if card.Failed {
+		title = ":x: " + title
+	}
+	blocks := []interface{}{
+		map[string]interface{}{
+			"type": "header",
+			"text": map[string]string{"type": "plain_text", "text": title},
+		},
+	}
+	var sections []map[string]interface{}
+	if card.Body != "" {
+		sections = append(sections, map[string]interface{}{
+			"type": "section",
+			"text": map[string]string{"type": "mrkdwn", "text": card.Body},
+		})
+	} else {
+		fields := make([]map[string]string, 0, len(card.Fields))
+		for _, field := range card.Fields {
+			fields = append(fields, map[string]string{
+				"type": "mrkdwn",
+				"text": fmt.Sprintf("*%s*\n%s", field.Name, field.Value),
+			})
+		}
+		for start := 0; start < len(fields); start += maxSectionFields {
+			sections = append(sections, map[string]interface{}{
+				"type":   "section",
+				"fields": fields[start:min(start+maxSectionFields, len(fields))],
+			})
+		}
+	}
+	if len(sections) > 0 && card.Poster != "" {
+		sections[0]["accessory"] = map[string]string{
+			"type":      "image",
+			"image_url": card.Poster,
+			"alt_text":  "poster",
+		}
+	}
+	for _, section := range sections {
+		blocks = append(blocks, section)
+	}
+	payload := map[string]interface{}{
+		"text":   card.Title, // 不支持Block Kit的客户端和通知栏中展示的文本
+		"blocks": blocks,
+	}
+
+	if err := robot.PostJSON(ctx, robot.HTTPClient(s.network), s.cfg.WebhookURL, nil, payload, nil); err != nil {
+		return fmt.Errorf("发送Slack通知失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MangataL/BangumiBuddy/internal/notice/robot"
+)
+
+func TestSender_Send(t *testing.T) {
+	type block struct {
+		Type string `json:"type"`
+		Text struct {
+			Text string `json:"text"`
+		} `json:"text"`
+		Fields    []json.RawMessage `json:"fields"`
+		Accessory struct {
+			Type     string `json:"type"`
+			ImageURL string `json:"image_url"`
+		} `json:"accessory"`
+	}
+	var payload struct {
+		Text   string  `json:"text"`
+		Blocks []block `json:"blocks"`
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	card := robot.Card{
+		Title:  "番剧转移成功：番剧",
+		Poster: "https://example.com/poster.jpg",
+	}
+	for i := 0; i < maxSectionFields+2; i++ {
+		card.Fields = append(card.Fields, robot.Field{Name: fmt.Sprintf("字段%d", i), Value: "值"})
+	}
+	s := &sender{cfg: Config{WebhookURL: ts.URL}}
+	require.NoError(t, s.Send(context.Background(), card))
+
+	assert.Equal(t, "番剧转移成功：番剧", payload.Text)
+	require.Len(t, payload.Blocks, 3)
+	assert.Equal(t, "header", payload.Blocks[0].Type)
+	assert.Equal(t, "番剧转移成功：番剧", payload.Blocks[0].Text.Text)
+	assert.Equal(t, "section", payload.Blocks[1].Type)
+	assert.Len(t, payload.Blocks[1].Fields, maxSectionFields)
+	assert.Equal(t, "image", payload.Blocks[1].Accessory.Type)
+	assert.Equal(t, "https://example.com/poster.jpg", payload.Blocks[1].Accessory.ImageURL)
+	assert.Len(t, payload.Blocks[2].Fields, 2)
+	assert.Empty(t, payload.Blocks[2].Accessory.Type)
+}
@@ -44,3 +44,15 @@ type NoticeTaskTransferredReq struct {
 	Error          error
 	MediaFilePaths map[string]string
 }
+
+type NoticeStorageLowReq struct {
+	Path     string
+	Free     int64
+	Total    int64
+	Critical bool // 空间严重不足，正在下载的种子会被暂停
+}
+
+const (
+	TestTitle   = "BangumiBuddy 测试通知"
+	TestContent = "收到这条消息说明通知渠道配置正确"
+)
@@ -0,0 +1,199 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"text/template"
+	"time"
+
+	"github.com/MangataL/BangumiBuddy/internal/network"
+	"github.com/MangataL/BangumiBuddy/internal/notice"
+	"github.com/MangataL/BangumiBuddy/pkg/utils"
+)
+
+// 默认模板，渲染为JSON，模板数据为对应的通知请求
+const (
+	defaultSubscriptionUpdatedTemplate = `{"event":"subscriptionUpdated","bangumiName":{{json .BangumiName}},"season":{{.Season}},` +
+		`"releaseGroup":{{json .ReleaseGroup}},"rssGUID":{{json .RSSGUID}},"poster":{{json .Poster}},"error":{{json .Error}}}`
+	defaultDownloadedTemplate = `{"event":"downloaded","torrentName":{{json .TorrentName}},"rssGUID":{{json .RSSGUID}},` +
+		`"failed":{{.Failed}},"failDetail":{{json .FailDetail}},"size":{{.Size}},"costSeconds":{{.Cost.Seconds}}}`
+	defaultSubscriptionTransferredTemplate = `{"event":"subscriptionTransferred","bangumiName":{{json .BangumiName}},"season":{{.Season}},` +
+		`"releaseGroup":{{json .ReleaseGroup}},"fileName":{{json .FileName}},"mediaFilePath":{{json .MediaFilePath}},` +
+		`"rssGUID":{{json .RSSGUID}},"poster":{{json .Poster}},"error":{{json .Error}}}`
+	defaultTaskTransferredTemplate = `{"event":"taskTransferred","bangumiName":{{json .BangumiName}},"torrentName":{{json .TorrentName}},` +
+		`"mediaFilePaths":{{json .MediaFilePaths}},"error":{{json .Error}}}`
+	defaultStorageLowTemplate = `{"event":"storageLow","path":{{json .Path}},"free":{{.Free}},"total":{{.Total}},"critical":{{.Critical}}}`
+	defaultTestTemplate       = `{"event":"test","title":{{json .Title}},"content":{{json .Content}}}`
+)
+
+// funcs 模板中可以使用的函数
+var funcs = template.FuncMap{
+	// json 将值序列化为JSON，error序列化为错误信息，nil序列化为null
+	"json": func(v interface{}) (string, error) {
+		if err, ok := v.(error); ok && err != nil {
+			v = err.Error()
+		}
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"fileSize": utils.FormatFileSize,
+	"duration": utils.FormatDuration,
+}
+
+// Config Webhook通知配置
+type Config struct {
+	URL       string            `mapstructure:"url" json:"url"`         // 请求地址，使用POST发送
+	Headers   map[string]string `mapstructure:"headers" json:"headers"` // 自定义请求头，未设置Content-Type时使用application/json
+	Templates Templates         `mapstructure:"templates" json:"templates"`
+}
+
+// Templates 各类通知的请求体模板，使用text/template语法，为空时使用默认的JSON模板
+type Templates struct {
+	SubscriptionUpdated     string `mapstructure:"subscription_updated" json:"subscriptionUpdated"`
+	Downloaded              string `mapstructure:"downloaded" json:"downloaded"`
+	SubscriptionTransferred string `mapstructure:"subscription_transferred" json:"subscriptionTransferred"`
+	TaskTransferred         string `mapstructure:"task_transferred" json:"taskTransferred"`
+	StorageLow              string `mapstructure:"storage_low" json:"storageLow"`
+}
+
+// Validate 检查请求地址和模板是否正确
+func (c Config) Validate() error {
+	if c.URL == "" {
+		return errors.New("Webhook地址不能为空")
+	}
+	_, err := c.parse()
+	return err
+}
+
+type templates struct {
+	subscriptionUpdated     *template.Template
+	downloaded              *template.Template
+	subscriptionTransferred *template.Template
+	taskTransferred         *template.Template
+	storageLow              *template.Template
+	test                    *template.Template
+}
+
+func (c Config) parse() (templates, error) {
+	var (
+		result templates
+		err    error
+	)
+	parse := func(name, text, defaultText string) *template.Template {
+		if err != nil {
+			return nil
+		}
+		if text == "" {
+			text = defaultText
+		}
+		var tmpl *template.Template
+		tmpl, err = template.New(name).Funcs(funcs).Parse(text)
+		if err != nil {
+			err = fmt.Errorf("解析Webhook模板 %s 失败: %w", name, err)
+		}
+		return tmpl
+	}
+	result.subscriptionUpdated = parse("subscriptionUpdated", c.Templates.SubscriptionUpdated, defaultSubscriptionUpdatedTemplate)
+	result.downloaded = parse("downloaded", c.Templates.Downloaded, defaultDownloadedTemplate)
+	result.subscriptionTransferred = parse("subscriptionTransferred", c.Templates.SubscriptionTransferred, defaultSubscriptionTransferredTemplate)
+	result.taskTransferred = parse("taskTransferred", c.Templates.TaskTransferred, defaultTaskTransferredTemplate)
+	result.storageLow = parse("storageLow", c.Templates.StorageLow, defaultStorageLowTemplate)
+	result.test = parse("test", "", defaultTestTemplate)
+	return result, err
+}
+
+// notifier 实现notice.Notifier接口，将通知渲染为请求体后发送到Webhook地址
+type notifier struct {
+	cfg       Config
+	templates templates
+	parseErr  error
+	network   network.HTTPClientProvider
+}
+
+// NewWebhookNotifier 创建新的Webhook通知器实例，模板错误在发送通知时返回
+func NewWebhookNotifier(cfg Config, provider network.HTTPClientProvider) notice.Notifier {
+	tmpls, err := cfg.parse()
+	return &notifier{
+		cfg:       cfg,
+		templates: tmpls,
+		parseErr:  err,
+		network:   provider,
+	}
+}
+
+func (n *notifier) httpClient() *http.Client {
+	if n.network == nil {
+		return &http.Client{Timeout: 30 * time.Second}
+	}
+	return n.network.HTTPClient(30 * time.Second)
+}
+
+// send 使用模板渲染请求体并发送
+func (n *notifier) send(ctx context.Context, tmpl *template.Template, data interface{}) error {
+	if n.parseErr != nil {
+		return n.parseErr
+	}
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, data); err != nil {
+		return fmt.Errorf("渲染Webhook模板 %s 失败: %w", tmpl.Name(), err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.cfg.URL, &body)
+	if err != nil {
+		return fmt.Errorf("创建Webhook请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range n.cfg.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := n.httpClient().Do(req)
+	if err != nil {
+		return fmt.Errorf("发送Webhook通知失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("发送Webhook通知失败: %d, %s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
+
+// NoticeSubscriptionUpdated 实现Notifier接口，通知订阅更新状态
+func (n *notifier) NoticeSubscriptionUpdated(ctx context.Context, req notice.NoticeSubscriptionUpdatedReq) error {
+	return n.send(ctx, n.templates.subscriptionUpdated, req)
+}
+
+// NoticeDownloaded 实现Notifier接口，通知资源下载状态
+func (n *notifier) NoticeDownloaded(ctx context.Context, req notice.NoticeDownloadedReq) error {
+	return n.send(ctx, n.templates.downloaded, req)
+}
+
+// NoticeSubscriptionTransferred 实现Notifier接口，通知资源转移状态
+func (n *notifier) NoticeSubscriptionTransferred(ctx context.Context, req notice.NoticeSubscriptionTransferredReq) error {
+	return n.send(ctx, n.templates.subscriptionTransferred, req)
+}
+
+// NoticeTaskTransferred 实现Notifier接口，通知任务转移状态
+func (n *notifier) NoticeTaskTransferred(ctx context.Context, req notice.NoticeTaskTransferredReq) error {
+	return n.send(ctx, n.templates.taskTransferred, req)
+}
+
+// NoticeStorageLow 实现Notifier接口，通知磁盘空间不足
+func (n *notifier) NoticeStorageLow(ctx context.Context, req notice.NoticeStorageLowReq) error {
+	return n.send(ctx, n.templates.storageLow, req)
+}
+
+// NoticeTest 实现Tester接口，发送测试消息
+func (n *notifier) NoticeTest(ctx context.Context) error {
+	return n.send(ctx, n.templates.test, notice.NoticeReq{
+		Title:   notice.TestTitle,
+		Content: notice.TestContent,
+	})
+}
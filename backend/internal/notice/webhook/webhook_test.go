@@ -0,0 +1,101 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MangataL/BangumiBuddy/internal/notice"
+)
+
+type received struct {
+	header http.Header
+	body   string
+}
+
+func newServer(t *testing.T, status int) (*httptest.Server, <-chan received) {
+	ch := make(chan received, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		body, _ := io.ReadAll(r.Body)
+		ch <- received{header: r.Header, body: string(body)}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(ts.Close)
+	return ts, ch
+}
+
+func TestNotifier_DefaultTemplates(t *testing.T) {
+	ctx := context.Background()
+	ts, ch := newServer(t, http.StatusOK)
+	n := NewWebhookNotifier(Config{URL: ts.URL}, nil)
+
+	require.NoError(t, n.NoticeSubscriptionUpdated(ctx, notice.NoticeSubscriptionUpdatedReq{
+		BangumiName:  "番剧 \"引号\"",
+		Season:       2,
+		ReleaseGroup: "组",
+		RSSGUID:      "guid",
+		Error:        errors.New("失败"),
+	}))
+	got := <-ch
+	assert.Equal(t, "application/json", got.header.Get("Content-Type"))
+	assert.JSONEq(t, `{"event":"subscriptionUpdated","bangumiName":"番剧 \"引号\"","season":2,"releaseGroup":"组",
+		"rssGUID":"guid","poster":"","error":"失败"}`, got.body)
+
+	require.NoError(t, n.NoticeDownloaded(ctx, notice.NoticeDownloadedReq{TorrentName: "a.torrent", Size: 1024, Cost: time.Minute}))
+	got = <-ch
+	assert.JSONEq(t, `{"event":"downloaded","torrentName":"a.torrent","rssGUID":"","failed":false,"failDetail":"",
+		"size":1024,"costSeconds":60}`, got.body)
+
+	require.NoError(t, n.NoticeSubscriptionTransferred(ctx, notice.NoticeSubscriptionTransferredReq{
+		BangumiName: "番剧", Season: 1, FileName: "a.mkv", MediaFilePath: "/tv/a.mkv",
+	}))
+	got = <-ch
+	assert.JSONEq(t, `{"event":"subscriptionTransferred","bangumiName":"番剧","season":1,"releaseGroup":"",
+		"fileName":"a.mkv","mediaFilePath":"/tv/a.mkv","rssGUID":"","poster":"","error":null}`, got.body)
+
+	require.NoError(t, n.NoticeTaskTransferred(ctx, notice.NoticeTaskTransferredReq{
+		BangumiName: "番剧", TorrentName: "t", MediaFilePaths: map[string]string{"a.mkv": "/tv/a.mkv"},
+	}))
+	got = <-ch
+	assert.JSONEq(t, `{"event":"taskTransferred","bangumiName":"番剧","torrentName":"t",
+		"mediaFilePaths":{"a.mkv":"/tv/a.mkv"},"error":null}`, got.body)
+}
+
+func TestNotifier_CustomTemplate(t *testing.T) {
+	ctx := context.Background()
+	ts, ch := newServer(t, http.StatusOK)
+	n := NewWebhookNotifier(Config{
+		URL:     ts.URL,
+		Headers: map[string]string{"Authorization": "Bearer token", "Content-Type": "text/plain"},
+		Templates: Templates{
+			Downloaded: `{{if .Failed}}下载失败{{else}}下载完成{{end}}: {{.TorrentName}} ({{fileSize .Size}})`,
+		},
+	}, nil)
+
+	require.NoError(t, n.NoticeDownloaded(ctx, notice.NoticeDownloadedReq{TorrentName: "a.torrent", Size: 2048}))
+	got := <-ch
+	assert.Equal(t, "Bearer token", got.header.Get("Authorization"))
+	assert.Equal(t, "text/plain", got.header.Get("Content-Type"))
+	assert.Equal(t, "下载完成: a.torrent (2.00 KB)", got.body)
+}
+
+func TestNotifier_Errors(t *testing.T) {
+	ctx := context.Background()
+	ts, _ := newServer(t, http.StatusInternalServerError)
+
+	n := NewWebhookNotifier(Config{URL: ts.URL}, nil)
+	assert.Error(t, n.NoticeStorageLow(ctx, notice.NoticeStorageLowReq{Path: "/tv"}), "非2xx状态码返回错误")
+
+	cfg := Config{URL: ts.URL, Templates: Templates{TaskTransferred: "{{.Unknown"}}
+	assert.Error(t, cfg.Validate())
+	assert.Error(t, NewWebhookNotifier(cfg, nil).NoticeTaskTransferred(ctx, notice.NoticeTaskTransferredReq{}))
+	assert.Error(t, Config{}.Validate())
+}
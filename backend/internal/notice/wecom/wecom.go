@@ -0,0 +1,73 @@
+package wecom
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/MangataL/BangumiBuddy/internal/network"
+	"github.com/MangataL/BangumiBuddy/internal/notice"
+	"github.com/MangataL/BangumiBuddy/internal/notice/message"
+	"github.com/MangataL/BangumiBuddy/internal/notice/robot"
+)
+
+// sender 实现robot.Sender接口，通过企业微信群机器人发送通知
+type sender struct {
+	cfg     Config
+	network network.HTTPClientProvider
+}
+
+// Config 企业微信群机器人配置
+type Config struct {
+	WebhookURL string `mapstructure:"webhook_url" json:"webhookURL"` // 机器人Webhook地址，包含key参数
+}
+
+// NewWeComNotifier 创建新的企业微信群机器人通知器实例
+func NewWeComNotifier(cfg Config, renderer *message.Renderer, provider network.HTTPClientProvider) notice.Notifier {
+	return robot.NewNotifier(&sender{
+		cfg:     cfg,
+		network: provider,
+	}, renderer)
+}
+
+type sendRsp struct {
+	ErrCode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+}
+
+// Send 有海报时发送图文消息，否则发送Markdown消息
+func (s *sender) Send(ctx context.Context, card robot.Card) error {
+	var payload map[string]interface{}
+	if card.Poster != "" {
+		payload = map[string]interface{}{
+			"msgtype": "news",
+			"news": map[string]interface{}{
+				"articles": []map[string]string{{
+					"title":       card.Title,
+					"description": card.Text(),
+					"url":         card.Poster,
+					"picurl":      card.Poster,
+				}},
+			},
+		}
+	} else {
+		title := card.Title
+		if card.Failed {
+			title = fmt.Sprintf(`<font color="warning">%s</font>`, title)
+		}
+		payload = map[string]interface{}{
+			"msgtype": "markdown",
+			"markdown": map[string]string{
+				"content": fmt.Sprintf("### %s\n%s", title, card.Markdown()),
+			},
+		}
+	}
+
+	var rsp sendRsp
+	if err := robot.PostJSON(ctx, robot.HTTPClient(s.network), s.cfg.WebhookURL, nil, payload, &rsp); err != nil {
+		return fmt.Errorf("发送企业微信通知失败: %w", err)
+	}
+	if rsp.ErrCode != 0 {
+		return fmt.Errorf("发送企业微信通知失败: %d, %s", rsp.ErrCode, rsp.ErrMsg)
+	}
+	return nil
+}
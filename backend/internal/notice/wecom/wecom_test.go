@@ -0,0 +1,60 @@
+package wecom
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MangataL/BangumiBuddy/internal/notice/robot"
+)
+
+type payload struct {
+	MsgType string `json:"msgtype"`
+	News    struct {
+		Articles []struct {
+			Title       string `json:"title"`
+			Description string `json:"description"`
+			PicURL      string `json:"picurl"`
+		} `json:"articles"`
+	} `json:"news"`
+	Markdown struct {
+		Content string `json:"content"`
+	} `json:"markdown"`
+}
+
+func TestSender_Send(t *testing.T) {
+	var got payload
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "key", r.URL.Query().Get("key"))
+		got = payload{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		_, _ = w.Write([]byte(`{"errcode":0,"errmsg":"ok"}`))
+	}))
+	defer ts.Close()
+	s := &sender{cfg: Config{WebhookURL: ts.URL + "/cgi-bin/webhook/send?key=key"}}
+
+	require.NoError(t, s.Send(context.Background(), robot.Card{
+		Title:  "番剧转移成功：番剧",
+		Poster: "https://example.com/poster.jpg",
+		Fields: []robot.Field{{Name: "媒体库路径", Value: "/tv/番剧/Season 1/番剧 S01E01.mkv"}},
+	}))
+	assert.Equal(t, "news", got.MsgType, "有海报时发送图文消息")
+	require.Len(t, got.News.Articles, 1)
+	assert.Equal(t, "番剧转移成功：番剧", got.News.Articles[0].Title)
+	assert.Equal(t, "https://example.com/poster.jpg", got.News.Articles[0].PicURL)
+	assert.Contains(t, got.News.Articles[0].Description, "媒体库路径: /tv/番剧/Season 1/番剧 S01E01.mkv")
+
+	require.NoError(t, s.Send(context.Background(), robot.Card{
+		Title:  "磁盘空间严重不足",
+		Failed: true,
+		Fields: []robot.Field{{Name: "路径", Value: "/tv"}},
+	}))
+	assert.Equal(t, "markdown", got.MsgType)
+	assert.Contains(t, got.Markdown.Content, `<font color="warning">磁盘空间严重不足</font>`)
+	assert.Contains(t, got.Markdown.Content, "**路径**: /tv")
+}
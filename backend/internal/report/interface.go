@@ -0,0 +1,11 @@
+package report
+
+import "context"
+
+//go:generate mockgen -destination interface_mock.go -source $GOFILE -package $GOPACKAGE
+
+// Interface 媒体库报告接口
+type Interface interface {
+	// Gaps 对比TMDB单集列表、转移记录和媒体库中的实际文件，列出媒体库中的缺口
+	Gaps(ctx context.Context, req GapsReq) (GapsRsp, error)
+}
@@ -0,0 +1,50 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: interface.go
+
+// Package report is a generated GoMock package.
+package report
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockInterface is a mock of Interface interface.
+type MockInterface struct {
+	ctrl     *gomock.Controller
+	recorder *MockInterfaceMockRecorder
+}
+
+// MockInterfaceMockRecorder is the mock recorder for MockInterface.
+type MockInterfaceMockRecorder struct {
+	mock *MockInterface
+}
+
+// NewMockInterface creates a new mock instance.
+func NewMockInterface(ctrl *gomock.Controller) *MockInterface {
+	mock := &MockInterface{ctrl: ctrl}
+	mock.recorder = &MockInterfaceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockInterface) EXPECT() *MockInterfaceMockRecorder {
+	return m.recorder
+}
+
+// Gaps mocks base method.
+func (m *MockInterface) Gaps(ctx context.Context, req GapsReq) (GapsRsp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Gaps", ctx, req)
+	ret0, _ := ret[0].(GapsRsp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Gaps indicates an expected call of Gaps.
+func (mr *MockInterfaceMockRecorder) Gaps(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Gaps", reflect.TypeOf((*MockInterface)(nil).Gaps), ctx, req)
+}
@@ -0,0 +1,297 @@
+package report
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/MangataL/BangumiBuddy/internal/downloader"
+	"github.com/MangataL/BangumiBuddy/internal/magnet"
+	"github.com/MangataL/BangumiBuddy/internal/meta"
+	"github.com/MangataL/BangumiBuddy/internal/subscriber"
+	"github.com/MangataL/BangumiBuddy/internal/transfer"
+	"github.com/MangataL/BangumiBuddy/pkg/utils"
+)
+
+var _ Interface = (*Reporter)(nil)
+
+const airDateLayout = "2006-01-02"
+
+type Dependency struct {
+	Subscriber    subscriber.Interface
+	Magnet        magnet.Interface
+	TransferFiles transfer.TransferFilesRepo
+	MetaParser    meta.Parser
+}
+
+func New(dep Dependency) *Reporter {
+	return &Reporter{
+		subscriber:    dep.Subscriber,
+		magnet:        dep.Magnet,
+		transferFiles: dep.TransferFiles,
+		metaParser:    dep.MetaParser,
+	}
+}
+
+// Reporter 媒体库报告
+type Reporter struct {
+	subscriber    subscriber.Interface
+	magnet        magnet.Interface
+	transferFiles transfer.TransferFilesRepo
+	metaParser    meta.Parser
+}
+
+// seasonKey 媒体库中的一季
+type seasonKey struct {
+	name   string
+	season int
+}
+
+// seasonTarget 需要检查缺集的一季
+type seasonTarget struct {
+	seasonKey
+	tmdbID          int
+	subscriptionIDs []string
+	taskIDs         []string
+}
+
+// taskEpisode 磁力任务中文件对应的集
+type taskEpisode struct {
+	taskID  string
+	episode int
+}
+
+// Gaps 实现Interface接口
+func (r *Reporter) Gaps(ctx context.Context, req GapsReq) (GapsRsp, error) {
+	bangumis, err := r.subscriber.List(ctx, subscriber.ListBangumiReq{})
+	if err != nil {
+		return GapsRsp{}, fmt.Errorf("获取订阅列表失败: %w", err)
+	}
+	tasks, _, err := r.magnet.ListTasks(ctx, magnet.ListTasksReq{})
+	if err != nil {
+		return GapsRsp{}, fmt.Errorf("获取磁力任务列表失败: %w", err)
+	}
+	records, err := r.transferFiles.List(ctx, transfer.ListFileTransferredReq{})
+	if err != nil {
+		return GapsRsp{}, fmt.Errorf("获取转移记录失败: %w", err)
+	}
+
+	rsp := GapsRsp{Gaps: []Gap{}, Failures: []GapFailure{}}
+	taskEpisodes := taskEpisodesByFileID(tasks)
+	recorded := make(map[string]bool, len(records))
+	present := make(map[seasonKey]map[int]bool)
+	for _, record := range records {
+		if record.NewFile == "" {
+			continue
+		}
+		recorded[filepath.Clean(record.NewFile)] = true
+		episode, taskID := recordEpisode(record, taskEpisodes)
+		// 有记录的集只按文件是否丢失报告，避免同一集重复出现在缺集中
+		if episode != 0 {
+			key := seasonKey{name: record.BangumiName, season: record.Season}
+			if present[key] == nil {
+				present[key] = make(map[int]bool)
+			}
+			present[key][episode] = true
+		}
+		if fileExists(record.NewFile) {
+			continue
+		}
+		gap := Gap{
+			Kind:        GapKindMissingFile,
+			BangumiName: record.BangumiName,
+			Season:      record.Season,
+			Episode:     episode,
+			FilePath:    record.NewFile,
+		}
+		if record.SubscriptionID != "" {
+			gap.SubscriptionIDs = []string{record.SubscriptionID}
+		}
+		if taskID != "" {
+			gap.TaskIDs = []string{taskID}
+		}
+		rsp.Gaps = append(rsp.Gaps, gap)
+	}
+
+	today := time.Now().Format(airDateLayout)
+	for _, target := range seasonTargets(bangumis, tasks) {
+		episodes, err := r.metaParser.GetSeasonEpisodes(ctx, target.tmdbID, target.season)
+		if err != nil {
+			rsp.Failures = append(rsp.Failures, GapFailure{
+				BangumiName: target.name,
+				Season:      target.season,
+				Message:     fmt.Sprintf("获取TMDB单集列表失败: %v", err),
+			})
+			continue
+		}
+		for _, episode := range episodes {
+			// 日期格式固定，可以直接按字符串比较
+			if episode.AirDate == "" || episode.AirDate > today || present[target.seasonKey][episode.Episode] {
+				continue
+			}
+			rsp.Gaps = append(rsp.Gaps, Gap{
+				Kind:            GapKindMissingEpisode,
+				BangumiName:     target.name,
+				Season:          target.season,
+				Episode:         episode.Episode,
+				AirDate:         episode.AirDate,
+				SubscriptionIDs: target.subscriptionIDs,
+				TaskIDs:         target.taskIDs,
+			})
+		}
+	}
+
+	for _, root := range req.LibraryPaths {
+		if root == "" {
+			continue
+		}
+		orphans, err := orphanFiles(root, recorded)
+		if err != nil {
+			rsp.Failures = append(rsp.Failures, GapFailure{
+				Path:    root,
+				Message: fmt.Sprintf("遍历媒体库目录失败: %v", err),
+			})
+		}
+		rsp.Gaps = append(rsp.Gaps, orphans...)
+	}
+
+	sortGaps(rsp.Gaps)
+	return rsp, nil
+}
+
+// taskEpisodesByFileID 按转移记录的新文件ID(种子hash-文件名)索引磁力任务中的剧集文件
+func taskEpisodesByFileID(tasks []magnet.Task) map[string]taskEpisode {
+	episodes := make(map[string]taskEpisode)
+	for _, task := range tasks {
+		for _, file := range task.Torrent.Files {
+			if !file.Media || !file.Download {
+				continue
+			}
+			episodes[fmt.Sprintf("%s-%s", task.Torrent.Hash, file.FileName)] = taskEpisode{
+				taskID:  task.TaskID,
+				episode: file.Episode,
+			}
+		}
+	}
+	return episodes
+}
+
+// recordEpisode 获取转移记录对应的集数和磁力任务，订阅的新文件ID格式为 番剧名/季/集
+func recordEpisode(record transfer.FileTransferred, taskEpisodes map[string]taskEpisode) (int, string) {
+	if record.SubscriptionID != "" {
+		episode, err := strconv.Atoi(record.NewFileID[strings.LastIndex(record.NewFileID, "/")+1:])
+		if err != nil {
+			return 0, ""
+		}
+		return episode, ""
+	}
+	task, ok := taskEpisodes[record.NewFileID]
+	if !ok {
+		return 0, ""
+	}
+	return task.episode, task.taskID
+}
+
+// seasonTargets 汇总订阅和磁力任务涉及的季，同名同季只检查一次
+func seasonTargets(bangumis []subscriber.Bangumi, tasks []magnet.Task) []*seasonTarget {
+	var targets []*seasonTarget
+	index := make(map[seasonKey]*seasonTarget)
+	get := func(name string, season, tmdbID int) *seasonTarget {
+		key := seasonKey{name: name, season: season}
+		if target, ok := index[key]; ok {
+			return target
+		}
+		target := &seasonTarget{seasonKey: key, tmdbID: tmdbID}
+		index[key] = target
+		targets = append(targets, target)
+		return target
+	}
+
+	for _, bangumi := range bangumis {
+		// 绝对集数订阅的集会映射到多个季，无法按订阅的季检查
+		if bangumi.TMDBID == 0 || bangumi.AbsoluteEpisode != nil {
+			continue
+		}
+		target := get(bangumi.Name, bangumi.Season, bangumi.TMDBID)
+		target.subscriptionIDs = append(target.subscriptionIDs, bangumi.SubscriptionID)
+	}
+	for _, task := range tasks {
+		seen := make(map[seasonKey]bool)
+		for _, file := range task.Torrent.Files {
+			if !file.Media || !file.Download || file.Episode == 0 {
+				continue
+			}
+			name, tmdbID, downloadType := task.Meta.ChineseName, task.Meta.TMDBID, task.DownloadType
+			if file.Meta != nil {
+				name, tmdbID, downloadType = file.Meta.ChineseName, file.Meta.TMDBID, file.Meta.MediaType
+			}
+			key := seasonKey{name: name, season: file.Season}
+			if downloadType != downloader.DownloadTypeTV || tmdbID == 0 || seen[key] {
+				continue
+			}
+			seen[key] = true
+			target := get(name, file.Season, tmdbID)
+			target.taskIDs = append(target.taskIDs, task.TaskID)
+		}
+	}
+	return targets
+}
+
+// orphanFiles 查找媒体库目录中没有转移记录的媒体文件，跳过隐藏文件和目录
+func orphanFiles(root string, recorded map[string]bool) ([]Gap, error) {
+	var gaps []Gap
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != root && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || !utils.IsMediaFile(d.Name()) || recorded[filepath.Clean(path)] {
+			return nil
+		}
+		gap := Gap{Kind: GapKindOrphanFile, FilePath: path}
+		if rel, err := filepath.Rel(root, path); err == nil {
+			if parts := strings.Split(filepath.ToSlash(rel), "/"); len(parts) > 1 {
+				gap.BangumiName = parts[0]
+			}
+		}
+		gaps = append(gaps, gap)
+		return nil
+	})
+	return gaps, err
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return !errors.Is(err, fs.ErrNotExist)
+}
+
+func sortGaps(gaps []Gap) {
+	sort.SliceStable(gaps, func(i, j int) bool {
+		a, b := gaps[i], gaps[j]
+		if a.BangumiName != b.BangumiName {
+			return a.BangumiName < b.BangumiName
+		}
+		if a.Season != b.Season {
+			return a.Season < b.Season
+		}
+		if a.Episode != b.Episode {
+			return a.Episode < b.Episode
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.FilePath < b.FilePath
+	})
+}
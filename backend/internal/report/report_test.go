@@ -0,0 +1,92 @@
+package report
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MangataL/BangumiBuddy/internal/downloader"
+	"github.com/MangataL/BangumiBuddy/internal/magnet"
+	"github.com/MangataL/BangumiBuddy/internal/meta"
+	"github.com/MangataL/BangumiBuddy/internal/subscriber"
+	"github.com/MangataL/BangumiBuddy/internal/transfer"
+)
+
+func TestReporter_Gaps(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	root := t.TempDir()
+	writeFile := func(rel string) string {
+		path := filepath.Join(root, rel)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, nil, 0o644))
+		return path
+	}
+	ep1 := writeFile("番剧/Season 1/番剧 S01E01.mkv")
+	ep2 := filepath.Join(root, "番剧/Season 1/番剧 S01E02.mkv")
+	orphan := writeFile("番剧/Season 1/番剧 S01E04.mkv")
+	writeFile("番剧/Season 1/番剧 S01E01.ass")
+	writeFile("番剧/Season 1/.bangumibuddy-replaced-番剧 S01E03.mkv")
+	movieEp1 := writeFile("剧场版/Season 1/剧场版 S01E01.mkv")
+
+	sub := subscriber.NewMockInterface(ctrl)
+	sub.EXPECT().List(ctx, subscriber.ListBangumiReq{}).Return([]subscriber.Bangumi{
+		{SubscriptionID: "sub1", Name: "番剧", Season: 1, TMDBID: 100},
+		{SubscriptionID: "sub2", Name: "绝对", Season: 1, TMDBID: 200, AbsoluteEpisode: &subscriber.AbsoluteEpisode{}},
+		{SubscriptionID: "sub3", Name: "没有元数据", Season: 1},
+	}, nil)
+	mag := magnet.NewMockInterface(ctrl)
+	mag.EXPECT().ListTasks(ctx, magnet.ListTasksReq{}).Return([]magnet.Task{
+		{
+			TaskID:       "task1",
+			DownloadType: downloader.DownloadTypeTV,
+			Meta:         magnet.Meta{ChineseName: "剧场版", TMDBID: 300},
+			Torrent: magnet.Torrent{
+				Hash: "hash",
+				Files: []magnet.TorrentFile{
+					{FileName: "a/01.mkv", Season: 1, Episode: 1, Media: true, Download: true},
+					{FileName: "a/02.mkv", Season: 1, Episode: 2, Media: true, Download: false},
+				},
+			},
+		},
+	}, 1, nil)
+	transferFiles := transfer.NewMockTransferFilesRepo(ctrl)
+	transferFiles.EXPECT().List(ctx, transfer.ListFileTransferredReq{}).Return([]transfer.FileTransferred{
+		{BangumiName: "番剧", Season: 1, SubscriptionID: "sub1", NewFile: ep1, NewFileID: "番剧/1/1"},
+		{BangumiName: "番剧", Season: 1, SubscriptionID: "sub1", NewFile: ep2, NewFileID: "番剧/1/2"},
+		{BangumiName: "剧场版", Season: 1, NewFile: movieEp1, NewFileID: "hash-a/01.mkv"},
+	}, nil)
+	metaParser := meta.NewMockParser(ctrl)
+	metaParser.EXPECT().GetSeasonEpisodes(ctx, 100, 1).Return([]meta.EpisodeInfo{
+		{Episode: 1, AirDate: "2024-01-01"},
+		{Episode: 2, AirDate: "2024-01-08"},
+		{Episode: 3, AirDate: "2024-01-15"},
+		{Episode: 4, AirDate: "2999-01-01"},
+		{Episode: 5},
+	}, nil)
+	metaParser.EXPECT().GetSeasonEpisodes(ctx, 300, 1).Return(nil, errors.New("tmdb error"))
+
+	reporter := New(Dependency{
+		Subscriber:    sub,
+		Magnet:        mag,
+		TransferFiles: transferFiles,
+		MetaParser:    metaParser,
+	})
+	got, err := reporter.Gaps(ctx, GapsReq{LibraryPaths: []string{root, ""}})
+	require.NoError(t, err)
+
+	assert.Equal(t, []Gap{
+		{Kind: GapKindOrphanFile, BangumiName: "番剧", FilePath: orphan},
+		{Kind: GapKindMissingFile, BangumiName: "番剧", Season: 1, Episode: 2, FilePath: ep2, SubscriptionIDs: []string{"sub1"}},
+		{Kind: GapKindMissingEpisode, BangumiName: "番剧", Season: 1, Episode: 3, AirDate: "2024-01-15", SubscriptionIDs: []string{"sub1"}},
+	}, got.Gaps)
+	assert.Equal(t, []GapFailure{
+		{BangumiName: "剧场版", Season: 1, Message: "获取TMDB单集列表失败: tmdb error"},
+	}, got.Failures)
+}
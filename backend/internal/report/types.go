@@ -0,0 +1,44 @@
+package report
+
+// GapKind 媒体库缺口类型
+type GapKind string
+
+const (
+	// GapKindMissingEpisode 已播出但媒体库中没有的集
+	GapKindMissingEpisode GapKind = "missingEpisode"
+	// GapKindMissingFile 有转移记录但媒体库文件已不存在
+	GapKindMissingFile GapKind = "missingFile"
+	// GapKindOrphanFile 媒体库中没有转移记录的文件
+	GapKindOrphanFile GapKind = "orphanFile"
+)
+
+// GapsReq 媒体库缺口报告请求
+type GapsReq struct {
+	LibraryPaths []string // 媒体库目录，用于查找没有转移记录的文件
+}
+
+// GapsRsp 媒体库缺口报告
+type GapsRsp struct {
+	Gaps     []Gap        `json:"gaps"`
+	Failures []GapFailure `json:"failures"` // 无法检查的番剧或目录，不影响其他结果
+}
+
+// Gap 媒体库缺口
+type Gap struct {
+	Kind            GapKind  `json:"kind"`
+	BangumiName     string   `json:"bangumiName"`
+	Season          int      `json:"season"`
+	Episode         int      `json:"episode"`                   // 集数，电影和无法识别集数的文件为0
+	AirDate         string   `json:"airDate,omitempty"`         // 播出日期，仅缺集时有值
+	FilePath        string   `json:"filePath,omitempty"`        // 媒体库文件路径，缺集时为空
+	SubscriptionIDs []string `json:"subscriptionIDs,omitempty"` // 关联的订阅
+	TaskIDs         []string `json:"taskIDs,omitempty"`         // 关联的磁力任务
+}
+
+// GapFailure 检查失败的番剧或目录
+type GapFailure struct {
+	BangumiName string `json:"bangumiName,omitempty"`
+	Season      int    `json:"season,omitempty"`
+	Path        string `json:"path,omitempty"`
+	Message     string `json:"message"`
+}
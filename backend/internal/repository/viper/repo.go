@@ -64,8 +64,8 @@ func (r *Repo) setComponentConfigLocked(name ComponentName, newConfig interface{
 	}
 	lastConfig, exists := r.lastConfigs[name]
 	if !exists || !r.configEquals(lastConfig, newConfig) {
-		var configMap map[string]interface{}
-		if err := mapstructure.Decode(newConfig, &configMap); err != nil {
+		configMap, err := toConfigMap(newConfig)
+		if err != nil {
 			return fmt.Errorf("convert config to map failed: %w", err)
 		}
 		oldValue, oldExists := r.file.Get(string(name)), r.file.IsSet(string(name))
@@ -89,6 +89,73 @@ func (r *Repo) setComponentConfigLocked(name ComponentName, newConfig interface{
 	return nil
 }
 
+// toConfigMap 将配置转换为map，切片和map中的结构体也会按照mapstructure标签转换，
+// 避免写入配置文件时使用字段名作为键
+func toConfigMap(config interface{}) (map[string]interface{}, error) {
+	value, err := toConfigValue(config)
+	if err != nil {
+		return nil, err
+	}
+	configMap, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("config must be a struct, got %T", config)
+	}
+	return configMap, nil
+}
+
+func toConfigValue(value interface{}) (interface{}, error) {
+	v := reflect.Indirect(reflect.ValueOf(value))
+	if !v.IsValid() {
+		return nil, nil
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		var m map[string]interface{}
+		if err := mapstructure.Decode(v.Interface(), &m); err != nil {
+			return nil, err
+		}
+		for key, item := range m {
+			converted, err := toConfigValue(item)
+			if err != nil {
+				return nil, err
+			}
+			m[key] = converted
+		}
+		return m, nil
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return value, nil
+		}
+		m := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			converted, err := toConfigValue(iter.Value().Interface())
+			if err != nil {
+				return nil, err
+			}
+			m[iter.Key().String()] = converted
+		}
+		return m, nil
+	case reflect.Slice:
+		switch v.Type().Elem().Kind() {
+		case reflect.Struct, reflect.Ptr, reflect.Map, reflect.Interface:
+		default:
+			return value, nil
+		}
+		items := make([]interface{}, v.Len())
+		for i := range items {
+			converted, err := toConfigValue(v.Index(i).Interface())
+			if err != nil {
+				return nil, err
+			}
+			items[i] = converted
+		}
+		return items, nil
+	default:
+		return value, nil
+	}
+}
+
 func (r *Repo) configEquals(oldConfig, newConfig interface{}) bool {
 	return reflect.DeepEqual(oldConfig, newConfig)
 }
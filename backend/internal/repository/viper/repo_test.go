@@ -17,6 +17,11 @@ type TestConfig struct {
 	Enabled bool   `mapstructure:"enabled"`
 }
 
+type NestedTestConfig struct {
+	TestConfig `mapstructure:",squash"`
+	Items      []TestConfig `mapstructure:"items"`
+}
+
 type failingReloadable struct {
 	err error
 }
@@ -80,6 +85,30 @@ func TestRepo_SetAndGetComponentConfig(t *testing.T) {
 	assert.Equal(t, updatedConfig.Enabled, readUpdatedConfig.Enabled)
 }
 
+func TestRepo_SetComponentConfig_NestedStructSlice(t *testing.T) {
+	tmpFile := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(tmpFile, []byte("{}"), 0644))
+
+	repo, err := NewRepo(tmpFile)
+	require.NoError(t, err)
+
+	config := &NestedTestConfig{
+		TestConfig: TestConfig{Name: "default", AgeNum: 1},
+		Items: []TestConfig{
+			{Name: "first", AgeNum: 18, Enabled: true},
+			{Name: "second", AgeNum: 20},
+		},
+	}
+	require.NoError(t, repo.SetComponentConfig("test_component", config))
+
+	// 切片中的结构体需要按照mapstructure标签写入，重新读取配置文件后才能还原
+	reloadedRepo, err := NewRepo(tmpFile)
+	require.NoError(t, err)
+	var diskConfig NestedTestConfig
+	require.NoError(t, reloadedRepo.GetComponentConfig("test_component", &diskConfig))
+	assert.Equal(t, *config, diskConfig)
+}
+
 func TestRepo_SetComponentConfig_RollbackWhenReloadFails(t *testing.T) {
 	tmpFile := filepath.Join(t.TempDir(), "config.yaml")
 	require.NoError(t, os.WriteFile(tmpFile, []byte("{}"), 0644))
@@ -0,0 +1,21 @@
+package viper
+
+import (
+	"github.com/MangataL/BangumiBuddy/internal/storage"
+)
+
+const (
+	ComponentNameStorage = ComponentName("storage")
+)
+
+func (r *Repo) GetStorageConfig() (storage.Config, error) {
+	var config storage.Config
+	if err := r.GetComponentConfig(ComponentNameStorage, &config); err != nil {
+		return storage.Config{}, err
+	}
+	return config, nil
+}
+
+func (r *Repo) SetStorageConfig(config *storage.Config) error {
+	return r.SetComponentConfig(ComponentNameStorage, config)
+}
@@ -19,3 +19,12 @@ func (r *Repo) GetTransferConfig() (transfer.Config, error) {
 func (r *Repo) SetTransferConfig(config *transfer.Config) error {
 	return r.SetComponentConfig(ComponentNameTransfer, config)
 }
+
+// GetMediaLibraryPaths 获取转移配置中剧集和电影的媒体库路径
+func (r *Repo) GetMediaLibraryPaths() ([]string, error) {
+	config, err := r.GetTransferConfig()
+	if err != nil {
+		return nil, err
+	}
+	return []string{config.TVPath, config.MoviePath}, nil
+}
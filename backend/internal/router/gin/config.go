@@ -11,7 +11,9 @@ import (
 	"github.com/MangataL/BangumiBuddy/internal/meta/tmdb"
 	"github.com/MangataL/BangumiBuddy/internal/network"
 	noticeadapter "github.com/MangataL/BangumiBuddy/internal/notice/adapter"
+	"github.com/MangataL/BangumiBuddy/internal/notice/message"
 	"github.com/MangataL/BangumiBuddy/internal/scrape"
+	"github.com/MangataL/BangumiBuddy/internal/storage"
 	"github.com/MangataL/BangumiBuddy/internal/subscriber"
 	"github.com/MangataL/BangumiBuddy/internal/transfer"
 	"github.com/MangataL/BangumiBuddy/pkg/subtitle/ass"
@@ -225,6 +227,44 @@ func (r *Router) SetNoticeConfig(ctx *gin.Context) {
 	ctx.Status(http.StatusOK)
 }
 
+// TestNoticeChannel 使用请求中的渠道配置发送测试通知
+// POST /apis/v1/config/notice/test
+func (r *Router) TestNoticeChannel(ctx *gin.Context) {
+	var config noticeadapter.ChannelConfig
+	if err := ctx.ShouldBindJSON(&config); err != nil {
+		writeError(ctx, err)
+		return
+	}
+	if err := r.notice.TestChannel(ctx.Request.Context(), config); err != nil {
+		writeError(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusOK)
+}
+
+// PreviewNoticeTemplate 使用示例数据预览通知模板
+// POST /apis/v1/config/notice/templates/preview
+func (r *Router) PreviewNoticeTemplate(ctx *gin.Context) {
+	var req noticeadapter.PreviewReq
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		writeError(ctx, err)
+		return
+	}
+	msg, err := noticeadapter.PreviewTemplate(req)
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, msg)
+}
+
+// GetNoticeTemplateDefaults 获取通知渠道的默认模板，群机器人渠道没有默认模板
+// GET /apis/v1/config/notice/templates/defaults?type=email
+func (r *Router) GetNoticeTemplateDefaults(ctx *gin.Context) {
+	templates, _ := message.Defaults(ctx.Query("type"))
+	ctx.JSON(http.StatusOK, templates)
+}
+
 // GetSubtitleOperatorConfig 获取字幕操作器配置
 // GET /apis/v1/config/subtitle
 func (r *Router) GetSubtitleOperatorConfig(ctx *gin.Context) {
@@ -276,3 +316,29 @@ func (r *Router) SetScraperConfig(ctx *gin.Context) {
 	}
 	ctx.Status(http.StatusOK)
 }
+
+// GetStorageConfig 获取存储空间检查配置
+// GET /apis/v1/config/storage
+func (r *Router) GetStorageConfig(ctx *gin.Context) {
+	config, err := r.repo.GetStorageConfig()
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, config)
+}
+
+// SetStorageConfig 设置存储空间检查配置
+// PUT /apis/v1/config/storage
+func (r *Router) SetStorageConfig(ctx *gin.Context) {
+	var config storage.Config
+	if err := ctx.ShouldBindJSON(&config); err != nil {
+		writeError(ctx, err)
+		return
+	}
+	if err := r.repo.SetStorageConfig(&config); err != nil {
+		writeError(ctx, err)
+		return
+	}
+	ctx.Status(http.StatusOK)
+}
@@ -134,6 +134,14 @@ func (f *fakeSubscriber) AutoStopSubscription(context.Context, string) bool {
 	return false
 }
 
+func (f *fakeSubscriber) GetBackfillCandidates(context.Context, string) ([]subscriber.BackfillEpisode, error) {
+	return nil, nil
+}
+
+func (f *fakeSubscriber) Backfill(context.Context, subscriber.BackfillReq) (subscriber.BackfillRsp, error) {
+	return subscriber.BackfillRsp{}, nil
+}
+
 func TestRouter_ParseDiscoveryCandidateRSS(t *testing.T) {
 	d := &fakeDiscovery{rssLink: "https://mikanani.me/RSS/Bangumi?bangumiId=681&subgroupid=370"}
 	s := &fakeSubscriber{}
@@ -6,6 +6,8 @@ import (
 
 	"github.com/autobrr/go-qbittorrent"
 	"github.com/gin-gonic/gin"
+
+	"github.com/MangataL/BangumiBuddy/internal/downloader/deluge"
 )
 
 type checkQBittorrentConnectionReq struct {
@@ -42,3 +44,29 @@ func (r *Router) checkQBittorrentConnection(config qbittorrent.Config) error {
 	}
 	return nil
 }
+
+type checkDelugeConnectionReq struct {
+	Host     string `json:"host"`
+	Password string `json:"password"`
+}
+
+// CheckDelugeConnection 检查Deluge连通性
+// POST /apis/v1/downloader/deluge/check
+func (r *Router) CheckDelugeConnection(c *gin.Context) {
+	var req checkDelugeConnectionReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, err)
+		return
+	}
+
+	client := deluge.NewDeluge(deluge.Config{
+		Host:     req.Host,
+		Password: req.Password,
+	})
+	if err := client.CheckConnection(c.Request.Context()); err != nil {
+		writeError(c, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
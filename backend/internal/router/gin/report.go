@@ -0,0 +1,54 @@
+package gin
+
+import (
+	"encoding/csv"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/MangataL/BangumiBuddy/internal/report"
+)
+
+// GetGapReport 获取媒体库缺口报告，format=csv 时以CSV文件返回
+// GET /apis/v1/reports/gaps
+func (r *Router) GetGapReport(ctx *gin.Context) {
+	transferConfig, err := r.repo.GetTransferConfig()
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+	rsp, err := r.report.Gaps(ctx.Request.Context(), report.GapsReq{
+		LibraryPaths: []string{transferConfig.TVPath, transferConfig.MoviePath},
+	})
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+	if ctx.Query("format") != "csv" {
+		ctx.JSON(http.StatusOK, rsp)
+		return
+	}
+
+	ctx.Header("Content-Disposition", `attachment; filename="gaps.csv"`)
+	ctx.Header("Content-Type", "text/csv; charset=utf-8")
+	ctx.Status(http.StatusOK)
+	// 写入BOM，避免Excel打开时中文乱码
+	_, _ = ctx.Writer.WriteString("\xEF\xBB\xBF")
+	w := csv.NewWriter(ctx.Writer)
+	_ = w.Write([]string{"kind", "bangumiName", "season", "episode", "airDate", "filePath", "subscriptionIDs", "taskIDs"})
+	for _, gap := range rsp.Gaps {
+		_ = w.Write([]string{
+			string(gap.Kind),
+			gap.BangumiName,
+			strconv.Itoa(gap.Season),
+			strconv.Itoa(gap.Episode),
+			gap.AirDate,
+			gap.FilePath,
+			strings.Join(gap.SubscriptionIDs, ";"),
+			strings.Join(gap.TaskIDs, ";"),
+		})
+	}
+	w.Flush()
+}
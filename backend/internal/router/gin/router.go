@@ -6,8 +6,11 @@ import (
 	"github.com/MangataL/BangumiBuddy/internal/downloader"
 	"github.com/MangataL/BangumiBuddy/internal/magnet"
 	"github.com/MangataL/BangumiBuddy/internal/meta"
+	noticeadapter "github.com/MangataL/BangumiBuddy/internal/notice/adapter"
+	"github.com/MangataL/BangumiBuddy/internal/report"
 	"github.com/MangataL/BangumiBuddy/internal/repository/viper"
 	"github.com/MangataL/BangumiBuddy/internal/scrape"
+	"github.com/MangataL/BangumiBuddy/internal/storage"
 	"github.com/MangataL/BangumiBuddy/internal/subscriber"
 	"github.com/MangataL/BangumiBuddy/internal/transfer"
 	"github.com/MangataL/BangumiBuddy/internal/web"
@@ -26,6 +29,9 @@ type Dependency struct {
 	Parser           meta.Parser
 	SubtitleOperator subtitle.Subsetter
 	Scraper          scrape.Interface
+	Storage          storage.Interface
+	Report           report.Interface
+	Notice           *noticeadapter.Adapter
 }
 
 func New(dep Dependency) *Router {
@@ -41,6 +47,9 @@ func New(dep Dependency) *Router {
 		metaParser:        dep.Parser,
 		subtitleSubsetter: dep.SubtitleOperator,
 		scraper:           dep.Scraper,
+		storage:           dep.Storage,
+		report:            dep.Report,
+		notice:            dep.Notice,
 	}
 }
 
@@ -56,4 +65,7 @@ type Router struct {
 	metaParser        meta.Parser
 	subtitleSubsetter subtitle.Subsetter
 	scraper           scrape.Interface
+	storage           storage.Interface
+	report            report.Interface
+	notice            *noticeadapter.Adapter
 }
@@ -218,3 +218,33 @@ func (r *Router) GetSubscriptionCalendar(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, calendar)
 }
+
+// GetBackfillCandidates 获取订阅缺失的剧集和可用于补全的资源
+// GET /apis/v1/bangumis/:id/backfill
+func (r *Router) GetBackfillCandidates(c *gin.Context) {
+	id := c.Param("id")
+	episodes, err := r.subscriber.GetBackfillCandidates(c.Request.Context(), id)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, episodes)
+}
+
+// Backfill 下载资源补全订阅缺失的剧集
+// POST /apis/v1/bangumis/:id/backfill
+func (r *Router) Backfill(c *gin.Context) {
+	var req subscriber.BackfillReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, err)
+		return
+	}
+	req.SubscriptionID = c.Param("id")
+
+	rsp, err := r.subscriber.Backfill(c.Request.Context(), req)
+	if err != nil {
+		writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, rsp)
+}
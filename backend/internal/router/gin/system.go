@@ -0,0 +1,48 @@
+package gin
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/MangataL/BangumiBuddy/internal/storage"
+)
+
+// GetStorage 获取下载目录和媒体库目录的磁盘使用情况
+// GET /apis/v1/system/storage
+func (r *Router) GetStorage(ctx *gin.Context) {
+	managerConfig, err := r.repo.GetDownloadManagerConfig()
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+	transferConfig, err := r.repo.GetTransferConfig()
+	if err != nil {
+		writeError(ctx, err)
+		return
+	}
+	paths := []struct {
+		name string
+		path string
+	}{
+		{name: "tvSavePath", path: managerConfig.TVSavePath},
+		{name: "movieSavePath", path: managerConfig.MovieSavePath},
+		{name: "tvPath", path: transferConfig.TVPath},
+		{name: "moviePath", path: transferConfig.MoviePath},
+	}
+
+	usages := make([]storage.PathUsage, 0, len(paths))
+	for _, p := range paths {
+		if p.path == "" {
+			continue
+		}
+		usage, err := r.storage.Stat(ctx.Request.Context(), p.path)
+		item := storage.PathUsage{Name: p.name, Usage: usage}
+		if err != nil {
+			item.Path = p.path
+			item.Error = err.Error()
+		}
+		usages = append(usages, item)
+	}
+	ctx.JSON(http.StatusOK, usages)
+}
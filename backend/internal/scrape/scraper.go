@@ -176,7 +176,8 @@ func (s *Scraper) processTask(ctx context.Context, task MetadataCheckTask) error
 		return errors.WithMessage(err, "解析 NFO 文件失败")
 	}
 
-	if nfoData.season == 0 || nfoData.episode == 0 {
+	// 特别篇的季数为0
+	if (nfoData.season == 0 && task.Season != 0) || nfoData.episode == 0 {
 		log.Debugf(ctx, "NFO 文件中没有季数或集数，等待下次轮询: %s", nfoPath)
 		return nil // 等待下次轮询
 	}
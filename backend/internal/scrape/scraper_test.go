@@ -745,3 +745,43 @@ func TestScraper_ProcessTask_RecordAndReadResultByPath(t *testing.T) {
 		})
 	}
 }
+
+func TestScraper_ProcessTask_SpecialEpisode(t *testing.T) {
+	ctx := context.Background()
+	repo := setupScrapeTestRepository(t)
+	ctrl := gomock.NewController(t)
+	parser := meta.NewMockParser(ctrl)
+	parser.EXPECT().GetEpisodeDetails(gomock.Any(), 95231, 0, 2).Return(meta.EpisodeDetails{
+		Name:      "总集篇",
+		Overview:  "剧情",
+		StillPath: "https://image.test/episode.jpg",
+	}, nil)
+	scraper := &Scraper{
+		config:     Config{Enable: true},
+		repo:       repo,
+		metaParser: parser,
+		network:    staticHTTPClientProvider{client: newImageOKHTTPClient()},
+	}
+
+	tempDir := t.TempDir()
+	filePath := filepath.Join(tempDir, "episode.mkv")
+	require.NoError(t, os.WriteFile(filePath, []byte("media"), 0644))
+	writeTestNFO(t, strings.TrimSuffix(filePath, filepath.Ext(filePath))+".nfo",
+		"第 2 集", "", 0, 2, filepath.Join(tempDir, "poster.jpg"), "")
+	require.NoError(t, repo.Add(ctx, MetadataCheckTask{
+		TMDBID:   95231,
+		FilePath: filePath,
+		Season:   0,
+		Episode:  2,
+		Statuses: []ScrapeStatus{ScrapeStatusPending},
+	}))
+
+	tasks, err := repo.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	require.NoError(t, scraper.processTask(ctx, tasks[0]))
+
+	tasks, err = repo.List(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, tasks, "特别篇的元数据更新完成后删除任务")
+}
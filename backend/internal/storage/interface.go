@@ -0,0 +1,15 @@
+package storage
+
+import "context"
+
+// Interface 存储空间检查接口
+type Interface interface {
+	// Check 检查路径所在磁盘的剩余空间，低于阈值时返回 ErrInsufficientSpace
+	Check(ctx context.Context, path string) error
+
+	// Stat 获取路径所在磁盘的使用情况
+	Stat(ctx context.Context, path string) (Usage, error)
+
+	// Monitor 定期检查时获取路径所在磁盘的使用情况，空间等级变差时发送通知
+	Monitor(ctx context.Context, path string) (Usage, error)
+}
@@ -0,0 +1,165 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+
+	"github.com/MangataL/BangumiBuddy/internal/notice"
+	"github.com/MangataL/BangumiBuddy/pkg/log"
+	"github.com/MangataL/BangumiBuddy/pkg/utils"
+)
+
+var _ Interface = (*Guard)(nil)
+
+// ErrInsufficientSpace 磁盘剩余空间不足
+var ErrInsufficientSpace = errors.New("磁盘剩余空间不足")
+
+const gb = 1 << 30
+
+// Config 存储空间检查配置
+type Config struct {
+	MinFreeGB      int `mapstructure:"min_free_gb" json:"minFreeGB" default:"10"`          // 剩余空间低于该值时拒绝新的下载和转移，单位GB，0表示不检查
+	CriticalFreeGB int `mapstructure:"critical_free_gb" json:"criticalFreeGB" default:"2"` // 剩余空间低于该值时暂停正在下载的种子，单位GB，0表示不检查
+}
+
+// NewGuard 创建存储空间检查器
+func NewGuard(config Config, notifier notice.Notifier) *Guard {
+	return &Guard{
+		config:   config,
+		notifier: notifier,
+		levels:   make(map[string]Level),
+	}
+}
+
+// Guard 检查磁盘剩余空间，剩余空间变少时发送通知
+type Guard struct {
+	mu       sync.Mutex
+	config   Config
+	notifier notice.Notifier
+	// levels 记录每个路径上次的空间等级，只在等级变差时通知
+	levels map[string]Level
+}
+
+func (g *Guard) Reload(config interface{}) error {
+	cfg, ok := config.(*Config)
+	if !ok {
+		return errors.New("配置类型错误")
+	}
+	g.mu.Lock()
+	g.config = *cfg
+	g.mu.Unlock()
+	return nil
+}
+
+// Check 实现Interface接口，获取磁盘信息失败时不阻止下载和转移
+func (g *Guard) Check(ctx context.Context, path string) error {
+	usage, err := g.Stat(ctx, path)
+	if err != nil {
+		log.Warnf(ctx, "检查磁盘剩余空间失败: %v", err)
+		return nil
+	}
+	if usage.Level == LevelOK {
+		return nil
+	}
+	return fmt.Errorf("%w: %s 剩余 %s", ErrInsufficientSpace, path, utils.FormatFileSize(usage.Free))
+}
+
+// Stat 实现Interface接口
+func (g *Guard) Stat(ctx context.Context, path string) (Usage, error) {
+	if path == "" {
+		return Usage{}, errors.New("路径为空")
+	}
+	total, free, err := diskUsage(path)
+	if err != nil {
+		return Usage{}, err
+	}
+	usage := Usage{
+		Path:  path,
+		Total: total,
+		Free:  free,
+		Level: g.level(free),
+	}
+	return usage, nil
+}
+
+// Monitor 实现Interface接口
+func (g *Guard) Monitor(ctx context.Context, path string) (Usage, error) {
+	usage, err := g.Stat(ctx, path)
+	if err != nil {
+		return Usage{}, err
+	}
+	g.notify(ctx, usage)
+	return usage, nil
+}
+
+func (g *Guard) level(free int64) Level {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	switch {
+	case g.config.CriticalFreeGB > 0 && free < int64(g.config.CriticalFreeGB)*gb:
+		return LevelCritical
+	case g.config.MinFreeGB > 0 && free < int64(g.config.MinFreeGB)*gb:
+		return LevelLow
+	default:
+		return LevelOK
+	}
+}
+
+// notify 空间等级变差时发送通知，空间恢复后重置记录
+func (g *Guard) notify(ctx context.Context, usage Usage) {
+	g.mu.Lock()
+	last, ok := g.levels[usage.Path]
+	g.levels[usage.Path] = usage.Level
+	g.mu.Unlock()
+	if !ok {
+		last = LevelOK
+	}
+	if usage.Level == LevelOK || usage.Level == last || (last == LevelCritical && usage.Level == LevelLow) {
+		return
+	}
+	if err := g.notifier.NoticeStorageLow(ctx, notice.NoticeStorageLowReq{
+		Path:     usage.Path,
+		Free:     usage.Free,
+		Total:    usage.Total,
+		Critical: usage.Level == LevelCritical,
+	}); err != nil {
+		log.Warnf(ctx, "通知磁盘空间不足失败 [%s]: %v", usage.Path, err)
+	}
+}
+
+// diskUsage 获取路径所在磁盘的总空间和可用空间，路径不存在时使用最近的已存在的上级目录
+func diskUsage(path string) (total, free int64, err error) {
+	path, err = existingDir(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, 0, fmt.Errorf("获取磁盘信息失败 [%s]: %w", path, err)
+	}
+	return int64(stat.Blocks) * int64(stat.Bsize), int64(stat.Bavail) * int64(stat.Bsize), nil
+}
+
+func existingDir(path string) (string, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("获取绝对路径失败 [%s]: %w", path, err)
+	}
+	for {
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("获取路径信息失败 [%s]: %w", path, err)
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return path, nil
+		}
+		path = parent
+	}
+}
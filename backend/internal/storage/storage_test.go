@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MangataL/BangumiBuddy/internal/notice"
+)
+
+// 1 EB，任何磁盘的剩余空间都低于该值
+const unreachableGB = 1 << 30
+
+type fakeNotifier struct {
+	notice.Empty
+	reqs []notice.NoticeStorageLowReq
+}
+
+func (f *fakeNotifier) NoticeStorageLow(ctx context.Context, req notice.NoticeStorageLowReq) error {
+	f.reqs = append(f.reqs, req)
+	return nil
+}
+
+func TestGuard_Check(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	testCases := []struct {
+		name      string
+		config    Config
+		wantLevel Level
+		wantErr   bool
+	}{
+		{
+			name:      "when threshold disabled then ok",
+			config:    Config{},
+			wantLevel: LevelOK,
+		},
+		{
+			name:      "when below min free then low",
+			config:    Config{MinFreeGB: unreachableGB},
+			wantLevel: LevelLow,
+			wantErr:   true,
+		},
+		{
+			name:      "when below critical free then critical",
+			config:    Config{MinFreeGB: unreachableGB, CriticalFreeGB: unreachableGB},
+			wantLevel: LevelCritical,
+			wantErr:   true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			notifier := &fakeNotifier{}
+			guard := NewGuard(tc.config, notifier)
+
+			usage, err := guard.Stat(ctx, dir)
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantLevel, usage.Level)
+			assert.Positive(t, usage.Total)
+
+			err = guard.Check(ctx, dir)
+			if tc.wantErr {
+				assert.True(t, errors.Is(err, ErrInsufficientSpace))
+			} else {
+				assert.NoError(t, err)
+			}
+			// 只有定期检查才发送通知
+			assert.Empty(t, notifier.reqs)
+		})
+	}
+}
+
+func TestGuard_StatNotExistPath(t *testing.T) {
+	guard := NewGuard(Config{}, &fakeNotifier{})
+
+	usage, err := guard.Stat(context.Background(), filepath.Join(t.TempDir(), "not", "exist"))
+
+	require.NoError(t, err)
+	assert.Positive(t, usage.Total)
+}
+
+func TestGuard_NotifyOnlyWhenLevelWorsens(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	notifier := &fakeNotifier{}
+	guard := NewGuard(Config{MinFreeGB: unreachableGB}, notifier)
+
+	_, _ = guard.Monitor(ctx, dir)
+	_, _ = guard.Monitor(ctx, dir)
+	require.Len(t, notifier.reqs, 1)
+	assert.False(t, notifier.reqs[0].Critical)
+
+	require.NoError(t, guard.Reload(&Config{MinFreeGB: unreachableGB, CriticalFreeGB: unreachableGB}))
+	_, _ = guard.Monitor(ctx, dir)
+	require.Len(t, notifier.reqs, 2)
+	assert.True(t, notifier.reqs[1].Critical)
+
+	// 空间恢复后再次不足时重新通知
+	require.NoError(t, guard.Reload(&Config{}))
+	_, _ = guard.Monitor(ctx, dir)
+	require.NoError(t, guard.Reload(&Config{MinFreeGB: unreachableGB}))
+	_, _ = guard.Monitor(ctx, dir)
+	assert.Len(t, notifier.reqs, 3)
+}
@@ -0,0 +1,28 @@
+package storage
+
+// Level 剩余空间等级
+type Level string
+
+const (
+	// LevelOK 剩余空间充足
+	LevelOK Level = "ok"
+	// LevelLow 剩余空间不足，拒绝新的下载和转移
+	LevelLow Level = "low"
+	// LevelCritical 剩余空间严重不足，暂停正在下载的种子
+	LevelCritical Level = "critical"
+)
+
+// Usage 磁盘使用情况
+type Usage struct {
+	Path  string `json:"path"`  // 检查的路径
+	Total int64  `json:"total"` // 磁盘总空间，单位 bytes
+	Free  int64  `json:"free"`  // 磁盘可用空间，单位 bytes
+	Level Level  `json:"level"` // 剩余空间等级
+}
+
+// PathUsage 配置路径的磁盘使用情况
+type PathUsage struct {
+	Name string `json:"name"` // 配置项名称
+	Usage
+	Error string `json:"error,omitempty"` // 获取失败时的错误信息
+}
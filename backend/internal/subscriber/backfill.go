@@ -0,0 +1,273 @@
+package subscriber
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/samber/lo"
+
+	"github.com/MangataL/BangumiBuddy/internal/downloader"
+	"github.com/MangataL/BangumiBuddy/internal/notice"
+	"github.com/MangataL/BangumiBuddy/pkg/bangumifile"
+	"github.com/MangataL/BangumiBuddy/pkg/errs"
+	"github.com/MangataL/BangumiBuddy/pkg/log"
+)
+
+// GetBackfillCandidates 获取订阅缺失的剧集和可用于补全的资源
+func (s *Subscriber) GetBackfillCandidates(ctx context.Context, subscriptionID string) ([]BackfillEpisode, error) {
+	bangumi, err := s.repo.Get(ctx, subscriptionID)
+	if err != nil {
+		return nil, fmt.Errorf("获取订阅失败: %w", err)
+	}
+	return s.backfillCandidates(ctx, &bangumi)
+}
+
+// Backfill 下载资源补全订阅缺失的剧集，Auto 为 true 时为每个缺失的集下载排在第一的候选资源
+func (s *Subscriber) Backfill(ctx context.Context, req BackfillReq) (BackfillRsp, error) {
+	bangumi, err := s.repo.Get(ctx, req.SubscriptionID)
+	if err != nil {
+		return BackfillRsp{}, fmt.Errorf("获取订阅失败: %w", err)
+	}
+	downloads := req.Downloads
+	if req.Auto {
+		episodes, err := s.backfillCandidates(ctx, &bangumi)
+		if err != nil {
+			return BackfillRsp{}, err
+		}
+		downloads = nil
+		for _, episode := range episodes {
+			if len(episode.Candidates) == 0 {
+				log.Infof(ctx, "番剧(%s S%d)第%d集没有找到可用于补全的资源", bangumi.Name, bangumi.Season, episode.Episode)
+				continue
+			}
+			candidate := episode.Candidates[0]
+			downloads = append(downloads, BackfillDownload{
+				Episode:     episode.Episode,
+				Title:       candidate.Title,
+				TorrentLink: candidate.TorrentLink,
+			})
+		}
+	}
+
+	savePath, err := s.savePath(&bangumi)
+	if err != nil {
+		return BackfillRsp{}, fmt.Errorf("生成保存路径失败 [%s]: %w", bangumi.Name, err)
+	}
+	rsp := BackfillRsp{
+		Downloaded: []BackfillDownload{},
+		Failures:   []BackfillFailure{},
+	}
+	for _, download := range downloads {
+		if err := s.downloadBackfill(ctx, &bangumi, savePath, download); err != nil {
+			log.Warnf(ctx, "补全第%d集失败: %v", download.Episode, err)
+			rsp.Failures = append(rsp.Failures, BackfillFailure{BackfillDownload: download, Message: err.Error()})
+			continue
+		}
+		rsp.Downloaded = append(rsp.Downloaded, download)
+	}
+	return rsp, nil
+}
+
+// backfillCandidates 搜索订阅缺失剧集的资源，订阅的发布组优先，其余按资源偏好排序
+func (s *Subscriber) backfillCandidates(ctx context.Context, bangumi *Bangumi) ([]BackfillEpisode, error) {
+	if bangumi.AbsoluteEpisode != nil {
+		return nil, errs.NewBadRequest("按绝对集数发布的订阅暂不支持补全缺失剧集")
+	}
+	missing, err := s.missingAiredEpisodes(ctx, bangumi)
+	if err != nil {
+		return nil, err
+	}
+	if len(missing) == 0 {
+		return []BackfillEpisode{}, nil
+	}
+
+	query := bangumi.SearchQuery
+	if query == "" {
+		query = bangumi.Name
+	}
+	items, err := s.searchKeyword(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("搜索资源失败 [%s]: %w", query, err)
+	}
+	preference := s.preference(bangumi)
+	if bangumi.ReleaseGroup != "" {
+		preference.ReleaseGroups = append([]string{bangumi.ReleaseGroup}, preference.ReleaseGroups...)
+	}
+	profile, hasProfile := s.qualityProfile(bangumi.QualityProfile)
+	candidates := make(map[int][]keywordCandidate)
+	for _, item := range items {
+		// 包含规则通常针对原发布组的标题格式，补全时只使用排除规则和标题过滤
+		if !s.matchesFilters(ctx, item.GUID, nil, bangumi.ExcludeRegs, bangumi.TitleFilter) {
+			continue
+		}
+		if hasProfile {
+			if _, ok := profile.score(item); !ok {
+				continue
+			}
+		}
+		bf, err := s.bfParser.Parse(ctx, item.GUID,
+			bangumifile.WithEpisodeLocation(bangumi.EpisodeLocation),
+			bangumifile.WithEpisodeOffset(bangumi.EpisodeOffset),
+		)
+		if err != nil || bf.IsBatch() || bf.IsSpecialEpisode() || !matchSeason(bangumi, bf) || !lo.Contains(missing, bf.Episode) {
+			continue
+		}
+		// 已经下载过的资源不再作为候选
+		processed, err := s.isAlreadyDownloaded(ctx, bangumi.SubscriptionID, item.GUID)
+		if err != nil {
+			return nil, fmt.Errorf("检查下载状态失败 [%s]: %w", item.GUID, err)
+		}
+		if processed {
+			continue
+		}
+		candidates[bf.Episode] = append(candidates[bf.Episode], keywordCandidate{
+			item:    item,
+			episode: bf.Episode,
+			rank:    preference.rank(item),
+			version: releaseVersion(bf),
+		})
+	}
+
+	episodes := make([]BackfillEpisode, 0, len(missing))
+	for _, episode := range missing {
+		sorted := candidates[episode]
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return sorted[i].better(sorted[j])
+		})
+		backfill := BackfillEpisode{Episode: episode, Candidates: make([]BackfillCandidate, 0, len(sorted))}
+		for _, candidate := range sorted {
+			backfill.Candidates = append(backfill.Candidates, BackfillCandidate{
+				Title:            candidate.item.GUID,
+				TorrentLink:      candidate.item.TorrentLink,
+				ReleaseGroup:     candidate.item.ReleaseGroup,
+				PublishedAt:      candidate.item.PublishedAt,
+				SameReleaseGroup: bangumi.ReleaseGroup != "" && candidate.rank[0] == 0,
+			})
+		}
+		episodes = append(episodes, backfill)
+	}
+	return episodes, nil
+}
+
+// missingAiredEpisodes 已播出但媒体库中没有、也没有正在下载的集数
+func (s *Subscriber) missingAiredEpisodes(ctx context.Context, bangumi *Bangumi) ([]int, error) {
+	inLibrary, err := s.library.ListEpisodes(ctx, bangumi.Name, bangumi.Season)
+	if err != nil {
+		return nil, fmt.Errorf("获取媒体库剧集失败: %w", err)
+	}
+	downloading, err := s.downloadingEpisodes(ctx, bangumi)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		missing []int
+		aired   = s.airedEpisodeNum(ctx, bangumi, inLibrary)
+	)
+	for episode := 1; episode <= aired; episode++ {
+		if !lo.Contains(inLibrary, episode) && !lo.Contains(downloading, episode) {
+			missing = append(missing, episode)
+		}
+	}
+	return missing, nil
+}
+
+// airedEpisodeNum 已播出的集数，取TMDB中已到播出日期的集、订阅的最新集数和媒体库中的集数的最大值，不超过总集数
+func (s *Subscriber) airedEpisodeNum(ctx context.Context, bangumi *Bangumi, inLibrary []int) int {
+	aired := max(bangumi.LastAirEpisode, lo.Max(inLibrary))
+	episodes, err := s.metaParser.GetSeasonEpisodes(ctx, bangumi.TMDBID, bangumi.Season)
+	if err != nil {
+		log.Warnf(ctx, "获取番剧(%s S%d)的播出日期失败，按已下载的集数判断缺失的剧集: %v", bangumi.Name, bangumi.Season, err)
+	}
+	today := time.Now().Format(time.DateOnly)
+	for _, episode := range episodes {
+		if episode.AirDate != "" && episode.AirDate <= today {
+			aired = max(aired, episode.Episode)
+		}
+	}
+	if bangumi.EpisodeTotalNum > 0 {
+		aired = min(aired, bangumi.EpisodeTotalNum)
+	}
+	return aired
+}
+
+// downloadingEpisodes 订阅中正在下载或等待转移的集数
+func (s *Subscriber) downloadingEpisodes(ctx context.Context, bangumi *Bangumi) ([]int, error) {
+	torrents, _, err := s.torrentOperator.List(ctx, downloader.TorrentFilter{
+		SubscriptionID: bangumi.SubscriptionID,
+		Statuses: []downloader.TorrentStatus{
+			downloader.TorrentStatusDownloading,
+			downloader.TorrentStatusDownloadPaused,
+			downloader.TorrentStatusDownloaded,
+			downloader.TorrentStatusStalled,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("获取订阅的种子失败: %w", err)
+	}
+	var episodes []int
+	for _, torrent := range torrents {
+		bf, err := s.bfParser.Parse(ctx, torrent.RSSGUID,
+			bangumifile.WithEpisodeLocation(bangumi.EpisodeLocation),
+			bangumifile.WithEpisodeOffset(bangumi.EpisodeOffset),
+		)
+		if err != nil || bf.IsSpecialEpisode() {
+			continue
+		}
+		if bf.IsBatch() {
+			episodes = append(episodes, batchEpisodes(bf)...)
+			continue
+		}
+		episodes = append(episodes, bf.Episode)
+	}
+	return episodes, nil
+}
+
+// downloadBackfill 下载补全资源，并将资源和集数标记为已处理，避免订阅再次下载同一集
+func (s *Subscriber) downloadBackfill(ctx context.Context, bangumi *Bangumi, savePath string, download BackfillDownload) error {
+	item := RSSItem{GUID: download.Title, TorrentLink: download.TorrentLink}
+	hash, err := itemHash(item)
+	if err != nil {
+		return fmt.Errorf("提取哈希值失败 [%s]: %w", item.GUID, err)
+	}
+	req := newDownloadReq(bangumi, savePath, hash, item, download.Episode)
+	profile, hasProfile := s.qualityProfile(bangumi.QualityProfile)
+	if hasProfile {
+		req.QualityScore, _ = profile.score(item)
+	}
+	err = s.downloader.Download(ctx, req)
+	if nerr := s.notifier.NoticeSubscriptionUpdated(ctx, notice.NoticeSubscriptionUpdatedReq{
+		BangumiName:  bangumi.Name,
+		Season:       bangumi.Season,
+		ReleaseGroup: bangumi.ReleaseGroup,
+		RSSGUID:      item.GUID,
+		Poster:       bangumi.PosterURL,
+		Error:        err,
+	}); nerr != nil {
+		log.Warnf(ctx, "通知订阅更新失败 [%s]: %v", item.GUID, nerr)
+	}
+	if err != nil {
+		return fmt.Errorf("下载失败 [%s]: %w", item.GUID, err)
+	}
+	if err := s.rssRecord.MarkProcessed(ctx, bangumi.SubscriptionID, item.GUID, episodeRecordGUID(download.Episode)); err != nil {
+		return fmt.Errorf("标记RSS条目为已处理失败 [%s]: %w", item.GUID, err)
+	}
+	if !hasProfile {
+		log.Infof(ctx, "成功添加补全下载任务 [%s]", item.GUID)
+		return nil
+	}
+	// 质量配置订阅记录补全资源的评分，后续出现更高分的资源时可以洗版
+	bf, _ := s.bfParser.Parse(ctx, item.GUID, bangumifile.IgnoreValidateEpisode())
+	if err := s.episodeQuality.SaveEpisodeQuality(ctx, EpisodeQuality{
+		SubscriptionID: bangumi.SubscriptionID,
+		Episode:        download.Episode,
+		GUID:           item.GUID,
+		Score:          req.QualityScore,
+		Version:        releaseVersion(bf),
+	}); err != nil {
+		return fmt.Errorf("保存第%d集质量记录失败: %w", download.Episode, err)
+	}
+	log.Infof(ctx, "成功添加补全下载任务 [%s]，质量评分 %d", item.GUID, req.QualityScore)
+	return nil
+}
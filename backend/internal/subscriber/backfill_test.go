@@ -0,0 +1,123 @@
+package subscriber
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MangataL/BangumiBuddy/internal/discovery"
+	"github.com/MangataL/BangumiBuddy/internal/downloader"
+	"github.com/MangataL/BangumiBuddy/internal/meta"
+	"github.com/MangataL/BangumiBuddy/internal/notice"
+	"github.com/MangataL/BangumiBuddy/pkg/bangumifile"
+)
+
+func TestSubscriber_Backfill(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	bangumi := Bangumi{
+		SubscriptionID:  "sub-1",
+		Name:            "番剧",
+		Season:          1,
+		TMDBID:          100,
+		ReleaseGroup:    "A",
+		LastAirEpisode:  2,
+		EpisodeTotalNum: 12,
+	}
+	files := map[string]bangumifile.BangumiFile{
+		"[A] 番剧 - 01":    {Season: 1, Episode: 1},
+		"[B] 番剧 - 01":    {Season: 1, Episode: 1},
+		"[A] 番剧 - 02":    {Season: 1, Episode: 2},
+		"[A] 番剧 - 03":    {Season: 1, Episode: 3},
+		"[B] 番剧 - 04":    {Season: 1, Episode: 4},
+		"[C] 番剧 - 04":    {Season: 1, Episode: 4},
+		"[C] 番剧 S2 - 04": {Season: 2, Episode: 4},
+	}
+
+	newSubscriber := func(t *testing.T) (*Subscriber, *MockRSSRecordRepository, *downloader.MockInterface) {
+		ctrl := gomock.NewController(t)
+		repo := NewMockRepository(ctrl)
+		repo.EXPECT().Get(ctx, "sub-1").Return(bangumi, nil)
+		library := NewMockEpisodeLibrary(ctrl)
+		library.EXPECT().ListEpisodes(ctx, "番剧", 1).Return([]int{2}, nil)
+		torrentOperator := downloader.NewMockTorrentOperator(ctrl)
+		torrentOperator.EXPECT().List(ctx, gomock.Any()).Return([]downloader.Torrent{
+			{Hash: "a03", SubscriptionID: "sub-1", RSSGUID: "[A] 番剧 - 03"},
+		}, 1, nil)
+		metaParser := meta.NewMockParser(ctrl)
+		metaParser.EXPECT().GetSeasonEpisodes(ctx, 100, 1).Return([]meta.EpisodeInfo{
+			{Episode: 1, AirDate: "2024-01-01"},
+			{Episode: 2, AirDate: "2024-01-08"},
+			{Episode: 3, AirDate: "2024-01-15"},
+			{Episode: 4, AirDate: "2024-01-22"},
+			{Episode: 5, AirDate: "2999-01-01"},
+		}, nil)
+		bfParser := bangumifile.NewMockParser(ctrl)
+		bfParser.EXPECT().Parse(ctx, gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, title string, opts ...bangumifile.ParserOption) (bangumifile.BangumiFile, error) {
+				return files[title], nil
+			}).AnyTimes()
+		rssRecord := NewMockRSSRecordRepository(ctrl)
+		rssRecord.EXPECT().IsProcessed(ctx, "sub-1", "[B] 番剧 - 04").Return(true, nil)
+		rssRecord.EXPECT().IsProcessed(ctx, "sub-1", gomock.Any()).Return(false, nil).AnyTimes()
+		dl := downloader.NewMockInterface(ctrl)
+		return &Subscriber{
+			repo:            repo,
+			library:         library,
+			torrentOperator: torrentOperator,
+			metaParser:      metaParser,
+			bfParser:        bfParser,
+			rssRecord:       rssRecord,
+			downloader:      dl,
+			notifier:        &notice.Empty{},
+			searcher: &fakeSearcher{resources: []discovery.ResourceCandidate{
+				{Title: "[B] 番剧 - 01", MagnetLink: "magnet:?xt=urn:btih:B01", ReleaseGroup: "B", PublishedAt: &now},
+				{Title: "[A] 番剧 - 01", MagnetLink: "magnet:?xt=urn:btih:A01", ReleaseGroup: "A", PublishedAt: &now},
+				{Title: "[A] 番剧 - 02", MagnetLink: "magnet:?xt=urn:btih:A02", ReleaseGroup: "A", PublishedAt: &now},
+				{Title: "[B] 番剧 - 04", MagnetLink: "magnet:?xt=urn:btih:B04", ReleaseGroup: "B", PublishedAt: &now},
+				{Title: "[C] 番剧 - 04", MagnetLink: "magnet:?xt=urn:btih:C04", ReleaseGroup: "C", PublishedAt: &now},
+				// 其他季的资源不作为候选
+				{Title: "[C] 番剧 S2 - 04", MagnetLink: "magnet:?xt=urn:btih:C204", ReleaseGroup: "C", PublishedAt: &now},
+			}},
+		}, rssRecord, dl
+	}
+
+	t.Run("列出已播出但缺失的剧集和候选资源", func(t *testing.T) {
+		s, _, _ := newSubscriber(t)
+		got, err := s.GetBackfillCandidates(ctx, "sub-1")
+		require.NoError(t, err)
+		assert.Equal(t, []BackfillEpisode{
+			{Episode: 1, Candidates: []BackfillCandidate{
+				{Title: "[A] 番剧 - 01", TorrentLink: "magnet:?xt=urn:btih:A01", ReleaseGroup: "A", PublishedAt: now, SameReleaseGroup: true},
+				{Title: "[B] 番剧 - 01", TorrentLink: "magnet:?xt=urn:btih:B01", ReleaseGroup: "B", PublishedAt: now},
+			}},
+			{Episode: 4, Candidates: []BackfillCandidate{
+				{Title: "[C] 番剧 - 04", TorrentLink: "magnet:?xt=urn:btih:C04", ReleaseGroup: "C", PublishedAt: now},
+			}},
+		}, got)
+	})
+
+	t.Run("自动下载每集排在第一的候选资源", func(t *testing.T) {
+		s, rssRecord, dl := newSubscriber(t)
+		dl.EXPECT().Download(ctx, gomock.Any()).DoAndReturn(func(ctx context.Context, req downloader.DownloadReq) error {
+			if req.Hash == "c04" {
+				return assert.AnError
+			}
+			assert.Equal(t, "a01", req.Hash)
+			return nil
+		}).Times(2)
+		rssRecord.EXPECT().MarkProcessed(ctx, "sub-1", "[A] 番剧 - 01", "episode:1").Return(nil)
+
+		rsp, err := s.Backfill(ctx, BackfillReq{SubscriptionID: "sub-1", Auto: true})
+		require.NoError(t, err)
+		assert.Equal(t, []BackfillDownload{
+			{Episode: 1, Title: "[A] 番剧 - 01", TorrentLink: "magnet:?xt=urn:btih:A01"},
+		}, rsp.Downloaded)
+		require.Len(t, rsp.Failures, 1)
+		assert.Equal(t, 4, rsp.Failures[0].Episode)
+	})
+}
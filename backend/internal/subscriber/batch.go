@@ -0,0 +1,183 @@
+package subscriber
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"github.com/MangataL/BangumiBuddy/internal/downloader"
+	"github.com/MangataL/BangumiBuddy/internal/notice"
+	"github.com/MangataL/BangumiBuddy/pkg/bangumifile"
+	"github.com/MangataL/BangumiBuddy/pkg/log"
+	"github.com/MangataL/BangumiBuddy/pkg/utils"
+)
+
+// batchEpisodes 合集资源包含的所有集数
+func batchEpisodes(bf bangumifile.BangumiFile) []int {
+	episodes := make([]int, 0, bf.EpisodeEnd-bf.Episode+1)
+	for episode := bf.Episode; episode <= bf.EpisodeEnd; episode++ {
+		episodes = append(episodes, episode)
+	}
+	return episodes
+}
+
+// missingEpisodes 返回合集中媒体库没有、也没有被其他资源处理过的集数
+func (s *Subscriber) missingEpisodes(ctx context.Context, bangumi *Bangumi, episodes []int) ([]int, error) {
+	inLibrary, err := s.library.ListEpisodes(ctx, bangumi.Name, bangumi.Season)
+	if err != nil {
+		return nil, fmt.Errorf("获取媒体库剧集失败: %w", err)
+	}
+	var missing []int
+	for _, episode := range episodes {
+		if lo.Contains(inLibrary, episode) {
+			continue
+		}
+		processed, err := s.isAlreadyDownloaded(ctx, bangumi.SubscriptionID, episodeRecordGUID(episode))
+		if err != nil {
+			return nil, fmt.Errorf("检查第%d集下载状态失败: %w", episode, err)
+		}
+		if !processed {
+			missing = append(missing, episode)
+		}
+	}
+	return missing, nil
+}
+
+// handleBatchItem 处理合集资源，只下载媒体库中缺少的集数，并将合集覆盖的所有集标记为已处理，
+// required 为即使已被处理过也需要下载的集数，如下载停滞需要替换资源的集
+func (s *Subscriber) handleBatchItem(ctx context.Context, bangumi *Bangumi, savePath string, item RSSItem, bf bangumifile.BangumiFile, required ...int) error {
+	episodes := batchEpisodes(bf)
+	missing, err := s.missingEpisodes(ctx, bangumi, episodes)
+	if err != nil {
+		return fmt.Errorf("检查合集缺少的剧集失败 [%s]: %w", item.GUID, err)
+	}
+	for _, episode := range required {
+		if lo.Contains(episodes, episode) && !lo.Contains(missing, episode) {
+			missing = append(missing, episode)
+		}
+	}
+	sort.Ints(missing)
+	if len(missing) == 0 {
+		log.Infof(ctx, "合集第%d-%d集已全部在媒体库中，跳过 [%s]", bf.Episode, bf.EpisodeEnd, item.GUID)
+		return s.markBatchProcessed(ctx, bangumi, item, episodes)
+	}
+
+	hash, err := itemHash(item)
+	if err != nil {
+		return fmt.Errorf("提取哈希值失败 [%s]: %w", item.GUID, err)
+	}
+	// 合集中部分剧集已存在时，先不启动下载，选择缺少的剧集文件后再继续
+	partial := len(missing) < len(episodes)
+	req := newDownloadReq(bangumi, savePath, hash, item, bf.Episode)
+	req.NotStart = partial
+	err = s.downloader.Download(ctx, req)
+	if err == nil && partial {
+		err = s.selectBatchFiles(ctx, bangumi, hash, missing)
+	}
+	if nerr := s.notifier.NoticeSubscriptionUpdated(ctx, notice.NoticeSubscriptionUpdatedReq{
+		BangumiName:  bangumi.Name,
+		Season:       bangumi.Season,
+		ReleaseGroup: bangumi.ReleaseGroup,
+		RSSGUID:      item.GUID,
+		Poster:       bangumi.PosterURL,
+		Error:        err,
+	}); nerr != nil {
+		log.Warnf(ctx, "通知订阅更新失败 [%s]: %v", item.GUID, nerr)
+	}
+	if err != nil {
+		return fmt.Errorf("下载失败 [%s]: %w", item.GUID, err)
+	}
+	if err := s.markBatchProcessed(ctx, bangumi, item, episodes); err != nil {
+		return err
+	}
+	log.Infof(ctx, "成功添加合集下载任务 [%s]，下载第 %v 集", item.GUID, missing)
+	return nil
+}
+
+// handleBatchCandidate 处理按集选择资源时遇到的合集，已处理过的合集不再重复处理
+func (s *Subscriber) handleBatchCandidate(ctx context.Context, bangumi *Bangumi, savePath string, item RSSItem, bf bangumifile.BangumiFile) error {
+	processed, err := s.isAlreadyDownloaded(ctx, bangumi.SubscriptionID, item.GUID)
+	if err != nil {
+		return fmt.Errorf("检查下载状态失败 [%s]: %w", item.GUID, err)
+	}
+	if processed {
+		return nil
+	}
+	return s.handleBatchItem(ctx, bangumi, savePath, item, bf)
+}
+
+// selectBatchFiles 只选择合集中缺少的剧集文件下载，字幕等非媒体文件全部下载
+func (s *Subscriber) selectBatchFiles(ctx context.Context, bangumi *Bangumi, hash string, episodes []int) error {
+	var fileNames []string
+	if err := wait.PollUntilContextTimeout(ctx, 500*time.Millisecond, 10*time.Second, true,
+		func(ctx context.Context) (bool, error) {
+			names, err := s.downloader.GetTorrentFileNames(ctx, hash)
+			if err != nil {
+				log.Debugf(ctx, "获取合集文件列表失败，等待重试: %v", err)
+				return false, nil
+			}
+			fileNames = names
+			return len(names) > 0, nil
+		}); err != nil {
+		return fmt.Errorf("获取合集文件列表失败: %w", err)
+	}
+
+	var (
+		selections    = make([]downloader.TorrentFileSelection, 0, len(fileNames))
+		selectedFiles []string
+		mediaSelected bool
+	)
+	for _, fileName := range fileNames {
+		download := true
+		if utils.IsMediaFile(fileName) {
+			bf, err := s.bfParser.Parse(ctx, filepath.Base(fileName),
+				bangumifile.WithEpisodeLocation(bangumi.EpisodeLocation),
+				bangumifile.WithEpisodeOffset(bangumi.EpisodeOffset),
+			)
+			download = err == nil && !bf.IsSpecialEpisode() && lo.Contains(episodes, bf.Episode)
+			mediaSelected = mediaSelected || download
+		}
+		selections = append(selections, downloader.TorrentFileSelection{
+			FileName: fileName,
+			Download: download,
+		})
+		if download {
+			selectedFiles = append(selectedFiles, fileName)
+		}
+	}
+	if !mediaSelected {
+		return errors.New("合集中没有找到缺少剧集的文件")
+	}
+
+	if err := s.downloader.SetTorrentFilePriorities(ctx, hash, selections); err != nil {
+		return fmt.Errorf("设置合集文件下载选择失败: %w", err)
+	}
+	if err := s.torrentOperator.SetTorrentStatus(ctx, hash, downloader.TorrentStatusDownloadPaused, "", &downloader.SetTorrentStatusOptions{
+		SelectedFiles: selectedFiles,
+	}); err != nil {
+		return fmt.Errorf("记录合集文件下载选择失败: %w", err)
+	}
+	// 由下载队列决定何时开始下载
+	if err := s.downloader.ContinueDownload(ctx, hash); err != nil {
+		return fmt.Errorf("继续下载合集失败: %w", err)
+	}
+	return nil
+}
+
+// markBatchProcessed 将合集和它覆盖的所有集标记为已处理
+func (s *Subscriber) markBatchProcessed(ctx context.Context, bangumi *Bangumi, item RSSItem, episodes []int) error {
+	guids := []string{item.GUID}
+	for _, episode := range episodes {
+		guids = append(guids, episodeRecordGUID(episode))
+	}
+	if err := s.rssRecord.MarkProcessed(ctx, bangumi.SubscriptionID, guids...); err != nil {
+		return fmt.Errorf("标记合集为已处理失败 [%s]: %w", item.GUID, err)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package subscriber
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/MangataL/BangumiBuddy/internal/downloader"
+	"github.com/MangataL/BangumiBuddy/internal/notice"
+	"github.com/MangataL/BangumiBuddy/pkg/bangumifile"
+)
+
+func TestSubscriber_HandleBatchItem(t *testing.T) {
+	ctx := context.Background()
+	bangumi := &Bangumi{SubscriptionID: "sub-1", Name: "番剧", Season: 1}
+	item := RSSItem{GUID: "[A] 番剧 [01-04]", TorrentLink: "magnet:?xt=urn:btih:BATCH"}
+	bf := bangumifile.BangumiFile{Episode: 1, EpisodeEnd: 4}
+	markAll := []any{"[A] 番剧 [01-04]", "episode:1", "episode:2", "episode:3", "episode:4"}
+
+	newSubscriber := func(t *testing.T) (*Subscriber, *MockEpisodeLibrary, *MockRSSRecordRepository, *downloader.MockInterface, *downloader.MockTorrentOperator, *bangumifile.MockParser) {
+		ctrl := gomock.NewController(t)
+		library := NewMockEpisodeLibrary(ctrl)
+		rssRecord := NewMockRSSRecordRepository(ctrl)
+		dl := downloader.NewMockInterface(ctrl)
+		torrentOperator := downloader.NewMockTorrentOperator(ctrl)
+		bfParser := bangumifile.NewMockParser(ctrl)
+		return &Subscriber{
+			library:         library,
+			rssRecord:       rssRecord,
+			downloader:      dl,
+			torrentOperator: torrentOperator,
+			bfParser:        bfParser,
+			notifier:        &notice.Empty{},
+		}, library, rssRecord, dl, torrentOperator, bfParser
+	}
+
+	t.Run("只下载媒体库中缺少的剧集", func(t *testing.T) {
+		s, library, rssRecord, dl, torrentOperator, bfParser := newSubscriber(t)
+		library.EXPECT().ListEpisodes(ctx, "番剧", 1).Return([]int{1, 3}, nil)
+		rssRecord.EXPECT().IsProcessed(ctx, "sub-1", "episode:2").Return(false, nil)
+		rssRecord.EXPECT().IsProcessed(ctx, "sub-1", "episode:4").Return(true, nil)
+		dl.EXPECT().Download(ctx, gomock.Any()).DoAndReturn(func(ctx context.Context, req downloader.DownloadReq) error {
+			assert.True(t, req.NotStart)
+			assert.Equal(t, "batch", req.Hash)
+			return nil
+		})
+		dl.EXPECT().GetTorrentFileNames(gomock.Any(), "batch").Return([]string{
+			"番剧/[A] 番剧 - 01.mkv",
+			"番剧/[A] 番剧 - 02.mkv",
+			"番剧/[A] 番剧 - 02.sc.ass",
+			"番剧/[A] 番剧 - 03.mkv",
+		}, nil)
+		bfParser.EXPECT().Parse(ctx, gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, title string, opts ...bangumifile.ParserOption) (bangumifile.BangumiFile, error) {
+				return map[string]bangumifile.BangumiFile{
+					"[A] 番剧 - 01.mkv": {Episode: 1},
+					"[A] 番剧 - 02.mkv": {Episode: 2},
+					"[A] 番剧 - 03.mkv": {Episode: 3},
+				}[title], nil
+			}).Times(3)
+		dl.EXPECT().SetTorrentFilePriorities(ctx, "batch", []downloader.TorrentFileSelection{
+			{FileName: "番剧/[A] 番剧 - 01.mkv", Download: false},
+			{FileName: "番剧/[A] 番剧 - 02.mkv", Download: true},
+			{FileName: "番剧/[A] 番剧 - 02.sc.ass", Download: true},
+			{FileName: "番剧/[A] 番剧 - 03.mkv", Download: false},
+		}).Return(nil)
+		torrentOperator.EXPECT().SetTorrentStatus(ctx, "batch", downloader.TorrentStatusDownloadPaused, "", &downloader.SetTorrentStatusOptions{
+			SelectedFiles: []string{"番剧/[A] 番剧 - 02.mkv", "番剧/[A] 番剧 - 02.sc.ass"},
+		}).Return(nil)
+		dl.EXPECT().ContinueDownload(ctx, "batch").Return(nil)
+		rssRecord.EXPECT().MarkProcessed(ctx, "sub-1", markAll...).Return(nil)
+
+		assert.NoError(t, s.handleBatchItem(ctx, bangumi, "番剧/Season 1", item, bf))
+	})
+
+	t.Run("缺少全部剧集时直接下载", func(t *testing.T) {
+		s, library, rssRecord, dl, _, _ := newSubscriber(t)
+		library.EXPECT().ListEpisodes(ctx, "番剧", 1).Return(nil, nil)
+		rssRecord.EXPECT().IsProcessed(ctx, "sub-1", gomock.Any()).Return(false, nil).Times(4)
+		dl.EXPECT().Download(ctx, gomock.Any()).DoAndReturn(func(ctx context.Context, req downloader.DownloadReq) error {
+			assert.False(t, req.NotStart)
+			return nil
+		})
+		rssRecord.EXPECT().MarkProcessed(ctx, "sub-1", markAll...).Return(nil)
+
+		assert.NoError(t, s.handleBatchItem(ctx, bangumi, "番剧/Season 1", item, bf))
+	})
+
+	t.Run("全部剧集已在媒体库中时跳过", func(t *testing.T) {
+		s, library, rssRecord, _, _, _ := newSubscriber(t)
+		library.EXPECT().ListEpisodes(ctx, "番剧", 1).Return([]int{1, 2, 3, 4}, nil)
+		rssRecord.EXPECT().MarkProcessed(ctx, "sub-1", markAll...).Return(nil)
+
+		assert.NoError(t, s.handleBatchItem(ctx, bangumi, "番剧/Season 1", item, bf))
+	})
+}
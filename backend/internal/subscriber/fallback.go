@@ -0,0 +1,241 @@
+package subscriber
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/MangataL/BangumiBuddy/internal/discovery"
+	"github.com/MangataL/BangumiBuddy/internal/downloader"
+	"github.com/MangataL/BangumiBuddy/internal/notice"
+	"github.com/MangataL/BangumiBuddy/pkg/bangumifile"
+	"github.com/MangataL/BangumiBuddy/pkg/log"
+)
+
+// ResourceSearcher 资源搜索，用于下载停滞时查找同一集的其他资源
+type ResourceSearcher interface {
+	Search(ctx context.Context, req discovery.SearchReq) (discovery.SearchResp, error)
+}
+
+// errNoFallback 没有找到可替代的资源
+var errNoFallback = errors.New("没有找到可替代的资源")
+
+// handleStalledTorrents 为下载停滞的订阅种子切换到其他资源
+func (s *Subscriber) handleStalledTorrents(ctx context.Context) {
+	torrents, _, err := s.torrentOperator.List(ctx, downloader.TorrentFilter{
+		Statuses: []downloader.TorrentStatus{downloader.TorrentStatusStalled},
+	})
+	if err != nil {
+		log.Errorf(ctx, "获取停滞的种子失败: %v", err)
+		return
+	}
+	for _, torrent := range torrents {
+		if torrent.SubscriptionID == "" {
+			continue
+		}
+		if err := s.fallback(ctx, torrent); err != nil {
+			log.Warnf(ctx, "切换停滞种子的下载资源失败 [%s]: %v", torrent.Name, err)
+		}
+	}
+}
+
+// fallback 查找停滞种子同一集的其他资源并下载，成功后移除停滞的种子，原RSS条目保持已处理
+func (s *Subscriber) fallback(ctx context.Context, torrent downloader.Torrent) error {
+	bangumi, err := s.repo.Get(ctx, torrent.SubscriptionID)
+	if err != nil {
+		return fmt.Errorf("获取订阅失败: %w", err)
+	}
+	bf, err := s.bfParser.Parse(ctx, torrent.RSSGUID,
+		bangumifile.WithEpisodeLocation(bangumi.EpisodeLocation),
+		bangumifile.WithEpisodeOffset(bangumi.EpisodeOffset),
+	)
+	if err != nil {
+		return fmt.Errorf("解析集数失败 [%s]: %w", torrent.RSSGUID, err)
+	}
+	if bf.IsSpecialEpisode() {
+		return fmt.Errorf("特别篇无法按集查找其他资源 [%s]", torrent.RSSGUID)
+	}
+
+	guid, err := s.fallbackFromSubscriptions(ctx, bangumi, torrent.Hash, bf.Episode)
+	if errors.Is(err, errNoFallback) {
+		guid, err = s.fallbackFromSearch(ctx, bangumi, torrent.Hash, bf.Episode)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := s.rssRecord.MarkProcessed(ctx, bangumi.SubscriptionID, torrent.RSSGUID); err != nil {
+		return fmt.Errorf("标记RSS条目为已处理失败 [%s]: %w", torrent.RSSGUID, err)
+	}
+	if err := s.downloader.DeleteTorrent(ctx, torrent.Hash); err != nil {
+		return fmt.Errorf("删除停滞的种子失败: %w", err)
+	}
+	log.Infof(ctx, "停滞的种子 [%s] 已切换为 [%s]", torrent.RSSGUID, guid)
+	return nil
+}
+
+// fallbackFromSubscriptions 从同一番剧同一季的其他订阅中查找同一集的资源
+func (s *Subscriber) fallbackFromSubscriptions(ctx context.Context, bangumi Bangumi, stalledHash string, episode int) (string, error) {
+	active := true
+	bangumis, err := s.repo.List(ctx, ListBangumiReq{Active: &active})
+	if err != nil {
+		return "", fmt.Errorf("获取订阅列表失败: %w", err)
+	}
+	for i := range bangumis {
+		other := &bangumis[i]
+		if other.SubscriptionID == bangumi.SubscriptionID ||
+			other.TMDBID != bangumi.TMDBID || other.Season != bangumi.Season {
+			continue
+		}
+		rss, err := s.parseSubscription(ctx, other)
+		if err != nil {
+			log.Warnf(ctx, "%v", err)
+			continue
+		}
+		for _, item := range rss.Items {
+			if !s.matchesFilters(ctx, item.GUID, other.IncludeRegs, other.ExcludeRegs, other.TitleFilter) {
+				continue
+			}
+			bf, err := s.bfParser.Parse(ctx, item.GUID,
+				bangumifile.WithEpisodeLocation(other.EpisodeLocation),
+				bangumifile.WithEpisodeOffset(other.EpisodeOffset),
+			)
+			if err != nil || !coversEpisode(other, bf, episode) {
+				continue
+			}
+			hash, err := itemHash(item)
+			if err != nil || hash == stalledHash {
+				continue
+			}
+			processed, err := s.isAlreadyDownloaded(ctx, other.SubscriptionID, item.GUID)
+			if err != nil {
+				return "", fmt.Errorf("检查下载状态失败 [%s]: %w", item.GUID, err)
+			}
+			// 其他订阅已经下载了这一集
+			if processed {
+				return item.GUID, nil
+			}
+			if err := s.downloadFallback(ctx, other, hash, item, bf, episode); err != nil {
+				return "", err
+			}
+			return item.GUID, nil
+		}
+	}
+	return "", errNoFallback
+}
+
+// fallbackFromSearch 通过资源搜索查找同一集的资源，使用原订阅下载，优先选择订阅的字幕组发布的资源
+func (s *Subscriber) fallbackFromSearch(ctx context.Context, bangumi Bangumi, stalledHash string, episode int) (string, error) {
+	if s.searcher == nil {
+		return "", errNoFallback
+	}
+	resp, err := s.searcher.Search(ctx, discovery.SearchReq{Query: bangumi.Name})
+	if err != nil {
+		return "", fmt.Errorf("搜索资源失败: %w", err)
+	}
+	type candidate struct {
+		resource discovery.ResourceCandidate
+		bf       bangumifile.BangumiFile
+	}
+	candidates := make([]candidate, 0, len(resp.Resources))
+	for _, resource := range resp.Resources {
+		if resource.MagnetLink == "" ||
+			!s.matchesFilters(ctx, resource.Title, bangumi.IncludeRegs, bangumi.ExcludeRegs, bangumi.TitleFilter) {
+			continue
+		}
+		bf, err := s.bfParser.Parse(ctx, resource.Title, bangumifile.WithEpisodeOffset(bangumi.EpisodeOffset))
+		if err != nil || !coversEpisode(&bangumi, bf, episode) {
+			continue
+		}
+		candidates = append(candidates, candidate{resource: resource, bf: bf})
+	}
+	// 订阅的字幕组优先，同一字幕组中单集优先于合集
+	rank := func(c candidate) int {
+		r := 0
+		if bangumi.ReleaseGroup == "" || c.resource.ReleaseGroup != bangumi.ReleaseGroup {
+			r += 2
+		}
+		if c.bf.IsBatch() {
+			r++
+		}
+		return r
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return rank(candidates[i]) < rank(candidates[j])
+	})
+	for _, c := range candidates {
+		resource := c.resource
+		hash, err := extractHashFromTorrentLink(resource.MagnetLink)
+		if err != nil || hash == stalledHash {
+			continue
+		}
+		item := RSSItem{
+			GUID:        resource.Title,
+			TorrentLink: resource.MagnetLink,
+		}
+		if resource.PublishedAt != nil {
+			item.PublishedAt = *resource.PublishedAt
+		}
+		if err := s.downloadFallback(ctx, &bangumi, hash, item, c.bf, episode); err != nil {
+			return "", err
+		}
+		return item.GUID, nil
+	}
+	return "", errNoFallback
+}
+
+// coversEpisode 判断解析后的资源是否包含订阅的季中指定的集数，合集包含该集时也视为包含
+func coversEpisode(bangumi *Bangumi, bf bangumifile.BangumiFile, episode int) bool {
+	if bf.IsSpecialEpisode() || !matchSeason(bangumi, bf) {
+		return false
+	}
+	if bf.IsBatch() {
+		return bf.Episode <= episode && episode <= bf.EpisodeEnd
+	}
+	return bf.Episode == episode
+}
+
+// downloadFallback 下载替代资源并标记为已处理，合集资源交由 handleBatchItem 处理，并确保下载停滞的集
+func (s *Subscriber) downloadFallback(ctx context.Context, bangumi *Bangumi, hash string, item RSSItem, bf bangumifile.BangumiFile, episode int) error {
+	savePath, err := s.savePath(bangumi)
+	if err != nil {
+		return fmt.Errorf("生成保存路径失败 [%s]: %w", bangumi.Name, err)
+	}
+	if bf.IsBatch() {
+		return s.handleBatchItem(ctx, bangumi, savePath, item, bf, episode)
+	}
+	err = s.downloader.Download(ctx, newDownloadReq(bangumi, savePath, hash, item, episode))
+	if nerr := s.notifier.NoticeSubscriptionUpdated(ctx, notice.NoticeSubscriptionUpdatedReq{
+		BangumiName:  bangumi.Name,
+		Season:       bangumi.Season,
+		ReleaseGroup: bangumi.ReleaseGroup,
+		RSSGUID:      item.GUID,
+		Poster:       bangumi.PosterURL,
+		Error:        err,
+	}); nerr != nil {
+		log.Warnf(ctx, "通知订阅更新失败 [%s]: %v", item.GUID, nerr)
+	}
+	if err != nil {
+		return fmt.Errorf("下载替代资源失败 [%s]: %w", item.GUID, err)
+	}
+	if err := s.rssRecord.MarkProcessed(ctx, bangumi.SubscriptionID, item.GUID); err != nil {
+		return fmt.Errorf("标记RSS条目为已处理失败 [%s]: %w", item.GUID, err)
+	}
+	return nil
+}
+
+// extractHashFromMagnet 从磁力链接中提取哈希值
+func extractHashFromMagnet(magnetLink string) (string, error) {
+	u, err := url.Parse(magnetLink)
+	if err != nil {
+		return "", fmt.Errorf("解析磁力链接失败: %w", err)
+	}
+	hash, ok := strings.CutPrefix(strings.ToLower(u.Query().Get("xt")), "urn:btih:")
+	if !ok || hash == "" {
+		return "", fmt.Errorf("磁力链接中没有哈希值: %s", magnetLink)
+	}
+	return hash, nil
+}
@@ -0,0 +1,197 @@
+package subscriber
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/MangataL/BangumiBuddy/internal/discovery"
+	"github.com/MangataL/BangumiBuddy/internal/downloader"
+	"github.com/MangataL/BangumiBuddy/internal/notice"
+	"github.com/MangataL/BangumiBuddy/pkg/bangumifile"
+)
+
+type fakeSearcher struct {
+	resources []discovery.ResourceCandidate
+}
+
+func (f *fakeSearcher) Search(ctx context.Context, req discovery.SearchReq) (discovery.SearchResp, error) {
+	return discovery.SearchResp{Resources: f.resources}, nil
+}
+
+func TestSubscriber_Fallback(t *testing.T) {
+	ctx := context.Background()
+	stalled := downloader.Torrent{Hash: "stalled", SubscriptionID: "sub-1", RSSGUID: "[A] 番剧 - 05"}
+	bangumi := Bangumi{
+		SubscriptionID: "sub-1",
+		Name:           "番剧",
+		TMDBID:         1,
+		Season:         1,
+		ReleaseGroup:   "A",
+		ExcludeRegs:    []string{"720p"},
+		Active:         true,
+	}
+	files := map[string]bangumifile.BangumiFile{
+		"[A] 番剧 - 05":        {Season: 1, Episode: 5},
+		"[A] 番剧 - 05v2":      {Season: 1, Episode: 5, Version: 2},
+		"[A] 番剧 - 05 [720p]": {Season: 1, Episode: 5},
+		"[B] 番剧 - 04":        {Season: 1, Episode: 4},
+		"[B] 番剧 - 05":        {Season: 1, Episode: 5},
+		"[C] 番剧 - 05":        {Season: 1, Episode: 5},
+		"[C] 番剧 S2 - 05":     {Season: 2, Episode: 5},
+	}
+
+	testCases := []struct {
+		name      string
+		others    []Bangumi
+		rss       RSS
+		resources []discovery.ResourceCandidate
+		wantReq   downloader.DownloadReq
+	}{
+		{
+			name:   "其他发布组的订阅有同一集时使用该订阅下载",
+			others: []Bangumi{{SubscriptionID: "sub-2", Name: "番剧", RSSLink: "rss-2", TMDBID: 1, Season: 1}},
+			rss: RSS{Items: []RSSItem{
+				{GUID: "[B] 番剧 - 04", TorrentLink: "https://mikan/b04.torrent"},
+				{GUID: "[B] 番剧 - 05", TorrentLink: "https://mikan/b05.torrent"},
+			}},
+			wantReq: downloader.DownloadReq{
+				TorrentLink:    "https://mikan/b05.torrent",
+				SubscriptionID: "sub-2",
+				Hash:           "b05",
+				RSSGUID:        "[B] 番剧 - 05",
+			},
+		},
+		{
+			name: "没有其他订阅时通过搜索下载",
+			resources: []discovery.ResourceCandidate{
+				{Title: "[A] 番剧 - 05", MagnetLink: "magnet:?xt=urn:btih:stalled"},
+				{Title: "[C] 番剧 - 05", MagnetLink: "magnet:?xt=urn:btih:C05"},
+			},
+			wantReq: downloader.DownloadReq{
+				TorrentLink:    "magnet:?xt=urn:btih:C05",
+				SubscriptionID: "sub-1",
+				Hash:           "c05",
+				RSSGUID:        "[C] 番剧 - 05",
+			},
+		},
+		{
+			name: "搜索时跳过排除的资源和其他季，优先选择订阅的字幕组",
+			resources: []discovery.ResourceCandidate{
+				{Title: "[C] 番剧 S2 - 05", MagnetLink: "magnet:?xt=urn:btih:C205", ReleaseGroup: "C"},
+				{Title: "[C] 番剧 - 05", MagnetLink: "magnet:?xt=urn:btih:C05", ReleaseGroup: "C"},
+				{Title: "[A] 番剧 - 05 [720p]", MagnetLink: "magnet:?xt=urn:btih:A05720", ReleaseGroup: "A"},
+				{Title: "[A] 番剧 - 05v2", MagnetLink: "magnet:?xt=urn:btih:A05V2", ReleaseGroup: "A"},
+			},
+			wantReq: downloader.DownloadReq{
+				TorrentLink:    "magnet:?xt=urn:btih:A05V2",
+				SubscriptionID: "sub-1",
+				Hash:           "a05v2",
+				RSSGUID:        "[A] 番剧 - 05v2",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repo := NewMockRepository(ctrl)
+			rssParser := NewMockRSSParser(ctrl)
+			rssRecord := NewMockRSSRecordRepository(ctrl)
+			bfParser := bangumifile.NewMockParser(ctrl)
+			dl := downloader.NewMockInterface(ctrl)
+			s := &Subscriber{
+				repo:       repo,
+				rssParser:  rssParser,
+				rssRecord:  rssRecord,
+				bfParser:   bfParser,
+				downloader: dl,
+				notifier:   &notice.Empty{},
+				searcher:   &fakeSearcher{resources: tc.resources},
+			}
+
+			bfParser.EXPECT().Parse(gomock.Any(), gomock.Any(), gomock.Any()).
+				DoAndReturn(func(ctx context.Context, title string, opts ...bangumifile.ParserOption) (bangumifile.BangumiFile, error) {
+					return files[title], nil
+				}).AnyTimes()
+			repo.EXPECT().Get(ctx, "sub-1").Return(bangumi, nil)
+			repo.EXPECT().List(ctx, gomock.Any()).Return(append([]Bangumi{bangumi}, tc.others...), nil)
+			for _, other := range tc.others {
+				rssParser.EXPECT().Parse(ctx, other.RSSLink).Return(tc.rss, nil)
+				rssRecord.EXPECT().IsProcessed(ctx, other.SubscriptionID, gomock.Any()).Return(false, nil)
+			}
+			dl.EXPECT().Download(ctx, gomock.Any()).DoAndReturn(func(ctx context.Context, req downloader.DownloadReq) error {
+				assert.Equal(t, tc.wantReq.TorrentLink, req.TorrentLink)
+				assert.Equal(t, tc.wantReq.SubscriptionID, req.SubscriptionID)
+				assert.Equal(t, tc.wantReq.Hash, req.Hash)
+				assert.Equal(t, tc.wantReq.RSSGUID, req.RSSGUID)
+				return nil
+			})
+			rssRecord.EXPECT().MarkProcessed(ctx, tc.wantReq.SubscriptionID, tc.wantReq.RSSGUID).Return(nil)
+			rssRecord.EXPECT().MarkProcessed(ctx, "sub-1", stalled.RSSGUID).Return(nil)
+			dl.EXPECT().DeleteTorrent(ctx, "stalled").Return(nil)
+
+			assert.NoError(t, s.fallback(ctx, stalled))
+		})
+	}
+}
+
+func TestSubscriber_FallbackToBatch(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	repo := NewMockRepository(ctrl)
+	rssRecord := NewMockRSSRecordRepository(ctrl)
+	library := NewMockEpisodeLibrary(ctrl)
+	bfParser := bangumifile.NewMockParser(ctrl)
+	dl := downloader.NewMockInterface(ctrl)
+	torrentOperator := downloader.NewMockTorrentOperator(ctrl)
+	s := &Subscriber{
+		repo:            repo,
+		rssRecord:       rssRecord,
+		library:         library,
+		bfParser:        bfParser,
+		downloader:      dl,
+		torrentOperator: torrentOperator,
+		notifier:        &notice.Empty{},
+		searcher: &fakeSearcher{resources: []discovery.ResourceCandidate{
+			{Title: "[C] 番剧 [04-05]", MagnetLink: "magnet:?xt=urn:btih:C0405", ReleaseGroup: "C"},
+		}},
+	}
+	stalled := downloader.Torrent{Hash: "stalled", SubscriptionID: "sub-1", RSSGUID: "[A] 番剧 - 05"}
+	bangumi := Bangumi{SubscriptionID: "sub-1", Name: "番剧", TMDBID: 1, Season: 1, Active: true}
+	files := map[string]bangumifile.BangumiFile{
+		"[A] 番剧 - 05":     {Season: 1, Episode: 5},
+		"[C] 番剧 [04-05]":  {Season: 1, Episode: 4, EpisodeEnd: 5},
+		"[C] 番剧 - 04.mkv": {Season: 1, Episode: 4},
+		"[C] 番剧 - 05.mkv": {Season: 1, Episode: 5},
+	}
+	bfParser.EXPECT().Parse(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, title string, opts ...bangumifile.ParserOption) (bangumifile.BangumiFile, error) {
+			return files[title], nil
+		}).AnyTimes()
+	repo.EXPECT().Get(ctx, "sub-1").Return(bangumi, nil)
+	repo.EXPECT().List(ctx, gomock.Any()).Return([]Bangumi{bangumi}, nil)
+
+	// 停滞的集已被标记为处理过，仍需要从合集中下载
+	library.EXPECT().ListEpisodes(ctx, "番剧", 1).Return([]int{4}, nil)
+	rssRecord.EXPECT().IsProcessed(ctx, "sub-1", "episode:5").Return(true, nil)
+	dl.EXPECT().Download(ctx, gomock.Any()).DoAndReturn(func(ctx context.Context, req downloader.DownloadReq) error {
+		assert.True(t, req.NotStart)
+		assert.Equal(t, "c0405", req.Hash)
+		return nil
+	})
+	dl.EXPECT().GetTorrentFileNames(gomock.Any(), "c0405").Return([]string{"[C] 番剧 - 04.mkv", "[C] 番剧 - 05.mkv"}, nil)
+	dl.EXPECT().SetTorrentFilePriorities(ctx, "c0405", []downloader.TorrentFileSelection{
+		{FileName: "[C] 番剧 - 04.mkv", Download: false},
+		{FileName: "[C] 番剧 - 05.mkv", Download: true},
+	}).Return(nil)
+	torrentOperator.EXPECT().SetTorrentStatus(ctx, "c0405", downloader.TorrentStatusDownloadPaused, "", gomock.Any()).Return(nil)
+	dl.EXPECT().ContinueDownload(ctx, "c0405").Return(nil)
+	rssRecord.EXPECT().MarkProcessed(ctx, "sub-1", "[C] 番剧 [04-05]", "episode:4", "episode:5").Return(nil)
+	rssRecord.EXPECT().MarkProcessed(ctx, "sub-1", stalled.RSSGUID).Return(nil)
+	dl.EXPECT().DeleteTorrent(ctx, "stalled").Return(nil)
+
+	assert.NoError(t, s.fallback(ctx, stalled))
+}
@@ -0,0 +1,56 @@
+package subscriber
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/MangataL/BangumiBuddy/pkg/bangumifile"
+)
+
+// match 检查解析后的标题是否符合过滤条件，不符合时返回原因
+func (f TitleFilter) match(bf bangumifile.BangumiFile) (string, bool) {
+	if f.ExcludeBatch && bf.IsBatch() {
+		return "合集", false
+	}
+	if f.ExcludeSpecial && bf.IsSpecial() {
+		return bf.Special, false
+	}
+	for _, cond := range []struct {
+		name    string
+		allowed []string
+		value   string
+	}{
+		{"分辨率", f.Resolutions, bf.Resolution},
+		{"视频编码", f.VideoCodecs, bf.VideoCodec},
+		{"片源", f.Sources, bf.Source},
+		{"字幕形式", f.SubtitleTypes, bf.SubtitleType},
+	} {
+		if cond.value != "" && len(cond.allowed) > 0 && !containsFold(cond.allowed, cond.value) {
+			return fmt.Sprintf("%s %s", cond.name, cond.value), false
+		}
+	}
+	if len(bf.SubtitleLanguages) > 0 {
+		for _, language := range f.SubtitleLanguages {
+			if !containsFold(bf.SubtitleLanguages, language) {
+				return fmt.Sprintf("缺少%s字幕", language), false
+			}
+		}
+	}
+	return "", true
+}
+
+// matchSeason 检查资源标题的季数是否为订阅的季，标题没有标注季数时解析为第1季，
+// 按绝对集数发布的资源通常不标注季数，不做检查
+func matchSeason(bangumi *Bangumi, bf bangumifile.BangumiFile) bool {
+	return bangumi.AbsoluteEpisode != nil || bf.Season == bangumi.Season
+}
+
+// containsFold 忽略大小写检查列表中是否包含指定值
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package subscriber
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/MangataL/BangumiBuddy/pkg/bangumifile"
+)
+
+func TestTitleFilter_Match(t *testing.T) {
+	filter := TitleFilter{
+		Resolutions:       []string{"1080p"},
+		SubtitleLanguages: []string{"简"},
+		ExcludeBatch:      true,
+		ExcludeSpecial:    true,
+	}
+
+	testCases := []struct {
+		name string
+		bf   bangumifile.BangumiFile
+		want bool
+	}{
+		{
+			name: "符合条件",
+			bf:   bangumifile.BangumiFile{Episode: 1, Resolution: "1080P", SubtitleLanguages: []string{"简", "日"}},
+			want: true,
+		},
+		{
+			name: "未识别的信息不参与过滤",
+			bf:   bangumifile.BangumiFile{Episode: 1},
+			want: true,
+		},
+		{
+			name: "分辨率不符",
+			bf:   bangumifile.BangumiFile{Episode: 1, Resolution: "720p"},
+			want: false,
+		},
+		{
+			name: "缺少字幕语言",
+			bf:   bangumifile.BangumiFile{Episode: 1, SubtitleLanguages: []string{"繁"}},
+			want: false,
+		},
+		{
+			name: "排除合集",
+			bf:   bangumifile.BangumiFile{Episode: 1, EpisodeEnd: 12},
+			want: false,
+		},
+		{
+			name: "排除特殊资源",
+			bf:   bangumifile.BangumiFile{Episode: 1, Special: "NCOP"},
+			want: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, got := filter.match(tc.bf)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
@@ -9,7 +9,10 @@ import (
 
 //go:generate mockgen -destination interface_mock.go -source $GOFILE -package $GOPACKAGE
 
-var ErrSubscriberNotFound = errs.NewNotFound("番剧未找到")
+var (
+	ErrSubscriberNotFound     = errs.NewNotFound("番剧未找到")
+	ErrEpisodeQualityNotFound = errs.NewNotFound("单集质量记录未找到")
+)
 
 // Interface 服务层
 type Interface interface {
@@ -43,4 +46,8 @@ type Interface interface {
 	StopSubscription(ctx context.Context, id string) error
 	// AutoStopSubscription 是否自动停止订阅
 	AutoStopSubscription(ctx context.Context, id string) bool
+	// GetBackfillCandidates 获取订阅缺失的剧集和可用于补全的资源
+	GetBackfillCandidates(ctx context.Context, subscriptionID string) ([]BackfillEpisode, error)
+	// Backfill 下载资源补全订阅缺失的剧集
+	Backfill(ctx context.Context, req BackfillReq) (BackfillRsp, error)
 }
@@ -49,6 +49,21 @@ func (mr *MockInterfaceMockRecorder) AutoStopSubscription(ctx, id interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AutoStopSubscription", reflect.TypeOf((*MockInterface)(nil).AutoStopSubscription), ctx, id)
 }
 
+// Backfill mocks base method.
+func (m *MockInterface) Backfill(ctx context.Context, req BackfillReq) (BackfillRsp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Backfill", ctx, req)
+	ret0, _ := ret[0].(BackfillRsp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Backfill indicates an expected call of Backfill.
+func (mr *MockInterfaceMockRecorder) Backfill(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Backfill", reflect.TypeOf((*MockInterface)(nil).Backfill), ctx, req)
+}
+
 // DeleteSubscription mocks base method.
 func (m *MockInterface) DeleteSubscription(ctx context.Context, id string) error {
 	m.ctrl.T.Helper()
@@ -78,6 +93,21 @@ func (mr *MockInterfaceMockRecorder) Get(ctx, id interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockInterface)(nil).Get), ctx, id)
 }
 
+// GetBackfillCandidates mocks base method.
+func (m *MockInterface) GetBackfillCandidates(ctx context.Context, subscriptionID string) ([]BackfillEpisode, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBackfillCandidates", ctx, subscriptionID)
+	ret0, _ := ret[0].([]BackfillEpisode)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBackfillCandidates indicates an expected call of GetBackfillCandidates.
+func (mr *MockInterfaceMockRecorder) GetBackfillCandidates(ctx, subscriptionID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBackfillCandidates", reflect.TypeOf((*MockInterface)(nil).GetBackfillCandidates), ctx, subscriptionID)
+}
+
 // GetRSSMatch mocks base method.
 func (m *MockInterface) GetRSSMatch(ctx context.Context, subscriptionID string) ([]RSSMatch, error) {
 	m.ctrl.T.Helper()
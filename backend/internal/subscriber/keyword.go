@@ -0,0 +1,279 @@
+package subscriber
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+
+	"github.com/MangataL/BangumiBuddy/internal/discovery"
+	"github.com/MangataL/BangumiBuddy/internal/notice"
+	"github.com/MangataL/BangumiBuddy/pkg/bangumifile"
+	"github.com/MangataL/BangumiBuddy/pkg/log"
+)
+
+const (
+	// keywordSearchPageSize 关键词订阅每次搜索获取的资源数量
+	keywordSearchPageSize = 100
+	// searchQueryPlaceholder RSS搜索链接模板中的关键词占位符
+	searchQueryPlaceholder = "{query}"
+)
+
+// keywordRSSLink 关键词订阅没有RSS链接，生成唯一的链接标识订阅
+func keywordRSSLink(tmdbID, season int, query string) string {
+	return fmt.Sprintf("keyword://%d/%d?q=%s", tmdbID, season, url.QueryEscape(strings.TrimSpace(query)))
+}
+
+// episodeRecordGUID 关键词订阅按集记录处理状态，避免同一集下载多个资源
+func episodeRecordGUID(episode int) string {
+	return fmt.Sprintf("episode:%d", episode)
+}
+
+// parseSubscription 获取订阅的资源列表，RSS订阅解析RSS链接，关键词订阅聚合所有搜索源
+func (s *Subscriber) parseSubscription(ctx context.Context, bangumi *Bangumi) (RSS, error) {
+	if bangumi.IsKeyword() {
+		items, err := s.searchKeyword(ctx, bangumi.SearchQuery)
+		if err != nil {
+			return RSS{}, fmt.Errorf("搜索资源失败 [%s]: %w", bangumi.Name, err)
+		}
+		return RSS{BangumiName: bangumi.Name, Season: bangumi.Season, Items: items}, nil
+	}
+	rss, err := s.rssParser.Parse(ctx, bangumi.RSSLink)
+	if err != nil {
+		return RSS{}, fmt.Errorf("解析RSS失败 [%s]: %w", bangumi.Name, err)
+	}
+	return rss, nil
+}
+
+// searchKeyword 从蜜柑计划搜索和配置的RSS搜索源中搜索资源，按种子哈希值去重
+func (s *Subscriber) searchKeyword(ctx context.Context, query string) ([]RSSItem, error) {
+	var (
+		items  []RSSItem
+		errs   *multierror.Error
+		hashes = make(map[string]struct{})
+	)
+	add := func(item RSSItem) {
+		hash, err := itemHash(item)
+		if err != nil {
+			return
+		}
+		if _, ok := hashes[hash]; ok {
+			return
+		}
+		hashes[hash] = struct{}{}
+		item.Hash = hash
+		items = append(items, item)
+	}
+
+	if s.searcher != nil {
+		resp, err := s.searcher.Search(ctx, discovery.SearchReq{Query: query, PageSize: keywordSearchPageSize})
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("蜜柑计划搜索失败: %w", err))
+		}
+		for _, resource := range resp.Resources {
+			if resource.MagnetLink == "" {
+				continue
+			}
+			item := RSSItem{
+				GUID:         resource.Title,
+				TorrentLink:  resource.MagnetLink,
+				ReleaseGroup: resource.ReleaseGroup,
+			}
+			if resource.PublishedAt != nil {
+				item.PublishedAt = *resource.PublishedAt
+			}
+			add(item)
+		}
+	}
+	for _, feed := range s.config.SearchFeeds {
+		link := strings.ReplaceAll(feed, searchQueryPlaceholder, url.QueryEscape(query))
+		rss, err := s.rssParser.Parse(ctx, link)
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("RSS搜索失败 [%s]: %w", link, err))
+			continue
+		}
+		for _, item := range rss.Items {
+			add(item)
+		}
+	}
+	// 部分搜索源失败时仍使用其他搜索源的结果
+	if len(items) == 0 {
+		return nil, errs.ErrorOrNil()
+	}
+	if err := errs.ErrorOrNil(); err != nil {
+		log.Warnf(ctx, "部分搜索源搜索失败: %v", err)
+	}
+	return items, nil
+}
+
+// keywordCandidate 关键词订阅单集的候选资源
+type keywordCandidate struct {
+	item    RSSItem
+	episode int
+	rank    []int
+	version int
+	bf      bangumifile.BangumiFile
+}
+
+// better 判断候选资源是否优于另一个，偏好相同时选择版本更高、较新的资源
+func (c keywordCandidate) better(other keywordCandidate) bool {
+	for i := range c.rank {
+		if c.rank[i] != other.rank[i] {
+			return c.rank[i] < other.rank[i]
+		}
+	}
+	if c.version != other.version {
+		return c.version > other.version
+	}
+	return c.item.PublishedAt.After(other.item.PublishedAt)
+}
+
+// rank 计算资源在偏好中的排名，依次比较发布组、分辨率、字幕语言
+func (p Preference) rank(item RSSItem) []int {
+	releaseGroup := item.ReleaseGroup
+	if releaseGroup == "" {
+		releaseGroup = item.GUID
+	}
+	return []int{
+		preferenceRank(p.ReleaseGroups, releaseGroup),
+		preferenceRank(p.Resolutions, item.GUID),
+		preferenceRank(p.SubtitleLanguages, item.GUID),
+	}
+}
+
+// preferenceRank 返回文本匹配的第一个偏好的位置，都不匹配时排在最后
+func preferenceRank(preferences []string, text string) int {
+	text = strings.ToLower(text)
+	for i, preference := range preferences {
+		if preference != "" && strings.Contains(text, strings.ToLower(preference)) {
+			return i
+		}
+	}
+	return len(preferences)
+}
+
+// preference 获取订阅使用的资源偏好，订阅未单独设置时使用全局配置
+func (s *Subscriber) preference(bangumi *Bangumi) Preference {
+	if bangumi.Preference != nil {
+		return *bangumi.Preference
+	}
+	return s.config.Preference
+}
+
+// handleKeywordSubscription 聚合搜索源的资源，每集按偏好选择最优的资源下载，设置了质量配置时按质量评分选择
+func (s *Subscriber) handleKeywordSubscription(ctx context.Context, bangumi *Bangumi) error {
+	items, err := s.searchKeyword(ctx, bangumi.SearchQuery)
+	if err != nil {
+		return fmt.Errorf("搜索资源失败 [%s]: %w", bangumi.Name, err)
+	}
+	savePath, err := s.savePath(bangumi)
+	if err != nil {
+		return fmt.Errorf("生成保存路径失败 [%s]: %w", bangumi.Name, err)
+	}
+	if profile, ok := s.qualityProfile(bangumi.QualityProfile); ok {
+		return s.handleQualitySubscription(ctx, bangumi, savePath, items, profile)
+	}
+
+	preference := s.preference(bangumi)
+	var (
+		best    = make(map[int]keywordCandidate)
+		batches []keywordCandidate
+	)
+	for _, item := range items {
+		if !s.matchesFilters(ctx, item.GUID, bangumi.IncludeRegs, bangumi.ExcludeRegs, bangumi.TitleFilter) {
+			continue
+		}
+		bf, err := s.bfParser.Parse(ctx, item.GUID,
+			bangumifile.WithEpisodeLocation(bangumi.EpisodeLocation),
+			bangumifile.WithEpisodeOffset(bangumi.EpisodeOffset),
+		)
+		if err != nil {
+			log.Debugf(ctx, "无法解析集数，跳过 [%s]: %v", item.GUID, err)
+			continue
+		}
+		if bf.IsSpecialEpisode() {
+			log.Debugf(ctx, "特别篇不参与按集选择资源，跳过 [%s]", item.GUID)
+			continue
+		}
+		if !matchSeason(bangumi, bf) {
+			log.Debugf(ctx, "资源为第%d季，与订阅的季不符，跳过 [%s]", bf.Season, item.GUID)
+			continue
+		}
+		candidate := keywordCandidate{item: item, episode: bf.Episode, rank: preference.rank(item), version: releaseVersion(bf), bf: bf}
+		if bf.IsBatch() {
+			batches = append(batches, candidate)
+			continue
+		}
+		if current, ok := best[bf.Episode]; !ok || candidate.better(current) {
+			best[bf.Episode] = candidate
+		}
+	}
+
+	episodes := make([]int, 0, len(best))
+	for episode := range best {
+		episodes = append(episodes, episode)
+	}
+	sort.Ints(episodes)
+
+	var errs *multierror.Error
+	for _, episode := range episodes {
+		candidate := best[episode]
+		recordGUID := episodeRecordGUID(episode)
+		downloaded, err := s.isAlreadyDownloaded(ctx, bangumi.SubscriptionID, recordGUID)
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("检查下载状态失败 [%s]: %w", recordGUID, err))
+			continue
+		}
+		if downloaded {
+			// 已下载的集只在出现未处理过的修正版本时重新下载
+			if candidate.version <= 1 {
+				continue
+			}
+			processed, err := s.isAlreadyDownloaded(ctx, bangumi.SubscriptionID, candidate.item.GUID)
+			if err != nil {
+				errs = multierror.Append(errs, fmt.Errorf("检查下载状态失败 [%s]: %w", candidate.item.GUID, err))
+				continue
+			}
+			if processed {
+				continue
+			}
+			log.Infof(ctx, "第%d集发现修正版本(v%d)，开始下载 [%s]", episode, candidate.version, candidate.item.GUID)
+		}
+
+		item := candidate.item
+		err = s.downloader.Download(ctx, newDownloadReq(bangumi, savePath, item.Hash, item, episode))
+		if nerr := s.notifier.NoticeSubscriptionUpdated(ctx, notice.NoticeSubscriptionUpdatedReq{
+			BangumiName:  bangumi.Name,
+			Season:       bangumi.Season,
+			ReleaseGroup: item.ReleaseGroup,
+			RSSGUID:      item.GUID,
+			Poster:       bangumi.PosterURL,
+			Error:        err,
+		}); nerr != nil {
+			log.Warnf(ctx, "通知订阅更新失败 [%s]: %v", item.GUID, nerr)
+		}
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("下载失败 [%s]: %w", item.GUID, err))
+			continue
+		}
+		if err := s.rssRecord.MarkProcessed(ctx, bangumi.SubscriptionID, item.GUID, recordGUID); err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("标记第%d集为已处理失败: %w", episode, err))
+			continue
+		}
+		log.Infof(ctx, "关键词订阅成功添加第%d集下载任务 [%s]", episode, item.GUID)
+	}
+
+	// 单集资源优先，合集只下载单集资源没有覆盖的集数
+	sort.SliceStable(batches, func(i, j int) bool {
+		return batches[i].better(batches[j])
+	})
+	for _, batch := range batches {
+		if err := s.handleBatchCandidate(ctx, bangumi, savePath, batch.item, batch.bf); err != nil {
+			errs = multierror.Append(errs, err)
+		}
+	}
+	return errs.ErrorOrNil()
+}
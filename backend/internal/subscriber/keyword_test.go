@@ -0,0 +1,140 @@
+package subscriber
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/MangataL/BangumiBuddy/internal/discovery"
+	"github.com/MangataL/BangumiBuddy/internal/downloader"
+	"github.com/MangataL/BangumiBuddy/internal/notice"
+	"github.com/MangataL/BangumiBuddy/pkg/bangumifile"
+)
+
+func TestPreference_Rank(t *testing.T) {
+	preference := Preference{
+		ReleaseGroups:     []string{"LoliHouse", "Nekomoe"},
+		Resolutions:       []string{"1080p", "720p"},
+		SubtitleLanguages: []string{"简", "繁"},
+	}
+
+	testCases := []struct {
+		name string
+		item RSSItem
+		want []int
+	}{
+		{
+			name: "全部匹配首选偏好",
+			item: RSSItem{GUID: "[LoliHouse] 番剧 - 01 [1080p][简繁内封]", ReleaseGroup: "LoliHouse"},
+			want: []int{0, 0, 0},
+		},
+		{
+			name: "没有发布组时从标题匹配",
+			item: RSSItem{GUID: "[Nekomoe kissaten] 番剧 - 01 [720p][繁日]"},
+			want: []int{1, 1, 1},
+		},
+		{
+			name: "都不匹配时排在最后",
+			item: RSSItem{GUID: "[Other] 番剧 - 01 [2160p]", ReleaseGroup: "Other"},
+			want: []int{2, 2, 2},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, preference.rank(tc.item))
+		})
+	}
+}
+
+func TestSubscriber_HandleKeywordSubscription(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	ctrl := gomock.NewController(t)
+	rssRecord := NewMockRSSRecordRepository(ctrl)
+	library := NewMockEpisodeLibrary(ctrl)
+	bfParser := bangumifile.NewMockParser(ctrl)
+	dl := downloader.NewMockInterface(ctrl)
+	s := &Subscriber{
+		rssRecord:  rssRecord,
+		library:    library,
+		bfParser:   bfParser,
+		downloader: dl,
+		notifier:   &notice.Empty{},
+		searcher: &fakeSearcher{resources: []discovery.ResourceCandidate{
+			{Title: "[B] 番剧 - 01 [720p]", MagnetLink: "magnet:?xt=urn:btih:B01", ReleaseGroup: "B", PublishedAt: &now},
+			{Title: "[A] 番剧 - 01 [1080p]", MagnetLink: "magnet:?xt=urn:btih:A01", ReleaseGroup: "A", PublishedAt: &now},
+			{Title: "[A] 番剧 - 01 [1080p]", MagnetLink: "magnet:?xt=urn:btih:A01", ReleaseGroup: "A", PublishedAt: &now},
+			{Title: "[B] 番剧 - 02 [1080p]", MagnetLink: "magnet:?xt=urn:btih:B02", ReleaseGroup: "B", PublishedAt: &now},
+			{Title: "[A] 番剧 - 03 [1080p]", MagnetLink: "magnet:?xt=urn:btih:A03", ReleaseGroup: "A", PublishedAt: &now},
+			{Title: "[A] 番剧 - 04 [1080p]", MagnetLink: "magnet:?xt=urn:btih:A04", ReleaseGroup: "A", PublishedAt: &now},
+			{Title: "[A] 番剧 - 04v2 [1080p]", MagnetLink: "magnet:?xt=urn:btih:A04V2", ReleaseGroup: "A", PublishedAt: &now},
+			{Title: "[A] 番剧 - 合集", MagnetLink: "magnet:?xt=urn:btih:ALL", ReleaseGroup: "A", PublishedAt: &now},
+			{Title: "[A] 番剧 [01-04]", MagnetLink: "magnet:?xt=urn:btih:A0104", ReleaseGroup: "A", PublishedAt: &now},
+			{Title: "[A] 番剧 S2 - 05 [1080p]", MagnetLink: "magnet:?xt=urn:btih:A205", ReleaseGroup: "A", PublishedAt: &now},
+		}},
+	}
+	bangumi := &Bangumi{
+		SubscriptionID: "sub-1",
+		Name:           "番剧",
+		Season:         1,
+		Kind:           SubscriptionKindKeyword,
+		SearchQuery:    "番剧",
+		Preference:     &Preference{ReleaseGroups: []string{"A", "B"}},
+	}
+	episodes := map[string]int{
+		"[B] 番剧 - 01 [720p]":  1,
+		"[A] 番剧 - 01 [1080p]": 1,
+		"[B] 番剧 - 02 [1080p]": 2,
+		"[A] 番剧 - 03 [1080p]": 3,
+		"[A] 番剧 - 04 [1080p]": 4,
+		// 已下载的第4集出现修正版本
+		"[A] 番剧 - 04v2 [1080p]": 4,
+	}
+
+	bfParser.EXPECT().Parse(ctx, gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, title string, opts ...bangumifile.ParserOption) (bangumifile.BangumiFile, error) {
+			switch title {
+			case "[A] 番剧 [01-04]":
+				return bangumifile.BangumiFile{Season: 1, Episode: 1, EpisodeEnd: 4}, nil
+			case "[A] 番剧 S2 - 05 [1080p]":
+				// 其他季的资源不参与选择
+				return bangumifile.BangumiFile{Season: 2, Episode: 5}, nil
+			}
+			episode, ok := episodes[title]
+			if !ok {
+				return bangumifile.BangumiFile{}, assert.AnError
+			}
+			version := 1
+			if strings.Contains(title, "v2") {
+				version = 2
+			}
+			return bangumifile.BangumiFile{Season: 1, Episode: episode, Version: version}, nil
+		}).AnyTimes()
+	rssRecord.EXPECT().IsProcessed(ctx, "sub-1", "episode:1").Return(false, nil)
+	rssRecord.EXPECT().IsProcessed(ctx, "sub-1", "episode:2").Return(false, nil)
+	rssRecord.EXPECT().IsProcessed(ctx, "sub-1", "episode:3").Return(true, nil)
+	rssRecord.EXPECT().IsProcessed(ctx, "sub-1", "episode:4").Return(true, nil)
+	rssRecord.EXPECT().IsProcessed(ctx, "sub-1", "[A] 番剧 - 04v2 [1080p]").Return(false, nil)
+
+	var downloaded []string
+	dl.EXPECT().Download(ctx, gomock.Any()).DoAndReturn(func(ctx context.Context, req downloader.DownloadReq) error {
+		downloaded = append(downloaded, req.Hash)
+		assert.Equal(t, "sub-1", req.SubscriptionID)
+		return nil
+	}).Times(3)
+	rssRecord.EXPECT().MarkProcessed(ctx, "sub-1", "[A] 番剧 - 01 [1080p]", "episode:1").Return(nil)
+	rssRecord.EXPECT().MarkProcessed(ctx, "sub-1", "[B] 番剧 - 02 [1080p]", "episode:2").Return(nil)
+	rssRecord.EXPECT().MarkProcessed(ctx, "sub-1", "[A] 番剧 - 04v2 [1080p]", "episode:4").Return(nil)
+	// 合集覆盖的集数都已下载，只标记为已处理
+	rssRecord.EXPECT().IsProcessed(ctx, "sub-1", "[A] 番剧 [01-04]").Return(false, nil)
+	library.EXPECT().ListEpisodes(ctx, "番剧", 1).Return([]int{1, 2, 3, 4}, nil)
+	rssRecord.EXPECT().MarkProcessed(ctx, "sub-1", "[A] 番剧 [01-04]", "episode:1", "episode:2", "episode:3", "episode:4").Return(nil)
+
+	assert.NoError(t, s.handleKeywordSubscription(ctx, bangumi))
+	assert.Equal(t, []string{"a01", "b02", "a04v2"}, downloaded)
+}
@@ -0,0 +1,258 @@
+package subscriber
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+
+	"github.com/MangataL/BangumiBuddy/internal/notice"
+	"github.com/MangataL/BangumiBuddy/pkg/bangumifile"
+	"github.com/MangataL/BangumiBuddy/pkg/errs"
+	"github.com/MangataL/BangumiBuddy/pkg/log"
+)
+
+// qualityWeightBase 每个维度的权重倍数，保证高权重维度的得分总是优先
+const qualityWeightBase = 100
+
+// score 计算资源标题的质量评分，发布组不在白名单中时返回false
+func (p QualityProfile) score(item RSSItem) (int, bool) {
+	releaseGroup := item.ReleaseGroup
+	if releaseGroup == "" {
+		releaseGroup = item.GUID
+	}
+	if len(p.ReleaseGroups) > 0 && preferenceRank(p.ReleaseGroups, releaseGroup) == len(p.ReleaseGroups) {
+		return 0, false
+	}
+	score := 0
+	for _, terms := range [][]string{p.Resolutions, p.Sources, p.SubtitleTags, p.Codecs} {
+		score = score*qualityWeightBase + len(terms) - preferenceRank(terms, item.GUID)
+	}
+	return score, true
+}
+
+// upgradable 判断首次下载于 downloadedAt 的资源是否仍在洗版窗口内
+func (p QualityProfile) upgradable(downloadedAt time.Time) bool {
+	if p.UpgradeWindowDays <= 0 {
+		return false
+	}
+	return time.Since(downloadedAt) < time.Duration(p.UpgradeWindowDays)*24*time.Hour
+}
+
+// qualityProfile 根据名称获取质量配置
+func (s *Subscriber) qualityProfile(name string) (QualityProfile, bool) {
+	if name == "" {
+		return QualityProfile{}, false
+	}
+	for _, profile := range s.config.QualityProfiles {
+		if profile.Name == name {
+			return profile, true
+		}
+	}
+	return QualityProfile{}, false
+}
+
+// checkQualityProfile 检查订阅引用的质量配置是否存在
+func (s *Subscriber) checkQualityProfile(name string) error {
+	if name == "" {
+		return nil
+	}
+	if _, ok := s.qualityProfile(name); !ok {
+		return errs.NewBadRequest(fmt.Sprintf("质量配置 %s 不存在", name))
+	}
+	return nil
+}
+
+// qualityCandidate 按质量配置评分的单集候选资源
+type qualityCandidate struct {
+	item    RSSItem
+	episode int
+	score   int
+	version int
+	bf      bangumifile.BangumiFile
+}
+
+// better 判断候选资源是否优于另一个，评分相同时选择版本更高、较新的资源
+func (c qualityCandidate) better(other qualityCandidate) bool {
+	if c.score != other.score {
+		return c.score > other.score
+	}
+	if c.version != other.version {
+		return c.version > other.version
+	}
+	return c.item.PublishedAt.After(other.item.PublishedAt)
+}
+
+// releaseVersion 资源的发布版本，未标注时为v1
+func releaseVersion(bf bangumifile.BangumiFile) int {
+	if bf.Version <= 0 {
+		return 1
+	}
+	return bf.Version
+}
+
+// handleQualitySubscription 按质量配置为每集选择评分最高的资源下载，洗版窗口内出现更高分的资源时重新下载
+func (s *Subscriber) handleQualitySubscription(ctx context.Context, bangumi *Bangumi, savePath string, items []RSSItem, profile QualityProfile) error {
+	var (
+		errs      *multierror.Error
+		best      = make(map[int]qualityCandidate)
+		processed = make(map[int]bool)
+		batches   []qualityCandidate
+	)
+	for _, item := range items {
+		if !s.matchesFilters(ctx, item.GUID, bangumi.IncludeRegs, bangumi.ExcludeRegs, bangumi.TitleFilter) {
+			continue
+		}
+		bf, err := s.bfParser.Parse(ctx, item.GUID,
+			bangumifile.WithEpisodeLocation(bangumi.EpisodeLocation),
+			bangumifile.WithEpisodeOffset(bangumi.EpisodeOffset),
+		)
+		if err != nil {
+			log.Debugf(ctx, "无法解析集数，跳过 [%s]: %v", item.GUID, err)
+			continue
+		}
+		if bf.IsSpecialEpisode() {
+			log.Debugf(ctx, "特别篇不参与按集选择资源，跳过 [%s]", item.GUID)
+			continue
+		}
+		if !matchSeason(bangumi, bf) {
+			log.Debugf(ctx, "资源为第%d季，与订阅的季不符，跳过 [%s]", bf.Season, item.GUID)
+			continue
+		}
+		if bf.IsBatch() {
+			// 合集不参与单集评分和洗版，在单集资源处理完成后补充缺少的集数
+			if score, ok := profile.score(item); ok {
+				batches = append(batches, qualityCandidate{item: item, episode: bf.Episode, score: score, version: releaseVersion(bf), bf: bf})
+			}
+			continue
+		}
+		downloaded, err := s.isAlreadyDownloaded(ctx, bangumi.SubscriptionID, item.GUID)
+		if err != nil {
+			errs = multierror.Append(errs, fmt.Errorf("检查下载状态失败 [%s]: %w", item.GUID, err))
+			continue
+		}
+		if downloaded {
+			processed[bf.Episode] = true
+			continue
+		}
+		score, ok := profile.score(item)
+		if !ok {
+			log.Debugf(ctx, "发布组不在质量配置 %s 的白名单中，跳过 [%s]", profile.Name, item.GUID)
+			continue
+		}
+		candidate := qualityCandidate{item: item, episode: bf.Episode, score: score, version: releaseVersion(bf)}
+		if current, ok := best[bf.Episode]; !ok || candidate.better(current) {
+			best[bf.Episode] = candidate
+		}
+	}
+
+	episodes := make([]int, 0, len(best))
+	for episode := range best {
+		episodes = append(episodes, episode)
+	}
+	sort.Ints(episodes)
+
+	for _, episode := range episodes {
+		candidate := best[episode]
+		if !processed[episode] {
+			// 合集下载时按集记录，之后发布的单集资源不再重复下载
+			covered, err := s.isAlreadyDownloaded(ctx, bangumi.SubscriptionID, episodeRecordGUID(episode))
+			if err != nil {
+				errs = multierror.Append(errs, fmt.Errorf("检查下载状态失败 [%s]: %w", candidate.item.GUID, err))
+				continue
+			}
+			processed[episode] = covered
+		}
+		download, err := s.shouldDownloadQuality(ctx, bangumi, candidate, profile, processed[episode])
+		if err != nil {
+			errs = multierror.Append(errs, err)
+			continue
+		}
+		if !download {
+			continue
+		}
+		if err := s.downloadQualityCandidate(ctx, bangumi, savePath, candidate); err != nil {
+			errs = multierror.Append(errs, err)
+		}
+	}
+
+	sort.SliceStable(batches, func(i, j int) bool {
+		return batches[i].better(batches[j])
+	})
+	for _, batch := range batches {
+		if err := s.handleBatchCandidate(ctx, bangumi, savePath, batch.item, batch.bf); err != nil {
+			errs = multierror.Append(errs, err)
+		}
+	}
+	return errs.ErrorOrNil()
+}
+
+// shouldDownloadQuality 判断是否下载单集的候选资源，已下载过的集只在洗版窗口内出现更高分资源或出现修正版本时下载
+func (s *Subscriber) shouldDownloadQuality(ctx context.Context, bangumi *Bangumi, candidate qualityCandidate, profile QualityProfile, processed bool) (bool, error) {
+	record, err := s.episodeQuality.GetEpisodeQuality(ctx, bangumi.SubscriptionID, candidate.episode)
+	if err != nil {
+		if !errors.Is(err, ErrEpisodeQualityNotFound) {
+			return false, fmt.Errorf("获取第%d集质量记录失败: %w", candidate.episode, err)
+		}
+		// 没有质量记录但已处理过的集是在启用质量配置前下载的，只下载修正版本，不参与洗版
+		return !processed || candidate.version > 1, nil
+	}
+	// 修正版本不受洗版窗口限制，但不能低于已下载资源的质量
+	if candidate.version > max(record.Version, 1) && candidate.score >= record.Score {
+		log.Infof(ctx, "第%d集发现修正版本(v%d)，开始下载 [%s]", candidate.episode, candidate.version, candidate.item.GUID)
+		return true, nil
+	}
+	if candidate.score <= record.Score {
+		return false, nil
+	}
+	if !profile.upgradable(record.CreatedAt) {
+		log.Debugf(ctx, "第%d集已超过洗版窗口，跳过更高评分的资源 [%s]", candidate.episode, candidate.item.GUID)
+		return false, nil
+	}
+	log.Infof(ctx, "第%d集发现更高评分的资源(%d > %d)，开始洗版 [%s]",
+		candidate.episode, candidate.score, record.Score, candidate.item.GUID)
+	return true, nil
+}
+
+// downloadQualityCandidate 下载候选资源并记录单集的质量评分
+func (s *Subscriber) downloadQualityCandidate(ctx context.Context, bangumi *Bangumi, savePath string, candidate qualityCandidate) error {
+	item := candidate.item
+	hash, err := itemHash(item)
+	if err != nil {
+		return fmt.Errorf("提取哈希值失败 [%s]: %w", item.GUID, err)
+	}
+	req := newDownloadReq(bangumi, savePath, hash, item, candidate.episode)
+	req.QualityScore = candidate.score
+	err = s.downloader.Download(ctx, req)
+	if nerr := s.notifier.NoticeSubscriptionUpdated(ctx, notice.NoticeSubscriptionUpdatedReq{
+		BangumiName:  bangumi.Name,
+		Season:       bangumi.Season,
+		ReleaseGroup: bangumi.ReleaseGroup,
+		RSSGUID:      item.GUID,
+		Poster:       bangumi.PosterURL,
+		Error:        err,
+	}); nerr != nil {
+		log.Warnf(ctx, "通知订阅更新失败 [%s]: %v", item.GUID, nerr)
+	}
+	if err != nil {
+		return fmt.Errorf("下载失败 [%s]: %w", item.GUID, err)
+	}
+	// 同时记录集数，关键词订阅和合集按集判断是否已下载
+	if err := s.rssRecord.MarkProcessed(ctx, bangumi.SubscriptionID, item.GUID, episodeRecordGUID(candidate.episode)); err != nil {
+		return fmt.Errorf("标记RSS条目为已处理失败 [%s]: %w", item.GUID, err)
+	}
+	if err := s.episodeQuality.SaveEpisodeQuality(ctx, EpisodeQuality{
+		SubscriptionID: bangumi.SubscriptionID,
+		Episode:        candidate.episode,
+		GUID:           item.GUID,
+		Score:          candidate.score,
+		Version:        candidate.version,
+	}); err != nil {
+		return fmt.Errorf("保存第%d集质量记录失败: %w", candidate.episode, err)
+	}
+	log.Infof(ctx, "成功添加下载任务 [%s]，质量评分 %d", item.GUID, candidate.score)
+	return nil
+}
@@ -0,0 +1,247 @@
+package subscriber
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/MangataL/BangumiBuddy/internal/downloader"
+	"github.com/MangataL/BangumiBuddy/internal/notice"
+	"github.com/MangataL/BangumiBuddy/pkg/bangumifile"
+)
+
+func TestQualityProfile_Score(t *testing.T) {
+	profile := QualityProfile{
+		Resolutions:  []string{"1080p", "720p"},
+		Sources:      []string{"BDRip", "WebRip"},
+		SubtitleTags: []string{"简日", "繁日"},
+		Codecs:       []string{"HEVC", "AVC"},
+	}
+
+	high, ok := profile.score(RSSItem{GUID: "[A] 番剧 - 01 [BDRip 1080p HEVC][简日内嵌]"})
+	assert.True(t, ok)
+	low, ok := profile.score(RSSItem{GUID: "[A] 番剧 - 01 [WebRip 1080p AVC][繁日内嵌]"})
+	assert.True(t, ok)
+	lower, ok := profile.score(RSSItem{GUID: "[A] 番剧 - 01 [BDRip 720p HEVC][简日内嵌]"})
+	assert.True(t, ok)
+	assert.Greater(t, high, low)
+	assert.Greater(t, low, lower, "分辨率的权重高于其他维度")
+
+	profile.ReleaseGroups = []string{"LoliHouse"}
+	_, ok = profile.score(RSSItem{GUID: "[A] 番剧 - 01 [1080p]", ReleaseGroup: "A"})
+	assert.False(t, ok)
+	_, ok = profile.score(RSSItem{GUID: "[LoliHouse] 番剧 - 01 [1080p]"})
+	assert.True(t, ok)
+}
+
+func TestSubscriber_HandleQualitySubscription(t *testing.T) {
+	ctx := context.Background()
+	profile := QualityProfile{Name: "hd", Resolutions: []string{"1080p", "720p"}, UpgradeWindowDays: 3}
+	bangumi := &Bangumi{SubscriptionID: "sub-1", Name: "番剧", Season: 1, QualityProfile: "hd"}
+	episodes := map[string]int{
+		"[A] 番剧 - 01 [720p]":  1,
+		"[B] 番剧 - 01 [1080p]": 1,
+		"[A] 番剧 - 02 [1080p]": 2,
+		"[A] 番剧 - 03 [720p]":  3,
+		"[B] 番剧 - 03 [1080p]": 3,
+		"[A] 番剧 - 04 [720p]":  4,
+		"[B] 番剧 - 04 [1080p]": 4,
+	}
+	// 其他季的资源和已处理的合集不参与评分
+	parsed := map[string]bangumifile.BangumiFile{
+		"[B] 番剧 S2 - 01 [1080p]": {Season: 2, Episode: 1},
+		"[B] 番剧 [01-04] [1080p]": {Season: 1, Episode: 1, EpisodeEnd: 4},
+	}
+	items := []RSSItem{
+		{GUID: "[B] 番剧 S2 - 01 [1080p]", TorrentLink: "magnet:?xt=urn:btih:B201"},
+		{GUID: "[B] 番剧 [01-04] [1080p]", TorrentLink: "magnet:?xt=urn:btih:B0104"},
+		{GUID: "[A] 番剧 - 01 [720p]", TorrentLink: "magnet:?xt=urn:btih:A01"},
+		{GUID: "[B] 番剧 - 01 [1080p]", TorrentLink: "magnet:?xt=urn:btih:B01"},
+		{GUID: "[A] 番剧 - 02 [1080p]", TorrentLink: "magnet:?xt=urn:btih:A02"},
+		{GUID: "[A] 番剧 - 03 [720p]", TorrentLink: "magnet:?xt=urn:btih:A03"},
+		{GUID: "[B] 番剧 - 03 [1080p]", TorrentLink: "magnet:?xt=urn:btih:B03"},
+		{GUID: "[A] 番剧 - 04 [720p]", TorrentLink: "magnet:?xt=urn:btih:A04"},
+		{GUID: "[B] 番剧 - 04 [1080p]", TorrentLink: "magnet:?xt=urn:btih:B04"},
+	}
+	processed := map[string]bool{
+		"[A] 番剧 - 02 [1080p]":    true,
+		"[A] 番剧 - 03 [720p]":     true,
+		"[A] 番剧 - 04 [720p]":     true,
+		"[B] 番剧 [01-04] [1080p]": true,
+	}
+
+	ctrl := gomock.NewController(t)
+	rssRecord := NewMockRSSRecordRepository(ctrl)
+	episodeQuality := NewMockEpisodeQualityRepository(ctrl)
+	bfParser := bangumifile.NewMockParser(ctrl)
+	dl := downloader.NewMockInterface(ctrl)
+	s := &Subscriber{
+		rssRecord:      rssRecord,
+		episodeQuality: episodeQuality,
+		bfParser:       bfParser,
+		downloader:     dl,
+		notifier:       &notice.Empty{},
+	}
+
+	bfParser.EXPECT().Parse(ctx, gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, title string, opts ...bangumifile.ParserOption) (bangumifile.BangumiFile, error) {
+			if bf, ok := parsed[title]; ok {
+				return bf, nil
+			}
+			return bangumifile.BangumiFile{Season: 1, Episode: episodes[title]}, nil
+		}).AnyTimes()
+	rssRecord.EXPECT().IsProcessed(ctx, "sub-1", gomock.Any()).
+		DoAndReturn(func(ctx context.Context, subscriptionID, guid string) (bool, error) {
+			return processed[guid], nil
+		}).AnyTimes()
+	// 第1集首次下载，选择评分最高的资源
+	episodeQuality.EXPECT().GetEpisodeQuality(ctx, "sub-1", 1).Return(EpisodeQuality{}, ErrEpisodeQualityNotFound)
+	// 第3集在洗版窗口内出现更高评分的资源
+	episodeQuality.EXPECT().GetEpisodeQuality(ctx, "sub-1", 3).Return(EpisodeQuality{
+		Score: 1000000, CreatedAt: time.Now().Add(-24 * time.Hour),
+	}, nil)
+	// 第4集已超过洗版窗口
+	episodeQuality.EXPECT().GetEpisodeQuality(ctx, "sub-1", 4).Return(EpisodeQuality{
+		Score: 1000000, CreatedAt: time.Now().Add(-96 * time.Hour),
+	}, nil)
+
+	var downloaded []string
+	dl.EXPECT().Download(ctx, gomock.Any()).DoAndReturn(func(ctx context.Context, req downloader.DownloadReq) error {
+		downloaded = append(downloaded, req.RSSGUID)
+		assert.Equal(t, 2000000, req.QualityScore)
+		return nil
+	}).Times(2)
+	rssRecord.EXPECT().MarkProcessed(ctx, "sub-1", "[B] 番剧 - 01 [1080p]", "episode:1").Return(nil)
+	rssRecord.EXPECT().MarkProcessed(ctx, "sub-1", "[B] 番剧 - 03 [1080p]", "episode:3").Return(nil)
+	episodeQuality.EXPECT().SaveEpisodeQuality(ctx, EpisodeQuality{
+		SubscriptionID: "sub-1", Episode: 1, GUID: "[B] 番剧 - 01 [1080p]", Score: 2000000, Version: 1,
+	}).Return(nil)
+	episodeQuality.EXPECT().SaveEpisodeQuality(ctx, EpisodeQuality{
+		SubscriptionID: "sub-1", Episode: 3, GUID: "[B] 番剧 - 03 [1080p]", Score: 2000000, Version: 1,
+	}).Return(nil)
+
+	assert.NoError(t, s.handleQualitySubscription(ctx, bangumi, "番剧/Season 1", items, profile))
+	assert.Equal(t, []string{"[B] 番剧 - 01 [1080p]", "[B] 番剧 - 03 [1080p]"}, downloaded)
+}
+
+func TestSubscriber_HandleQualitySubscription_Version(t *testing.T) {
+	ctx := context.Background()
+	profile := QualityProfile{Name: "hd", Resolutions: []string{"1080p", "720p"}}
+	bangumi := &Bangumi{SubscriptionID: "sub-1", Name: "番剧", Season: 1, QualityProfile: "hd"}
+	versions := map[string]int{
+		"[A] 番剧 - 01 [1080p]":   1,
+		"[A] 番剧 - 01v2 [1080p]": 2,
+		"[A] 番剧 - 02 [1080p]":   1,
+		"[A] 番剧 - 02v2 [720p]":  2,
+	}
+	items := []RSSItem{
+		{GUID: "[A] 番剧 - 01 [1080p]", TorrentLink: "magnet:?xt=urn:btih:A01"},
+		{GUID: "[A] 番剧 - 01v2 [1080p]", TorrentLink: "magnet:?xt=urn:btih:A01V2"},
+		{GUID: "[A] 番剧 - 02 [1080p]", TorrentLink: "magnet:?xt=urn:btih:A02"},
+		{GUID: "[A] 番剧 - 02v2 [720p]", TorrentLink: "magnet:?xt=urn:btih:A02V2"},
+	}
+
+	ctrl := gomock.NewController(t)
+	rssRecord := NewMockRSSRecordRepository(ctrl)
+	episodeQuality := NewMockEpisodeQualityRepository(ctrl)
+	bfParser := bangumifile.NewMockParser(ctrl)
+	dl := downloader.NewMockInterface(ctrl)
+	s := &Subscriber{
+		rssRecord:      rssRecord,
+		episodeQuality: episodeQuality,
+		bfParser:       bfParser,
+		downloader:     dl,
+		notifier:       &notice.Empty{},
+	}
+
+	bfParser.EXPECT().Parse(ctx, gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, title string, opts ...bangumifile.ParserOption) (bangumifile.BangumiFile, error) {
+			episode := 1
+			if strings.Contains(title, "- 02") {
+				episode = 2
+			}
+			return bangumifile.BangumiFile{Season: 1, Episode: episode, Version: versions[title]}, nil
+		}).AnyTimes()
+	rssRecord.EXPECT().IsProcessed(ctx, "sub-1", gomock.Any()).
+		DoAndReturn(func(ctx context.Context, subscriptionID, guid string) (bool, error) {
+			return versions[guid] == 1, nil
+		}).AnyTimes()
+	// 修正版本不受洗版窗口限制
+	episodeQuality.EXPECT().GetEpisodeQuality(ctx, "sub-1", 1).Return(EpisodeQuality{
+		GUID: "[A] 番剧 - 01 [1080p]", Score: 2000000, Version: 1, CreatedAt: time.Now().Add(-96 * time.Hour),
+	}, nil)
+	// 质量更低的修正版本不下载
+	episodeQuality.EXPECT().GetEpisodeQuality(ctx, "sub-1", 2).Return(EpisodeQuality{
+		GUID: "[A] 番剧 - 02 [1080p]", Score: 2000000, Version: 1, CreatedAt: time.Now(),
+	}, nil)
+
+	dl.EXPECT().Download(ctx, gomock.Any()).DoAndReturn(func(ctx context.Context, req downloader.DownloadReq) error {
+		assert.Equal(t, "[A] 番剧 - 01v2 [1080p]", req.RSSGUID)
+		return nil
+	})
+	rssRecord.EXPECT().MarkProcessed(ctx, "sub-1", "[A] 番剧 - 01v2 [1080p]", "episode:1").Return(nil)
+	episodeQuality.EXPECT().SaveEpisodeQuality(ctx, EpisodeQuality{
+		SubscriptionID: "sub-1", Episode: 1, GUID: "[A] 番剧 - 01v2 [1080p]", Score: 2000000, Version: 2,
+	}).Return(nil)
+
+	assert.NoError(t, s.handleQualitySubscription(ctx, bangumi, "番剧/Season 1", items, profile))
+}
+
+func TestSubscriber_HandleQualitySubscription_BatchCovered(t *testing.T) {
+	ctx := context.Background()
+	profile := QualityProfile{Name: "hd", Resolutions: []string{"1080p", "720p"}}
+	bangumi := &Bangumi{SubscriptionID: "sub-1", Name: "番剧", Season: 1, QualityProfile: "hd"}
+	parsed := map[string]bangumifile.BangumiFile{
+		"[B] 番剧 [01-02] [1080p]": {Season: 1, Episode: 1, EpisodeEnd: 2},
+		"[A] 番剧 - 02 [1080p]":    {Season: 1, Episode: 2},
+		"[A] 番剧 - 03 [1080p]":    {Season: 1, Episode: 3},
+	}
+	items := []RSSItem{
+		{GUID: "[B] 番剧 [01-02] [1080p]", TorrentLink: "magnet:?xt=urn:btih:B0102"},
+		{GUID: "[A] 番剧 - 02 [1080p]", TorrentLink: "magnet:?xt=urn:btih:A02"},
+		{GUID: "[A] 番剧 - 03 [1080p]", TorrentLink: "magnet:?xt=urn:btih:A03"},
+	}
+	// 合集已下载并按集记录了第1、2集
+	processed := map[string]bool{
+		"[B] 番剧 [01-02] [1080p]": true,
+		"episode:1":              true,
+		"episode:2":              true,
+	}
+
+	ctrl := gomock.NewController(t)
+	rssRecord := NewMockRSSRecordRepository(ctrl)
+	episodeQuality := NewMockEpisodeQualityRepository(ctrl)
+	bfParser := bangumifile.NewMockParser(ctrl)
+	dl := downloader.NewMockInterface(ctrl)
+	s := &Subscriber{
+		rssRecord:      rssRecord,
+		episodeQuality: episodeQuality,
+		bfParser:       bfParser,
+		downloader:     dl,
+		notifier:       &notice.Empty{},
+	}
+
+	bfParser.EXPECT().Parse(ctx, gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, title string, opts ...bangumifile.ParserOption) (bangumifile.BangumiFile, error) {
+			return parsed[title], nil
+		}).AnyTimes()
+	rssRecord.EXPECT().IsProcessed(ctx, "sub-1", gomock.Any()).
+		DoAndReturn(func(ctx context.Context, subscriptionID, guid string) (bool, error) {
+			return processed[guid], nil
+		}).AnyTimes()
+	episodeQuality.EXPECT().GetEpisodeQuality(ctx, "sub-1", gomock.Any()).Return(EpisodeQuality{}, ErrEpisodeQualityNotFound).Times(2)
+
+	// 合集覆盖的第2集不再下载单集资源
+	dl.EXPECT().Download(ctx, gomock.Any()).DoAndReturn(func(ctx context.Context, req downloader.DownloadReq) error {
+		assert.Equal(t, "[A] 番剧 - 03 [1080p]", req.RSSGUID)
+		return nil
+	})
+	rssRecord.EXPECT().MarkProcessed(ctx, "sub-1", "[A] 番剧 - 03 [1080p]", "episode:3").Return(nil)
+	episodeQuality.EXPECT().SaveEpisodeQuality(ctx, gomock.Any()).Return(nil)
+
+	assert.NoError(t, s.handleQualitySubscription(ctx, bangumi, "番剧/Season 1", items, profile))
+}
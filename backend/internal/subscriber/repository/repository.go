@@ -16,6 +16,8 @@ import (
 var (
 	_ subscriber.Repository          = &Repository{}
 	_ subscriber.RSSRecordRepository = &Repository{}
+
+	_ subscriber.EpisodeQualityRepository = &Repository{}
 )
 
 // Repository 实现 subscriber.Repository 接口
@@ -27,6 +29,7 @@ type Repository struct {
 func NewRepository(db *gorm.DB) *Repository {
 	db.AutoMigrate(&bangumiSchema{})
 	db.AutoMigrate(&rssRecordSchema{})
+	db.AutoMigrate(&episodeQualitySchema{})
 	return &Repository{db: db}
 }
 
@@ -34,6 +37,7 @@ func NewRepository(db *gorm.DB) *Repository {
 func New(db *gorm.DB) *Repository {
 	db.AutoMigrate(&bangumiSchema{})
 	db.AutoMigrate(&rssRecordSchema{})
+	db.AutoMigrate(&episodeQualitySchema{})
 	return &Repository{
 		db: db,
 	}
@@ -125,6 +129,10 @@ func (r *Repository) Delete(ctx context.Context, subscriptionID string) error {
 		tx.Rollback()
 		return err
 	}
+	if err := tx.Where("subscription_id = ?", subscriptionID).Delete(&episodeQualitySchema{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
 	return tx.Commit().Error
 }
 
@@ -204,6 +212,47 @@ func (r *Repository) DeleteProcessed(ctx context.Context, subscriptionID string,
 	return nil
 }
 
+// GetEpisodeQuality 获取单集的质量记录
+func (r *Repository) GetEpisodeQuality(ctx context.Context, subscriptionID string, episode int) (subscriber.EpisodeQuality, error) {
+	var model episodeQualitySchema
+	err := r.db.WithContext(ctx).
+		Where("subscription_id = ? AND episode = ?", subscriptionID, episode).
+		First(&model).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return subscriber.EpisodeQuality{}, subscriber.ErrEpisodeQualityNotFound
+		}
+		return subscriber.EpisodeQuality{}, fmt.Errorf("查询单集质量记录失败: %w", err)
+	}
+	return subscriber.EpisodeQuality{
+		SubscriptionID: model.SubscriptionID,
+		Episode:        model.Episode,
+		GUID:           model.GUID,
+		Score:          model.Score,
+		Version:        model.Version,
+		CreatedAt:      model.CreatedAt,
+	}, nil
+}
+
+// SaveEpisodeQuality 保存单集的质量记录，已存在时保留首次下载时间
+func (r *Repository) SaveEpisodeQuality(ctx context.Context, quality subscriber.EpisodeQuality) error {
+	model := episodeQualitySchema{
+		SubscriptionID: quality.SubscriptionID,
+		Episode:        quality.Episode,
+		GUID:           quality.GUID,
+		Score:          quality.Score,
+		Version:        quality.Version,
+	}
+	err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "subscription_id"}, {Name: "episode"}},
+		DoUpdates: clause.AssignmentColumns([]string{"guid", "score", "version", "updated_at"}),
+	}).Create(&model).Error
+	if err != nil {
+		return fmt.Errorf("保存单集质量记录失败: %w", err)
+	}
+	return nil
+}
+
 // StopSubscription 停止订阅
 func (r *Repository) StopSubscription(ctx context.Context, subscriptionID string) error {
 	return r.db.WithContext(ctx).Model(&bangumiSchema{}).Where("subscription_id = ?", subscriptionID).Update("active", false).Error
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"time"
 
+	"github.com/MangataL/BangumiBuddy/internal/downloader"
 	"github.com/MangataL/BangumiBuddy/internal/subscriber"
 )
 
@@ -32,6 +33,17 @@ type bangumiSchema struct {
 	Genres          string       `gorm:"type:text"`
 	AirWeekday      time.Weekday `gorm:"type:int"`
 	EpisodeTotalNum int          `gorm:"type:int;default:0"`
+	Downloader      string       `gorm:"type:varchar(64);not null;default:''"`
+	Category        string       `gorm:"type:varchar(255);not null;default:''"`
+	Tags            string       `gorm:"type:text"` // JSON 格式存储多个标签
+	SavePath        string       `gorm:"type:varchar(512);not null;default:''"`
+	SeedingPolicy   string       `gorm:"type:text"` // JSON 格式存储做种策略，为空时使用全局配置
+	Kind            string       `gorm:"type:varchar(16);not null;default:''"`
+	SearchQuery     string       `gorm:"type:varchar(512);not null;default:''"`
+	Preference      string       `gorm:"type:text"` // JSON 格式存储资源偏好，为空时使用全局配置
+	QualityProfile  string       `gorm:"type:varchar(255);not null;default:''"`
+	TitleFilter     string       `gorm:"type:text"` // JSON 格式存储标题过滤条件
+	AbsoluteEpisode string       `gorm:"type:text"` // JSON 格式存储绝对集数映射配置
 }
 
 // TableName 设置表名
@@ -52,10 +64,44 @@ func (rssRecordSchema) TableName() string {
 	return "rss_records"
 }
 
+// episodeQualitySchema 订阅单集质量记录
+type episodeQualitySchema struct {
+	ID             uint      `gorm:"type:int;primaryKey;autoIncrement"`
+	SubscriptionID string    `gorm:"type:varchar(36);uniqueIndex:idx_subscription_episode,priority:1"`
+	Episode        int       `gorm:"type:int;uniqueIndex:idx_subscription_episode,priority:2"`
+	GUID           string    `gorm:"type:varchar(255)"`
+	Score          int       `gorm:"type:int;not null;default:0"`
+	Version        int       `gorm:"type:int;not null;default:1"`
+	CreatedAt      time.Time `gorm:"type:datetime;autoCreateTime"`
+	UpdatedAt      time.Time `gorm:"type:datetime;autoUpdateTime"`
+}
+
+// TableName 表名
+func (episodeQualitySchema) TableName() string {
+	return "episode_qualities"
+}
+
 // 模型转换函数
 func fromBangumi(b subscriber.Bangumi) bangumiSchema {
 	includeRegsJSON, _ := json.Marshal(b.IncludeRegs)
 	excludeRegsJSON, _ := json.Marshal(b.ExcludeRegs)
+	tagsJSON, _ := json.Marshal(b.Tags)
+	var seedingPolicyJSON []byte
+	if b.SeedingPolicy != nil {
+		seedingPolicyJSON, _ = json.Marshal(b.SeedingPolicy)
+	}
+	var preferenceJSON []byte
+	if b.Preference != nil {
+		preferenceJSON, _ = json.Marshal(b.Preference)
+	}
+	var titleFilterJSON []byte
+	if b.TitleFilter != nil {
+		titleFilterJSON, _ = json.Marshal(b.TitleFilter)
+	}
+	var absoluteEpisodeJSON []byte
+	if b.AbsoluteEpisode != nil {
+		absoluteEpisodeJSON, _ = json.Marshal(b.AbsoluteEpisode)
+	}
 
 	return bangumiSchema{
 		SubscriptionID:  b.SubscriptionID,
@@ -79,6 +125,17 @@ func fromBangumi(b subscriber.Bangumi) bangumiSchema {
 		EpisodeTotalNum: b.EpisodeTotalNum,
 		CreatedAt:       b.CreatedAt,
 		LastAirEpisode:  b.LastAirEpisode,
+		Downloader:      b.Downloader,
+		Category:        b.Category,
+		Tags:            string(tagsJSON),
+		SavePath:        b.SavePath,
+		SeedingPolicy:   string(seedingPolicyJSON),
+		Kind:            string(b.Kind),
+		SearchQuery:     b.SearchQuery,
+		Preference:      string(preferenceJSON),
+		QualityProfile:  b.QualityProfile,
+		TitleFilter:     string(titleFilterJSON),
+		AbsoluteEpisode: string(absoluteEpisodeJSON),
 	}
 }
 
@@ -95,6 +152,43 @@ func toBangumi(m bangumiSchema) subscriber.Bangumi {
 		_ = json.Unmarshal([]byte(m.ExcludeRegs), &excludeRegs)
 	}
 
+	var tags []string
+	if m.Tags != "" {
+		_ = json.Unmarshal([]byte(m.Tags), &tags)
+	}
+
+	var seedingPolicy *downloader.SeedingPolicy
+	if m.SeedingPolicy != "" {
+		seedingPolicy = &downloader.SeedingPolicy{}
+		if err := json.Unmarshal([]byte(m.SeedingPolicy), seedingPolicy); err != nil {
+			seedingPolicy = nil
+		}
+	}
+
+	var preference *subscriber.Preference
+	if m.Preference != "" {
+		preference = &subscriber.Preference{}
+		if err := json.Unmarshal([]byte(m.Preference), preference); err != nil {
+			preference = nil
+		}
+	}
+
+	var titleFilter *subscriber.TitleFilter
+	if m.TitleFilter != "" {
+		titleFilter = &subscriber.TitleFilter{}
+		if err := json.Unmarshal([]byte(m.TitleFilter), titleFilter); err != nil {
+			titleFilter = nil
+		}
+	}
+
+	var absoluteEpisode *subscriber.AbsoluteEpisode
+	if m.AbsoluteEpisode != "" {
+		absoluteEpisode = &subscriber.AbsoluteEpisode{}
+		if err := json.Unmarshal([]byte(m.AbsoluteEpisode), absoluteEpisode); err != nil {
+			absoluteEpisode = nil
+		}
+	}
+
 	return subscriber.Bangumi{
 		SubscriptionID:  m.SubscriptionID,
 		Name:            m.Name,
@@ -117,5 +211,16 @@ func toBangumi(m bangumiSchema) subscriber.Bangumi {
 		EpisodeTotalNum: m.EpisodeTotalNum,
 		LastAirEpisode:  m.LastAirEpisode,
 		CreatedAt:       m.CreatedAt,
+		Downloader:      m.Downloader,
+		Category:        m.Category,
+		Tags:            tags,
+		SavePath:        m.SavePath,
+		SeedingPolicy:   seedingPolicy,
+		Kind:            subscriber.SubscriptionKind(m.Kind),
+		SearchQuery:     m.SearchQuery,
+		Preference:      preference,
+		QualityProfile:  m.QualityProfile,
+		TitleFilter:     titleFilter,
+		AbsoluteEpisode: absoluteEpisode,
 	}
 }
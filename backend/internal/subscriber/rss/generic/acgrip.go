@@ -0,0 +1,24 @@
+package generic
+
+import (
+	"github.com/mmcdole/gofeed"
+
+	"github.com/MangataL/BangumiBuddy/internal/network"
+	"github.com/MangataL/BangumiBuddy/internal/subscriber"
+	"github.com/MangataL/BangumiBuddy/pkg/bangumifile"
+)
+
+// NewAcgRipParser 创建 acg.rip RSS 解析器，RSS不提供哈希值，需要下载种子文件计算
+func NewAcgRipParser(bfParser bangumifile.Parser, provider network.HTTPClientProvider) subscriber.RSSParser {
+	return newParser("acg.rip", extractAcgRipItem, bfParser, provider)
+}
+
+func extractAcgRipItem(item *gofeed.Item) (subscriber.RSSItem, bool) {
+	link, size := enclosure(item)
+	return subscriber.RSSItem{
+		GUID:        item.Title,
+		TorrentLink: link,
+		PublishedAt: publishedAt(item),
+		Size:        size,
+	}, link != ""
+}
@@ -0,0 +1,34 @@
+package generic
+
+import (
+	"strings"
+
+	"github.com/mmcdole/gofeed"
+
+	"github.com/MangataL/BangumiBuddy/internal/network"
+	"github.com/MangataL/BangumiBuddy/internal/subscriber"
+	"github.com/MangataL/BangumiBuddy/pkg/bangumifile"
+)
+
+// NewDmhyParser 创建动漫花园 RSS 解析器，附件为磁力链接
+func NewDmhyParser(bfParser bangumifile.Parser, provider network.HTTPClientProvider) subscriber.RSSParser {
+	return newParser("动漫花园", extractDmhyItem, bfParser, provider)
+}
+
+func extractDmhyItem(item *gofeed.Item) (subscriber.RSSItem, bool) {
+	link, size := enclosure(item)
+	if !strings.HasPrefix(link, "magnet:") {
+		return subscriber.RSSItem{}, false
+	}
+	rssItem := subscriber.RSSItem{
+		GUID:        item.Title,
+		TorrentLink: link,
+		PublishedAt: publishedAt(item),
+		Size:        size,
+	}
+	// 动漫花园的作者为发布组名称
+	if item.Author != nil {
+		rssItem.ReleaseGroup = item.Author.Name
+	}
+	return rssItem, true
+}
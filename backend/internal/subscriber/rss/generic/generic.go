@@ -0,0 +1,200 @@
+package generic
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/anacrolix/torrent/metainfo"
+	"github.com/hashicorp/golang-lru/v2/expirable"
+	"github.com/mmcdole/gofeed"
+	"github.com/pkg/errors"
+
+	"github.com/MangataL/BangumiBuddy/internal/network"
+	"github.com/MangataL/BangumiBuddy/internal/subscriber"
+	"github.com/MangataL/BangumiBuddy/pkg/bangumifile"
+	"github.com/MangataL/BangumiBuddy/pkg/log"
+)
+
+const (
+	hashCacheSize = 1024
+	hashCacheTTL  = 24 * time.Hour
+)
+
+// itemExtractor 从RSS条目中提取种子信息，返回 false 表示跳过该条目
+type itemExtractor func(item *gofeed.Item) (subscriber.RSSItem, bool)
+
+// parser 通用RSS解析器，不同站点只需要提供条目提取规则
+type parser struct {
+	name     string
+	extract  itemExtractor
+	bfParser bangumifile.Parser
+	client   *http.Client
+	// hashes 种子链接对应的哈希值，RSS没有提供哈希值时需要下载种子文件计算
+	hashes *expirable.LRU[string, string]
+}
+
+func newParser(name string, extract itemExtractor, bfParser bangumifile.Parser, provider network.HTTPClientProvider) *parser {
+	return &parser{
+		name:     name,
+		extract:  extract,
+		bfParser: bfParser,
+		client:   provider.HTTPClient(30 * time.Second),
+		hashes:   expirable.NewLRU[string, string](hashCacheSize, nil, hashCacheTTL),
+	}
+}
+
+func (p *parser) Parse(ctx context.Context, link string) (subscriber.RSS, error) {
+	fp := gofeed.NewParser()
+	fp.Client = p.client
+	feed, err := fp.ParseURLWithContext(link, ctx)
+	if err != nil {
+		return subscriber.RSS{}, errors.WithMessage(err, "解析RSS失败")
+	}
+
+	items := make([]subscriber.RSSItem, 0, len(feed.Items))
+	for _, feedItem := range feed.Items {
+		item, ok := p.extract(feedItem)
+		if !ok || item.TorrentLink == "" {
+			log.Warnf(ctx, "%s RSS条目 %s 没有种子链接", p.name, feedItem.Title)
+			continue
+		}
+		if item.Hash == "" {
+			hash, err := p.torrentHash(ctx, item.TorrentLink)
+			if err != nil {
+				log.Warnf(ctx, "获取种子哈希值失败 [%s]: %v", item.GUID, err)
+				continue
+			}
+			item.Hash = hash
+		}
+		item.Hash = strings.ToLower(item.Hash)
+		item.ReleaseGroup = p.releaseGroup(ctx, item.GUID, item.ReleaseGroup)
+		items = append(items, item)
+	}
+
+	rss := subscriber.RSS{Items: items}
+	if len(items) > 0 {
+		rss.ReleaseGroup = items[0].ReleaseGroup
+		rss.BangumiName = p.bangumiName(ctx, items[0].GUID)
+	}
+	return rss, nil
+}
+
+// releaseGroup 从标题中解析发布组，解析失败时使用RSS提供的发布组
+func (p *parser) releaseGroup(ctx context.Context, title, fallback string) string {
+	bf, err := p.bfParser.Parse(ctx, title,
+		bangumifile.IgnoreValidateEpisode(),
+		bangumifile.PreserveOriginName(),
+	)
+	if err != nil || bf.ReleaseGroup == "" {
+		return fallback
+	}
+	return bf.ReleaseGroup
+}
+
+// bangumiName 从标题中解析番剧名称，多语言标题只取第一个
+func (p *parser) bangumiName(ctx context.Context, title string) string {
+	bf, err := p.bfParser.Parse(ctx, title,
+		bangumifile.IgnoreValidateEpisode(),
+		bangumifile.PreserveOriginName(),
+	)
+	if err != nil || bf.AnimeTitle == "" {
+		log.Warnf(ctx, "无法解析番剧名称: %s", title)
+		return title
+	}
+	name, _, _ := strings.Cut(bf.AnimeTitle, "/")
+	return strings.TrimSpace(name)
+}
+
+// torrentHash 下载种子文件并计算哈希值
+func (p *parser) torrentHash(ctx context.Context, link string) (string, error) {
+	if hash, ok := p.hashes.Get(link); ok {
+		return hash, nil
+	}
+	if strings.HasPrefix(link, "magnet:") {
+		m, err := metainfo.ParseMagnetUri(link)
+		if err != nil {
+			return "", fmt.Errorf("解析磁力链接失败: %w", err)
+		}
+		return m.InfoHash.HexString(), nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, link, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("下载种子文件失败: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("下载种子文件失败，状态码 %d", resp.StatusCode)
+	}
+	mi, err := metainfo.Load(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("解析种子文件失败: %w", err)
+	}
+	hash := mi.HashInfoBytes().HexString()
+	p.hashes.Add(link, hash)
+	return hash, nil
+}
+
+// enclosure 获取条目的附件链接和大小
+func enclosure(item *gofeed.Item) (string, int64) {
+	for _, e := range item.Enclosures {
+		if e.URL == "" {
+			continue
+		}
+		length, _ := strconv.ParseInt(e.Length, 10, 64)
+		return e.URL, length
+	}
+	return "", 0
+}
+
+// publishedAt 获取条目的发布时间
+func publishedAt(item *gofeed.Item) time.Time {
+	if item.PublishedParsed != nil {
+		return item.PublishedParsed.Local()
+	}
+	return time.Time{}
+}
+
+// extension 获取条目的扩展元素值
+func extension(item *gofeed.Item, prefix, name string) string {
+	elements := item.Extensions[prefix][name]
+	if len(elements) == 0 {
+		return ""
+	}
+	return strings.TrimSpace(elements[0].Value)
+}
+
+var sizePattern = regexp.MustCompile(`(?i)^\s*(\d+(?:\.\d+)?)\s*([KMGT]?i?B)\s*$`)
+
+var sizeUnits = map[string]float64{
+	"B":   1,
+	"KB":  1000,
+	"MB":  1000 * 1000,
+	"GB":  1000 * 1000 * 1000,
+	"TB":  1000 * 1000 * 1000 * 1000,
+	"KIB": 1 << 10,
+	"MIB": 1 << 20,
+	"GIB": 1 << 30,
+	"TIB": 1 << 40,
+}
+
+// parseSize 解析带单位的资源大小，如 1.2 GiB，无法解析时返回0
+func parseSize(size string) int64 {
+	matches := sizePattern.FindStringSubmatch(size)
+	if len(matches) < 3 {
+		return 0
+	}
+	value, err := strconv.ParseFloat(matches[1], 64)
+	if err != nil {
+		return 0
+	}
+	return int64(value * sizeUnits[strings.ToUpper(matches[2])])
+}
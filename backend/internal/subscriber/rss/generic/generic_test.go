@@ -0,0 +1,152 @@
+package generic
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anacrolix/torrent/bencode"
+	"github.com/anacrolix/torrent/metainfo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/MangataL/BangumiBuddy/internal/network"
+	"github.com/MangataL/BangumiBuddy/internal/subscriber"
+	"github.com/MangataL/BangumiBuddy/pkg/bangumifile"
+	"github.com/MangataL/BangumiBuddy/pkg/bangumifile/anito"
+)
+
+type staticHTTPClientProvider struct {
+	client *http.Client
+}
+
+func (p staticHTTPClientProvider) HTTPClient(time.Duration) *http.Client {
+	return p.client
+}
+
+func TestParser_Parse(t *testing.T) {
+	torrentFile, torrentHash := newTorrentFile(t)
+	testCases := []struct {
+		name      string
+		newParser func(bangumifile.Parser, network.HTTPClientProvider) subscriber.RSSParser
+		feed      string
+		want      subscriber.RSS
+	}{
+		{
+			name:      "nyaa",
+			newParser: NewNyaaParser,
+			feed:      "nyaa",
+			want: subscriber.RSS{
+				BangumiName:  "GIRLS BAND CRY",
+				ReleaseGroup: "LoliHouse",
+				Items: []subscriber.RSSItem{{
+					GUID:         "[LoliHouse] GIRLS BAND CRY - 02 [WebRip 1080p HEVC-10bit AAC][简繁日内封字幕]",
+					TorrentLink:  "https://nyaa.si/download/1805203.torrent",
+					PublishedAt:  time.Date(2024, 4, 13, 16, 35, 38, 0, time.UTC).Local(),
+					Hash:         "3a2e456a689ead23ca8f49fdc74ba1872c6f0c12",
+					Size:         477 << 20,
+					ReleaseGroup: "LoliHouse",
+				}},
+			},
+		},
+		{
+			name:      "动漫花园",
+			newParser: NewDmhyParser,
+			feed:      "dmhy",
+			want: subscriber.RSS{
+				BangumiName:  "吹响吧！上低音号 3",
+				ReleaseGroup: "千夏字幕组&LoliHouse",
+				Items: []subscriber.RSSItem{{
+					GUID:         "[千夏字幕组&LoliHouse] 吹响吧！上低音号 3 / Hibike! Euphonium 3 - 09 [WebRip 1080p HEVC-10bit AAC][简繁内封字幕]",
+					TorrentLink:  "magnet:?xt=urn:btih:AEG24SVPUX7EIBXGTIUYTW4BZ7IHPTX5&dn=&tr=http%3A%2F%2F104.143.10.186%3A8000%2Fannounce",
+					PublishedAt:  time.Date(2024, 6, 14, 14, 54, 27, 0, time.UTC).Local(),
+					Hash:         "010dae4aafa5fe4406e69a2989db81cfd077cefd",
+					Size:         1,
+					ReleaseGroup: "千夏字幕组&LoliHouse",
+				}},
+			},
+		},
+		{
+			name:      "torznab",
+			newParser: NewTorznabParser,
+			feed:      "torznab",
+			want: subscriber.RSS{
+				BangumiName:  "Kono Subarashii Sekai ni Shukufuku wo!",
+				ReleaseGroup: "LoliHouse",
+				Items: []subscriber.RSSItem{{
+					GUID:         "[LoliHouse] Kono Subarashii Sekai ni Shukufuku wo! S3 - 10 [WebRip 1080p HEVC-10bit AAC]",
+					TorrentLink:  "https://jackett.example/dl/indexer/?path=305bcdb1",
+					PublishedAt:  time.Date(2024, 6, 13, 5, 2, 6, 0, time.UTC).Local(),
+					Hash:         "305bcdb1dc367d1684d8350188beab851c8d75e1",
+					Size:         381660704,
+					ReleaseGroup: "LoliHouse",
+				}},
+			},
+		},
+		{
+			name:      "acg.rip 下载种子文件计算哈希值",
+			newParser: NewAcgRipParser,
+			feed:      "acgrip",
+			want: subscriber.RSS{
+				BangumiName:  "GIRLS BAND CRY",
+				ReleaseGroup: "LoliHouse",
+				Items: []subscriber.RSSItem{{
+					GUID:         "[LoliHouse] GIRLS BAND CRY - 01 [WebRip 1080p HEVC-10bit AAC][简繁日内封字幕]",
+					TorrentLink:  "{{server}}/t/301234.torrent",
+					PublishedAt:  time.Date(2024, 4, 7, 10, 50, 3, 0, time.UTC).Local(),
+					Hash:         torrentHash,
+					ReleaseGroup: "LoliHouse",
+				}},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var server *httptest.Server
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if strings.HasSuffix(r.URL.Path, ".torrent") {
+					_, _ = w.Write(torrentFile)
+					return
+				}
+				data, err := os.ReadFile("./testdata/" + tc.feed + ".xml")
+				require.NoError(t, err)
+				_, _ = w.Write([]byte(strings.ReplaceAll(string(data), "{{server}}", server.URL)))
+			}))
+			defer server.Close()
+			p := tc.newParser(anito.NewParser(), staticHTTPClientProvider{client: server.Client()})
+
+			rss, err := p.Parse(context.Background(), server.URL)
+			require.NoError(t, err)
+
+			for i := range tc.want.Items {
+				tc.want.Items[i].TorrentLink = strings.ReplaceAll(tc.want.Items[i].TorrentLink, "{{server}}", server.URL)
+			}
+			assert.Equal(t, tc.want, rss)
+		})
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	assert.Equal(t, int64(1536<<20), parseSize("1.5 GiB"))
+	assert.Equal(t, int64(300_000_000), parseSize("300MB"))
+	assert.Equal(t, int64(0), parseSize("unknown"))
+}
+
+func newTorrentFile(t *testing.T) ([]byte, string) {
+	infoBytes, err := bencode.Marshal(metainfo.Info{
+		Name:        "GIRLS BAND CRY - 01.mkv",
+		PieceLength: 16384,
+		Length:      1,
+		Pieces:      make([]byte, 20),
+	})
+	require.NoError(t, err)
+	mi := metainfo.MetaInfo{InfoBytes: infoBytes}
+	var buf strings.Builder
+	require.NoError(t, mi.Write(&buf))
+	return []byte(buf.String()), mi.HashInfoBytes().HexString()
+}
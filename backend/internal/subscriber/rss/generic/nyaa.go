@@ -0,0 +1,28 @@
+package generic
+
+import (
+	"github.com/mmcdole/gofeed"
+
+	"github.com/MangataL/BangumiBuddy/internal/network"
+	"github.com/MangataL/BangumiBuddy/internal/subscriber"
+	"github.com/MangataL/BangumiBuddy/pkg/bangumifile"
+)
+
+// NewNyaaParser 创建 Nyaa RSS 解析器，种子哈希值和大小来自 nyaa 扩展元素
+func NewNyaaParser(bfParser bangumifile.Parser, provider network.HTTPClientProvider) subscriber.RSSParser {
+	return newParser("Nyaa", extractNyaaItem, bfParser, provider)
+}
+
+func extractNyaaItem(item *gofeed.Item) (subscriber.RSSItem, bool) {
+	link := item.Link
+	if url, _ := enclosure(item); url != "" {
+		link = url
+	}
+	return subscriber.RSSItem{
+		GUID:        item.Title,
+		TorrentLink: link,
+		PublishedAt: publishedAt(item),
+		Hash:        extension(item, "nyaa", "infoHash"),
+		Size:        parseSize(extension(item, "nyaa", "size")),
+	}, link != ""
+}
@@ -0,0 +1,50 @@
+package generic
+
+import (
+	"strconv"
+
+	"github.com/mmcdole/gofeed"
+
+	"github.com/MangataL/BangumiBuddy/internal/network"
+	"github.com/MangataL/BangumiBuddy/internal/subscriber"
+	"github.com/MangataL/BangumiBuddy/pkg/bangumifile"
+)
+
+// NewTorznabParser 创建通用 RSS 解析器，兼容 torznab 扩展属性，也用于未知站点的RSS
+func NewTorznabParser(bfParser bangumifile.Parser, provider network.HTTPClientProvider) subscriber.RSSParser {
+	return newParser("RSS", extractTorznabItem, bfParser, provider)
+}
+
+func extractTorznabItem(item *gofeed.Item) (subscriber.RSSItem, bool) {
+	attrs := torznabAttrs(item)
+	link, size := enclosure(item)
+	if link == "" {
+		link = attrs["magneturl"]
+	}
+	if link == "" {
+		link = item.Link
+	}
+	if attrSize, err := strconv.ParseInt(attrs["size"], 10, 64); err == nil && attrSize > 0 {
+		size = attrSize
+	}
+	return subscriber.RSSItem{
+		GUID:        item.Title,
+		TorrentLink: link,
+		PublishedAt: publishedAt(item),
+		Hash:        attrs["infohash"],
+		Size:        size,
+	}, link != ""
+}
+
+// torznabAttrs 获取 torznab:attr 扩展属性
+func torznabAttrs(item *gofeed.Item) map[string]string {
+	attrs := make(map[string]string)
+	for _, prefix := range []string{"torznab", "newznab"} {
+		for _, element := range item.Extensions[prefix]["attr"] {
+			if _, ok := attrs[element.Attrs["name"]]; !ok {
+				attrs[element.Attrs["name"]] = element.Attrs["value"]
+			}
+		}
+	}
+	return attrs
+}
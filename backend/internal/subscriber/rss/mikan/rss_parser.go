@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/http"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -36,7 +37,13 @@ func (p *parser) Parse(ctx context.Context, link string) (subscriber.RSS, error)
 		return subscriber.RSS{}, errors.WithMessage(err, "解析RSS失败")
 	}
 	items := getItems(ctx, feed.Items)
-	rg := p.parseReleaseGroup(ctx, items)
+	for i := range items {
+		items[i].ReleaseGroup = p.parseReleaseGroup(ctx, items[i].GUID)
+	}
+	var rg string
+	if len(items) > 0 {
+		rg = items[0].ReleaseGroup
+	}
 	return subscriber.RSS{
 		BangumiName:  getBangumiName(ctx, feed.Title),
 		ReleaseGroup: rg,
@@ -54,11 +61,8 @@ func newHTTPClient(provider network.HTTPClientProvider) *http.Client {
 	return provider.HTTPClient(30 * time.Second)
 }
 
-func (p *parser) parseReleaseGroup(ctx context.Context, items []subscriber.RSSItem) string {
-	if len(items) == 0 {
-		return ""
-	}
-	bf, err := p.bfParser.Parse(ctx, items[0].GUID,
+func (p *parser) parseReleaseGroup(ctx context.Context, title string) string {
+	bf, err := p.bfParser.Parse(ctx, title,
 		bangumifile.IgnoreValidateEpisode(),
 		bangumifile.PreserveOriginName(),
 	)
@@ -93,10 +97,12 @@ func getItems(ctx context.Context, items []*gofeed.Item) []subscriber.RSSItem {
 			log.Warnf(ctx, "item %s has no enclosures", item.GUID)
 			continue
 		}
+		size, _ := strconv.ParseInt(item.Enclosures[0].Length, 10, 64)
 		rssItems = append(rssItems, subscriber.RSSItem{
 			GUID:        item.GUID,
 			TorrentLink: item.Enclosures[0].URL,
 			PublishedAt: parsePublishedAt(ctx, item),
+			Size:        size,
 		})
 	}
 	return rssItems
@@ -0,0 +1,83 @@
+package rss
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	"github.com/MangataL/BangumiBuddy/internal/network"
+	"github.com/MangataL/BangumiBuddy/internal/subscriber"
+	"github.com/MangataL/BangumiBuddy/internal/subscriber/rss/generic"
+	"github.com/MangataL/BangumiBuddy/internal/subscriber/rss/mikan"
+	"github.com/MangataL/BangumiBuddy/pkg/bangumifile"
+	"github.com/MangataL/BangumiBuddy/pkg/errs"
+)
+
+// MikanHostProvider 提供配置的蜜柑计划域名，用于识别镜像站的RSS链接
+type MikanHostProvider interface {
+	MikanHost() string
+}
+
+// NewParser 创建RSS解析器，根据RSS链接的域名自动选择对应站点的解析器
+func NewParser(bfParser bangumifile.Parser, provider network.HTTPClientProvider, mikanHost MikanHostProvider) subscriber.RSSParser {
+	return &parser{
+		mikanHost: mikanHost,
+		mikan:     mikan.NewParser(bfParser, provider),
+		nyaa:      generic.NewNyaaParser(bfParser, provider),
+		dmhy:      generic.NewDmhyParser(bfParser, provider),
+		acgRip:    generic.NewAcgRipParser(bfParser, provider),
+		torznab:   generic.NewTorznabParser(bfParser, provider),
+	}
+}
+
+type parser struct {
+	mikanHost MikanHostProvider
+	mikan     subscriber.RSSParser
+	nyaa      subscriber.RSSParser
+	dmhy      subscriber.RSSParser
+	acgRip    subscriber.RSSParser
+	torznab   subscriber.RSSParser
+}
+
+func (p *parser) Parse(ctx context.Context, link string) (subscriber.RSS, error) {
+	u, err := url.Parse(link)
+	if err != nil {
+		return subscriber.RSS{}, errs.NewBadRequest("RSS链接格式错误")
+	}
+	return p.selectParser(u).Parse(ctx, link)
+}
+
+// selectParser 根据域名选择解析器，蜜柑计划包括配置的镜像站，未知站点使用通用解析器
+func (p *parser) selectParser(u *url.URL) subscriber.RSSParser {
+	host := strings.ToLower(u.Hostname())
+	switch {
+	case hostIs(host, "nyaa.si", "nyaa.land"):
+		return p.nyaa
+	case hostIs(host, "dmhy.org"):
+		return p.dmhy
+	case hostIs(host, "acg.rip"):
+		return p.acgRip
+	case hostIs(host, "mikanani.me", "mikanime.tv"), p.isMikanMirror(host):
+		return p.mikan
+	default:
+		return p.torznab
+	}
+}
+
+func (p *parser) isMikanMirror(host string) bool {
+	if p.mikanHost == nil {
+		return false
+	}
+	mirror := strings.ToLower(p.mikanHost.MikanHost())
+	return mirror != "" && hostIs(host, mirror)
+}
+
+// hostIs 判断域名是否为指定站点或其子域名
+func hostIs(host string, domains ...string) bool {
+	for _, domain := range domains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,52 @@
+package rss
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/MangataL/BangumiBuddy/internal/subscriber"
+)
+
+type namedParser struct {
+	subscriber.RSSParser
+	name string
+}
+
+type mikanHost string
+
+func (h mikanHost) MikanHost() string {
+	return string(h)
+}
+
+func TestParser_SelectParser(t *testing.T) {
+	p := &parser{
+		mikanHost: mikanHost("mikan.example.com"),
+		mikan:     namedParser{name: "mikan"},
+		nyaa:      namedParser{name: "nyaa"},
+		dmhy:      namedParser{name: "dmhy"},
+		acgRip:    namedParser{name: "acgrip"},
+		torznab:   namedParser{name: "torznab"},
+	}
+	testCases := []struct {
+		link string
+		want string
+	}{
+		{link: "https://mikanani.me/RSS/Bangumi?bangumiId=3299&subgroupid=370", want: "mikan"},
+		{link: "https://mikanime.tv/RSS/MyBangumi?token=abc", want: "mikan"},
+		{link: "https://mikan.example.com/RSS/Bangumi?bangumiId=3299", want: "mikan"},
+		{link: "https://example.com/RSS/feed.xml", want: "torznab"},
+		{link: "https://nyaa.si/?page=rss&q=GIRLS+BAND+CRY", want: "nyaa"},
+		{link: "https://share.dmhy.org/topics/rss/rss.xml?keyword=LoliHouse", want: "dmhy"},
+		{link: "https://acg.rip/.xml?term=LoliHouse", want: "acgrip"},
+		{link: "http://jackett:9117/api/v2.0/indexers/all/results/torznab/api?t=search", want: "torznab"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.link, func(t *testing.T) {
+			u, err := url.Parse(tc.link)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, p.selectParser(u).(namedParser).name)
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"path"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -16,6 +17,7 @@ import (
 	"github.com/MangataL/BangumiBuddy/internal/downloader"
 	"github.com/MangataL/BangumiBuddy/internal/meta"
 	"github.com/MangataL/BangumiBuddy/internal/notice"
+	"github.com/MangataL/BangumiBuddy/pkg/bangumifile"
 	"github.com/MangataL/BangumiBuddy/pkg/errs"
 	"github.com/MangataL/BangumiBuddy/pkg/log"
 )
@@ -24,7 +26,11 @@ import (
 
 var _ Interface = (*Subscriber)(nil)
 
-const episodeTotalNumCacheTTL = time.Hour
+const (
+	episodeTotalNumCacheTTL = time.Hour
+	// defaultSavePath 默认保存路径模板，相对于下载管理器的TV保存路径
+	defaultSavePath = "{name}/Season {season}"
+)
 
 // NewSubscriber 创建订阅器
 func NewSubscriber(dep Dependency) *Subscriber {
@@ -34,10 +40,14 @@ func NewSubscriber(dep Dependency) *Subscriber {
 		metaParser:      dep.MetaParser,
 		repo:            dep.Repository,
 		rssRecord:       dep.RSSRecordRepository,
+		episodeQuality:  dep.EpisodeQualityRepository,
+		library:         dep.EpisodeLibrary,
 		config:          dep.Config,
 		downloader:      dep.Downloader,
 		torrentOperator: dep.TorrentOperator,
 		notifier:        dep.Notifier,
+		bfParser:        dep.BangumiFileParser,
+		searcher:        dep.ResourceSearcher,
 		stop:            cancel,
 	}
 
@@ -52,11 +62,15 @@ type Dependency struct {
 	RSSParser
 	Repository
 	RSSRecordRepository
+	EpisodeQualityRepository
+	EpisodeLibrary
 	downloader.TorrentOperator
 	notice.Notifier
 	Config
-	Downloader downloader.Interface
-	MetaParser meta.Parser
+	Downloader        downloader.Interface
+	MetaParser        meta.Parser
+	BangumiFileParser bangumifile.Parser
+	ResourceSearcher  ResourceSearcher
 }
 
 // RSSParser RSS解析器
@@ -88,12 +102,32 @@ type RSSRecordRepository interface {
 	DeleteProcessed(ctx context.Context, subscriptionID string, guid ...string) error
 }
 
+// EpisodeQualityRepository 用于存储订阅每集已下载资源的质量评分
+type EpisodeQualityRepository interface {
+	// GetEpisodeQuality 获取单集的质量记录，不存在时返回 ErrEpisodeQualityNotFound
+	GetEpisodeQuality(ctx context.Context, subscriptionID string, episode int) (EpisodeQuality, error)
+	// SaveEpisodeQuality 保存单集的质量记录，已存在时只更新资源和评分
+	SaveEpisodeQuality(ctx context.Context, quality EpisodeQuality) error
+}
+
+// EpisodeLibrary 媒体库查询接口，用于判断合集中哪些集已经在媒体库中
+type EpisodeLibrary interface {
+	// ListEpisodes 获取番剧某季已转移到媒体库的集数
+	ListEpisodes(ctx context.Context, bangumiName string, season int) ([]int, error)
+}
+
 // Config 配置项
 type Config struct {
 	RSSCheckInterval int      `mapstructure:"rss_check_interval" json:"rssCheckInterval" default:"30"`
 	IncludeRegs      []string `mapstructure:"include_regs" json:"includeRegs"`
 	ExcludeRegs      []string `mapstructure:"exclude_regs" json:"excludeRegs"`
 	AutoStop         bool     `mapstructure:"auto_stop" json:"autoStop"`
+	SavePath         string   `mapstructure:"save_path" json:"savePath" default:"{name}/Season {season}"` // 保存路径模板，支持 {name}、{season}、{year}、{release_group}
+	// SearchFeeds 关键词订阅使用的RSS搜索链接模板，{query} 会被替换为搜索关键词，如 https://nyaa.si/?page=rss&q={query}
+	SearchFeeds []string   `mapstructure:"search_feeds" json:"searchFeeds"`
+	Preference  Preference `mapstructure:"preference" json:"preference"` // 关键词订阅的默认资源偏好
+	// QualityProfiles 质量配置，订阅通过名称引用
+	QualityProfiles []QualityProfile `mapstructure:"quality_profiles" json:"qualityProfiles"`
 }
 
 type Subscriber struct {
@@ -101,10 +135,14 @@ type Subscriber struct {
 	metaParser      meta.Parser
 	repo            Repository
 	rssRecord       RSSRecordRepository
+	episodeQuality  EpisodeQualityRepository
+	library         EpisodeLibrary
 	config          Config
 	downloader      downloader.Interface
 	torrentOperator downloader.TorrentOperator
 	notifier        notice.Notifier
+	bfParser        bangumifile.Parser
+	searcher        ResourceSearcher
 	stop            func()
 
 	rssTicker *time.Ticker
@@ -119,9 +157,12 @@ func (s *Subscriber) ParseRSS(ctx context.Context, req ParserRSSReq) (ParseRSSRs
 		meta meta.Meta
 		err  error
 	)
-	rss, err := s.rssParser.Parse(ctx, rssLink)
-	if err != nil {
-		return ParseRSSRsp{}, err
+	// 关键词订阅没有RSS链接，只解析TMDB元数据
+	var rss RSS
+	if rssLink != "" {
+		if rss, err = s.rssParser.Parse(ctx, rssLink); err != nil {
+			return ParseRSSRsp{}, err
+		}
 	}
 	if req.TMDBID != 0 {
 		meta, err = s.metaParser.ParseTV(ctx, req.TMDBID)
@@ -147,6 +188,15 @@ func (s *Subscriber) ParseRSS(ctx context.Context, req ParserRSSReq) (ParseRSSRs
 }
 
 func (s *Subscriber) Subscribe(ctx context.Context, req SubscribeReq) (Bangumi, error) {
+	if req.Kind == SubscriptionKindKeyword {
+		if strings.TrimSpace(req.SearchQuery) == "" {
+			return Bangumi{}, errs.NewBadRequest("关键词订阅的搜索关键词不能为空")
+		}
+		req.RSSLink = keywordRSSLink(req.TMDBID, req.Season, req.SearchQuery)
+	}
+	if err := s.checkQualityProfile(req.QualityProfile); err != nil {
+		return Bangumi{}, err
+	}
 	meta, err := s.metaParser.ParseTV(ctx, req.TMDBID)
 	if err != nil {
 		return Bangumi{}, fmt.Errorf("解析元数据失败: %w", err)
@@ -178,6 +228,20 @@ func (s *Subscriber) Subscribe(ctx context.Context, req SubscribeReq) (Bangumi,
 		Genres:          meta.Genres,
 		AirWeekday:      req.AirWeekday,
 		EpisodeTotalNum: req.EpisodeTotalNum,
+		Downloader:      req.Downloader,
+		Category:        req.Category,
+		Tags:            req.Tags,
+		SavePath:        req.SavePath,
+		SeedingPolicy:   req.SeedingPolicy,
+		Kind:            req.Kind,
+		SearchQuery:     req.SearchQuery,
+		Preference:      req.Preference,
+		QualityProfile:  req.QualityProfile,
+		TitleFilter:     req.TitleFilter,
+		AbsoluteEpisode: req.AbsoluteEpisode,
+	}
+	if _, err := s.savePath(&bangumi); err != nil {
+		return Bangumi{}, errs.NewBadRequest(err.Error())
 	}
 	if err := s.repo.Save(ctx, bangumi); err != nil {
 		return Bangumi{}, fmt.Errorf("保存失败: %w", err)
@@ -203,6 +267,9 @@ func (s *Subscriber) UpdateSubscription(ctx context.Context, req UpdateSubscribe
 	if err := validateUpdateSubscribeReq(req); err != nil {
 		return err
 	}
+	if err := s.checkQualityProfile(req.QualityProfile); err != nil {
+		return err
+	}
 
 	oldBangumi, err := s.Get(ctx, req.SubscriptionID)
 	if err != nil {
@@ -240,6 +307,26 @@ func (s *Subscriber) UpdateSubscription(ctx context.Context, req UpdateSubscribe
 		Name:            oldBangumi.Name,
 		Year:            oldBangumi.Year,
 		CreatedAt:       oldBangumi.CreatedAt,
+		Downloader:      req.Downloader,
+		Category:        req.Category,
+		Tags:            req.Tags,
+		SavePath:        req.SavePath,
+		SeedingPolicy:   req.SeedingPolicy,
+		Kind:            oldBangumi.Kind,
+		QualityProfile:  req.QualityProfile,
+		TitleFilter:     req.TitleFilter,
+		AbsoluteEpisode: req.AbsoluteEpisode,
+	}
+	if bangumi.IsKeyword() {
+		if strings.TrimSpace(req.SearchQuery) == "" {
+			return errs.NewBadRequest("关键词订阅的搜索关键词不能为空")
+		}
+		bangumi.SearchQuery = req.SearchQuery
+		bangumi.Preference = req.Preference
+		bangumi.RSSLink = keywordRSSLink(bangumi.TMDBID, bangumi.Season, req.SearchQuery)
+	}
+	if _, err := s.savePath(&bangumi); err != nil {
+		return errs.NewBadRequest(err.Error())
 	}
 	if err := s.repo.Save(ctx, bangumi); err != nil {
 		return errors.WithMessage(err, "更新订阅失败")
@@ -325,20 +412,33 @@ func (s *Subscriber) handleSubscriptions(ctx context.Context) {
 			continue
 		}
 	}
+
+	s.handleStalledTorrents(ctx)
 }
 
 func (s *Subscriber) handleBangumiSubscription(ctx context.Context, bangumi *Bangumi) error {
+	if bangumi.IsKeyword() {
+		return s.handleKeywordSubscription(ctx, bangumi)
+	}
 	// 解析RSS
 	rss, err := s.rssParser.Parse(ctx, bangumi.RSSLink)
 	if err != nil {
 		return fmt.Errorf("解析RSS失败 [%s]: %w", bangumi.Name, err)
 	}
 
+	savePath, err := s.savePath(bangumi)
+	if err != nil {
+		return fmt.Errorf("生成保存路径失败 [%s]: %w", bangumi.Name, err)
+	}
+	if profile, ok := s.qualityProfile(bangumi.QualityProfile); ok {
+		return s.handleQualitySubscription(ctx, bangumi, savePath, rss.Items, profile)
+	}
+
 	var errs *multierror.Error
 	// 处理RSS中的每个item
 	for _, item := range rss.Items {
 		// 根据包含和排除规则过滤
-		if !s.matchesFilters(ctx, item.GUID, bangumi.IncludeRegs, bangumi.ExcludeRegs) {
+		if !s.matchesFilters(ctx, item.GUID, bangumi.IncludeRegs, bangumi.ExcludeRegs, bangumi.TitleFilter) {
 			continue
 		}
 
@@ -354,21 +454,41 @@ func (s *Subscriber) handleBangumiSubscription(ctx context.Context, bangumi *Ban
 			continue
 		}
 
-		hash, err := extractHashFromTorrentLink(item.TorrentLink)
+		bf, err := s.bfParser.Parse(ctx, item.GUID,
+			bangumifile.WithEpisodeLocation(bangumi.EpisodeLocation),
+			bangumifile.WithEpisodeOffset(bangumi.EpisodeOffset),
+		)
+		if err == nil && bf.IsBatch() {
+			if err := s.handleBatchItem(ctx, bangumi, savePath, item, bf); err != nil {
+				errs = multierror.Append(errs, err)
+			}
+			continue
+		}
+		episode := 0
+		if err == nil && !bf.IsSpecialEpisode() {
+			episode = bf.Episode
+		}
+		if err == nil && !bf.IsSpecialEpisode() && releaseVersion(bf) == 1 {
+			// 已经通过合集下载的集不再重复下载单集资源，特别篇的编号与正片集数无关
+			covered, err := s.isAlreadyDownloaded(ctx, bangumi.SubscriptionID, episodeRecordGUID(bf.Episode))
+			if err != nil {
+				errs = multierror.Append(errs, fmt.Errorf("检查下载状态失败 [%s]: %w", item.GUID, err))
+				continue
+			}
+			if covered {
+				log.Infof(ctx, "第%d集已通过合集下载，跳过 [%s]", bf.Episode, item.GUID)
+				continue
+			}
+		}
+
+		hash, err := itemHash(item)
 		if err != nil {
 			errs = multierror.Append(errs, fmt.Errorf("提取哈希值失败 [%s]: %w", item.GUID, err))
 			continue
 		}
 
 		// 执行下载
-		err = s.downloader.Download(ctx, downloader.DownloadReq{
-			TorrentLink:    item.TorrentLink,
-			SavePath:       filepath.Join(bangumi.Name, fmt.Sprintf("Season %d", bangumi.Season)),
-			DownloadType:   downloader.DownloadTypeTV,
-			SubscriptionID: bangumi.SubscriptionID,
-			Hash:           hash,
-			RSSGUID:        item.GUID,
-		})
+		err = s.downloader.Download(ctx, newDownloadReq(bangumi, savePath, hash, item, episode))
 		if nerr := s.notifier.NoticeSubscriptionUpdated(ctx, notice.NoticeSubscriptionUpdatedReq{
 			BangumiName:  bangumi.Name,
 			Season:       bangumi.Season,
@@ -395,7 +515,61 @@ func (s *Subscriber) handleBangumiSubscription(ctx context.Context, bangumi *Ban
 	return errs.ErrorOrNil()
 }
 
+// newDownloadReq 创建订阅RSS条目的下载请求，episode 为资源的集数，合集为起始集数，未知时为0
+func newDownloadReq(bangumi *Bangumi, savePath, hash string, item RSSItem, episode int) downloader.DownloadReq {
+	return downloader.DownloadReq{
+		TorrentLink:    item.TorrentLink,
+		SavePath:       savePath,
+		DownloadType:   downloader.DownloadTypeTV,
+		SubscriptionID: bangumi.SubscriptionID,
+		Hash:           hash,
+		RSSGUID:        item.GUID,
+		Downloader:     bangumi.Downloader,
+		ReleaseGroup:   bangumi.ReleaseGroup,
+		Category:       bangumi.Category,
+		Tags:           bangumi.Tags,
+		SeedingPolicy:  bangumi.SeedingPolicy,
+		Priority:       bangumi.Priority,
+		PublishedAt:    item.PublishedAt,
+		Episode:        episode,
+		Size:           item.Size,
+	}
+}
+
+// savePath 根据保存路径模板生成订阅的保存路径，生成的路径相对于下载管理器的TV保存路径
+func (s *Subscriber) savePath(bangumi *Bangumi) (string, error) {
+	template := bangumi.SavePath
+	if template == "" {
+		template = s.config.SavePath
+	}
+	if template == "" {
+		template = defaultSavePath
+	}
+	savePath := filepath.Clean(strings.NewReplacer(
+		"{name}", bangumi.Name,
+		"{season}", strconv.Itoa(bangumi.Season),
+		"{year}", bangumi.Year,
+		"{release_group}", bangumi.ReleaseGroup,
+	).Replace(template))
+	if !filepath.IsLocal(savePath) {
+		return "", fmt.Errorf("保存路径模板 %s 生成的路径 %s 不是相对路径或超出了保存目录", template, savePath)
+	}
+	return savePath, nil
+}
+
+// itemHash 获取RSS条目的种子哈希值，RSS中没有提供时从种子链接中解析
+func itemHash(item RSSItem) (string, error) {
+	if item.Hash != "" {
+		return strings.ToLower(item.Hash), nil
+	}
+	return extractHashFromTorrentLink(item.TorrentLink)
+}
+
 func extractHashFromTorrentLink(torrentLink string) (string, error) {
+	if strings.HasPrefix(torrentLink, "magnet:") {
+		return extractHashFromMagnet(torrentLink)
+	}
+
 	// 获取URL的最后一部分
 	base := path.Base(torrentLink)
 
@@ -406,7 +580,7 @@ func extractHashFromTorrentLink(torrentLink string) (string, error) {
 }
 
 // matchesFilters 检查是否符合过滤规则
-func (s *Subscriber) matchesFilters(ctx context.Context, guid string, includeRegs, excludeRegs []string) bool {
+func (s *Subscriber) matchesFilters(ctx context.Context, guid string, includeRegs, excludeRegs []string, titleFilter *TitleFilter) bool {
 	includeRegs = append(includeRegs, s.config.IncludeRegs...)
 	excludeRegs = append(excludeRegs, s.config.ExcludeRegs...)
 	// 优先检查排除规则
@@ -418,6 +592,10 @@ func (s *Subscriber) matchesFilters(ctx context.Context, guid string, includeReg
 		}
 	}
 
+	if !s.matchesTitleFilter(ctx, guid, titleFilter) {
+		return false
+	}
+
 	// 如果没有包含规则，默认包含所有
 	if len(includeRegs) == 0 {
 		return true
@@ -435,6 +613,23 @@ func (s *Subscriber) matchesFilters(ctx context.Context, guid string, includeReg
 	return true
 }
 
+// matchesTitleFilter 解析标题中的分辨率、字幕等信息，检查是否符合标题过滤条件
+func (s *Subscriber) matchesTitleFilter(ctx context.Context, guid string, titleFilter *TitleFilter) bool {
+	if titleFilter == nil || s.bfParser == nil {
+		return true
+	}
+	bf, err := s.bfParser.Parse(ctx, guid, bangumifile.IgnoreValidateEpisode(), bangumifile.PreserveOriginName())
+	if err != nil {
+		log.Debugf(ctx, "%s 解析标题失败，跳过标题过滤: %v", guid, err)
+		return true
+	}
+	if reason, ok := titleFilter.match(bf); !ok {
+		log.Debugf(ctx, "%s 不符合标题过滤条件: %s", guid, reason)
+		return false
+	}
+	return true
+}
+
 // isAlreadyDownloaded 检查种子是否已经下载过
 func (s *Subscriber) isAlreadyDownloaded(ctx context.Context, subscriptionID string, guid string) (bool, error) {
 	return s.rssRecord.IsProcessed(ctx, subscriptionID, guid)
@@ -491,9 +686,9 @@ func (s *Subscriber) GetRSSMatch(ctx context.Context, subscriptionID string) ([]
 	if err != nil {
 		return nil, fmt.Errorf("获取订阅失败: %w", err)
 	}
-	rss, err := s.rssParser.Parse(ctx, bangumi.RSSLink)
+	rss, err := s.parseSubscription(ctx, &bangumi)
 	if err != nil {
-		return nil, fmt.Errorf("解析RSS失败: %w", err)
+		return nil, err
 	}
 
 	// 获取所有已处理的GUID
@@ -512,7 +707,7 @@ func (s *Subscriber) GetRSSMatch(ctx context.Context, subscriptionID string) ([]
 	for _, item := range rss.Items {
 		matches = append(matches, RSSMatch{
 			GUID:        item.GUID,
-			Match:       s.matchesFilters(ctx, item.GUID, bangumi.IncludeRegs, bangumi.ExcludeRegs),
+			Match:       s.matchesFilters(ctx, item.GUID, bangumi.IncludeRegs, bangumi.ExcludeRegs, bangumi.TitleFilter),
 			Processed:   processedMap[item.GUID],
 			PublishedAt: item.PublishedAt,
 		})
@@ -529,7 +724,7 @@ func (s *Subscriber) PreviewRSSMatch(ctx context.Context, req PreviewRSSMatchReq
 	for _, item := range rss.Items {
 		matches = append(matches, RSSMatch{
 			GUID:        item.GUID,
-			Match:       s.matchesFilters(ctx, item.GUID, req.IncludeRegs, req.ExcludeRegs),
+			Match:       s.matchesFilters(ctx, item.GUID, req.IncludeRegs, req.ExcludeRegs, req.TitleFilter),
 			Processed:   false,
 			PublishedAt: item.PublishedAt,
 		})
@@ -276,3 +276,93 @@ func (mr *MockRSSRecordRepositoryMockRecorder) MarkProcessed(ctx, subscriptionID
 	varargs := append([]interface{}{ctx, subscriptionID}, guid...)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkProcessed", reflect.TypeOf((*MockRSSRecordRepository)(nil).MarkProcessed), varargs...)
 }
+
+// MockEpisodeQualityRepository is a mock of EpisodeQualityRepository interface.
+type MockEpisodeQualityRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockEpisodeQualityRepositoryMockRecorder
+}
+
+// MockEpisodeQualityRepositoryMockRecorder is the mock recorder for MockEpisodeQualityRepository.
+type MockEpisodeQualityRepositoryMockRecorder struct {
+	mock *MockEpisodeQualityRepository
+}
+
+// NewMockEpisodeQualityRepository creates a new mock instance.
+func NewMockEpisodeQualityRepository(ctrl *gomock.Controller) *MockEpisodeQualityRepository {
+	mock := &MockEpisodeQualityRepository{ctrl: ctrl}
+	mock.recorder = &MockEpisodeQualityRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEpisodeQualityRepository) EXPECT() *MockEpisodeQualityRepositoryMockRecorder {
+	return m.recorder
+}
+
+// GetEpisodeQuality mocks base method.
+func (m *MockEpisodeQualityRepository) GetEpisodeQuality(ctx context.Context, subscriptionID string, episode int) (EpisodeQuality, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetEpisodeQuality", ctx, subscriptionID, episode)
+	ret0, _ := ret[0].(EpisodeQuality)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetEpisodeQuality indicates an expected call of GetEpisodeQuality.
+func (mr *MockEpisodeQualityRepositoryMockRecorder) GetEpisodeQuality(ctx, subscriptionID, episode interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetEpisodeQuality", reflect.TypeOf((*MockEpisodeQualityRepository)(nil).GetEpisodeQuality), ctx, subscriptionID, episode)
+}
+
+// SaveEpisodeQuality mocks base method.
+func (m *MockEpisodeQualityRepository) SaveEpisodeQuality(ctx context.Context, quality EpisodeQuality) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveEpisodeQuality", ctx, quality)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveEpisodeQuality indicates an expected call of SaveEpisodeQuality.
+func (mr *MockEpisodeQualityRepositoryMockRecorder) SaveEpisodeQuality(ctx, quality interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveEpisodeQuality", reflect.TypeOf((*MockEpisodeQualityRepository)(nil).SaveEpisodeQuality), ctx, quality)
+}
+
+// MockEpisodeLibrary is a mock of EpisodeLibrary interface.
+type MockEpisodeLibrary struct {
+	ctrl     *gomock.Controller
+	recorder *MockEpisodeLibraryMockRecorder
+}
+
+// MockEpisodeLibraryMockRecorder is the mock recorder for MockEpisodeLibrary.
+type MockEpisodeLibraryMockRecorder struct {
+	mock *MockEpisodeLibrary
+}
+
+// NewMockEpisodeLibrary creates a new mock instance.
+func NewMockEpisodeLibrary(ctrl *gomock.Controller) *MockEpisodeLibrary {
+	mock := &MockEpisodeLibrary{ctrl: ctrl}
+	mock.recorder = &MockEpisodeLibraryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEpisodeLibrary) EXPECT() *MockEpisodeLibraryMockRecorder {
+	return m.recorder
+}
+
+// ListEpisodes mocks base method.
+func (m *MockEpisodeLibrary) ListEpisodes(ctx context.Context, bangumiName string, season int) ([]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListEpisodes", ctx, bangumiName, season)
+	ret0, _ := ret[0].([]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListEpisodes indicates an expected call of ListEpisodes.
+func (mr *MockEpisodeLibraryMockRecorder) ListEpisodes(ctx, bangumiName, season interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListEpisodes", reflect.TypeOf((*MockEpisodeLibrary)(nil).ListEpisodes), ctx, bangumiName, season)
+}
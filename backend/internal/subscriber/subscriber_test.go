@@ -166,3 +166,53 @@ func TestSubscriber_HandleEpisodeTransferred(t *testing.T) {
 		})
 	}
 }
+
+func TestSubscriber_SavePath(t *testing.T) {
+	bangumi := Bangumi{Name: "葬送的芙莉莲", Season: 1, Year: "2023", ReleaseGroup: "LoliHouse"}
+
+	testCases := []struct {
+		name     string
+		config   Config
+		template string
+		want     string
+		wantErr  bool
+	}{
+		{
+			name: "when no template then uses default",
+			want: "葬送的芙莉莲/Season 1",
+		},
+		{
+			name:   "when global template then replaces variables",
+			config: Config{SavePath: "{year}/{name}"},
+			want:   "2023/葬送的芙莉莲",
+		},
+		{
+			name:     "when subscription template then overrides global",
+			config:   Config{SavePath: "{year}/{name}"},
+			template: "{release_group}/{name} S{season}",
+			want:     "LoliHouse/葬送的芙莉莲 S1",
+		},
+		{
+			name:     "when template escapes save dir then returns error",
+			template: "../{name}",
+			wantErr:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			subscriber := &Subscriber{config: tc.config}
+			b := bangumi
+			b.SavePath = tc.template
+
+			got, err := subscriber.savePath(&b)
+
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
@@ -1,30 +1,106 @@
 package subscriber
 
-import "time"
+import (
+	"time"
+
+	"github.com/MangataL/BangumiBuddy/internal/downloader"
+)
 
 // Bangumi 番剧信息
 type Bangumi struct {
-	SubscriptionID  string       `json:"subscriptionID"`  // 订阅ID
-	Name            string       `json:"name"`            // 番剧名称
-	RSSLink         string       `json:"rssLink"`         // 番剧RSS链接
-	Active          bool         `json:"active"`          // 订阅状态，true表示订阅中，false表示已停止
-	IncludeRegs     []string     `json:"includeRegs"`     // 包含匹配，多个正则表达式，作用于RSS标题
-	ExcludeRegs     []string     `json:"excludeRegs"`     // 排除匹配，多个正则表达式，作用于RSS标题
-	Priority        int          `json:"priority"`        // 优先级，同一个番剧，优先级高的会覆盖优先级低的
-	EpisodeOffset   int          `json:"episodeOffset"`   // 集数偏移
-	Season          int          `json:"season"`          // 季数
-	Year            string       `json:"year"`            // 年份
-	TMDBID          int          `json:"tmdbID"`          // TMDB ID
-	ReleaseGroup    string       `json:"releaseGroup"`    // 发布组
-	EpisodeLocation string       `json:"episodeLocation"` // 集数位置
-	PosterURL       string       `json:"posterURL"`       // 海报URL
-	BackdropURL     string       `json:"backdropURL"`     // 背景图URL
-	Overview        string       `json:"overview"`        // 简介
-	Genres          string       `json:"genres"`          // 类型
-	AirWeekday      time.Weekday `json:"airWeekday"`      // 播出时间
-	EpisodeTotalNum int          `json:"episodeTotalNum"` // 集数总数
-	LastAirEpisode  int          `json:"lastAirEpisode"`  // 最新下载集数
-	CreatedAt       time.Time    `json:"-"`               // 创建时间
+	SubscriptionID  string                    `json:"subscriptionID"`  // 订阅ID
+	Name            string                    `json:"name"`            // 番剧名称
+	RSSLink         string                    `json:"rssLink"`         // 番剧RSS链接
+	Active          bool                      `json:"active"`          // 订阅状态，true表示订阅中，false表示已停止
+	IncludeRegs     []string                  `json:"includeRegs"`     // 包含匹配，多个正则表达式，作用于RSS标题
+	ExcludeRegs     []string                  `json:"excludeRegs"`     // 排除匹配，多个正则表达式，作用于RSS标题
+	Priority        int                       `json:"priority"`        // 优先级，同一个番剧，优先级高的会覆盖优先级低的
+	EpisodeOffset   int                       `json:"episodeOffset"`   // 集数偏移
+	Season          int                       `json:"season"`          // 季数
+	Year            string                    `json:"year"`            // 年份
+	TMDBID          int                       `json:"tmdbID"`          // TMDB ID
+	ReleaseGroup    string                    `json:"releaseGroup"`    // 发布组
+	EpisodeLocation string                    `json:"episodeLocation"` // 集数位置
+	PosterURL       string                    `json:"posterURL"`       // 海报URL
+	BackdropURL     string                    `json:"backdropURL"`     // 背景图URL
+	Overview        string                    `json:"overview"`        // 简介
+	Genres          string                    `json:"genres"`          // 类型
+	AirWeekday      time.Weekday              `json:"airWeekday"`      // 播出时间
+	EpisodeTotalNum int                       `json:"episodeTotalNum"` // 集数总数
+	LastAirEpisode  int                       `json:"lastAirEpisode"`  // 最新下载集数
+	Downloader      string                    `json:"downloader"`      // 下载器实例名称，为空时按路由规则选择
+	Category        string                    `json:"category"`        // 下载器分类，为空时使用下载器的默认分类
+	Tags            []string                  `json:"tags"`            // 下载器额外标签
+	SavePath        string                    `json:"savePath"`        // 保存路径模板，为空时使用全局配置
+	SeedingPolicy   *downloader.SeedingPolicy `json:"seedingPolicy"`   // 做种策略，为空时使用全局配置
+	Kind            SubscriptionKind          `json:"kind"`            // 订阅类型，为空时为RSS订阅
+	SearchQuery     string                    `json:"searchQuery"`     // 搜索关键词，关键词订阅使用
+	Preference      *Preference               `json:"preference"`      // 资源偏好，关键词订阅使用，为空时使用全局配置
+	QualityProfile  string                    `json:"qualityProfile"`  // 质量配置名称，为空时不按质量评分选择资源
+	TitleFilter     *TitleFilter              `json:"titleFilter"`     // 标题过滤条件，按解析出的分辨率、字幕等信息过滤资源
+	AbsoluteEpisode *AbsoluteEpisode          `json:"absoluteEpisode"` // 绝对集数映射，资源按绝对集数发布时设置，为空时不映射
+	CreatedAt       time.Time                 `json:"-"`               // 创建时间
+}
+
+// SubscriptionKind 订阅类型
+type SubscriptionKind string
+
+const (
+	// SubscriptionKindRSS 绑定单个RSS链接的订阅
+	SubscriptionKindRSS SubscriptionKind = "rss"
+	// SubscriptionKindKeyword 按关键词聚合多个搜索源的订阅
+	SubscriptionKindKeyword SubscriptionKind = "keyword"
+)
+
+// IsKeyword 是否为关键词订阅
+func (b Bangumi) IsKeyword() bool {
+	return b.Kind == SubscriptionKindKeyword
+}
+
+// AbsoluteEpisode 绝对集数映射配置，转移时将绝对集数转换为TMDB中对应的季和集
+type AbsoluteEpisode struct {
+	EpisodeGroupID string `json:"episodeGroupID"` // TMDB剧集组ID，为空时按TMDB各季的集数顺序映射
+}
+
+// Preference 资源偏好，列表中越靠前越优先，不在列表中的排在最后
+type Preference struct {
+	ReleaseGroups     []string `mapstructure:"release_groups" json:"releaseGroups"`         // 发布组
+	Resolutions       []string `mapstructure:"resolutions" json:"resolutions"`              // 分辨率，如 2160p、1080p
+	SubtitleLanguages []string `mapstructure:"subtitle_languages" json:"subtitleLanguages"` // 字幕语言关键词，如 简繁、简体、CHT
+}
+
+// QualityProfile 质量配置，按标题中的关键词为资源评分
+// 每个维度的列表中越靠前得分越高，维度的权重依次为分辨率、来源、字幕、编码
+type QualityProfile struct {
+	Name              string   `mapstructure:"name" json:"name"`                             // 配置名称
+	Resolutions       []string `mapstructure:"resolutions" json:"resolutions"`               // 分辨率，如 2160p、1080p
+	Sources           []string `mapstructure:"sources" json:"sources"`                       // 来源，如 BDRip、WebRip
+	SubtitleTags      []string `mapstructure:"subtitle_tags" json:"subtitleTags"`            // 字幕标签，如 简日、繁日、内封
+	Codecs            []string `mapstructure:"codecs" json:"codecs"`                         // 编码，如 HEVC、AVC
+	ReleaseGroups     []string `mapstructure:"release_groups" json:"releaseGroups"`          // 发布组白名单，为空时不限制
+	UpgradeWindowDays int      `mapstructure:"upgrade_window_days" json:"upgradeWindowDays"` // 洗版窗口，首次下载后的天数内出现更高分资源时替换，为0时不洗版
+}
+
+// TitleFilter 标题过滤条件，按解析出的标题信息过滤资源，为空的条件不限制
+// 标题中无法识别的信息不参与过滤，如标题未标注分辨率时不受分辨率条件限制
+type TitleFilter struct {
+	Resolutions       []string `json:"resolutions"`       // 允许的分辨率，如 1080p
+	VideoCodecs       []string `json:"videoCodecs"`       // 允许的视频编码，如 HEVC、AVC
+	Sources           []string `json:"sources"`           // 允许的片源，如 WebRip、BDRip
+	SubtitleLanguages []string `json:"subtitleLanguages"` // 必须包含的字幕语言，取值为 简、繁、日
+	SubtitleTypes     []string `json:"subtitleTypes"`     // 允许的字幕形式，取值为 内封、内嵌、外挂
+	ExcludeBatch      bool     `json:"excludeBatch"`      // 排除合集
+	ExcludeSpecial    bool     `json:"excludeSpecial"`    // 排除OVA、SP、NCOP等非正片资源
+}
+
+// EpisodeQuality 订阅单集已下载资源的质量记录
+type EpisodeQuality struct {
+	SubscriptionID string    // 订阅ID
+	Episode        int       // 集数
+	GUID           string    // 已下载的RSS条目
+	Score          int       // 质量评分
+	Version        int       // 发布版本，如 v2 为2
+	CreatedAt      time.Time // 首次下载时间，用于计算洗版窗口
 }
 
 // ParserRSSReq 解析RSS请求
@@ -57,24 +133,38 @@ type RSS struct {
 
 // RSSItem RSS节点信息
 type RSSItem struct {
-	GUID        string
-	TorrentLink string
-	PublishedAt time.Time
+	GUID         string
+	TorrentLink  string
+	PublishedAt  time.Time
+	Hash         string // 种子哈希值，为空时从种子链接中解析
+	Size         int64  // 资源大小，单位 bytes，未知时为0
+	ReleaseGroup string // 发布组
 }
 
 // SubscribeReq 订阅请求
 type SubscribeReq struct {
-	RSSLink         string       `json:"rssLink" binding:"required"`      // RSS链接
-	Season          int          `json:"season" binding:"gt=0"`           // 季数
-	IncludeRegs     []string     `json:"includeRegs"`                     // 包含匹配，多个正则表达式，作用于RSS标题
-	ExcludeRegs     []string     `json:"excludeRegs"`                     // 排除匹配，多个正则表达式，作用于RSS标题
-	EpisodeOffset   int          `json:"episodeOffset"`                   // 集数偏移
-	Priority        int          `json:"priority"`                        // 优先级，同一个番剧，优先级高的会覆盖优先级低的
-	TMDBID          int          `json:"tmdbID" binding:"required"`       // TMDB ID
-	ReleaseGroup    string       `json:"releaseGroup" binding:"required"` // 发布组
-	EpisodeLocation string       `json:"episodeLocation"`                 // 集数位置
-	EpisodeTotalNum int          `json:"episodeTotalNum" binding:"gt=0"`  // 集数总数
-	AirWeekday      time.Weekday `json:"airWeekday"`                      // 播出时间
+	Kind            SubscriptionKind          `json:"kind"`                                                // 订阅类型，为空时为RSS订阅
+	RSSLink         string                    `json:"rssLink" binding:"required_unless=Kind keyword"`      // RSS链接
+	SearchQuery     string                    `json:"searchQuery"`                                         // 搜索关键词，关键词订阅必填
+	Preference      *Preference               `json:"preference"`                                          // 资源偏好，关键词订阅使用，为空时使用全局配置
+	Season          int                       `json:"season" binding:"gt=0"`                               // 季数
+	IncludeRegs     []string                  `json:"includeRegs"`                                         // 包含匹配，多个正则表达式，作用于RSS标题
+	ExcludeRegs     []string                  `json:"excludeRegs"`                                         // 排除匹配，多个正则表达式，作用于RSS标题
+	EpisodeOffset   int                       `json:"episodeOffset"`                                       // 集数偏移
+	Priority        int                       `json:"priority"`                                            // 优先级，同一个番剧，优先级高的会覆盖优先级低的
+	TMDBID          int                       `json:"tmdbID" binding:"required"`                           // TMDB ID
+	ReleaseGroup    string                    `json:"releaseGroup" binding:"required_unless=Kind keyword"` // 发布组
+	EpisodeLocation string                    `json:"episodeLocation"`                                     // 集数位置
+	EpisodeTotalNum int                       `json:"episodeTotalNum" binding:"gt=0"`                      // 集数总数
+	AirWeekday      time.Weekday              `json:"airWeekday"`                                          // 播出时间
+	Downloader      string                    `json:"downloader"`                                          // 下载器实例名称，为空时按路由规则选择
+	Category        string                    `json:"category"`                                            // 下载器分类，为空时使用下载器的默认分类
+	Tags            []string                  `json:"tags"`                                                // 下载器额外标签
+	SavePath        string                    `json:"savePath"`                                            // 保存路径模板，为空时使用全局配置
+	SeedingPolicy   *downloader.SeedingPolicy `json:"seedingPolicy"`                                       // 做种策略，为空时使用全局配置
+	QualityProfile  string                    `json:"qualityProfile"`                                      // 质量配置名称，为空时不按质量评分选择资源
+	TitleFilter     *TitleFilter              `json:"titleFilter"`                                         // 标题过滤条件，按解析出的分辨率、字幕等信息过滤资源
+	AbsoluteEpisode *AbsoluteEpisode          `json:"absoluteEpisode"`                                     // 绝对集数映射，资源按绝对集数发布时设置，为空时不映射
 }
 
 // ListBangumiReq 查询番剧请求
@@ -88,22 +178,33 @@ type ListBangumiReq struct {
 
 // UpdateSubscribeReq 更新订阅请求
 type UpdateSubscribeReq struct {
-	SubscriptionID  string       `json:"-"`               // 订阅ID
-	Active          bool         `json:"active"`          // 订阅状态
-	IncludeRegs     []string     `json:"includeRegs"`     // 包含匹配，多个正则表达式，作用于RSS标题
-	ExcludeRegs     []string     `json:"excludeRegs"`     // 排除匹配，多个正则表达式，作用于RSS标题
-	EpisodeOffset   int          `json:"episodeOffset"`   // 集数偏移
-	Priority        int          `json:"priority"`        // 优先级，同一个番剧，优先级高的会覆盖优先级低的
-	EpisodeLocation string       `json:"episodeLocation"` // 集数位置
-	EpisodeTotalNum int          `json:"episodeTotalNum"` // 集数总数
-	AirWeekday      time.Weekday `json:"airWeekday"`      // 播出时间
+	SubscriptionID  string                    `json:"-"`               // 订阅ID
+	Active          bool                      `json:"active"`          // 订阅状态
+	IncludeRegs     []string                  `json:"includeRegs"`     // 包含匹配，多个正则表达式，作用于RSS标题
+	ExcludeRegs     []string                  `json:"excludeRegs"`     // 排除匹配，多个正则表达式，作用于RSS标题
+	EpisodeOffset   int                       `json:"episodeOffset"`   // 集数偏移
+	Priority        int                       `json:"priority"`        // 优先级，同一个番剧，优先级高的会覆盖优先级低的
+	EpisodeLocation string                    `json:"episodeLocation"` // 集数位置
+	EpisodeTotalNum int                       `json:"episodeTotalNum"` // 集数总数
+	AirWeekday      time.Weekday              `json:"airWeekday"`      // 播出时间
+	Downloader      string                    `json:"downloader"`      // 下载器实例名称，为空时按路由规则选择
+	Category        string                    `json:"category"`        // 下载器分类，为空时使用下载器的默认分类
+	Tags            []string                  `json:"tags"`            // 下载器额外标签
+	SavePath        string                    `json:"savePath"`        // 保存路径模板，为空时使用全局配置
+	SeedingPolicy   *downloader.SeedingPolicy `json:"seedingPolicy"`   // 做种策略，为空时使用全局配置
+	SearchQuery     string                    `json:"searchQuery"`     // 搜索关键词，仅关键词订阅生效
+	Preference      *Preference               `json:"preference"`      // 资源偏好，仅关键词订阅生效
+	QualityProfile  string                    `json:"qualityProfile"`  // 质量配置名称，为空时不按质量评分选择资源
+	TitleFilter     *TitleFilter              `json:"titleFilter"`     // 标题过滤条件，按解析出的分辨率、字幕等信息过滤资源
+	AbsoluteEpisode *AbsoluteEpisode          `json:"absoluteEpisode"` // 绝对集数映射，资源按绝对集数发布时设置，为空时不映射
 }
 
 // PreviewRSSMatchReq 预览RSS匹配请求
 type PreviewRSSMatchReq struct {
-	RSSLink     string   `json:"rssLink"`
-	IncludeRegs []string `json:"includeRegs"`
-	ExcludeRegs []string `json:"excludeRegs"`
+	RSSLink     string       `json:"rssLink"`
+	IncludeRegs []string     `json:"includeRegs"`
+	ExcludeRegs []string     `json:"excludeRegs"`
+	TitleFilter *TitleFilter `json:"titleFilter"`
 }
 
 // RSSMatch RSS匹配
@@ -127,3 +228,44 @@ type CalendarItem struct {
 	PosterURL   string `json:"posterURL"`
 	Season      int    `json:"season"`
 }
+
+// BackfillEpisode 订阅缺失的一集和可用于补全的资源
+type BackfillEpisode struct {
+	Episode    int                 `json:"episode"`    // 集数
+	Candidates []BackfillCandidate `json:"candidates"` // 候选资源，原发布组优先，其余按资源偏好排序
+}
+
+// BackfillCandidate 用于补全缺失剧集的资源
+type BackfillCandidate struct {
+	Title            string    `json:"title"`            // 资源标题
+	TorrentLink      string    `json:"torrentLink"`      // 种子链接
+	ReleaseGroup     string    `json:"releaseGroup"`     // 发布组
+	PublishedAt      time.Time `json:"publishedAt"`      // 发布时间
+	SameReleaseGroup bool      `json:"sameReleaseGroup"` // 是否为订阅的发布组
+}
+
+// BackfillReq 补全缺失剧集请求
+type BackfillReq struct {
+	SubscriptionID string             `json:"-"`                        // 订阅ID
+	Auto           bool               `json:"auto"`                     // 为每个缺失的集自动下载排在第一的候选资源
+	Downloads      []BackfillDownload `json:"downloads" binding:"dive"` // 手动选择下载的资源，Auto 为 true 时忽略
+}
+
+// BackfillDownload 补全下载的资源
+type BackfillDownload struct {
+	Episode     int    `json:"episode" binding:"gt=0"`         // 集数
+	Title       string `json:"title" binding:"required"`       // 资源标题
+	TorrentLink string `json:"torrentLink" binding:"required"` // 种子链接
+}
+
+// BackfillRsp 补全缺失剧集结果
+type BackfillRsp struct {
+	Downloaded []BackfillDownload `json:"downloaded"` // 成功添加下载任务的资源
+	Failures   []BackfillFailure  `json:"failures"`   // 添加下载任务失败的资源
+}
+
+// BackfillFailure 补全下载失败的资源
+type BackfillFailure struct {
+	BackfillDownload
+	Message string `json:"message"` // 失败原因
+}
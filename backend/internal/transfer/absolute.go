@@ -0,0 +1,28 @@
+package transfer
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	metapkg "github.com/MangataL/BangumiBuddy/internal/meta"
+	"github.com/MangataL/BangumiBuddy/pkg/log"
+)
+
+// episodeMappingCacheTTL 绝对集数映射的缓存时间，避免转移合集时重复请求TMDB
+const episodeMappingCacheTTL = time.Hour
+
+// mapAbsoluteEpisode 将绝对集数映射为TMDB中的季和集
+func (t *Transfer) mapAbsoluteEpisode(ctx context.Context, meta Meta, absolute int) (metapkg.SeasonEpisode, error) {
+	mapping, err := t.metaParser.GetEpisodeMapping(ctx, meta.TMDBID, meta.EpisodeGroupID, metapkg.WithCacheTTL(episodeMappingCacheTTL))
+	if err != nil {
+		return metapkg.SeasonEpisode{}, errors.WithMessage(err, "获取绝对集数映射失败")
+	}
+	seasonEpisode, ok := mapping.Map(absolute)
+	if !ok {
+		return metapkg.SeasonEpisode{}, errors.Errorf("绝对集数 %d 超出TMDB中的集数范围(共%d集)", absolute, len(mapping))
+	}
+	log.Infof(ctx, "文件 %s 的绝对集数 %d 映射为第%d季第%d集", meta.FileName, absolute, seasonEpisode.Season, seasonEpisode.Episode)
+	return seasonEpisode, nil
+}
@@ -0,0 +1,62 @@
+package transfer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	metapkg "github.com/MangataL/BangumiBuddy/internal/meta"
+	"github.com/MangataL/BangumiBuddy/pkg/bangumifile"
+)
+
+func TestTransfer_transferFileWithCheckers_AbsoluteEpisode(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	bfParser := bangumifile.NewMockParser(ctrl)
+	bfParser.EXPECT().Parse(ctx, "[A] 番剧 - 14 [1080p].mkv", gomock.Any()).
+		Return(bangumifile.BangumiFile{Episode: 14}, nil).Times(2)
+	metaParser := metapkg.NewMockParser(ctrl)
+	metaParser.EXPECT().GetEpisodeMapping(ctx, 100, "group", gomock.Any()).Return(metapkg.EpisodeMapping{
+		{Season: 1, Episode: 1}, {Season: 1, Episode: 2}, {Season: 1, Episode: 3}, {Season: 1, Episode: 4},
+		{Season: 1, Episode: 5}, {Season: 1, Episode: 6}, {Season: 1, Episode: 7}, {Season: 1, Episode: 8},
+		{Season: 1, Episode: 9}, {Season: 1, Episode: 10}, {Season: 1, Episode: 11}, {Season: 1, Episode: 12},
+		{Season: 2, Episode: 1}, {Season: 2, Episode: 2},
+	}, nil)
+	metaParser.EXPECT().GetEpisodeMapping(ctx, 100, "", gomock.Any()).Return(metapkg.EpisodeMapping{
+		{Season: 1, Episode: 1},
+	}, nil)
+	transfer := &Transfer{bfParser: bfParser, metaParser: metaParser}
+
+	var gotFileID string
+	skip := func(ctx context.Context, newFileID string, bf bangumifile.BangumiFile) (bool, error) {
+		gotFileID = newFileID
+		return false, nil
+	}
+	meta := Meta{
+		ChineseName:     "番剧",
+		Season:          1,
+		FileName:        "[A] 番剧 - 14 [1080p].mkv",
+		TMDBID:          100,
+		AbsoluteEpisode: true,
+		EpisodeGroupID:  "group",
+	}
+	_, transferred, err := transfer.transferFileWithCheckers(ctx, &meta, skip)
+	require.NoError(t, err)
+	assert.False(t, transferred)
+	assert.Equal(t, "番剧/2/2", gotFileID)
+	assert.Equal(t, 2, meta.Season)
+	assert.Equal(t, 2, meta.Episode)
+
+	meta = Meta{
+		ChineseName:     "番剧",
+		Season:          1,
+		FileName:        "[A] 番剧 - 14 [1080p].mkv",
+		TMDBID:          100,
+		AbsoluteEpisode: true,
+	}
+	_, _, err = transfer.transferFileWithCheckers(ctx, &meta, skip)
+	assert.Error(t, err, "超出映射范围时转移失败")
+}
@@ -0,0 +1,45 @@
+package transfer
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/MangataL/BangumiBuddy/internal/subscriber"
+)
+
+var _ subscriber.EpisodeLibrary = (*episodeLibrary)(nil)
+
+// NewEpisodeLibrary 创建基于转移记录的媒体库查询
+func NewEpisodeLibrary(transferFiles TransferFilesRepo) subscriber.EpisodeLibrary {
+	return &episodeLibrary{transferFiles: transferFiles}
+}
+
+type episodeLibrary struct {
+	transferFiles TransferFilesRepo
+}
+
+// ListEpisodes 从转移记录的新文件ID(番剧名/季/集)中获取已转移的集数
+func (l *episodeLibrary) ListEpisodes(ctx context.Context, bangumiName string, season int) ([]int, error) {
+	files, err := l.transferFiles.List(ctx, ListFileTransferredReq{
+		BangumiName: bangumiName,
+		Season:      season,
+	})
+	if err != nil {
+		return nil, errors.WithMessage(err, "获取转移记录失败")
+	}
+	episodes := make([]int, 0, len(files))
+	for _, file := range files {
+		if file.Season != season {
+			continue
+		}
+		episode, err := strconv.Atoi(file.NewFileID[strings.LastIndex(file.NewFileID, "/")+1:])
+		if err != nil {
+			continue
+		}
+		episodes = append(episodes, episode)
+	}
+	return episodes, nil
+}
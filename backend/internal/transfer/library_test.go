@@ -0,0 +1,25 @@
+package transfer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEpisodeLibrary_ListEpisodes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := NewMockTransferFilesRepo(ctrl)
+	repo.EXPECT().List(gomock.Any(), ListFileTransferredReq{BangumiName: "番剧", Season: 1}).Return([]FileTransferred{
+		{BangumiName: "番剧", Season: 1, NewFileID: "番剧/1/1"},
+		{BangumiName: "番剧", Season: 1, NewFileID: "番剧/1/12"},
+		{BangumiName: "番剧", Season: 1, NewFileID: "番剧 (2024)"},
+	}, nil)
+
+	episodes, err := NewEpisodeLibrary(repo).ListEpisodes(context.Background(), "番剧", 1)
+
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 12}, episodes)
+}
@@ -0,0 +1,75 @@
+package transfer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+
+	"github.com/MangataL/BangumiBuddy/pkg/log"
+)
+
+// replacedFilePrefix 被替换文件的备份文件名前缀，以.开头避免被媒体服务器扫描
+const replacedFilePrefix = ".bangumibuddy-replaced-"
+
+// replacedFiles 被新版本替换的媒体库文件
+// 旧文件先移动为备份，新文件转移成功后删除备份，转移失败时恢复旧文件和转移记录，保证媒体库中始终有可用的版本
+type replacedFiles struct {
+	record  *FileTransferred
+	backups map[string]string // 原文件路径 -> 备份文件路径
+}
+
+// backup 将文件移动为备份
+func (r *replacedFiles) backup(file string) error {
+	backup := filepath.Join(filepath.Dir(file), replacedFilePrefix+filepath.Base(file))
+	if err := os.Rename(file, backup); err != nil {
+		return errors.WithMessagef(err, "备份文件 %s 失败", file)
+	}
+	if r.backups == nil {
+		r.backups = make(map[string]string)
+	}
+	r.backups[file] = backup
+	return nil
+}
+
+// commit 新文件转移成功，删除旧文件的备份
+func (r *replacedFiles) commit(ctx context.Context) {
+	for file, backup := range r.backups {
+		if err := os.Remove(backup); err != nil && !os.IsNotExist(err) {
+			log.Warnf(ctx, "删除被替换文件 %s 的备份失败: %v", file, err)
+		}
+	}
+	r.backups = nil
+}
+
+// restore 新文件转移失败，恢复旧文件和转移记录
+func (r *replacedFiles) restore(ctx context.Context, transferFiles TransferFilesRepo) {
+	if len(r.backups) == 0 {
+		return
+	}
+	for file, backup := range r.backups {
+		if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+			log.Warnf(ctx, "恢复被替换文件时删除新文件 %s 失败: %v", file, err)
+		}
+		if err := os.Rename(backup, file); err != nil {
+			log.Errorf(ctx, "恢复被替换文件 %s 失败: %v", file, err)
+			continue
+		}
+		log.Infof(ctx, "新文件转移失败，已恢复被替换的文件 %s", file)
+	}
+	r.backups = nil
+	if r.record == nil {
+		return
+	}
+	if err := transferFiles.Set(ctx, *r.record); err != nil {
+		log.Warnf(ctx, "恢复被替换文件的转移记录失败: %v", err)
+	}
+}
+
+// withReplacedFiles 删除媒体库文件时改为备份，由调用方在新文件转移后决定提交或恢复
+func withReplacedFiles(replaced *replacedFiles) deleteTransferFileOption {
+	return func(options *deleteTransferFilesOptions) {
+		options.replaced = replaced
+	}
+}
@@ -12,6 +12,8 @@ type fileTransferredSchema struct {
 	SubscriptionID    string `gorm:"type:varchar(36);index"`
 	BangumiName       string `gorm:"type:varchar(255);not null;index:idx_bangumi,priority:1"`
 	Season            int    `gorm:"type:int;not null;index:idx_bangumi,priority:2"`
+	QualityScore      int    `gorm:"type:int;not null;default:0"`
+	Version           int    `gorm:"type:int;not null;default:1"`
 }
 
 func (fileTransferredSchema) TableName() string {
@@ -26,6 +28,8 @@ func toFileTransferred(schema fileTransferredSchema) transfer.FileTransferred {
 		Season:         schema.Season,
 		SubscriptionID: schema.SubscriptionID,
 		NewFile:        schema.NewFile,
+		QualityScore:   schema.QualityScore,
+		Version:        schema.Version,
 	}
 }
 
@@ -37,5 +41,7 @@ func fromFileTransferred(fileTransferred transfer.FileTransferred) fileTransferr
 		Season:         fileTransferred.Season,
 		SubscriptionID: fileTransferred.SubscriptionID,
 		NewFile:        fileTransferred.NewFile,
+		QualityScore:   fileTransferred.QualityScore,
+		Version:        fileTransferred.Version,
 	}
 }
@@ -0,0 +1,96 @@
+package transfer
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/pkg/errors"
+
+	metapkg "github.com/MangataL/BangumiBuddy/internal/meta"
+	"github.com/MangataL/BangumiBuddy/pkg/log"
+)
+
+// specialAirDateTolerance 按播出日期匹配特别篇时，资源发布时间与播出日期允许的最大间隔
+const specialAirDateTolerance = 14 * 24 * time.Hour
+
+// mapSpecialEpisode 将OVA、SP等特别篇映射为TMDB第0季中的集数，先按标题匹配，匹配不到时按播出日期匹配，
+// 都匹配不到时使用文件名中解析出的特别篇集数
+func (t *Transfer) mapSpecialEpisode(ctx context.Context, meta Meta, parsedEpisode int) (int, error) {
+	episodes, err := t.metaParser.GetSeasonEpisodes(ctx, meta.TMDBID, 0)
+	if err != nil {
+		return 0, errors.WithMessage(err, "获取TMDB特别篇信息失败")
+	}
+	if episode, ok := matchSpecialByTitle(meta.FileName, episodes); ok {
+		log.Infof(ctx, "文件 %s 按标题匹配为特别篇第%d集", meta.FileName, episode)
+		return episode, nil
+	}
+	if episode, ok := matchSpecialByAirDate(meta.ReleasedAt, parsedEpisode, episodes); ok {
+		log.Infof(ctx, "文件 %s 按播出日期匹配为特别篇第%d集", meta.FileName, episode)
+		return episode, nil
+	}
+	for _, episode := range episodes {
+		if episode.Episode == parsedEpisode {
+			log.Infof(ctx, "文件 %s 按解析的集数匹配为特别篇第%d集", meta.FileName, episode.Episode)
+			return episode.Episode, nil
+		}
+	}
+	return 0, errors.Errorf("无法在TMDB特别篇(共%d集)中匹配文件 %s", len(episodes), meta.FileName)
+}
+
+// matchSpecialByTitle 文件名中包含单集标题时匹配成功，多个标题都匹配时选择最长的标题
+func matchSpecialByTitle(fileName string, episodes []metapkg.EpisodeInfo) (int, bool) {
+	name := normalizeTitle(fileName)
+	var (
+		matched    int
+		matchedLen int
+	)
+	for _, episode := range episodes {
+		title := normalizeTitle(episode.Name)
+		// 过短的标题容易误匹配
+		if len([]rune(title)) < 2 || !strings.Contains(name, title) {
+			continue
+		}
+		if len(title) > matchedLen {
+			matched, matchedLen = episode.Episode, len(title)
+		}
+	}
+	return matched, matchedLen > 0
+}
+
+// matchSpecialByAirDate 选择播出日期与资源发布时间最接近的单集，间隔相同时优先选择与解析集数相同的单集，
+// 间隔超过 specialAirDateTolerance 时匹配失败
+func matchSpecialByAirDate(releasedAt time.Time, parsedEpisode int, episodes []metapkg.EpisodeInfo) (int, bool) {
+	if releasedAt.IsZero() {
+		return 0, false
+	}
+	var (
+		matched     int
+		minInterval = specialAirDateTolerance + 1
+	)
+	for _, episode := range episodes {
+		airDate, err := time.ParseInLocation(time.DateOnly, episode.AirDate, releasedAt.Location())
+		if err != nil {
+			continue
+		}
+		interval := releasedAt.Sub(airDate)
+		if interval < 0 {
+			interval = -interval
+		}
+		if interval < minInterval || (interval == minInterval && episode.Episode == parsedEpisode) {
+			matched, minInterval = episode.Episode, interval
+		}
+	}
+	return matched, minInterval <= specialAirDateTolerance
+}
+
+// normalizeTitle 去除标题中的空白和标点并转为小写，便于比较
+func normalizeTitle(title string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, title)
+}
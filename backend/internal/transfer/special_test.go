@@ -0,0 +1,104 @@
+package transfer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	metapkg "github.com/MangataL/BangumiBuddy/internal/meta"
+	"github.com/MangataL/BangumiBuddy/pkg/bangumifile"
+)
+
+func TestTransfer_transferFileWithCheckers_SpecialEpisode(t *testing.T) {
+	ctx := context.Background()
+	specials := []metapkg.EpisodeInfo{
+		{Episode: 1, Name: "温泉回", AirDate: "2023-03-01"},
+		{Episode: 2, Name: "总集篇", AirDate: "2023-06-20"},
+		{Episode: 3, AirDate: "2023-09-30"},
+		{Episode: 4, AirDate: "2023-09-30"},
+	}
+	var gotFileID string
+	skip := func(ctx context.Context, newFileID string, bf bangumifile.BangumiFile) (bool, error) {
+		gotFileID = newFileID
+		return false, nil
+	}
+
+	testCases := []struct {
+		name        string
+		fileName    string
+		episode     int
+		releasedAt  time.Time
+		wantFileID  string
+		wantEpisode int
+		wantErr     bool
+	}{
+		{
+			name:        "按标题匹配",
+			fileName:    "[A] 番剧 - SP 总集篇 [1080p].mkv",
+			wantFileID:  "番剧/0/2",
+			wantEpisode: 2,
+		},
+		{
+			name:        "按播出日期匹配",
+			fileName:    "[A] 番剧 - OVA [1080p].mkv",
+			releasedAt:  time.Date(2023, 10, 2, 20, 0, 0, 0, time.Local),
+			wantFileID:  "番剧/0/3",
+			wantEpisode: 3,
+		},
+		{
+			name:        "播出日期相同时优先选择解析的集数",
+			fileName:    "[A] 番剧 - OVA2 [1080p].mkv",
+			episode:     4,
+			releasedAt:  time.Date(2023, 10, 2, 20, 0, 0, 0, time.Local),
+			wantFileID:  "番剧/0/4",
+			wantEpisode: 4,
+		},
+		{
+			name:        "播出日期相差太远时使用解析的集数",
+			fileName:    "[A] 番剧 - OVA2 [1080p].mkv",
+			episode:     2,
+			releasedAt:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local),
+			wantFileID:  "番剧/0/2",
+			wantEpisode: 2,
+		},
+		{
+			name:       "都匹配不到时失败",
+			fileName:   "[A] 番剧 - OVA [1080p].mkv",
+			releasedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local),
+			wantErr:    true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			bfParser := bangumifile.NewMockParser(ctrl)
+			bfParser.EXPECT().Parse(ctx, tc.fileName, gomock.Any()).
+				Return(bangumifile.BangumiFile{Episode: tc.episode, Special: "SP"}, nil)
+			metaParser := metapkg.NewMockParser(ctrl)
+			metaParser.EXPECT().GetSeasonEpisodes(ctx, 100, 0).Return(specials, nil)
+			transfer := &Transfer{bfParser: bfParser, metaParser: metaParser}
+			gotFileID = ""
+
+			meta := Meta{
+				ChineseName: "番剧",
+				Season:      1,
+				FileName:    tc.fileName,
+				TMDBID:      100,
+				ReleasedAt:  tc.releasedAt,
+			}
+			_, _, err := transfer.transferFileWithCheckers(ctx, &meta, skip)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantFileID, gotFileID)
+			assert.Equal(t, 0, meta.Season)
+			assert.Equal(t, tc.wantEpisode, meta.Episode)
+		})
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -17,8 +18,10 @@ import (
 
 	"github.com/MangataL/BangumiBuddy/internal/downloader"
 	"github.com/MangataL/BangumiBuddy/internal/magnet"
+	metapkg "github.com/MangataL/BangumiBuddy/internal/meta"
 	"github.com/MangataL/BangumiBuddy/internal/notice"
 	"github.com/MangataL/BangumiBuddy/internal/scrape"
+	"github.com/MangataL/BangumiBuddy/internal/storage"
 	"github.com/MangataL/BangumiBuddy/internal/subscriber"
 	"github.com/MangataL/BangumiBuddy/pkg/bangumifile"
 	"github.com/MangataL/BangumiBuddy/pkg/log"
@@ -40,11 +43,13 @@ func NewTransfer(dep Dependency) *Transfer {
 		downloader:      dep.Downloader,
 		subscriber:      dep.Subscriber,
 		bfParser:        dep.BangumiFileParser,
+		metaParser:      dep.MetaParser,
 		transferFiles:   dep.TransferFiles,
 		notifier:        dep.Notifier,
 		magnetManager:   dep.MagnetManager,
 		fontSubsetter:   dep.FontOperator,
 		scraper:         dep.Scraper,
+		storage:         dep.Storage,
 	}
 
 	go transfer.run(ctx)
@@ -55,6 +60,7 @@ type Dependency struct {
 	Config
 	downloader.TorrentOperator
 	BangumiFileParser bangumifile.Parser
+	MetaParser        metapkg.Parser
 	Downloader        downloader.Interface
 	Subscriber        subscriber.Interface
 	TransferFiles     TransferFilesRepo
@@ -62,6 +68,7 @@ type Dependency struct {
 	MagnetManager     magnet.Interface
 	FontOperator      subtitle.Subsetter
 	Scraper           scrape.Interface
+	Storage           storage.Interface
 }
 
 type EpisodeParser interface {
@@ -84,7 +91,7 @@ var ErrFileTransferredNotFound = errors.New("文件转移记录未找到")
 type Config struct {
 	Interval             int                  `mapstructure:"interval" json:"interval" default:"1"`
 	TVPath               string               `mapstructure:"tv_path" json:"tvPath"`
-	TVFormat             string               `mapstructure:"tv_format" json:"tvFormat" default:"{name}/Season {season}/{name} {season_episode}"`
+	TVFormat             string               `mapstructure:"tv_format" json:"tvFormat" default:"{name}/Season {season}/{name} {season_episode}"` // 还支持从文件名解析的 {resolution}、{video_codec}、{audio_codec}、{source}、{subtitle_lang}、{subtitle_type}、{version}
 	MoviePath            string               `mapstructure:"movie_path" json:"moviePath"`
 	MovieFormat          string               `mapstructure:"movie_format" json:"movieFormat" default:"{name} ({year})"`
 	TransferType         string               `mapstructure:"transfer_type" json:"transferType"`
@@ -110,10 +117,12 @@ type Transfer struct {
 	magnetManager   magnet.Interface
 	subscriber      subscriber.Interface
 	bfParser        bangumifile.Parser
+	metaParser      metapkg.Parser
 	transferFiles   TransferFilesRepo
 	notifier        notice.Notifier
 	fontSubsetter   subtitle.Subsetter
 	scraper         scrape.Interface
+	storage         storage.Interface
 }
 
 func (t *Transfer) run(ctx context.Context) {
@@ -211,6 +220,11 @@ func (t *Transfer) transferTorrentForSubscribe(ctx context.Context, torrent down
 		if !utils.IsMediaFile(path) {
 			continue
 		}
+		// 合集种子只下载了媒体库中缺少的剧集，跳过未选择的文件
+		if len(torrent.SelectedFiles) > 0 && !lo.Contains(torrent.SelectedFiles, fileName) {
+			log.Debugf(ctx, "文件 %s 未被选择下载，跳过转移", fileName)
+			continue
+		}
 		if err := t.transferFileForSubscribe(ctx, torrent, path, fileName, fontSubsetter); err != nil {
 			log.Errorf(ctx, "文件 %s 转移失败： %v", fileName, err)
 			transferErr.Append(err, fileName)
@@ -234,17 +248,38 @@ func (t *Transfer) transferFileForSubscribe(ctx context.Context, torrent downloa
 		FilePath:        path,
 		SubscriptionID:  torrent.SubscriptionID,
 		ReleaseGroup:    bangumi.ReleaseGroup,
+		QualityScore:    torrent.QualityScore,
 		FontSubsetter:   fontSubsetter,
+		TMDBID:          bangumi.TMDBID,
+		ReleasedAt:      torrent.PublishedAt,
+	}
+	// 旧的种子记录没有发布时间，使用添加下载的时间代替
+	if meta.ReleasedAt.IsZero() {
+		meta.ReleasedAt = torrent.CreatedAt
 	}
-	checkPriority := func(ctx context.Context, newFileID string) (bool, error) {
+	if bangumi.AbsoluteEpisode != nil {
+		meta.AbsoluteEpisode = true
+		meta.EpisodeGroupID = bangumi.AbsoluteEpisode.EpisodeGroupID
+	}
+	replaced := &replacedFiles{}
+	checkPriority := func(ctx context.Context, newFileID string, bf bangumifile.BangumiFile) (bool, error) {
 		return t.checkPriority(ctx, newFilePriority{
-			newFileID: newFileID,
-			fileName:  fileName,
-			priority:  bangumi.Priority,
+			newFileID:      newFileID,
+			fileName:       fileName,
+			priority:       bangumi.Priority,
+			qualityScore:   torrent.QualityScore,
+			subscriptionID: torrent.SubscriptionID,
+			version:        bf.Version,
+			replaced:       replaced,
 		})
 	}
 
-	episode, newFilePath, transferd, err := t.transferFileWithCheckers(ctx, meta, checkPriority)
+	newFilePath, transferd, err := t.transferFileWithCheckers(ctx, &meta, checkPriority)
+	if err != nil {
+		replaced.restore(ctx, t.transferFiles)
+	} else {
+		replaced.commit(ctx)
+	}
 	if (torrent.Status != downloader.TorrentStatusTransferredError && err != nil) || transferd {
 		if err := t.notifier.NoticeSubscriptionTransferred(ctx, notice.NoticeSubscriptionTransferredReq{
 			RSSGUID:       torrent.RSSGUID,
@@ -265,8 +300,11 @@ func (t *Transfer) transferFileForSubscribe(ctx context.Context, torrent downloa
 	if !transferd {
 		return nil
 	}
-	if err := t.subscriber.HandleEpisodeTransferred(ctx, torrent.SubscriptionID, episode); err != nil {
-		log.Warnf(ctx, "更新订阅信息失败: %v", err)
+	// 订阅的更新进度以订阅季的集数计算，特别篇和绝对集数映射到其他季的文件不计入
+	if meta.Season == bangumi.Season {
+		if err := t.subscriber.HandleEpisodeTransferred(ctx, torrent.SubscriptionID, meta.Episode); err != nil {
+			log.Warnf(ctx, "更新订阅信息失败: %v", err)
+		}
 	}
 
 	if t.scraper.Enable() {
@@ -275,8 +313,8 @@ func (t *Transfer) transferFileForSubscribe(ctx context.Context, torrent downloa
 			TMDBID:      bangumi.TMDBID,
 			BangumiName: bangumi.Name,
 			PosterURL:   bangumi.PosterURL,
-			Season:      bangumi.Season,
-			Episode:     episode,
+			Season:      meta.Season,
+			Episode:     meta.Episode,
 		}); err != nil {
 			log.Warnf(ctx, "添加元数据填充任务失败: %v", err)
 		}
@@ -285,15 +323,20 @@ func (t *Transfer) transferFileForSubscribe(ctx context.Context, torrent downloa
 }
 
 type newFilePriority struct {
-	fileName  string
-	priority  int
-	newFileID string
+	fileName       string
+	priority       int
+	qualityScore   int
+	newFileID      string
+	subscriptionID string
+	version        int
+	// replaced 不为空时旧文件先备份，新文件转移完成后再删除
+	replaced *replacedFiles
 }
 
 // 检查优先级，返回是否应该进行转移以及可能的错误
 func (t *Transfer) checkPriority(ctx context.Context, newFilePriority newFilePriority) (bool, error) {
 	// 从缓存中获取优先级信息
-	var priorityToCompare int
+	var priorityToCompare, scoreToCompare int
 	transferred, err := t.transferFiles.Get(ctx, GetFileTransferredReq{
 		NewFileID: newFilePriority.newFileID,
 	})
@@ -315,6 +358,7 @@ func (t *Transfer) checkPriority(ctx context.Context, newFilePriority newFilePri
 			return true, nil
 		}
 		priorityToCompare = bangumi.Priority
+		scoreToCompare = transferred.QualityScore
 	}
 
 	// 比较优先级
@@ -323,16 +367,30 @@ func (t *Transfer) checkPriority(ctx context.Context, newFilePriority newFilePri
 		log.Infof(ctx, "文件 %s 已存在更高优先级的版本，跳过转移", newFilePriority.fileName)
 		return false, nil
 	}
-
-	// 当前文件优先级更高，删除现有文件
-	log.Infof(ctx, "文件 %s 优先级(%d)不低于现有文件优先级(%d)，将覆盖现有文件",
-		newFilePriority.fileName, newFilePriority.priority, priorityToCompare)
+	oldVersion, newVersion := normalizeVersion(transferred.Version), normalizeVersion(newFilePriority.version)
+	if newFilePriority.subscriptionID != "" && transferred.SubscriptionID == newFilePriority.subscriptionID && oldVersion != newVersion {
+		// 同一订阅的资源按发布版本替换，v2 等修正版本总是覆盖旧版本
+		if oldVersion > newVersion {
+			log.Infof(ctx, "文件 %s 已存在更高的版本(v%d)，跳过转移", newFilePriority.fileName, oldVersion)
+			return false, nil
+		}
+		log.Infof(ctx, "文件 %s 为修正版本(v%d)，将替换现有版本(v%d)", newFilePriority.fileName, newVersion, oldVersion)
+	} else if priorityToCompare == newFilePriority.priority && scoreToCompare > newFilePriority.qualityScore {
+		// 优先级相同时比较质量评分，评分更高的版本用于洗版
+		log.Infof(ctx, "文件 %s 已存在质量评分更高的版本(%d > %d)，跳过转移",
+			newFilePriority.fileName, scoreToCompare, newFilePriority.qualityScore)
+		return false, nil
+	} else {
+		// 当前文件优先级更高，删除现有文件
+		log.Infof(ctx, "文件 %s 优先级(%d)不低于现有文件优先级(%d)，将覆盖现有文件",
+			newFilePriority.fileName, newFilePriority.priority, priorityToCompare)
+	}
 
 	if transferred.NewFile == "" {
 		log.Warnf(ctx, "转移记录 %s 中没有新文件路径，跳过删除", transferred.NewFileID)
 		return true, nil
 	}
-	_ = t.deleteTransferFiles(ctx, transferred.NewFile,
+	options := []deleteTransferFileOption{
 		withIgnoreNFOFile(),
 		withFindBaseFileErrorHook(func(err error) error {
 			log.Warnf(ctx, "删除低优先级文件时查找文件出错: %v", err)
@@ -348,51 +406,92 @@ func (t *Transfer) checkPriority(ctx context.Context, newFilePriority newFilePri
 		withDeleteTransferFilesSuccessHook(func(files []string) {
 			log.Infof(ctx, "删除低优先级文件 %v 成功", files)
 		}),
-	)
+	}
+	if newFilePriority.replaced != nil {
+		newFilePriority.replaced.record = &transferred
+		options = append(options, withReplacedFiles(newFilePriority.replaced))
+	}
+	_ = t.deleteTransferFiles(ctx, transferred.NewFile, options...)
 	return true, nil
 }
 
-type transferChecker func(ctx context.Context, newFileID string) (bool, error)
+// normalizeVersion 未记录版本的旧转移记录视为v1
+func normalizeVersion(version int) int {
+	if version <= 0 {
+		return 1
+	}
+	return version
+}
 
-func (t *Transfer) transferFileWithCheckers(ctx context.Context, meta Meta, checkers ...transferChecker) (int, string, bool, error) {
+type transferChecker func(ctx context.Context, newFileID string, bf bangumifile.BangumiFile) (bool, error)
+
+// transferFileWithCheckers 转移文件，媒体库中的季和集记录在 meta 中
+func (t *Transfer) transferFileWithCheckers(ctx context.Context, meta *Meta, checkers ...transferChecker) (string, bool, error) {
 	log.Infof(ctx, "开始转移文件 %s", meta.FileName)
 	bf, err := t.bfParser.Parse(ctx, meta.FileName,
 		bangumifile.WithEpisodeLocation(meta.EpisodeLocation),
 		bangumifile.WithEpisodeOffset(meta.EpisodeOffset),
 	)
 	if err != nil {
-		return 0, "", false, err
+		return "", false, err
+	}
+	meta.Version = bf.Version
+	meta.Episode = bf.Episode
+	switch {
+	case bf.IsSpecialEpisode():
+		special, err := t.mapSpecialEpisode(ctx, *meta, bf.Episode)
+		if err != nil {
+			return "", false, err
+		}
+		meta.Season, meta.Episode = 0, special
+	case meta.AbsoluteEpisode:
+		seasonEpisode, err := t.mapAbsoluteEpisode(ctx, *meta, bf.Episode)
+		if err != nil {
+			return "", false, err
+		}
+		meta.Season, meta.Episode = seasonEpisode.Season, seasonEpisode.Episode
 	}
-	episode := bf.Episode
 
-	newFileID := fmt.Sprintf("%s/%s/%s", meta.ChineseName, strconv.Itoa(meta.Season), strconv.Itoa(episode))
+	newFileID := fmt.Sprintf("%s/%s/%s", meta.ChineseName, strconv.Itoa(meta.Season), strconv.Itoa(meta.Episode))
 
 	for _, checker := range checkers {
-		shouldTransfer, err := checker(ctx, newFileID)
+		shouldTransfer, err := checker(ctx, newFileID, bf)
 		if err != nil {
-			return 0, "", false, errors.WithMessage(err, "检查优先级失败")
+			return "", false, errors.WithMessage(err, "检查优先级失败")
 		}
 		if !shouldTransfer {
-			return 0, "", false, nil
+			return "", false, nil
 		}
 	}
 
-	_, newFilePath, err := t.transferFileForTV(ctx, meta, episode, newFileID)
+	_, newFilePath, err := t.transferFileForTV(ctx, *meta, meta.Episode, newFileID)
 	if err != nil {
-		return 0, "", false, errors.WithMessage(err, "转移文件失败")
+		return "", false, errors.WithMessage(err, "转移文件失败")
 	}
-	return episode, newFilePath, true, nil
+	return newFilePath, true, nil
 }
 
 func (t *Transfer) transferFileForTV(ctx context.Context, meta Meta, episode int, newFileID string) (originFile string, newFilePath string, err error) {
 	// 生成新文件路径
-	newFilePathWithoutExt := t.generateNewFilePath(t.config.TVFormat, meta, episode)
+	newFilePathWithoutExt := t.generateNewFilePath(ctx, t.config.TVFormat, meta, episode)
 	originFile, newFilePath, err = t.transferFile(ctx, newFilePathWithoutExt, meta, newFileID)
 	return
 }
 
+// linkTransferTypes 不占用额外磁盘空间的转移方式
+var linkTransferTypes = map[string]struct{}{
+	"hardlink": {},
+	"softlink": {},
+}
+
 func (t *Transfer) transferFile(ctx context.Context, newFilePathWithoutExt string, meta Meta, newFileID string) (originFile, newFilePath string, err error) {
 	newFilePath = newFilePathWithoutExt + filepath.Ext(meta.FileName)
+	// 在删除旧的转移文件之前检查剩余空间，避免媒体库文件丢失，链接方式转移不占用额外空间，无需检查
+	if _, isLink := linkTransferTypes[t.config.TransferType]; t.storage != nil && !isLink {
+		if err := t.storage.Check(ctx, filepath.Dir(newFilePath)); err != nil {
+			return "", "", err
+		}
+	}
 
 	// 在转移文件之前，检查是否有旧的转移记录
 	// 如果有旧记录，则删除旧的转移记录
@@ -438,6 +537,8 @@ func (t *Transfer) transferFile(ctx context.Context, newFilePathWithoutExt strin
 		SubscriptionID: meta.SubscriptionID,
 		NewFile:        newFilePath,
 		NewFileID:      newFileID,
+		QualityScore:   meta.QualityScore,
+		Version:        meta.Version,
 	}); err != nil {
 		log.Warnf(ctx, "更新转移记录失败: %v", err)
 	}
@@ -445,8 +546,8 @@ func (t *Transfer) transferFile(ctx context.Context, newFilePathWithoutExt strin
 }
 
 // 生成新文件的路径，不包含扩展名
-func (t *Transfer) generateNewFilePath(format string, meta Meta, episode int) string {
-	result := t.replaceCommonVar(format, meta)
+func (t *Transfer) generateNewFilePath(ctx context.Context, format string, meta Meta, episode int) string {
+	result := t.replaceCommonVar(ctx, format, meta)
 
 	episodeStr := strconv.Itoa(episode)
 	result = strings.ReplaceAll(result, "{episode}", episodeStr)
@@ -460,13 +561,65 @@ func (t *Transfer) generateNewFilePath(format string, meta Meta, episode int) st
 	return filepath.Join(t.config.TVPath, result)
 }
 
-func (t *Transfer) replaceCommonVar(format string, meta Meta) string {
+func (t *Transfer) replaceCommonVar(ctx context.Context, format string, meta Meta) string {
 	result := strings.ReplaceAll(format, "{name}", meta.ChineseName)
 	result = strings.ReplaceAll(result, "{year}", meta.Year)
 	result = strings.ReplaceAll(result, "{release_group}", meta.ReleaseGroup)
 	originName := utils.GetFileBaseName(meta.FileName)
 	result = strings.ReplaceAll(result, "{origin_name}", originName)
-	return result
+	return t.replaceTitleVar(ctx, result, meta)
+}
+
+// titleVars 从文件名解析的命名变量
+var titleVars = []string{"{resolution}", "{video_codec}", "{audio_codec}", "{source}", "{subtitle_lang}", "{subtitle_type}", "{version}"}
+
+// emptyTitleVarPatterns 匹配值为空的命名变量，连同只包含该变量的括号和它前面（位于开头时为后面）的分隔符
+var emptyTitleVarPatterns = func() map[string]*regexp.Regexp {
+	patterns := make(map[string]*regexp.Regexp, len(titleVars))
+	for _, v := range titleVars {
+		token := fmt.Sprintf(`(?:[\[(【]\s*%[1]s\s*[\])】]|%[1]s)`, regexp.QuoteMeta(v))
+		patterns[v] = regexp.MustCompile(fmt.Sprintf(`[ ._-]+%[1]s|%[1]s[ ._-]*`, token))
+	}
+	return patterns
+}()
+
+// replaceTitleVar 替换从文件名解析出的分辨率、编码、片源、字幕和版本变量，无法识别的变量连同多余的括号和分隔符一起去除
+func (t *Transfer) replaceTitleVar(ctx context.Context, format string, meta Meta) string {
+	used := false
+	for _, v := range titleVars {
+		if strings.Contains(format, v) {
+			used = true
+			break
+		}
+	}
+	if !used {
+		return format
+	}
+	bf, err := t.bfParser.Parse(ctx, meta.FileName, bangumifile.IgnoreValidateEpisode())
+	if err != nil {
+		log.Warnf(ctx, "解析文件名 %s 失败，命名变量替换为空: %v", meta.FileName, err)
+	}
+	version := ""
+	if bf.Version > 1 {
+		version = fmt.Sprintf("v%d", bf.Version)
+	}
+	values := map[string]string{
+		"{resolution}":    bf.Resolution,
+		"{video_codec}":   bf.VideoCodec,
+		"{audio_codec}":   bf.AudioCodec,
+		"{source}":        bf.Source,
+		"{subtitle_lang}": strings.Join(bf.SubtitleLanguages, ""),
+		"{subtitle_type}": bf.SubtitleType,
+		"{version}":       version,
+	}
+	for _, v := range titleVars {
+		if values[v] == "" {
+			format = emptyTitleVarPatterns[v].ReplaceAllString(format, "")
+		} else {
+			format = strings.ReplaceAll(format, v, values[v])
+		}
+	}
+	return format
 }
 
 var audioExtensions = map[string]struct{}{
@@ -948,7 +1101,7 @@ func (t *Transfer) transferForTaskTV(ctx context.Context, meta Meta, file magnet
 }
 
 func (t *Transfer) transferForTaskMovie(ctx context.Context, meta Meta, newFileID string) (string, string, error) {
-	newPath := filepath.Join(t.config.MoviePath, t.replaceCommonVar(t.config.MovieFormat, meta))
+	newPath := filepath.Join(t.config.MoviePath, t.replaceCommonVar(ctx, t.config.MovieFormat, meta))
 	originFile, newFilePath, err := t.transferFile(ctx, newPath, meta, newFileID)
 	return originFile, newFilePath, err
 }
@@ -1013,7 +1166,11 @@ func (t *Transfer) deleteTransferFiles(ctx context.Context, file string, options
 	if len(files) != 0 {
 		opt.deleteTransferFilesHook(files)
 		for _, file := range files {
-			if err := os.Remove(file); err != nil {
+			remove := os.Remove
+			if opt.replaced != nil {
+				remove = opt.replaced.backup
+			}
+			if err := remove(file); err != nil {
 				if err = opt.deleteTransferFileErrorHook(file, err); err != nil {
 					return err
 				}
@@ -1035,6 +1192,7 @@ type deleteTransferFilesOptions struct {
 	deleteTransferFileErrorHook    func(file string, err error) error
 	deleteTransferFilesSuccessHook func(files []string)
 	ignoreNFOFile                  bool
+	replaced                       *replacedFiles
 }
 
 type deleteTransferFileOption func(*deleteTransferFilesOptions)
@@ -9,6 +9,7 @@ import (
 
 	"github.com/MangataL/BangumiBuddy/internal/downloader"
 	"github.com/MangataL/BangumiBuddy/internal/subscriber"
+	"github.com/MangataL/BangumiBuddy/pkg/bangumifile/anito"
 	"github.com/creasty/defaults"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
@@ -584,3 +585,191 @@ func TestTransfer_checkPriority(t *testing.T) {
 		})
 	}
 }
+
+func TestTransfer_checkPriority_QualityScore(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	t.Cleanup(ctrl.Finish)
+
+	mockSubscriber := subscriber.NewMockInterface(ctrl)
+	mockSubscriber.EXPECT().Get(gomock.Any(), "sub-1").Return(subscriber.Bangumi{Priority: 1}, nil)
+	mockTransferRepo := NewMockTransferFilesRepo(ctrl)
+	mockTransferRepo.EXPECT().Get(gomock.Any(), GetFileTransferredReq{NewFileID: "new-file-id"}).Return(FileTransferred{
+		SubscriptionID: "sub-1",
+		NewFile:        filepath.Join(t.TempDir(), "episode.mkv"),
+		NewFileID:      "new-file-id",
+		QualityScore:   20,
+	}, nil)
+
+	transfer := &Transfer{
+		subscriber:    mockSubscriber,
+		transferFiles: mockTransferRepo,
+	}
+	shouldTransfer, err := transfer.checkPriority(context.Background(), newFilePriority{
+		newFileID:    "new-file-id",
+		fileName:     "new-file.mkv",
+		priority:     1,
+		qualityScore: 10,
+	})
+
+	require.NoError(t, err)
+	assert.False(t, shouldTransfer)
+}
+
+func TestTransfer_generateNewFilePath_TitleVars(t *testing.T) {
+	transfer := &Transfer{
+		config:   Config{TVPath: "/tv"},
+		bfParser: anito.NewParser(),
+	}
+	meta := Meta{
+		ChineseName: "葬送的芙莉莲",
+		Season:      1,
+		FileName:    "[ANi] Sousou no Frieren - 05v2 [1080P][Baha][WEB-DL][AAC AVC][CHT].mp4",
+	}
+
+	got := transfer.generateNewFilePath(context.Background(),
+		"{name}/Season {season}/{name} {season_episode}{version} [{source} {resolution} {video_codec}][{subtitle_lang}]", meta, 5)
+
+	assert.Equal(t, "/tv/葬送的芙莉莲/Season 1/葬送的芙莉莲 S01E05v2 [WEB-DL 1080p AVC][繁]", got)
+}
+
+func TestTransfer_generateNewFilePath_EmptyTitleVars(t *testing.T) {
+	transfer := &Transfer{
+		config:   Config{TVPath: "/tv"},
+		bfParser: anito.NewParser(),
+	}
+	meta := Meta{
+		ChineseName: "葬送的芙莉莲",
+		Season:      1,
+		FileName:    "[ANi] Sousou no Frieren - 05 [1080P].mp4",
+	}
+
+	testCases := []struct {
+		format string
+		want   string
+	}{
+		{
+			format: "{name} {season_episode}{version} [{source}] - {video_codec}",
+			want:   "/tv/葬送的芙莉莲 S01E05",
+		},
+		{
+			format: "{name} {season_episode} [{source} {resolution} {video_codec}][{subtitle_type}]",
+			want:   "/tv/葬送的芙莉莲 S01E05 [1080p]",
+		},
+		{
+			format: "{source}.{name} {season_episode}",
+			want:   "/tv/葬送的芙莉莲 S01E05",
+		},
+	}
+	for _, tc := range testCases {
+		got := transfer.generateNewFilePath(context.Background(), tc.format, meta, 5)
+		assert.Equal(t, tc.want, got, tc.format)
+	}
+}
+
+func TestTransfer_checkPriority_Version(t *testing.T) {
+	setup := func(t *testing.T, version int) (*Transfer, *MockTransferFilesRepo, FileTransferred) {
+		dir := t.TempDir()
+		mediaFile := filepath.Join(dir, "episode.mkv")
+		subtitleFile := filepath.Join(dir, "episode.zh.ass")
+		require.NoError(t, os.WriteFile(mediaFile, []byte("v1"), 0o644))
+		require.NoError(t, os.WriteFile(subtitleFile, []byte("v1"), 0o644))
+		transferred := FileTransferred{
+			SubscriptionID: "sub-1",
+			NewFile:        mediaFile,
+			NewFileID:      "new-file-id",
+			QualityScore:   20,
+			Version:        version,
+		}
+
+		ctrl := gomock.NewController(t)
+		t.Cleanup(ctrl.Finish)
+		mockSubscriber := subscriber.NewMockInterface(ctrl)
+		mockSubscriber.EXPECT().Get(gomock.Any(), "sub-1").Return(subscriber.Bangumi{Priority: 1}, nil)
+		mockTransferRepo := NewMockTransferFilesRepo(ctrl)
+		mockTransferRepo.EXPECT().Get(gomock.Any(), GetFileTransferredReq{NewFileID: "new-file-id"}).Return(transferred, nil)
+		return &Transfer{
+			subscriber:    mockSubscriber,
+			transferFiles: mockTransferRepo,
+		}, mockTransferRepo, transferred
+	}
+
+	t.Run("高版本替换旧版本并在转移成功后删除备份", func(t *testing.T) {
+		transfer, mockTransferRepo, transferred := setup(t, 1)
+		mockTransferRepo.EXPECT().Del(gomock.Any(), DeleteFileTransferredReq{NewFile: transferred.NewFile}).Return(nil)
+		replaced := &replacedFiles{}
+
+		shouldTransfer, err := transfer.checkPriority(context.Background(), newFilePriority{
+			newFileID:      "new-file-id",
+			fileName:       "new-file-v2.mkv",
+			priority:       1,
+			subscriptionID: "sub-1",
+			version:        2,
+			replaced:       replaced,
+		})
+
+		require.NoError(t, err)
+		assert.True(t, shouldTransfer, "同一订阅的v2忽略质量评分")
+		assert.NoFileExists(t, transferred.NewFile)
+		assert.Len(t, replaced.backups, 2)
+		replaced.commit(context.Background())
+		entries, err := os.ReadDir(filepath.Dir(transferred.NewFile))
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+
+	t.Run("转移失败时恢复旧版本", func(t *testing.T) {
+		transfer, mockTransferRepo, transferred := setup(t, 1)
+		mockTransferRepo.EXPECT().Del(gomock.Any(), DeleteFileTransferredReq{NewFile: transferred.NewFile}).Return(nil)
+		mockTransferRepo.EXPECT().Set(gomock.Any(), transferred).Return(nil)
+		replaced := &replacedFiles{}
+
+		shouldTransfer, err := transfer.checkPriority(context.Background(), newFilePriority{
+			newFileID:      "new-file-id",
+			fileName:       "new-file-v2.mkv",
+			priority:       1,
+			subscriptionID: "sub-1",
+			version:        2,
+			replaced:       replaced,
+		})
+		require.NoError(t, err)
+		require.True(t, shouldTransfer)
+		replaced.restore(context.Background(), mockTransferRepo)
+
+		assert.FileExists(t, transferred.NewFile)
+		assert.FileExists(t, filepath.Join(filepath.Dir(transferred.NewFile), "episode.zh.ass"))
+	})
+
+	t.Run("版本相同时按质量评分比较", func(t *testing.T) {
+		transfer, mockTransferRepo, transferred := setup(t, 1)
+		mockTransferRepo.EXPECT().Del(gomock.Any(), DeleteFileTransferredReq{NewFile: transferred.NewFile}).Return(nil)
+
+		shouldTransfer, err := transfer.checkPriority(context.Background(), newFilePriority{
+			newFileID:      "new-file-id",
+			fileName:       "new-file.mkv",
+			priority:       1,
+			qualityScore:   30,
+			subscriptionID: "sub-1",
+			version:        1,
+		})
+		require.NoError(t, err)
+		assert.True(t, shouldTransfer)
+		assert.NoFileExists(t, transferred.NewFile)
+	})
+
+	t.Run("已存在更高版本时跳过", func(t *testing.T) {
+		transfer, _, transferred := setup(t, 2)
+
+		shouldTransfer, err := transfer.checkPriority(context.Background(), newFilePriority{
+			newFileID:      "new-file-id",
+			fileName:       "new-file.mkv",
+			priority:       1,
+			qualityScore:   30,
+			subscriptionID: "sub-1",
+			version:        1,
+			replaced:       &replacedFiles{},
+		})
+		require.NoError(t, err)
+		assert.False(t, shouldTransfer)
+		assert.FileExists(t, transferred.NewFile)
+	})
+}
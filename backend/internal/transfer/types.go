@@ -1,6 +1,10 @@
 package transfer
 
-import "github.com/MangataL/BangumiBuddy/pkg/subtitle"
+import (
+	"time"
+
+	"github.com/MangataL/BangumiBuddy/pkg/subtitle"
+)
 
 type TransferReq struct {
 }
@@ -15,7 +19,14 @@ type Meta struct {
 	FilePath        string
 	SubscriptionID  string
 	ReleaseGroup    string
+	QualityScore    int
+	Version         int
 	FontSubsetter   subtitle.Subsetter
+	TMDBID          int
+	AbsoluteEpisode bool      // 文件名中为绝对集数，转移时映射为TMDB中的季和集
+	EpisodeGroupID  string    // 绝对集数映射使用的TMDB剧集组ID
+	Episode         int       // 媒体库中的集数，绝对集数映射后与文件名中的集数不同
+	ReleasedAt      time.Time // 资源的发布时间，用于按播出日期匹配特别篇
 }
 
 type DeletePriorityReq struct {
@@ -35,6 +46,8 @@ type FileTransferred struct {
 	SubscriptionID string
 	NewFile        string
 	NewFileID      string
+	QualityScore   int // 质量评分，同优先级的订阅按评分决定是否覆盖
+	Version        int // 发布版本，同一订阅的高版本覆盖低版本
 }
 
 type GetFileTransferredReq struct {
@@ -40,6 +40,20 @@ type Torrent struct {
 	Collection    bool                     `json:"collection"`
 	Season        int                      `json:"season"`
 	Episode       int                      `json:"episode"`
+	EpisodeEnd    int                      `json:"episodeEnd,omitempty"` // 合集的结束集数
+	TitleInfo     TitleInfo                `json:"titleInfo"`            // 从种子名称解析出的资源信息
+}
+
+// TitleInfo 从资源标题解析出的结构化信息
+type TitleInfo struct {
+	Resolution        string   `json:"resolution"`
+	VideoCodec        string   `json:"videoCodec"`
+	AudioCodec        string   `json:"audioCodec"`
+	Source            string   `json:"source"`
+	SubtitleLanguages []string `json:"subtitleLanguages"`
+	SubtitleType      string   `json:"subtitleType"`
+	Version           int      `json:"version"`
+	Special           string   `json:"special"`
 }
 
 type File struct {
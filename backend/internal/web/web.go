@@ -169,15 +169,19 @@ func (w *Web) GetBangumiTorrents(ctx context.Context, subscriptionID string) ([]
 			torrent.StatusDetail = ds[0].Error
 		}
 
-		torrent.Collection = w.isCollection(t.Name)
+		bf, _ := w.bfParser.Parse(ctx, t.Name,
+			bangumifile.IgnoreValidateEpisode(),
+			bangumifile.WithEpisodeLocation(bangumi.EpisodeLocation),
+			bangumifile.WithEpisodeOffset(bangumi.EpisodeOffset),
+		)
+		torrent.TitleInfo = newTitleInfo(bf)
+		torrent.Collection = w.isCollection(t.Name) || bf.IsBatch()
 		if !torrent.Collection {
-			bf, _ := w.bfParser.Parse(ctx, t.Name,
-				bangumifile.IgnoreValidateEpisode(),
-				bangumifile.WithEpisodeLocation(bangumi.EpisodeLocation),
-				bangumifile.WithEpisodeOffset(bangumi.EpisodeOffset),
-			)
 			torrent.Episode = bf.Episode
 			torrent.Season = bangumi.Season
+		} else if bf.IsBatch() {
+			torrent.Episode = bf.Episode
+			torrent.EpisodeEnd = bf.EpisodeEnd
 		}
 
 		torrents = append(torrents, torrent)
@@ -185,6 +189,20 @@ func (w *Web) GetBangumiTorrents(ctx context.Context, subscriptionID string) ([]
 	return torrents, nil
 }
 
+// newTitleInfo 转换标题解析结果用于展示
+func newTitleInfo(bf bangumifile.BangumiFile) TitleInfo {
+	return TitleInfo{
+		Resolution:        bf.Resolution,
+		VideoCodec:        bf.VideoCodec,
+		AudioCodec:        bf.AudioCodec,
+		Source:            bf.Source,
+		SubtitleLanguages: bf.SubtitleLanguages,
+		SubtitleType:      bf.SubtitleType,
+		Version:           bf.Version,
+		Special:           bf.Special,
+	}
+}
+
 var collectionRegex = regexp.MustCompile(`\d+-\d+`)
 
 func (w *Web) isCollection(torrentName string) bool {
@@ -39,13 +39,15 @@ import (
 	"github.com/MangataL/BangumiBuddy/internal/meta/tmdb"
 	"github.com/MangataL/BangumiBuddy/internal/network"
 	noticeadapter "github.com/MangataL/BangumiBuddy/internal/notice/adapter"
+	"github.com/MangataL/BangumiBuddy/internal/report"
 	"github.com/MangataL/BangumiBuddy/internal/repository/viper"
 	ginrouter "github.com/MangataL/BangumiBuddy/internal/router/gin"
 	"github.com/MangataL/BangumiBuddy/internal/scrape"
 	scraperepo "github.com/MangataL/BangumiBuddy/internal/scrape/repository"
+	"github.com/MangataL/BangumiBuddy/internal/storage"
 	"github.com/MangataL/BangumiBuddy/internal/subscriber"
 	subscriberrepo "github.com/MangataL/BangumiBuddy/internal/subscriber/repository"
-	"github.com/MangataL/BangumiBuddy/internal/subscriber/rss/mikan"
+	"github.com/MangataL/BangumiBuddy/internal/subscriber/rss"
 	"github.com/MangataL/BangumiBuddy/internal/transfer"
 	_ "github.com/MangataL/BangumiBuddy/internal/transfer/hadrlink"
 	transferrepo "github.com/MangataL/BangumiBuddy/internal/transfer/repository"
@@ -118,7 +120,6 @@ func main() {
 	}
 	conf.RegisterReloadable(viper.ComponentNameNetwork, networkManager)
 
-	rssParser := mikan.NewParser(bfParser, networkManager)
 	tmdbConfig, err := conf.GetTMDBConfig()
 	if err != nil {
 		log.Fatalf(ctx, "get tmdb config failed %s", err)
@@ -133,12 +134,19 @@ func main() {
 	noticeAdapter := noticeadapter.NewAdapter(noticeConfig, networkManager)
 	conf.RegisterReloadable(viper.ComponentNameNotice, noticeAdapter)
 
+	storageConfig, err := conf.GetStorageConfig()
+	if err != nil {
+		log.Fatalf(ctx, "get storage config failed %s", err)
+	}
+	storageGuard := storage.NewGuard(storageConfig, noticeAdapter)
+	conf.RegisterReloadable(viper.ComponentNameStorage, storageGuard)
+
 	downloaderConfig, err := conf.GetDownloaderConfig()
 	if err != nil {
 		log.Fatalf(ctx, "get downloader config failed %s", err)
 	}
 	torrentOperator := downloader.NewTorrentOperator(db)
-	downloadAdapter := downloadadapter.NewAdapter(downloaderConfig)
+	downloadAdapter := downloadadapter.NewAdapter(downloaderConfig, networkManager)
 	conf.RegisterReloadable(viper.ComponentNameDownloader, downloadAdapter)
 
 	downloadManagerConfig, err := conf.GetDownloadManagerConfig()
@@ -146,10 +154,13 @@ func main() {
 		log.Fatalf(ctx, "get download manager config failed %s", err)
 	}
 	downloadManager := downloader.NewManager(downloader.Dependency{
-		Downloader:      downloadAdapter,
+		Instances:       downloadAdapter,
 		TorrentOperator: torrentOperator,
 		Config:          downloadManagerConfig,
 		Notifier:        noticeAdapter,
+		Storage:         storageGuard,
+		QueueRepository: downloader.NewQueueRepository(db),
+		MediaLibrary:    conf,
 	})
 	conf.RegisterReloadable(viper.ComponentNameDownloadManager, downloadManager)
 	subscriberConfig, err := conf.GetSubscriberConfig()
@@ -157,25 +168,31 @@ func main() {
 		log.Fatalf(ctx, "get subscriber config failed %s", err)
 	}
 	subscriberRepo := subscriberrepo.New(db)
-
-	subscriberDep := subscriber.Dependency{
-		RSSParser:           rssParser,
-		MetaParser:          metaParser,
-		Repository:          subscriberRepo,
-		Downloader:          downloadManager,
-		TorrentOperator:     torrentOperator,
-		Config:              subscriberConfig,
-		RSSRecordRepository: subscriberRepo,
-		Notifier:            noticeAdapter,
-	}
-	subscriber := subscriber.NewSubscriber(subscriberDep)
-	conf.RegisterReloadable(viper.ComponentNameSubscriber, subscriber)
 	discoveryConfig, err := conf.GetDiscoveryConfig()
 	if err != nil {
 		log.Fatalf(ctx, "get discovery config failed %s", err)
 	}
-	discoveryService := discoverymikan.New(discoveryConfig, subscriber, networkManager)
+	discoveryService := discoverymikan.New(discoveryConfig, subscriberRepo, networkManager)
 	conf.RegisterReloadable(viper.ComponentNameDiscovery, discoveryService)
+	rssParser := rss.NewParser(bfParser, networkManager, discoveryService)
+
+	transferFilesRepo := transferrepo.NewTransferFilesRepo(db)
+	subscriberDep := subscriber.Dependency{
+		RSSParser:                rssParser,
+		MetaParser:               metaParser,
+		Repository:               subscriberRepo,
+		Downloader:               downloadManager,
+		TorrentOperator:          torrentOperator,
+		Config:                   subscriberConfig,
+		RSSRecordRepository:      subscriberRepo,
+		EpisodeQualityRepository: subscriberRepo,
+		EpisodeLibrary:           transfer.NewEpisodeLibrary(transferFilesRepo),
+		Notifier:                 noticeAdapter,
+		BangumiFileParser:        bfParser,
+		ResourceSearcher:         discoveryService,
+	}
+	subscriber := subscriber.NewSubscriber(subscriberDep)
+	conf.RegisterReloadable(viper.ComponentNameSubscriber, subscriber)
 
 	magnetService := magnet.New(magnet.Dependency{
 		Downloader:        downloadManager,
@@ -214,12 +231,14 @@ func main() {
 		TorrentOperator:   torrentOperator,
 		Downloader:        downloadManager,
 		Subscriber:        subscriber,
-		TransferFiles:     transferrepo.NewTransferFilesRepo(db),
+		TransferFiles:     transferFilesRepo,
 		Notifier:          noticeAdapter,
 		MagnetManager:     magnetService,
 		FontOperator:      subtitleOperator,
 		Scraper:           scraper,
 		BangumiFileParser: bfParser,
+		MetaParser:        metaParser,
+		Storage:           storageGuard,
 	})
 	conf.RegisterReloadable(viper.ComponentNameTransfer, transfer)
 
@@ -232,6 +251,13 @@ func main() {
 		BangumiFileParser: bfParser,
 	})
 
+	reporter := report.New(report.Dependency{
+		Subscriber:    subscriber,
+		Magnet:        magnetService,
+		TransferFiles: transferFilesRepo,
+		MetaParser:    metaParser,
+	})
+
 	// 注册路由
 
 	// 注册认证相关路由
@@ -247,6 +273,9 @@ func main() {
 		Parser:           metaParser,
 		SubtitleOperator: subtitleOperator,
 		Scraper:          scraper,
+		Storage:          storageGuard,
+		Report:           reporter,
+		Notice:           noticeAdapter,
 	})
 	r.POST("/apis/v1/token", router.Token)
 	apisRouter := r.Group("/apis/v1", router.CheckToken)
@@ -269,10 +298,15 @@ func main() {
 	apisRouter.PUT("/config/transfer", router.SetTransferConfig)
 	apisRouter.GET("/config/notice", router.GetNoticeConfig)
 	apisRouter.PUT("/config/notice", router.SetNoticeConfig)
+	apisRouter.POST("/config/notice/test", router.TestNoticeChannel)
+	apisRouter.POST("/config/notice/templates/preview", router.PreviewNoticeTemplate)
+	apisRouter.GET("/config/notice/templates/defaults", router.GetNoticeTemplateDefaults)
 	apisRouter.GET("/config/subtitle", router.GetSubtitleOperatorConfig)
 	apisRouter.PUT("/config/subtitle", router.SetSubtitleOperatorConfig)
 	apisRouter.GET("/config/scraper", router.GetScraperConfig)
 	apisRouter.PUT("/config/scraper", router.SetScraperConfig)
+	apisRouter.GET("/config/storage", router.GetStorageConfig)
+	apisRouter.PUT("/config/storage", router.SetStorageConfig)
 
 	// 注册番剧相关路由
 	apisRouter.GET("/bangumis/rss", router.ParseRSS)
@@ -286,6 +320,8 @@ func main() {
 	apisRouter.POST("/bangumis/rss_match/preview", router.PreviewRSSMatch)
 	apisRouter.POST("/bangumis/:id/rss_match", router.MarkRSSRecord)
 	apisRouter.POST("/bangumis/:id/download", router.HandleBangumiSubscription)
+	apisRouter.GET("/bangumis/:id/backfill", router.GetBackfillCandidates)
+	apisRouter.POST("/bangumis/:id/backfill", router.Backfill)
 	apisRouter.GET("/bangumis/:id/torrents", router.GetBangumiTorrents)
 	apisRouter.GET("/bangumis/calendar", router.GetSubscriptionCalendar)
 
@@ -304,6 +340,13 @@ func main() {
 
 	// 注册qbittorrent相关路由
 	apisRouter.POST("/downloader/qbittorrent/check", router.CheckQBittorrentConnection)
+	apisRouter.POST("/downloader/deluge/check", router.CheckDelugeConnection)
+
+	// 注册系统相关路由
+	apisRouter.GET("/system/storage", router.GetStorage)
+
+	// 注册报告相关路由
+	apisRouter.GET("/reports/gaps", router.GetGapReport)
 
 	// 注册日志相关路由
 	apisRouter.GET("/logs", ginrouter.GetLogContent)
@@ -51,18 +51,40 @@ func (p *parser) Parse(ctx context.Context, fileName string, opts ...bangumifile
 		episode, err = p.parseEpisodeFromAnitogoMeta(anitogoMeta)
 	}
 
+	bf := bangumifile.BangumiFile{
+		Season:       season,
+		AnimeTitle:   anitogoMeta.AnimeTitle,
+		ReleaseGroup: anitogoMeta.ReleaseGroup,
+	}
+	fillTags(&bf, fileName, anitogoMeta)
+	if bf.IsSpecialEpisode() {
+		// 特别篇使用自身的编号，不受集数位置和集数偏移的影响，没有编号的特别篇集数为0
+		bf.Episode, _ = p.parseEpisodeFromAnitogoMeta(anitogoMeta)
+		return bf, nil
+	}
+
 	if !options.IgnoreValidateEpisode && err != nil {
 		return bangumifile.BangumiFile{}, err
 	}
 
 	episode += options.EpisodeOffset
+	bf.Episode = episode
+	if options.EpisodeLocation == "" {
+		bf.EpisodeEnd = p.parseEpisodeEnd(anitogoMeta, episode, options.EpisodeOffset)
+	}
+	return bf, nil
+}
 
-	return bangumifile.BangumiFile{
-		Season:       season,
-		Episode:      episode,
-		AnimeTitle:   anitogoMeta.AnimeTitle,
-		ReleaseGroup: anitogoMeta.ReleaseGroup,
-	}, nil
+// parseEpisodeEnd 解析合集的结束集数，如 [01-12] 返回12，单集资源返回0
+func (p *parser) parseEpisodeEnd(anitogoMeta *anitogo.Elements, episode, offset int) int {
+	if len(anitogoMeta.EpisodeNumber) < 2 {
+		return 0
+	}
+	end, err := strconv.Atoi(anitogoMeta.EpisodeNumber[len(anitogoMeta.EpisodeNumber)-1])
+	if err != nil || end+offset <= episode {
+		return 0
+	}
+	return end + offset
 }
 
 var (
@@ -70,6 +92,8 @@ var (
 	reEpisodeNoPrefixHua   = regexp.MustCompile(`(\d{1,4})话`)
 	reEpisodeWithPrefixJi  = regexp.MustCompile(`第(\d{1,4})集`)
 	reEpisodeNoPrefixJi    = regexp.MustCompile(`(\d{1,4})集`)
+	// reHalfEpisode 12.5 这类插在两集之间的总集篇、特别篇
+	reHalfEpisode = regexp.MustCompile(`^(\d{1,4})\.5$`)
 )
 
 // normalizeFilenameForAnitogo makes simplified Chinese episode counters parsable by anitogo.
@@ -89,6 +113,10 @@ func (p *parser) parseEpisodeFromAnitogoMeta(anitogoMeta *anitogo.Elements) (int
 	}
 	episode, err := strconv.Atoi(anitogoMeta.EpisodeNumber[0])
 	if err != nil {
+		// 12.5 这类集数取整数部分，由 fillTags 标记为特别篇
+		if m := reHalfEpisode.FindStringSubmatch(anitogoMeta.EpisodeNumber[0]); m != nil {
+			return strconv.Atoi(m[1])
+		}
 		return 0, errors.New("不是有效的集数信息")
 	}
 	return episode, nil
@@ -25,6 +25,11 @@ func TestAntiParser_Parse(t *testing.T) {
 				Episode:      2,
 				AnimeTitle:   "Food Court de, Mata Ashita.",
 				ReleaseGroup: "LoliHouse",
+				Resolution:   "1080p",
+				VideoCodec:   "HEVC",
+				AudioCodec:   "AAC",
+				Source:       "WebRip",
+				Version:      1,
 			},
 			wantErr: assert.NoError,
 		},
@@ -36,6 +41,8 @@ func TestAntiParser_Parse(t *testing.T) {
 				Episode:      5,
 				AnimeTitle:   "My Anime",
 				ReleaseGroup: "Group",
+				Resolution:   "1080p",
+				Version:      1,
 			},
 			wantErr: assert.NoError,
 		},
@@ -48,6 +55,8 @@ func TestAntiParser_Parse(t *testing.T) {
 				Episode:      12,
 				AnimeTitle:   "My Anime",
 				ReleaseGroup: "Group",
+				Resolution:   "1080p",
+				Version:      1,
 			},
 			wantErr: assert.NoError,
 		},
@@ -59,6 +68,8 @@ func TestAntiParser_Parse(t *testing.T) {
 				Episode:      8,
 				AnimeTitle:   "My Anime",
 				ReleaseGroup: "Group",
+				Resolution:   "1080p",
+				Version:      1,
 			},
 			wantErr: assert.NoError,
 		},
@@ -71,6 +82,8 @@ func TestAntiParser_Parse(t *testing.T) {
 				Episode:      13,
 				AnimeTitle:   "My Anime",
 				ReleaseGroup: "Group",
+				Resolution:   "1080p",
+				Version:      1,
 			},
 			wantErr: assert.NoError,
 		},
@@ -83,15 +96,50 @@ func TestAntiParser_Parse(t *testing.T) {
 				Episode:      0,
 				AnimeTitle:   "My Anime - 特别篇",
 				ReleaseGroup: "Group",
+				Resolution:   "1080p",
+				Version:      1,
+				Special:      "SP",
 			},
 			wantErr: assert.NoError,
 		},
 		{
 			name:     "无法识别集数且未忽略 (默认行为)",
-			fileName: "[Group] My Anime - 特别篇 [1080p].mkv",
+			fileName: "[Group] My Anime [1080p].mkv",
 			want:     bangumifile.BangumiFile{},
 			wantErr:  assert.Error,
 		},
+		{
+			name:     "没有编号的特别篇",
+			fileName: "[Group] My Anime - 特别篇 [1080p].mkv",
+			want: bangumifile.BangumiFile{
+				Season:       1,
+				Episode:      0,
+				AnimeTitle:   "My Anime - 特别篇",
+				ReleaseGroup: "Group",
+				Resolution:   "1080p",
+				Version:      1,
+				Special:      "SP",
+			},
+			wantErr: assert.NoError,
+		},
+		{
+			name:     "特别篇不受集数位置和偏移的影响",
+			fileName: "[Group] My Anime [SP02][1080p].mkv",
+			opts: []bangumifile.ParserOption{
+				bangumifile.WithEpisodeLocation("第{ep}话"),
+				bangumifile.WithEpisodeOffset(12),
+			},
+			want: bangumifile.BangumiFile{
+				Season:       1,
+				Episode:      2,
+				AnimeTitle:   "My Anime",
+				ReleaseGroup: "Group",
+				Resolution:   "1080p",
+				Version:      1,
+				Special:      "SP",
+			},
+			wantErr: assert.NoError,
+		},
 		{
 			name:     "自定义位置匹配失败",
 			fileName: "[Group] My Anime - 01 [1080p].mkv",
@@ -104,10 +152,17 @@ func TestAntiParser_Parse(t *testing.T) {
 			fileName: "[LoliHouse] 为美好的世界献上祝福！3 / Kono Subarashii Sekai ni Shukufuku wo! S3 - 10 [WebRip 1080p HEVC-10bit AAC][简繁内封字幕]",
 			opts:     []bangumifile.ParserOption{bangumifile.PreserveOriginName()},
 			want: bangumifile.BangumiFile{
-				Season:       3,
-				Episode:      10,
-				AnimeTitle:   "为美好的世界献上祝福！3 / Kono Subarashii Sekai ni Shukufuku wo!",
-				ReleaseGroup: "LoliHouse",
+				Season:            3,
+				Episode:           10,
+				AnimeTitle:        "为美好的世界献上祝福！3 / Kono Subarashii Sekai ni Shukufuku wo!",
+				ReleaseGroup:      "LoliHouse",
+				Resolution:        "1080p",
+				VideoCodec:        "HEVC",
+				AudioCodec:        "AAC",
+				Source:            "WebRip",
+				SubtitleLanguages: []string{"简", "繁"},
+				SubtitleType:      "内封",
+				Version:           1,
 			},
 			wantErr: assert.NoError,
 		},
@@ -194,3 +249,114 @@ func TestAntiParser_parseEpisodeWithLocation(t *testing.T) {
 		})
 	}
 }
+
+func TestAntiParser_ParseTags(t *testing.T) {
+	testCases := []struct {
+		name     string
+		fileName string
+		want     bangumifile.BangumiFile
+	}{
+		{
+			name:     "合集",
+			fileName: "[Nekomoe kissaten][Sousou no Frieren][01-28][1080p][JPSC].mp4",
+			want: bangumifile.BangumiFile{
+				Season:            1,
+				Episode:           1,
+				EpisodeEnd:        28,
+				AnimeTitle:        "Sousou no Frieren",
+				ReleaseGroup:      "Nekomoe kissaten",
+				Resolution:        "1080p",
+				SubtitleLanguages: []string{"简", "日"},
+				Version:           1,
+			},
+		},
+		{
+			name:     "修正版本",
+			fileName: "[ANi] 葬送的芙莉蓮 - 05v2 [1080P][Baha][WEB-DL][AAC AVC][CHT].mp4",
+			want: bangumifile.BangumiFile{
+				Season:            1,
+				Episode:           5,
+				AnimeTitle:        "葬送的芙莉蓮",
+				ReleaseGroup:      "ANi",
+				Resolution:        "1080p",
+				VideoCodec:        "AVC",
+				AudioCodec:        "AAC",
+				Source:            "WEB-DL",
+				SubtitleLanguages: []string{"繁"},
+				Version:           2,
+			},
+		},
+		{
+			name:     "中文字幕标签",
+			fileName: "[桜都字幕组] 间谍过家家 / Spy x Family [12][1080P][简日双语内嵌]",
+			want: bangumifile.BangumiFile{
+				Season:            1,
+				Episode:           12,
+				AnimeTitle:        "间谍过家家 / Spy x Family",
+				ReleaseGroup:      "桜都字幕组",
+				Resolution:        "1080p",
+				SubtitleLanguages: []string{"简", "日"},
+				SubtitleType:      "内嵌",
+				Version:           1,
+			},
+		},
+		{
+			name:     "特殊标记",
+			fileName: "[Airota][Kono Subarashii][SP01][BDRip 1080p AVC AAC][CHS_JP]",
+			want: bangumifile.BangumiFile{
+				Season:            1,
+				Episode:           1,
+				AnimeTitle:        "Kono Subarashii",
+				ReleaseGroup:      "Airota",
+				Resolution:        "1080p",
+				VideoCodec:        "AVC",
+				AudioCodec:        "AAC",
+				Source:            "BDRip",
+				SubtitleLanguages: []string{"简", "日"},
+				Version:           1,
+				Special:           "SP",
+			},
+		},
+		{
+			name:     "没有编号的OVA",
+			fileName: "[ANi] Kimetsu no Yaiba - OVA [1080P][Baha][WEB-DL][AAC AVC][CHT].mp4",
+			want: bangumifile.BangumiFile{
+				Season:            1,
+				AnimeTitle:        "Kimetsu no Yaiba - OVA",
+				ReleaseGroup:      "ANi",
+				Resolution:        "1080p",
+				VideoCodec:        "AVC",
+				AudioCodec:        "AAC",
+				Source:            "WEB-DL",
+				SubtitleLanguages: []string{"繁"},
+				Version:           1,
+				Special:           "OVA",
+			},
+		},
+		{
+			name:     "小数集数",
+			fileName: "[LoliHouse] Oshi no Ko - 12.5 [WebRip 1080p HEVC-10bit AAC]",
+			want: bangumifile.BangumiFile{
+				Season:       1,
+				Episode:      12,
+				AnimeTitle:   "Oshi no Ko",
+				ReleaseGroup: "LoliHouse",
+				Resolution:   "1080p",
+				VideoCodec:   "HEVC",
+				AudioCodec:   "AAC",
+				Source:       "WebRip",
+				Version:      1,
+				Special:      "SP",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := NewParser().Parse(context.Background(), tc.fileName, bangumifile.PreserveOriginName())
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package anito
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/nssteinbrenner/anitogo"
+
+	"github.com/MangataL/BangumiBuddy/pkg/bangumifile"
+)
+
+// termRule 标题中的关键词与规范名称的对应关系
+type termRule struct {
+	re   *regexp.Regexp
+	name string
+}
+
+// newTermRule 创建关键词规则，关键词前后不能紧邻字母或数字，避免 AVC 匹配到 HEVC
+func newTermRule(name string, terms ...string) termRule {
+	return termRule{
+		re:   regexp.MustCompile(`(?i)(?:^|[^a-z0-9])(?:` + strings.Join(terms, "|") + `)(?:[^a-z0-9]|$)`),
+		name: name,
+	}
+}
+
+// match 返回第一个匹配的规则名称
+func match(rules []termRule, text string) string {
+	for _, rule := range rules {
+		if rule.re.MatchString(text) {
+			return rule.name
+		}
+	}
+	return ""
+}
+
+var (
+	videoCodecRules = []termRule{
+		newTermRule("HEVC", `hevc`, `[xh]\.?265`),
+		newTermRule("AVC", `avc`, `[xh]\.?264`),
+		newTermRule("AV1", `av1`),
+		newTermRule("VP9", `vp9`),
+	}
+	audioCodecRules = []termRule{
+		newTermRule("FLAC", `flac`),
+		newTermRule("OPUS", `opus`),
+		newTermRule("DTS", `dts(?:-?hd)?`),
+		newTermRule("AC3", `e?ac-?3`),
+		newTermRule("AAC", `aac`),
+	}
+	sourceRules = []termRule{
+		newTermRule("BDRip", `bd-?rip`, `blu-?ray`, `bdmv`, `bd`),
+		newTermRule("WEB-DL", `web-?dl`),
+		newTermRule("WebRip", `web-?rip`, `web`),
+		newTermRule("DVDRip", `dvd-?rip`, `dvd`),
+		newTermRule("TVRip", `tv-?rip`, `hdtv`),
+	}
+	subtitleLanguageRules = []termRule{
+		{re: regexp.MustCompile(`(?i)简|簡|` + asciiTerms(`chs`, `sc`, `gb`, `jpsc`)), name: "简"},
+		{re: regexp.MustCompile(`(?i)繁|` + asciiTerms(`cht`, `tc`, `big5`, `jptc`)), name: "繁"},
+		{re: regexp.MustCompile(`(?i)日|双语|雙語|` + asciiTerms(`jp`, `jpn`, `jpsc`, `jptc`)), name: "日"},
+	}
+	subtitleTypeRules = []termRule{
+		{re: regexp.MustCompile(`内封|內封`), name: "内封"},
+		{re: regexp.MustCompile(`内嵌|內嵌`), name: "内嵌"},
+		{re: regexp.MustCompile(`外挂|外掛`), name: "外挂"},
+	}
+	// reSubtitleTag 包含字幕信息的标签，只有这些标签中的 简/繁/日 才会被识别为字幕语言
+	reSubtitleTag = regexp.MustCompile(`(?i)字幕|双语|雙語|内封|內封|内嵌|內嵌|外挂|外掛|中字|简|簡|繁|` +
+		asciiTerms(`chs`, `cht`, `sc`, `tc`, `gb`, `big5`, `jpsc`, `jptc`))
+	reBracketTag  = regexp.MustCompile(`[\[【(（]([^\]】)）]+)[\]】)）]`)
+	reResolution  = regexp.MustCompile(`(?i)(?:^|[^a-z0-9])(?:\d{3,4}x(\d{3,4})|(\d{3,4})[pi]|(4k))(?:[^a-z0-9]|$)`)
+	reChineseSP   = regexp.MustCompile(`特别篇|特別篇|番外`)
+	specialMarker = map[string]string{
+		"OVA":      "OVA",
+		"OAD":      "OAD",
+		"SP":       "SP",
+		"SPECIAL":  "SP",
+		"SPECIALS": "SP",
+		"NCOP":     "NCOP",
+		"NCED":     "NCED",
+		"OP":       "OP",
+		"ED":       "ED",
+		"PV":       "PV",
+		"CM":       "CM",
+		"MENU":     "MENU",
+		"PREVIEW":  "PV",
+	}
+)
+
+// asciiTerms 生成前后不紧邻字母或数字的英文关键词匹配
+func asciiTerms(terms ...string) string {
+	return `(?:^|[^a-zA-Z0-9])(?:` + strings.Join(terms, "|") + `)(?:[^a-zA-Z0-9]|$)`
+}
+
+// fillTags 从文件名和anitogo的解析结果中提取分辨率、编码、片源、字幕、版本和特殊标记
+func fillTags(bf *bangumifile.BangumiFile, fileName string, anitogoMeta *anitogo.Elements) {
+	bf.Resolution = normalizeResolution(anitogoMeta.VideoResolution)
+	if bf.Resolution == "" {
+		bf.Resolution = normalizeResolution(fileName)
+	}
+	bf.VideoCodec = match(videoCodecRules, fileName)
+	bf.AudioCodec = match(audioCodecRules, fileName)
+	bf.Source = match(sourceRules, fileName)
+	bf.SubtitleLanguages, bf.SubtitleType = parseSubtitle(fileName)
+
+	bf.Version = 1
+	if len(anitogoMeta.ReleaseVersion) > 0 {
+		if v, err := strconv.Atoi(anitogoMeta.ReleaseVersion[0]); err == nil && v > 0 {
+			bf.Version = v
+		}
+	}
+
+	for _, animeType := range anitogoMeta.AnimeType {
+		if marker, ok := specialMarker[strings.ToUpper(animeType)]; ok {
+			bf.Special = marker
+			break
+		}
+	}
+	if bf.Special == "" && reChineseSP.MatchString(fileName) {
+		bf.Special = "SP"
+	}
+	if bf.Special == "" && len(anitogoMeta.EpisodeNumber) > 0 && reHalfEpisode.MatchString(anitogoMeta.EpisodeNumber[0]) {
+		bf.Special = "SP"
+	}
+}
+
+// normalizeResolution 将 1080P、1920x1080、4K 等格式统一为 1080p、2160p
+func normalizeResolution(text string) string {
+	// anitogo 可能返回不带 p 的分辨率，如 1080
+	if _, err := strconv.Atoi(text); err == nil && len(text) >= 3 {
+		return text + "p"
+	}
+	matches := reResolution.FindStringSubmatch(" " + text + " ")
+	if matches == nil {
+		return ""
+	}
+	switch {
+	case matches[1] != "":
+		return matches[1] + "p"
+	case matches[2] != "":
+		return matches[2] + "p"
+	default:
+		return "2160p"
+	}
+}
+
+// parseSubtitle 从文件名的标签中解析字幕语言和字幕形式
+func parseSubtitle(fileName string) ([]string, string) {
+	var tags []string
+	for _, m := range reBracketTag.FindAllStringSubmatch(fileName, -1) {
+		if reSubtitleTag.MatchString(m[1]) {
+			tags = append(tags, m[1])
+		}
+	}
+	if len(tags) == 0 {
+		return nil, ""
+	}
+	text := strings.Join(tags, " ")
+	var languages []string
+	for _, rule := range subtitleLanguageRules {
+		if rule.re.MatchString(text) {
+			languages = append(languages, rule.name)
+		}
+	}
+	return languages, match(subtitleTypeRules, text)
+}
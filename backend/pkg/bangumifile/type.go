@@ -1,8 +1,36 @@
 package bangumifile
 
 type BangumiFile struct {
-	Season       int
-	Episode      int
-	AnimeTitle   string
-	ReleaseGroup string
+	Season            int
+	Episode           int
+	AnimeTitle        string
+	ReleaseGroup      string
+	EpisodeEnd        int      // 合集的结束集数，单集资源为0
+	Resolution        string   // 分辨率，如 1080p、2160p
+	VideoCodec        string   // 视频编码，如 HEVC、AVC
+	AudioCodec        string   // 音频编码，如 AAC、FLAC
+	Source            string   // 片源，如 WebRip、WEB-DL、BDRip
+	SubtitleLanguages []string // 字幕语言，取值为 简、繁、日
+	SubtitleType      string   // 字幕形式，取值为 内封、内嵌、外挂
+	Version           int      // 版本号，如 v2 为2，未标注时为1
+	Special           string   // 特殊标记，如 OVA、SP、NCOP，正片为空；特别篇的集数为其编号，如 SP01 为1、12.5 为12，没有编号时为0
+}
+
+// IsBatch 是否为包含多集的合集资源
+func (b BangumiFile) IsBatch() bool {
+	return b.EpisodeEnd > b.Episode
+}
+
+// IsSpecial 是否为OVA、SP、NCOP等非正片资源
+func (b BangumiFile) IsSpecial() bool {
+	return b.Special != ""
+}
+
+// IsSpecialEpisode 是否为对应TMDB第0季的OVA、SP等特别篇，NCOP、PV等附加内容不是特别篇
+func (b BangumiFile) IsSpecialEpisode() bool {
+	switch b.Special {
+	case "SP", "OVA", "OAD":
+		return true
+	}
+	return false
 }